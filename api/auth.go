@@ -5,6 +5,7 @@ import (
 	"github.com/pquerna/otp"
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
 	"net/http"
@@ -225,6 +226,22 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) (ok bool, req
 			return
 		}
 
+		if !token.IsActive(tz.Now()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if !apiTokenAllows(token, r) {
+			helpers.WriteErrorStatus(w, "Operation is not allowed by the token scope", http.StatusForbidden)
+			return
+		}
+
+		if token.LastUsed == nil || tz.Now().Sub(*token.LastUsed) > apiTokenTouchInterval {
+			if err = helpers.Store(r).TouchAPIToken(token.UserID, token.ID, clientIP(r)); err != nil {
+				log.Error(err)
+			}
+		}
+
 		userID = token.UserID
 	} else {
 		session, found := getSession(r)
@@ -246,6 +263,11 @@ func authenticationHandler(w http.ResponseWriter, r *http.Request) (ok bool, req
 			return
 		}
 
+		if !refreshOidcSession(r, session) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
 		userID = session.UserID
 
 		if err := helpers.Store(r).TouchSession(userID, session.ID); err != nil {
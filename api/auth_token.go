@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// apiTokenTouchInterval limits how often the last usage of an API token
+// is written to the database.
+const apiTokenTouchInterval = time.Minute
+
+const projectRoutePrefix = "/project/{project_id}"
+
+// runTaskRoutes contains project routes which are allowed by
+// the run_tasks scope for modifying requests.
+var runTaskRoutes = []string{
+	"/tasks",
+	"/tasks/{task_id}/stop",
+	"/tasks/{task_id}/confirm",
+	"/tasks/{task_id}/reject",
+	"/templates/{template_id}/stop_all_tasks",
+	"/templates/{template_id}/deployments/rollback",
+}
+
+// projectAdminRoutes contains project routes which are not allowed by
+// the manage_resources scope, including their sub-routes.
+var projectAdminRoutes = []string{
+	"/me",
+	"/users",
+	"/invites",
+	"/roles",
+}
+
+// tokenUserRoutes contains routes outside projects which can be read
+// with a token restricted to the single project.
+var tokenUserRoutes = []string{
+	"/user",
+	"/info",
+}
+
+func routePathTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+
+	return tpl
+}
+
+// projectRoutePath returns the part of the route template after the project prefix.
+func projectRoutePath(tpl string) (path string, ok bool) {
+	i := strings.Index(tpl, projectRoutePrefix)
+	if i < 0 {
+		return
+	}
+
+	return tpl[i+len(projectRoutePrefix):], true
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func isProjectAdminRoute(path string) bool {
+	if path == "" { // project itself
+		return true
+	}
+
+	for _, route := range projectAdminRoutes {
+		if path == route || strings.HasPrefix(path, route+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTokenUserRoute(tpl string) bool {
+	for _, route := range tokenUserRoutes {
+		if strings.HasSuffix(tpl, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiTokenAllows checks project restriction and scopes of the token
+// against the matched route of the request.
+func apiTokenAllows(token db.APIToken, r *http.Request) bool {
+	tpl := routePathTemplate(r)
+	projectPath, isProjectRoute := projectRoutePath(tpl)
+
+	if token.ProjectID != nil {
+		if isProjectRoute {
+			if mux.Vars(r)["project_id"] != strconv.Itoa(*token.ProjectID) {
+				return false
+			}
+		} else if !isSafeMethod(r.Method) || !isTokenUserRoute(tpl) {
+			return false
+		}
+	}
+
+	if !token.IsScoped() || isSafeMethod(r.Method) {
+		return true
+	}
+
+	if !isProjectRoute {
+		return false
+	}
+
+	isRunTaskRoute := slices.Contains(runTaskRoutes, projectPath)
+
+	if isRunTaskRoute {
+		return token.HasScope(db.APITokenScopeRunTasks)
+	}
+
+	return token.HasScope(db.APITokenScopeManageResources) && !isProjectAdminRoute(projectPath)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
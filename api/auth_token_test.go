@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func tokenAllows(token db.APIToken, method string, path string) (allowed bool) {
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		allowed = apiTokenAllows(token, r)
+	}
+
+	api.Path("/user").HandlerFunc(handler)
+	api.Path("/user/tokens").HandlerFunc(handler)
+	api.Path("/projects").HandlerFunc(handler)
+	project := api.PathPrefix("/project/{project_id}").Subrouter()
+	project.Path("").HandlerFunc(handler)
+	project.Path("/tasks").HandlerFunc(handler)
+	project.Path("/tasks/{task_id}/stop").HandlerFunc(handler)
+	project.Path("/templates/{template_id}").HandlerFunc(handler)
+	project.Path("/templates/{template_id}/deployments/rollback").HandlerFunc(handler)
+	project.Path("/users/{user_id}").HandlerFunc(handler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	return
+}
+
+func TestAPITokenAllows_Unscoped(t *testing.T) {
+	token := db.APIToken{}
+
+	assert.True(t, tokenAllows(token, "POST", "/api/projects"))
+	assert.True(t, tokenAllows(token, "DELETE", "/api/project/1"))
+}
+
+func TestAPITokenAllows_ReadOnly(t *testing.T) {
+	token := db.APIToken{Scopes: db.APITokenScopes{db.APITokenScopeReadOnly}}
+
+	assert.True(t, tokenAllows(token, "GET", "/api/project/1/tasks"))
+	assert.False(t, tokenAllows(token, "POST", "/api/project/1/tasks"))
+	assert.False(t, tokenAllows(token, "POST", "/api/user/tokens"))
+}
+
+func TestAPITokenAllows_RunTasks(t *testing.T) {
+	token := db.APIToken{Scopes: db.APITokenScopes{db.APITokenScopeRunTasks}}
+
+	assert.True(t, tokenAllows(token, "POST", "/api/project/1/tasks"))
+	assert.True(t, tokenAllows(token, "POST", "/api/project/1/tasks/5/stop"))
+	assert.True(t, tokenAllows(token, "POST", "/api/project/1/templates/3/deployments/rollback"))
+	assert.False(t, tokenAllows(token, "PUT", "/api/project/1/templates/3"))
+}
+
+func TestAPITokenAllows_ManageResources(t *testing.T) {
+	token := db.APIToken{Scopes: db.APITokenScopes{db.APITokenScopeManageResources}}
+
+	assert.True(t, tokenAllows(token, "PUT", "/api/project/1/templates/3"))
+	assert.False(t, tokenAllows(token, "POST", "/api/project/1/tasks"))
+	assert.False(t, tokenAllows(token, "POST", "/api/project/1/templates/3/deployments/rollback"))
+	assert.False(t, tokenAllows(token, "DELETE", "/api/project/1"))
+	assert.False(t, tokenAllows(token, "PUT", "/api/project/1/users/2"))
+}
+
+func TestAPITokenAllows_ProjectRestriction(t *testing.T) {
+	projectID := 1
+	token := db.APIToken{ProjectID: &projectID}
+
+	assert.True(t, tokenAllows(token, "GET", "/api/user"))
+	assert.True(t, tokenAllows(token, "POST", "/api/project/1/tasks"))
+	assert.False(t, tokenAllows(token, "GET", "/api/project/2/tasks"))
+	assert.False(t, tokenAllows(token, "GET", "/api/projects"))
+	assert.False(t, tokenAllows(token, "POST", "/api/user/tokens"))
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/api/projects"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/services/instance_backup"
+	"github.com/semaphoreui/semaphore/services/server"
+	log "github.com/sirupsen/logrus"
+)
+
+type InstanceBackupController struct {
+	encryptionService server.AccessKeyEncryptionService
+}
+
+func NewInstanceBackupController(encryptionService server.AccessKeyEncryptionService) *InstanceBackupController {
+	return &InstanceBackupController{
+		encryptionService: encryptionService,
+	}
+}
+
+// CreateBackup returns the archive with all projects and the global state.
+// Secrets in the archive are encrypted by the passphrase from the request body.
+func (c *InstanceBackupController) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+
+	if !helpers.Bind(w, r, &req) {
+		return
+	}
+
+	if req.Passphrase == "" {
+		helpers.WriteErrorStatus(w, "passphrase required", http.StatusBadRequest)
+		return
+	}
+
+	filename := "semaphore-backup-" + strconv.FormatInt(tz.Now().Unix(), 10) + ".tar.gz"
+
+	// The archive is streamed, headers are sent with its first bytes,
+	// so errors of loading the instance are reported with the error status.
+	out := &archiveResponseWriter{w: w, filename: filename}
+
+	err := instance_backup.Create(helpers.Store(r), out, instance_backup.Options{
+		Passphrase:        req.Passphrase,
+		EncryptionService: c.encryptionService,
+	})
+
+	if err != nil {
+		log.Error(err)
+		if !out.started {
+			helpers.WriteError(w, err)
+		}
+		return
+	}
+
+	if !out.started {
+		out.start()
+	}
+}
+
+// archiveResponseWriter starts the response on the first write.
+type archiveResponseWriter struct {
+	w        http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (a *archiveResponseWriter) start() {
+	a.started = true
+	a.w.Header().Set("content-type", "application/gzip")
+	a.w.Header().Set("content-disposition", "attachment; filename=\""+a.filename+"\"")
+	a.w.WriteHeader(http.StatusOK)
+}
+
+func (a *archiveResponseWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.start()
+	}
+	return a.w.Write(p)
+}
+
+// RestoreBackup restores the archive from the request body into the instance
+// without projects. The passphrase is passed in the X-Backup-Passphrase header.
+func (c *InstanceBackupController) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	passphrase := r.Header.Get(projects.BackupPassphraseHeader)
+
+	if passphrase == "" {
+		helpers.WriteErrorStatus(w, "passphrase required", http.StatusBadRequest)
+		return
+	}
+
+	res, err := instance_backup.Restore(helpers.Store(r), r.Body, instance_backup.Options{
+		Passphrase:        passphrase,
+		EncryptionService: c.encryptionService,
+	})
+
+	if err != nil {
+		log.Error(err)
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
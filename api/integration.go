@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/json"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/semaphoreui/semaphore/pkg/conv"
+	"github.com/semaphoreui/semaphore/services/metrics"
 	"github.com/semaphoreui/semaphore/services/server"
 	task2 "github.com/semaphoreui/semaphore/services/tasks"
 
@@ -48,11 +50,13 @@ func hmacHashPayload(secret string, payloadBody []byte) string {
 
 type IntegrationController struct {
 	integrationService server.IntegrationService
+	throttle           *task2.IntegrationThrottle
 }
 
 func NewIntegrationController(integrationService server.IntegrationService) *IntegrationController {
 	return &IntegrationController{
 		integrationService: integrationService,
+		throttle:           task2.NewIntegrationThrottle(),
 	}
 }
 
@@ -209,7 +213,7 @@ func (c *IntegrationController) ReceiveIntegration(w http.ResponseWriter, r *htt
 			}
 		}
 
-		RunIntegration(integration, project, r, payload)
+		c.RunIntegration(integration, project, r, payload)
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -319,32 +323,154 @@ func GetTaskDefinition(integration db.Integration, payload []byte, r *http.Reque
 	return
 }
 
-func RunIntegration(integration db.Integration, project db.Project, r *http.Request, payload []byte) {
+// RunIntegration creates a task for the matched integration delivery.
+// It applies the integration rate limit and debounce window and records
+// the outcome in the delivery history.
+func (c *IntegrationController) RunIntegration(integration db.Integration, project db.Project, r *http.Request, payload []byte) {
 
 	log.Info(fmt.Sprintf("Running integration %d", integration.ID))
 
+	store := helpers.Store(r)
+
+	delivery := db.IntegrationDelivery{
+		ProjectID:     integration.ProjectID,
+		IntegrationID: integration.ID,
+	}
+
+	if !c.throttle.Allow(integration) {
+		log.WithFields(log.Fields{
+			"context":        "integrations",
+			"integration_id": integration.ID,
+		}).Warn("Integration rate limit exceeded")
+
+		delivery.Status = db.IntegrationDeliveryRateLimited
+		delivery.Message = fmt.Sprintf("Rate limit of %d deliveries per minute exceeded", integration.RateLimit)
+		createIntegrationDelivery(store, delivery)
+		deliveryFinished(delivery)
+		return
+	}
+
 	taskDefinition, err := GetTaskDefinition(integration, payload, r)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"context":        "integrations",
 			"integration_id": integration.ID,
 		}).Error("Failed to get task definition")
+
+		delivery.Status = db.IntegrationDeliveryFailed
+		delivery.Message = err.Error()
+		createIntegrationDelivery(store, delivery)
+		deliveryFinished(delivery)
 		return
 	}
 
-	tpl, err := helpers.Store(r).GetTemplate(integration.ProjectID, integration.TemplateID)
+	pool := helpers.GetFromContext(r, "task_pool").(*task2.TaskPool)
+
+	// The debounced task is added after the request is finished.
+	ctx := context.WithoutCancel(r.Context())
+
+	if integration.DebounceSec <= 0 {
+		delivery.Status = db.IntegrationDeliveryTriggered
+		delivery = createIntegrationDelivery(store, delivery)
+		enqueueIntegrationTask(ctx, store, pool, integration, taskDefinition, delivery)
+		return
+	}
+
+	delivery.Status = db.IntegrationDeliveryDebounced
+	delivery.Message = fmt.Sprintf("Waiting %d seconds for newer deliveries", integration.DebounceSec)
+	delivery = createIntegrationDelivery(store, delivery)
+
+	supersededID := c.throttle.Debounce(integration, delivery.ID, func() {
+		db.StoreSession(store, "integration debounce", func() {
+			enqueueIntegrationTask(ctx, store, pool, integration, taskDefinition, delivery)
+		})
+	})
+
+	if supersededID != nil {
+		superseded := db.IntegrationDelivery{
+			ID:            *supersededID,
+			ProjectID:     integration.ProjectID,
+			IntegrationID: integration.ID,
+			Status:        db.IntegrationDeliverySuperseded,
+			Message:       fmt.Sprintf("Superseded by delivery %d", delivery.ID),
+		}
+		updateIntegrationDelivery(store, superseded)
+		deliveryFinished(superseded)
+	}
+}
+
+func enqueueIntegrationTask(
+	ctx context.Context,
+	store db.Store,
+	pool *task2.TaskPool,
+	integration db.Integration,
+	taskDefinition db.Task,
+	delivery db.IntegrationDelivery,
+) {
+	delivery.Status = db.IntegrationDeliveryFailed
+	delivery.Message = ""
+
+	defer func() {
+		if delivery.ID != 0 {
+			updateIntegrationDelivery(store, delivery)
+		}
+		deliveryFinished(delivery)
+	}()
+
+	tpl, err := store.GetTemplate(integration.ProjectID, integration.TemplateID)
 	if err != nil {
 		log.Error(err)
+		delivery.Message = err.Error()
 		return
 	}
 
-	pool := helpers.GetFromContext(r, "task_pool").(*task2.TaskPool)
+	var stopped []int
+
+	if integration.CancelQueued {
+		stopped = pool.StopQueuedIntegrationTasks(integration.ProjectID, integration.ID)
+	}
 
-	_, err = pool.AddTask(taskDefinition, nil, "", integration.ProjectID, tpl.App.NeedTaskAlias())
+	task, err := pool.AddTask(ctx, taskDefinition, nil, "", integration.ProjectID, tpl.App.NeedTaskAlias())
 	if err != nil {
 		log.Error(err)
+		delivery.Message = err.Error()
 		return
 	}
+
+	delivery.Status = db.IntegrationDeliveryTriggered
+	delivery.TaskID = &task.ID
+
+	if len(stopped) > 0 {
+		delivery.Message = fmt.Sprintf("Stopped %d queued task(s) of the integration", len(stopped))
+	}
+}
+
+// deliveryFinished records the final status of the delivery in metrics.
+// It is called once per delivery, intermediate statuses are not counted.
+func deliveryFinished(delivery db.IntegrationDelivery) {
+	metrics.IntegrationDelivered(delivery.ProjectID, delivery.IntegrationID, string(delivery.Status))
+}
+
+func createIntegrationDelivery(store db.Store, delivery db.IntegrationDelivery) db.IntegrationDelivery {
+	newDelivery, err := store.CreateIntegrationDelivery(delivery)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"context":        "integrations",
+			"integration_id": delivery.IntegrationID,
+		}).Error("Failed to save integration delivery")
+		return delivery
+	}
+	return newDelivery
+}
+
+func updateIntegrationDelivery(store db.Store, delivery db.IntegrationDelivery) {
+	err := store.UpdateIntegrationDelivery(delivery)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"context":        "integrations",
+			"integration_id": delivery.IntegrationID,
+		}).Error("Failed to update integration delivery")
+	}
 }
 
 func Extract(extractValues []db.IntegrationExtractValue, r *http.Request, payload []byte) (result map[string]string) {
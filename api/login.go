@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -25,6 +24,8 @@ import (
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/random"
+	"github.com/semaphoreui/semaphore/services/ldap_sync"
+	"github.com/semaphoreui/semaphore/services/membership"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
@@ -48,26 +49,12 @@ func tryFindLDAPUser(username, password string) (*db.User, error) {
 		return nil, fmt.Errorf("LDAP not configured")
 	}
 
-	var l *ldap.Conn
-	var err error
-	if util.Config.LdapNeedTLS {
-		l, err = ldap.DialTLS("tcp", util.Config.LdapServer, &tls.Config{
-			InsecureSkipVerify: true,
-		})
-	} else {
-		l, err = ldap.Dial("tcp", util.Config.LdapServer)
-	}
-
+	l, err := ldap_sync.Connect()
 	if err != nil {
 		return nil, err
 	}
 	defer l.Close() //nolint:errcheck
 
-	// First bind with a read only user
-	if err = l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err != nil {
-		return nil, err
-	}
-
 	// Filter for the given username
 	searchRequest := ldap.NewSearchRequest(
 		util.Config.LdapSearchDN,
@@ -134,6 +121,7 @@ func tryFindLDAPUser(username, password string) (*db.User, error) {
 		Name:     claims.name,
 		Email:    claims.email,
 		External: true,
+		Ldap:     true,
 		Alert:    false,
 	}
 
@@ -148,9 +136,9 @@ func tryFindLDAPUser(username, password string) (*db.User, error) {
 	return &ldapUser, nil
 }
 
-// createSession creates session for passed user and stores session details
-// in cookies.
-func createSession(w http.ResponseWriter, r *http.Request, user db.User, oidc bool) {
+// createSession creates the session of the user and sets the session cookie.
+// oidcSession is nil for sessions created by other login methods.
+func createSession(w http.ResponseWriter, r *http.Request, user db.User, oidcSession *oidcSessionInfo) {
 	var err error
 	var verificationMethod db.SessionVerificationMethod
 	verified := false
@@ -159,7 +147,7 @@ func createSession(w http.ResponseWriter, r *http.Request, user db.User, oidc bo
 	case user.Totp != nil && util.Config.Auth.Totp.Enabled:
 		verificationMethod = db.SessionVerificationTotp
 
-	case util.Config.Auth.Email.Enabled && (!util.Config.Auth.Email.DisableForOidc || !oidc):
+	case util.Config.Auth.Email.Enabled && (!util.Config.Auth.Email.DisableForOidc || oidcSession == nil):
 
 		err = newEmailOtp(user.ID, user.Email, helpers.Store(r))
 
@@ -178,7 +166,7 @@ func createSession(w http.ResponseWriter, r *http.Request, user db.User, oidc bo
 		verified = true
 	}
 
-	newSession, err := helpers.Store(r).CreateSession(db.Session{
+	session := db.Session{
 		UserID:             user.ID,
 		Created:            tz.Now(),
 		LastActive:         tz.Now(),
@@ -187,7 +175,13 @@ func createSession(w http.ResponseWriter, r *http.Request, user db.User, oidc bo
 		Expired:            false,
 		VerificationMethod: verificationMethod,
 		Verified:           verified,
-	})
+	}
+
+	if oidcSession != nil {
+		oidcSession.fillSession(&session)
+	}
+
+	newSession, err := helpers.Store(r).CreateSession(session)
 
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
@@ -255,6 +249,15 @@ func loginByLDAP(store db.Store, ldapUser db.User) (user db.User, err error) {
 		return
 	}
 
+	// Users which logged in via LDAP before the flag was introduced.
+	if !user.Ldap {
+		err = store.SetUserLdap(user.ID)
+		if err != nil {
+			return
+		}
+		user.Ldap = true
+	}
+
 	return
 }
 
@@ -356,6 +359,13 @@ func login(w http.ResponseWriter, r *http.Request) {
 		user, err = loginByPassword(helpers.Store(r), login.Auth, login.Password)
 	} else {
 		user, err = loginByLDAP(helpers.Store(r), *ldapUser)
+
+		if err == nil && util.Config.LdapGroupSync.IsEnabled() {
+			syncErr := ldap_sync.NewGroupSync(helpers.Store(r), util.Config.LdapGroupSync).SyncUser(user)
+			if syncErr != nil {
+				log.WithError(syncErr).Error("Failed to sync LDAP groups of user " + user.Username)
+			}
+		}
 	}
 
 	if err != nil {
@@ -374,7 +384,7 @@ func login(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 
-	createSession(w, r, user, false)
+	createSession(w, r, user, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -391,6 +401,8 @@ func login(w http.ResponseWriter, r *http.Request) {
 // - 204 No Content: Logout successful.
 // - 500 Internal Server Error: An error occurred while expiring the session.
 func logout(w http.ResponseWriter, r *http.Request) {
+	var endSessionURL string
+
 	if session, ok := getSession(r); ok {
 		err := helpers.Store(r).ExpireSession(session.UserID, session.ID)
 		if err != nil {
@@ -398,6 +410,13 @@ func logout(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+
+		if session.OidcProvider != "" {
+			endSessionURL, err = oidcEndSessionURL(r.Context(), session)
+			if err != nil {
+				log.WithError(err).Warn("Failed to get OIDC end session URL")
+			}
+		}
 	}
 
 	http.SetCookie(w, &http.Cookie{
@@ -408,6 +427,14 @@ func logout(w http.ResponseWriter, r *http.Request) {
 		HttpOnly: true,
 	})
 
+	if endSessionURL != "" {
+		// The UI redirects the user to the provider to finish the logout there.
+		helpers.WriteJSON(w, http.StatusOK, map[string]string{
+			"redirect_url": endSessionURL,
+		})
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -513,19 +540,30 @@ func oidcLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	state := generateStateOauthCookie(w)
-	u := oauth.AuthCodeURL(state)
+
+	verifier := oauth2.GenerateVerifier()
+	setOidcFlowCookie(w, oidcVerifierCookie, verifier)
+
+	nonce := generateOauthRandomValue()
+	setOidcFlowCookie(w, oidcNonceCookie, nonce)
+
+	u := oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce))
 	http.Redirect(w, r, u, http.StatusTemporaryRedirect)
 }
 
-func generateStateOauthCookie(w http.ResponseWriter) string {
-	expiration := tz.Now().Add(365 * 24 * time.Hour)
-
+func generateOauthRandomValue() string {
 	b := make([]byte, 16)
 	_, err := rand.Read(b)
 	if err != nil {
 		panic(err)
 	}
-	oauthState := base64.URLEncoding.EncodeToString(b)
+	return base64.URLEncoding.EncodeToString(b)
+}
+
+func generateStateOauthCookie(w http.ResponseWriter) string {
+	expiration := tz.Now().Add(365 * 24 * time.Hour)
+
+	oauthState := generateOauthRandomValue()
 	cookie := http.Cookie{Name: "oauthstate", Value: oauthState, Expires: expiration}
 	http.SetCookie(w, &cookie)
 
@@ -536,6 +574,9 @@ type claimResult struct {
 	username string
 	name     string
 	email    string
+	// claims contains all claims returned by the provider,
+	// they are used for role mappings.
+	claims map[string]any
 }
 
 func parseClaim(str string, claims map[string]any) (string, bool) {
@@ -593,6 +634,7 @@ func prepareClaims(claims map[string]any) {
 
 func parseClaims(claims map[string]any, provider util.ClaimsProvider) (res claimResult, err error) {
 	var ok bool
+	res.claims = claims
 	res.email, ok = parseClaim(provider.GetEmailClaim(), claims)
 
 	if !ok {
@@ -688,7 +730,14 @@ func oidcRedirect(w http.ResponseWriter, r *http.Request) {
 
 	code := r.URL.Query().Get("code")
 
-	oauth2Token, err := oauth.Exchange(ctx, code)
+	codeVerifier, err := r.Cookie(oidcVerifierCookie)
+	if err != nil {
+		log.Error("OIDC PKCE verifier cookie is missing")
+		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	oauth2Token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier.Value))
 	if err != nil {
 		log.Error(err.Error())
 		http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
@@ -697,6 +746,12 @@ func oidcRedirect(w http.ResponseWriter, r *http.Request) {
 
 	var claims claimResult
 
+	oidcSession := &oidcSessionInfo{
+		provider:     pid,
+		refreshToken: oauth2Token.RefreshToken,
+		expiry:       oauth2Token.Expiry,
+	}
+
 	// Extract the ID Token from OAuth2 token.
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
 
@@ -705,9 +760,19 @@ func oidcRedirect(w http.ResponseWriter, r *http.Request) {
 		// Parse and verify ID Token payload.
 		idToken, err = verifier.Verify(ctx, rawIDToken)
 
+		if err == nil {
+			err = verifyOidcNonce(r, idToken)
+		}
+
 		if err == nil {
 			claims, err = claimOidcToken(idToken, provider)
 		}
+
+		if err == nil {
+			oidcSession.idToken = rawIDToken
+			oidcSession.subject = idToken.Subject
+			oidcSession.sid, _ = claims.claims["sid"].(string)
+		}
 	} else {
 		var userInfo *oidc.UserInfo
 		userInfo, err = _oidc.UserInfo(ctx, oauth2.StaticTokenSource(oauth2Token))
@@ -718,6 +783,7 @@ func oidcRedirect(w http.ResponseWriter, r *http.Request) {
 			} else {
 				claims.email = userInfo.Email
 				claims.name = userInfo.Profile
+				err = userInfo.Claims(&claims.claims)
 			}
 		}
 
@@ -756,7 +822,25 @@ func oidcRedirect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createSession(w, r, user, true)
+	if len(provider.RoleMappings) > 0 {
+		// Login fails if mappings can not be applied, otherwise
+		// the user could keep access revoked by the provider.
+		err = membership.Apply(helpers.Store(r), user, membership.FromClaims(claims.claims, provider.RoleMappings))
+		if err != nil {
+			log.WithError(err).Error("Failed to apply OIDC role mappings of user " + user.Username)
+			http.Redirect(w, r, loginURL, http.StatusTemporaryRedirect)
+			return
+		}
+	}
+
+	if provider.RefreshSession && oidcSession.refreshToken == "" {
+		log.Warn("OIDC provider " + pid + " did not return refresh token, session of user " +
+			user.Username + " will not be refreshed")
+	}
+
+	clearOidcFlowCookies(w)
+
+	createSession(w, r, user, oidcSession)
 
 	redirectPath := mux.Vars(r)["redirect_path"]
 
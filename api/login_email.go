@@ -86,7 +86,7 @@ func loginEmail(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	createSession(w, r, user, false)
+	createSession(w, r, user, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcVerifierCookie = "oauthverifier"
+	oidcNonceCookie    = "oauthnonce"
+
+	// oidcFlowCookieTTL limits the time between the login redirect
+	// to the provider and the callback.
+	oidcFlowCookieTTL = 10 * time.Minute
+
+	// oidcRefreshInterval is used when the provider does not return
+	// expiration time of the access token.
+	oidcRefreshInterval = 5 * time.Minute
+
+	oidcBackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+)
+
+// oidcRefreshLock prevents concurrent requests of the same session from
+// using a rotated refresh token twice.
+var oidcRefreshLock sync.Mutex
+
+// oidcSessionInfo contains tokens issued by the provider on login.
+type oidcSessionInfo struct {
+	provider     string
+	subject      string
+	sid          string
+	idToken      string
+	refreshToken string
+	expiry       time.Time
+}
+
+func (info *oidcSessionInfo) fillSession(session *db.Session) {
+	session.OidcProvider = info.provider
+	session.OidcSubject = info.subject
+	session.OidcSessionID = info.sid
+
+	if info.idToken != "" {
+		session.OidcIDToken = &info.idToken
+	}
+
+	if info.refreshToken == "" {
+		return
+	}
+
+	encoded, err := encodeOidcRefreshToken(info.refreshToken)
+	if err != nil {
+		log.WithError(err).Error("Failed to encode OIDC refresh token")
+		return
+	}
+
+	expiry := oidcTokenExpiry(info.expiry)
+	session.OidcRefreshToken = &encoded
+	session.OidcTokenExpiry = &expiry
+}
+
+func encodeOidcRefreshToken(token string) (string, error) {
+	return util.Cookie.Encode("oidc_refresh_token", token)
+}
+
+func decodeOidcRefreshToken(encoded string) (token string, err error) {
+	err = util.Cookie.Decode("oidc_refresh_token", encoded, &token)
+	return
+}
+
+func oidcTokenExpiry(expiry time.Time) time.Time {
+	if expiry.IsZero() {
+		return tz.Now().Add(oidcRefreshInterval)
+	}
+	return expiry
+}
+
+// oidcFlowCookiePath returns the path of OIDC endpoints, it respects
+// the path of the web host when Semaphore is served from a sub-path.
+func oidcFlowCookiePath() string {
+	webPath := "/"
+	if util.WebHostURL != nil {
+		webPath = util.WebHostURL.Path
+		if !strings.HasSuffix(webPath, "/") {
+			webPath += "/"
+		}
+	}
+	return webPath + "api/auth/oidc"
+}
+
+func setOidcFlowCookie(w http.ResponseWriter, name string, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     oidcFlowCookiePath(),
+		MaxAge:   int(oidcFlowCookieTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearOidcFlowCookies(w http.ResponseWriter) {
+	for _, name := range []string{oidcVerifierCookie, oidcNonceCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Path:     oidcFlowCookiePath(),
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+	}
+}
+
+// verifyOidcNonce compares the nonce of the ID token with the one
+// sent to the provider by the login request.
+func verifyOidcNonce(r *http.Request, idToken *oidc.IDToken) error {
+	nonce, err := r.Cookie(oidcNonceCookie)
+	if err != nil {
+		return errors.New("OIDC nonce cookie is missing")
+	}
+
+	if nonce.Value == "" || idToken.Nonce != nonce.Value {
+		return errors.New("OIDC ID token nonce mismatch")
+	}
+
+	return nil
+}
+
+// refreshOidcSession refreshes tokens of the session created by the provider
+// with enabled refresh_session option when the access token expires.
+// It returns false if the session can not be used anymore.
+func refreshOidcSession(r *http.Request, session *db.Session) bool {
+	if session.OidcProvider == "" {
+		return true
+	}
+
+	provider, ok := util.Config.OidcProviders[session.OidcProvider]
+	if !ok {
+		return false
+	}
+
+	if !provider.RefreshSession || session.OidcRefreshToken == nil {
+		return true
+	}
+
+	if session.OidcTokenExpiry != nil && tz.Now().Before(*session.OidcTokenExpiry) {
+		return true
+	}
+
+	oidcRefreshLock.Lock()
+	defer oidcRefreshLock.Unlock()
+
+	store := helpers.Store(r)
+
+	// The session could be refreshed by a concurrent request.
+	current, err := store.GetSession(session.UserID, session.ID)
+	if err != nil {
+		return false
+	}
+
+	if current.OidcTokenExpiry != nil && tz.Now().Before(*current.OidcTokenExpiry) {
+		return true
+	}
+
+	refreshToken, err := decodeOidcRefreshToken(*current.OidcRefreshToken)
+	if err != nil {
+		log.WithError(err).Error("Failed to decode OIDC refresh token")
+		return false
+	}
+
+	_, oauth, err := getOidcProvider(session.OidcProvider, r.Context(), "")
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+
+	token, err := oauth.TokenSource(r.Context(), &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			// The provider revoked the user or the session.
+			if err2 := store.ExpireSession(session.UserID, session.ID); err2 != nil {
+				log.Error(err2)
+			}
+		}
+		log.WithError(err).WithField("user_id", session.UserID).Warn("Failed to refresh OIDC session")
+		return false
+	}
+
+	if token.RefreshToken != "" {
+		refreshToken = token.RefreshToken
+	}
+
+	encoded, err := encodeOidcRefreshToken(refreshToken)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+
+	expiry := oidcTokenExpiry(token.Expiry)
+
+	if err = store.UpdateSessionOidcToken(session.UserID, session.ID, encoded, &expiry); err != nil {
+		log.Error(err)
+		return false
+	}
+
+	return true
+}
+
+// oidcEndSessionURL returns the address of the provider which
+// terminates the user session there, or empty string if the provider
+// does not support RP-initiated logout.
+func oidcEndSessionURL(ctx context.Context, session *db.Session) (string, error) {
+	provider, ok := util.Config.OidcProviders[session.OidcProvider]
+	if !ok {
+		return "", nil
+	}
+
+	oidcProvider, oauth, err := getOidcProvider(session.OidcProvider, ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := provider.Endpoint.EndSessionURL
+
+	if endpoint == "" {
+		var meta struct {
+			EndSessionEndpoint string `json:"end_session_endpoint"`
+		}
+
+		// Providers configured without discovery have no claims.
+		if oidcProvider.Claims(&meta) == nil {
+			endpoint = meta.EndSessionEndpoint
+		}
+	}
+
+	if endpoint == "" {
+		return "", nil
+	}
+
+	redirectURL := provider.PostLogoutRedirectURL
+	if redirectURL == "" {
+		if redirectURL, err = url.JoinPath(util.Config.WebHost, "auth/login"); err != nil {
+			return "", err
+		}
+	}
+
+	res, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	q := res.Query()
+	q.Set("client_id", oauth.ClientID)
+	q.Set("post_logout_redirect_uri", redirectURL)
+	if session.OidcIDToken != nil {
+		q.Set("id_token_hint", *session.OidcIDToken)
+	}
+	res.RawQuery = q.Encode()
+
+	return res.String(), nil
+}
+
+// oidcBackChannelLogout expires sessions terminated by the provider.
+// See https://openid.net/specs/openid-connect-backchannel-1_0.html
+func oidcBackChannelLogout(w http.ResponseWriter, r *http.Request) {
+	pid := mux.Vars(r)["provider"]
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	logoutToken := r.PostFormValue("logout_token")
+	if logoutToken == "" {
+		helpers.WriteErrorStatus(w, "logout_token is required", http.StatusBadRequest)
+		return
+	}
+
+	oidcProvider, oauth, err := getOidcProvider(pid, r.Context(), "")
+	if err != nil {
+		helpers.WriteErrorStatus(w, "unknown provider", http.StatusBadRequest)
+		return
+	}
+
+	token, err := oidcProvider.Verifier(&oidc.Config{ClientID: oauth.ClientID}).Verify(r.Context(), logoutToken)
+	if err != nil {
+		log.WithError(err).Warn("Invalid OIDC logout token")
+		helpers.WriteErrorStatus(w, "invalid logout token", http.StatusBadRequest)
+		return
+	}
+
+	var claims struct {
+		Sid    string         `json:"sid"`
+		Nonce  *string        `json:"nonce"`
+		Events map[string]any `json:"events"`
+	}
+
+	if err = token.Claims(&claims); err != nil {
+		helpers.WriteErrorStatus(w, "invalid logout token", http.StatusBadRequest)
+		return
+	}
+
+	_, isLogoutEvent := claims.Events[oidcBackChannelLogoutEvent]
+
+	if !isLogoutEvent || claims.Nonce != nil || (token.Subject == "" && claims.Sid == "") {
+		helpers.WriteErrorStatus(w, "invalid logout token", http.StatusBadRequest)
+		return
+	}
+
+	if err = helpers.Store(r).ExpireOidcSessions(pid, token.Subject, claims.Sid); err != nil {
+		log.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
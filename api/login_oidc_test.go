@@ -0,0 +1,426 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fakeOidcClientID = "semaphore"
+	fakeOidcVerifier = "verifier-0123456789-0123456789-0123456789-0123"
+	fakeOidcNonce    = "nonce"
+)
+
+// fakeOidcIssuer is a minimal OpenID provider which issues ID tokens
+// with the configured claims for any authorization code.
+type fakeOidcIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+	// revoked makes the issuer reject refresh tokens.
+	revoked bool
+}
+
+func newFakeOidcIssuer(t *testing.T) *fakeOidcIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &fakeOidcIssuer{key: key}
+
+	mx := http.NewServeMux()
+	mx.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer.URL,
+			"authorization_endpoint":                issuer.URL + "/auth",
+			"token_endpoint":                        issuer.URL + "/token",
+			"jwks_uri":                              issuer.URL + "/keys",
+			"end_session_endpoint":                  issuer.URL + "/logout",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mx.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key:       &key.PublicKey,
+			KeyID:     "test",
+			Algorithm: string(jose.RS256),
+			Use:       "sig",
+		}}})
+	})
+	mx.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.PostFormValue("grant_type") {
+		case "authorization_code":
+			if r.PostFormValue("code_verifier") != fakeOidcVerifier {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "access",
+				"token_type":    "Bearer",
+				"expires_in":    3600,
+				"refresh_token": "refresh-1",
+				"id_token":      issuer.idToken(t),
+			})
+		case "refresh_token":
+			if issuer.revoked {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "access",
+				"token_type":    "Bearer",
+				"expires_in":    3600,
+				"refresh_token": "refresh-2",
+			})
+		}
+	})
+
+	issuer.Server = httptest.NewServer(mx)
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+func (i *fakeOidcIssuer) idToken(t *testing.T) string {
+	claims := map[string]any{
+		"nonce": fakeOidcNonce,
+		"sid":   "session-1",
+	}
+	for k, v := range i.claims {
+		claims[k] = v
+	}
+
+	return i.sign(t, claims)
+}
+
+func (i *fakeOidcIssuer) logoutToken(t *testing.T, sid string) string {
+	return i.sign(t, map[string]any{
+		"sid": sid,
+		"jti": "logout-1",
+		"events": map[string]any{
+			oidcBackChannelLogoutEvent: map[string]any{},
+		},
+	})
+}
+
+func (i *fakeOidcIssuer) sign(t *testing.T, extra map[string]any) string {
+	claims := map[string]any{
+		"iss": i.URL,
+		"aud": fakeOidcClientID,
+		"sub": "1",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: i.key},
+		(&jose.SignerOptions{}).WithHeader("kid", "test"),
+	)
+	require.NoError(t, err)
+
+	obj, err := signer.Sign(payload)
+	require.NoError(t, err)
+
+	token, err := obj.CompactSerialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func setupOidcTest(t *testing.T, issuer *fakeOidcIssuer, mappings []util.OidcRoleMapping) {
+	util.Config = &util.ConfigType{
+		WebHost: "http://localhost:3000",
+		Auth: &util.AuthConfig{
+			Totp:  &util.TotpConfig{},
+			Email: &util.EmailAuthConfig{},
+		},
+		OidcProviders: map[string]util.OidcProvider{
+			"fake": {
+				ClientID:      fakeOidcClientID,
+				ClientSecret:  "secret",
+				AutoDiscovery: issuer.URL,
+				UsernameClaim: "preferred_username",
+				NameClaim:     "name",
+				EmailClaim:    "email",
+				RoleMappings:  mappings,
+			},
+		},
+	}
+	util.Cookie = securecookie.New(securecookie.GenerateRandomKey(32), nil)
+}
+
+func doOidcRedirect(store db.Store) *httptest.ResponseRecorder {
+	return doOidcRedirectWithCookies(store, fakeOidcVerifier, fakeOidcNonce)
+}
+
+func doOidcRedirectWithCookies(store db.Store, verifier string, nonce string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/fake/redirect?state=state&code=code", nil)
+	r.AddCookie(&http.Cookie{Name: "oauthstate", Value: "state"})
+	r.AddCookie(&http.Cookie{Name: oidcVerifierCookie, Value: verifier})
+	r.AddCookie(&http.Cookie{Name: oidcNonceCookie, Value: nonce})
+	r = mux.SetURLVars(r, map[string]string{"provider": "fake"})
+	r = helpers.SetContextValue(r, "store", store)
+
+	w := httptest.NewRecorder()
+	oidcRedirect(w, r)
+
+	return w
+}
+
+func TestOidcRedirect_RoleMappings(t *testing.T) {
+	issuer := newFakeOidcIssuer(t)
+	store := bolt.CreateTestStore()
+
+	ops, err := store.CreateProject(db.Project{Name: "Ops"})
+	require.NoError(t, err)
+	dev, err := store.CreateProject(db.Project{Name: "Dev"})
+	require.NoError(t, err)
+
+	setupOidcTest(t, issuer, []util.OidcRoleMapping{
+		{Claim: "groups", Value: "platform-admins", Admin: true},
+		{Claim: "groups", Value: "platform-admins", ProjectID: ops.ID, Role: string(db.ProjectOwner)},
+		{Claim: "groups", Value: "developers", ProjectID: dev.ID, Role: string(db.ProjectTaskRunner)},
+		{Claim: "realm_access.roles", Value: "viewer", ProjectID: dev.ID, Role: string(db.ProjectGuest)},
+	})
+
+	issuer.claims = map[string]any{
+		"email":              "john@example.org",
+		"preferred_username": "john",
+		"name":               "John",
+		"groups":             []string{"platform-admins", "developers"},
+	}
+
+	w := doOidcRedirect(store)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	assert.False(t, strings.HasSuffix(w.Header().Get("Location"), "/auth/login"))
+
+	user, err := store.GetUserByLoginOrEmail("", "john@example.org")
+	require.NoError(t, err)
+	assert.True(t, user.External)
+	assert.True(t, user.Admin)
+
+	projectUser, err := store.GetProjectUser(ops.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.ProjectOwner, projectUser.Role)
+
+	projectUser, err = store.GetProjectUser(dev.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.ProjectTaskRunner, projectUser.Role)
+
+	// Claims disappeared at the provider.
+	issuer.claims["groups"] = []string{}
+	issuer.claims["realm_access"] = map[string]any{"roles": []string{"viewer"}}
+
+	w = doOidcRedirect(store)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	user, err = store.GetUser(user.ID)
+	require.NoError(t, err)
+	assert.False(t, user.Admin)
+
+	_, err = store.GetProjectUser(ops.ID, user.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	projectUser, err = store.GetProjectUser(dev.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.ProjectGuest, projectUser.Role)
+}
+
+func isLoginRedirect(w *httptest.ResponseRecorder) bool {
+	return w.Code == http.StatusTemporaryRedirect && strings.HasSuffix(w.Header().Get("Location"), "/auth/login")
+}
+
+// oidcLoginSession logs in with the fake issuer and returns the created session.
+func oidcLoginSession(t *testing.T, store db.Store) (db.Session, *http.Cookie) {
+	w := doOidcRedirect(store)
+	require.False(t, isLoginRedirect(w))
+
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "semaphore" {
+			cookie = c
+		}
+	}
+	require.NotNil(t, cookie)
+
+	value := make(map[string]any)
+	require.NoError(t, util.Cookie.Decode("semaphore", cookie.Value, &value))
+
+	session, err := store.GetSession(value["user"].(int), value["session"].(int))
+	require.NoError(t, err)
+
+	return session, cookie
+}
+
+func TestOidcRedirect_PKCEAndNonce(t *testing.T) {
+	issuer := newFakeOidcIssuer(t)
+	store := bolt.CreateTestStore()
+	setupOidcTest(t, issuer, nil)
+
+	issuer.claims = map[string]any{"email": "john@example.org"}
+
+	assert.True(t, isLoginRedirect(doOidcRedirectWithCookies(store, "wrong-verifier", fakeOidcNonce)))
+	assert.True(t, isLoginRedirect(doOidcRedirectWithCookies(store, fakeOidcVerifier, "wrong-nonce")))
+
+	_, err := store.GetUserByLoginOrEmail("", "john@example.org")
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	session, _ := oidcLoginSession(t, store)
+	assert.Equal(t, "fake", session.OidcProvider)
+	assert.Equal(t, "1", session.OidcSubject)
+	assert.Equal(t, "session-1", session.OidcSessionID)
+	require.NotNil(t, session.OidcRefreshToken)
+
+	refreshToken, err := decodeOidcRefreshToken(*session.OidcRefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-1", refreshToken)
+}
+
+func TestRefreshOidcSession(t *testing.T) {
+	issuer := newFakeOidcIssuer(t)
+	store := bolt.CreateTestStore()
+	setupOidcTest(t, issuer, nil)
+
+	provider := util.Config.OidcProviders["fake"]
+	provider.RefreshSession = true
+	util.Config.OidcProviders["fake"] = provider
+
+	issuer.claims = map[string]any{"email": "john@example.org"}
+
+	session, _ := oidcLoginSession(t, store)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/user", nil)
+	r = helpers.SetContextValue(r, "store", store)
+
+	// Access token is still valid.
+	assert.True(t, refreshOidcSession(r, &session))
+
+	expired := time.Now().Add(-time.Minute)
+	require.NoError(t, store.UpdateSessionOidcToken(session.UserID, session.ID, *session.OidcRefreshToken, &expired))
+	session.OidcTokenExpiry = &expired
+
+	assert.True(t, refreshOidcSession(r, &session))
+
+	session, err := store.GetSession(session.UserID, session.ID)
+	require.NoError(t, err)
+	assert.True(t, session.OidcTokenExpiry.After(time.Now()))
+
+	refreshToken, err := decodeOidcRefreshToken(*session.OidcRefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh-2", refreshToken)
+
+	issuer.revoked = true
+	require.NoError(t, store.UpdateSessionOidcToken(session.UserID, session.ID, *session.OidcRefreshToken, &expired))
+	session.OidcTokenExpiry = &expired
+
+	assert.False(t, refreshOidcSession(r, &session))
+
+	_, err = store.GetSession(session.UserID, session.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestOidcLogout(t *testing.T) {
+	issuer := newFakeOidcIssuer(t)
+	store := bolt.CreateTestStore()
+	setupOidcTest(t, issuer, nil)
+
+	issuer.claims = map[string]any{"email": "john@example.org"}
+
+	session, cookie := oidcLoginSession(t, store)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	r.AddCookie(cookie)
+	r = helpers.SetContextValue(r, "store", store)
+
+	w := httptest.NewRecorder()
+	logout(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		RedirectURL string `json:"redirect_url"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.True(t, strings.HasPrefix(res.RedirectURL, issuer.URL+"/logout?"))
+	assert.Contains(t, res.RedirectURL, "id_token_hint=")
+	assert.Contains(t, res.RedirectURL, "post_logout_redirect_uri=")
+
+	_, err := store.GetSession(session.UserID, session.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestOidcBackChannelLogout(t *testing.T) {
+	issuer := newFakeOidcIssuer(t)
+	store := bolt.CreateTestStore()
+	setupOidcTest(t, issuer, nil)
+
+	issuer.claims = map[string]any{"email": "john@example.org"}
+
+	session, _ := oidcLoginSession(t, store)
+
+	doLogout := func(token string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/fake/backchannel_logout",
+			strings.NewReader(url.Values{"logout_token": {token}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r = mux.SetURLVars(r, map[string]string{"provider": "fake"})
+		r = helpers.SetContextValue(r, "store", store)
+
+		w := httptest.NewRecorder()
+		oidcBackChannelLogout(w, r)
+		return w.Code
+	}
+
+	// ID token is not a logout token.
+	assert.Equal(t, http.StatusBadRequest, doLogout(issuer.idToken(t)))
+
+	assert.Equal(t, http.StatusOK, doLogout(issuer.logoutToken(t, "other-session")))
+
+	_, err := store.GetSession(session.UserID, session.ID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, doLogout(issuer.logoutToken(t, "session-1")))
+
+	_, err = store.GetSession(session.UserID, session.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestOidcFlowCookiePath(t *testing.T) {
+	defer func(u *url.URL) { util.WebHostURL = u }(util.WebHostURL)
+
+	util.WebHostURL = nil
+	assert.Equal(t, "/api/auth/oidc", oidcFlowCookiePath())
+
+	util.WebHostURL, _ = url.Parse("https://example.org/semaphore")
+	assert.Equal(t, "/semaphore/api/auth/oidc", oidcFlowCookiePath())
+
+	w := httptest.NewRecorder()
+	setOidcFlowCookie(w, oidcVerifierCookie, "verifier")
+	assert.Equal(t, "/semaphore/api/auth/oidc", w.Result().Cookies()[0].Path)
+}
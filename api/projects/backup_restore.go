@@ -3,15 +3,54 @@ package projects
 import (
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/services/server"
 	log "github.com/sirupsen/logrus"
 )
 
-func GetBackup(w http.ResponseWriter, r *http.Request) {
+// BackupPassphraseHeader contains the passphrase which decrypts
+// secrets of the restored backup.
+const BackupPassphraseHeader = "X-Backup-Passphrase"
+
+type BackupController struct {
+	encryptionService server.AccessKeyEncryptionService
+}
+
+func NewBackupController(encryptionService server.AccessKeyEncryptionService) *BackupController {
+	return &BackupController{
+		encryptionService: encryptionService,
+	}
+}
+
+func (c *BackupController) secretOptions(passphrase string) *projectService.SecretOptions {
+	if passphrase == "" {
+		return nil
+	}
+
+	return &projectService.SecretOptions{
+		Passphrase:        passphrase,
+		EncryptionService: c.encryptionService,
+	}
+}
+
+func writeBackup(w http.ResponseWriter, backup *projectService.BackupFormat) {
+	str, err := backup.Marshal()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(str))
+}
+
+func (c *BackupController) GetBackup(w http.ResponseWriter, r *http.Request) {
 	project := helpers.GetFromContext(r, "project").(db.Project)
 
 	store := helpers.Store(r)
@@ -23,27 +62,49 @@ func GetBackup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	str, err := backup.Marshal()
+	writeBackup(w, backup)
+}
+
+// GetBackupWithSecrets returns the backup of the project including
+// secrets encrypted by the passphrase from the request body.
+func (c *BackupController) GetBackupWithSecrets(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+
+	if !helpers.Bind(w, r, &req) {
+		return
+	}
+
+	if req.Passphrase == "" {
+		helpers.WriteErrorStatus(w, "passphrase required", http.StatusBadRequest)
+		return
+	}
+
+	store := helpers.Store(r)
+
+	backup, err := projectService.GetBackupWithSecrets(project.ID, store, c.secretOptions(req.Passphrase))
+
 	if err != nil {
+		log.Error(err)
 		helpers.WriteError(w, err)
 		return
 	}
 
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(str))
+	writeBackup(w, backup)
 }
 
-func Restore(w http.ResponseWriter, r *http.Request) {
-	user := helpers.GetFromContext(r, "user").(*db.User)
-
+// readBackup reads and verifies the backup from the request body.
+func readBackup(w http.ResponseWriter, r *http.Request) (*projectService.BackupFormat, bool) {
 	var backup projectService.BackupFormat
 
 	buf := new(strings.Builder)
 	if _, err := io.Copy(buf, r.Body); err != nil {
 		log.Error(err)
 		helpers.WriteError(w, err)
-		return
+		return nil, false
 	}
 
 	str := buf.String()
@@ -51,18 +112,30 @@ func Restore(w http.ResponseWriter, r *http.Request) {
 	if err := backup.Unmarshal(str); err != nil {
 		log.Error(err)
 		helpers.WriteError(w, err)
-		return
+		return nil, false
 	}
 
-	store := helpers.Store(r)
 	if err := backup.Verify(); err != nil {
 		log.Error(err)
 		helpers.WriteError(w, err)
+		return nil, false
+	}
+
+	return &backup, true
+}
+
+func (c *BackupController) Restore(w http.ResponseWriter, r *http.Request) {
+	user := helpers.GetFromContext(r, "user").(*db.User)
+
+	backup, ok := readBackup(w, r)
+	if !ok {
 		return
 	}
 
+	store := helpers.Store(r)
+
 	var p *db.Project
-	p, err := backup.Restore(*user, store)
+	p, err := backup.RestoreWithSecrets(*user, store, c.secretOptions(r.Header.Get(BackupPassphraseHeader)))
 
 	if err != nil {
 		log.Error(err)
@@ -72,3 +145,29 @@ func Restore(w http.ResponseWriter, r *http.Request) {
 
 	helpers.WriteJSON(w, http.StatusOK, p)
 }
+
+// RestoreInto applies the backup to the existing project and returns the diff.
+// With dry_run query parameter the project is not changed.
+func (c *BackupController) RestoreInto(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	backup, ok := readBackup(w, r)
+	if !ok {
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	diff, err := backup.RestoreInto(project.ID, helpers.Store(r), projectService.RestoreIntoOptions{
+		DryRun:  dryRun,
+		Secrets: c.secretOptions(r.Header.Get(BackupPassphraseHeader)),
+	})
+
+	if err != nil {
+		log.Error(err)
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, diff)
+}
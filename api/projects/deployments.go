@@ -0,0 +1,244 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/common_errors"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	log "github.com/sirupsen/logrus"
+)
+
+// makeDeployments adds the target inventory and the deployed version to tasks.
+func makeDeployments(store db.Store, tasks []db.TaskWithTpl) (deployments []db.Deployment, err error) {
+	deployments = make([]db.Deployment, 0, len(tasks))
+	templates := make(map[int]db.Template)
+
+	for _, task := range tasks {
+		deployment := db.Deployment{
+			TaskWithTpl:       task,
+			TargetInventoryID: task.InventoryID,
+		}
+
+		if deployment.TargetInventoryID == nil {
+			tpl, ok := templates[task.TemplateID]
+			if !ok {
+				tpl, err = store.GetTemplate(task.ProjectID, task.TemplateID)
+				if err != nil {
+					return
+				}
+				templates[task.TemplateID] = tpl
+			}
+
+			deployment.TargetInventoryID = tpl.InventoryID
+		}
+
+		if buildTask := task.GetIncomingBuildTask(store); buildTask != nil {
+			deployment.IncomingBuildTaskID = &buildTask.ID
+			deployment.IncomingVersion = buildTask.Version
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	return
+}
+
+// getDeploymentTemplate returns the deploy template from the context and
+// the inventory from the query string or writes the error.
+func getDeploymentTemplate(w http.ResponseWriter, r *http.Request) (tpl db.Template, inventoryID *int, ok bool) {
+	tpl = helpers.GetFromContext(r, "template").(db.Template)
+
+	if tpl.Type != db.TemplateDeploy {
+		helpers.WriteErrorStatus(w, "Template is not a deploy template", http.StatusBadRequest)
+		return
+	}
+
+	if str := r.URL.Query().Get("inventory_id"); str != "" {
+		id, err := strconv.Atoi(str)
+		if err != nil {
+			helpers.WriteErrorStatus(w, "Invalid inventory_id", http.StatusBadRequest)
+			return
+		}
+		inventoryID = &id
+	}
+
+	ok = true
+	return
+}
+
+// GetDeployments returns the last successful deployment of every pair
+// of the deploy template and the inventory.
+func GetDeployments(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	tasks, err := store.GetDeployments(project.ID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	deployments, err := makeDeployments(store, tasks)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, deployments)
+}
+
+// GetTemplateDeployments returns the history of deployments of the template
+// to the inventory from the inventory_id query parameter.
+func GetTemplateDeployments(w http.ResponseWriter, r *http.Request) {
+	tpl, inventoryID, ok := getDeploymentTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	store := helpers.Store(r)
+
+	tasks, err := store.GetDeploymentTasks(tpl.ProjectID, tpl.ID, inventoryID, db.RetrieveQueryParams{Count: limit})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	deployments, err := makeDeployments(store, tasks)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, deployments)
+}
+
+func equalInventoryIDs(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// findRollbackTask returns the last successful deployment of the version
+// which preceded the currently deployed version. tasks are sorted from the
+// newest to the oldest. Deployment of a version which was deployed before
+// rolls back all versions deployed after it, so repeated rollbacks walk back
+// through the history instead of returning to the rolled back version.
+func findRollbackTask(store db.Store, tasks []db.TaskWithTpl) (task db.Task, buildTask *db.Task) {
+	type deployedBuild struct {
+		task  db.Task
+		build *db.Task
+	}
+
+	var deployed []deployedBuild
+
+	for i := len(tasks) - 1; i >= 0; i-- {
+		build := tasks[i].GetIncomingBuildTask(store)
+		if build == nil {
+			continue
+		}
+
+		n := slices.IndexFunc(deployed, func(d deployedBuild) bool {
+			return d.build.ID == build.ID
+		})
+		if n >= 0 {
+			deployed = deployed[:n]
+		}
+
+		deployed = append(deployed, deployedBuild{task: tasks[i].Task, build: build})
+	}
+
+	if len(deployed) < 2 {
+		return
+	}
+
+	previous := deployed[len(deployed)-2]
+	return previous.task, previous.build
+}
+
+// RollbackDeployment runs the deploy template with the version deployed
+// before the current one to the inventory from the inventory_id query parameter.
+func RollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	user := helpers.GetFromContext(r, "user").(*db.User)
+
+	tpl, inventoryID, ok := getDeploymentTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	tasks, err := store.GetDeploymentTasks(tpl.ProjectID, tpl.ID, inventoryID, db.RetrieveQueryParams{
+		TaskFilter: &db.TaskFilter{Status: []task_logger.TaskStatus{task_logger.TaskSuccessStatus}},
+	})
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	canOverrideInventory, err := tpl.CanOverrideInventory()
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	if !canOverrideInventory && !equalInventoryIDs(inventoryID, tpl.InventoryID) {
+		helpers.WriteErrorStatus(w, "The template can not override the inventory", http.StatusBadRequest)
+		return
+	}
+
+	previous, buildTask := findRollbackTask(store, tasks)
+
+	if buildTask == nil {
+		helpers.WriteErrorStatus(w, "There is no previous version to roll back to", http.StatusBadRequest)
+		return
+	}
+
+	version := fmt.Sprintf("task #%d", buildTask.ID)
+	if buildTask.Version != nil {
+		version = *buildTask.Version
+	}
+
+	newTask, err := taskPool(r).AddTask(
+		r.Context(),
+		db.Task{
+			TemplateID:  tpl.ID,
+			ProjectID:   tpl.ProjectID,
+			InventoryID: inventoryID,
+			BuildTaskID: &buildTask.ID,
+			Playbook:    previous.Playbook,
+			Environment: previous.Environment,
+			Arguments:   previous.Arguments,
+			GitBranch:   previous.GitBranch,
+			CommitHash:  previous.CommitHash,
+			Params:      previous.Params,
+			Message:     "Rollback to " + version,
+		},
+		&user.ID,
+		user.Username,
+		tpl.ProjectID,
+		tpl.App.NeedTaskAlias(),
+	)
+
+	if errors.Is(err, common_errors.ErrInvalidSubscription) {
+		helpers.WriteErrorStatus(w, "No active subscription available.", http.StatusForbidden)
+		return
+	} else if err != nil {
+		log.WithError(err).WithField("template_id", tpl.ID).Error("Failed to roll back the deployment")
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, newTask)
+}
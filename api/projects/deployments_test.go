@@ -0,0 +1,122 @@
+package projects
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployments(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "deployments"})
+	require.NoError(t, err)
+
+	staging, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID, Name: "staging", Type: db.InventoryStatic})
+	require.NoError(t, err)
+
+	production, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID, Name: "production", Type: db.InventoryStatic})
+	require.NoError(t, err)
+
+	build, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "build", Playbook: "build.yml", Type: db.TemplateBuild})
+	require.NoError(t, err)
+
+	deploy, err := store.CreateTemplate(db.Template{
+		ProjectID:       proj.ID,
+		Name:            "deploy",
+		Playbook:        "deploy.yml",
+		Type:            db.TemplateDeploy,
+		BuildTemplateID: &build.ID,
+		InventoryID:     &staging.ID,
+	})
+	require.NoError(t, err)
+
+	createBuild := func(version string) db.Task {
+		task, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: build.ID, Version: &version}, 0)
+		require.NoError(t, err)
+		return task
+	}
+
+	createDeploy := func(buildTask db.Task, inventoryID *int, status task_logger.TaskStatus) db.Task {
+		task, err := store.CreateTask(db.Task{
+			ProjectID:   proj.ID,
+			TemplateID:  deploy.ID,
+			BuildTaskID: &buildTask.ID,
+			InventoryID: inventoryID,
+		}, 0)
+		require.NoError(t, err)
+
+		task.Status = status
+		require.NoError(t, store.UpdateTask(task))
+		return task
+	}
+
+	v1 := createBuild("1.0.0")
+	v2 := createBuild("2.0.0")
+
+	first := createDeploy(v1, nil, task_logger.TaskSuccessStatus)
+	second := createDeploy(v2, nil, task_logger.TaskSuccessStatus)
+	inProduction := createDeploy(v1, &production.ID, task_logger.TaskSuccessStatus)
+	createDeploy(v2, &staging.ID, task_logger.TaskFailStatus)
+
+	tasks, err := store.GetDeployments(proj.ID)
+	require.NoError(t, err)
+
+	deployments, err := makeDeployments(store, tasks)
+	require.NoError(t, err)
+	require.Len(t, deployments, 2)
+
+	byInventory := make(map[int]db.Deployment)
+	for _, d := range deployments {
+		require.NotNil(t, d.TargetInventoryID)
+		byInventory[*d.TargetInventoryID] = d
+	}
+
+	assert.Equal(t, second.ID, byInventory[staging.ID].ID)
+	assert.Equal(t, "2.0.0", *byInventory[staging.ID].IncomingVersion)
+	assert.Equal(t, inProduction.ID, byInventory[production.ID].ID)
+	assert.Equal(t, "1.0.0", *byInventory[production.ID].IncomingVersion)
+
+	// The failed task overrides the inventory of the template with the same one.
+	history, err := store.GetDeploymentTasks(proj.ID, deploy.ID, &staging.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	successful, err := store.GetDeploymentTasks(proj.ID, deploy.ID, &staging.ID, db.RetrieveQueryParams{
+		TaskFilter: &db.TaskFilter{Status: []task_logger.TaskStatus{task_logger.TaskSuccessStatus}},
+	})
+	require.NoError(t, err)
+	require.Len(t, successful, 2)
+
+	previous, buildTask := findRollbackTask(store, successful)
+	require.NotNil(t, buildTask)
+	assert.Equal(t, first.ID, previous.ID)
+	assert.Equal(t, v1.ID, buildTask.ID)
+
+	// Production has no previous version.
+	successful, err = store.GetDeploymentTasks(proj.ID, deploy.ID, &production.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+
+	_, buildTask = findRollbackTask(store, successful)
+	assert.Nil(t, buildTask)
+
+	// Repeated rollbacks do not return to the rolled back version.
+	v3 := createBuild("3.0.0")
+
+	createDeploy(v2, &production.ID, task_logger.TaskSuccessStatus)
+	createDeploy(v3, &production.ID, task_logger.TaskSuccessStatus)
+	rollback := createDeploy(v2, &production.ID, task_logger.TaskSuccessStatus)
+
+	successful, err = store.GetDeploymentTasks(proj.ID, deploy.ID, &production.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Equal(t, rollback.ID, successful[0].ID)
+
+	previous, buildTask = findRollbackTask(store, successful)
+	require.NotNil(t, buildTask)
+	assert.Equal(t, inProduction.ID, previous.ID)
+	assert.Equal(t, v1.ID, buildTask.ID)
+}
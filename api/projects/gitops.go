@@ -0,0 +1,191 @@
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/gitops"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	log "github.com/sirupsen/logrus"
+)
+
+// gitopsManagedRoutes matches routes which change objects described
+// by the project definition. Paths are relative to the project.
+var gitopsManagedRoutes = regexp.MustCompile(`^/(templates|inventory|environment|schedules|integrations|views)(/.*)?$`)
+
+// gitopsUnmanagedRoutes matches routes of managed objects which
+// do not change the objects, like stopping tasks of the template.
+var gitopsUnmanagedRoutes = regexp.MustCompile(`^/(templates/\{template_id\}/(stop_all_tasks|deployments/rollback)|schedules/validate|inventory/\{inventory_id\}/terraform/.*|integrations/aliases(/.*)?)$`)
+
+func isGitopsManagedRoute(pathTemplate string) bool {
+	_, rel, ok := strings.Cut(pathTemplate, "/project/{project_id}")
+	if !ok {
+		return false
+	}
+
+	return gitopsManagedRoutes.MatchString(rel) && !gitopsUnmanagedRoutes.MatchString(rel)
+}
+
+// GitopsLockMiddleware rejects changes of objects managed by the project
+// definition if the project has GitOps settings with locking enabled.
+func GitopsLockMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" || r.Method == "HEAD" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil || !isGitopsManagedRoute(pathTemplate) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		project := helpers.GetFromContext(r, "project").(db.Project)
+
+		settings, err := helpers.Store(r).GetProjectGitops(project.ID)
+
+		if errors.Is(err, db.ErrNotFound) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		if settings.LockManaged {
+			helpers.WriteErrorStatus(w, "Object is managed by the project definition in the repository.", http.StatusConflict)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type GitopsController struct {
+	reconciler *gitops.Reconciler
+}
+
+func NewGitopsController(reconciler *gitops.Reconciler) *GitopsController {
+	return &GitopsController{
+		reconciler: reconciler,
+	}
+}
+
+// projectGitops is GitOps settings of the project with decoded drift.
+type projectGitops struct {
+	db.ProjectGitops
+	Drift []projectService.RestoreChange `json:"drift"`
+}
+
+func newProjectGitops(settings db.ProjectGitops) (res projectGitops, err error) {
+	res.ProjectGitops = settings
+	res.Drift = make([]projectService.RestoreChange, 0)
+
+	if settings.Drift != nil {
+		err = json.Unmarshal([]byte(*settings.Drift), &res.Drift)
+	}
+
+	return
+}
+
+func writeProjectGitops(w http.ResponseWriter, settings db.ProjectGitops) {
+	res, err := newProjectGitops(settings)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+func (c *GitopsController) GetGitops(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	settings, err := helpers.Store(r).GetProjectGitops(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	writeProjectGitops(w, settings)
+}
+
+func (c *GitopsController) SetGitops(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	var settings db.ProjectGitops
+	if !helpers.Bind(w, r, &settings) {
+		return
+	}
+
+	settings.ProjectID = project.ID
+
+	store := helpers.Store(r)
+
+	if _, err := store.GetRepository(project.ID, settings.RepositoryID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			helpers.WriteErrorStatus(w, "Repository not found.", http.StatusBadRequest)
+		} else {
+			helpers.WriteError(w, err)
+		}
+		return
+	}
+
+	res, err := store.SetProjectGitops(settings)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	writeProjectGitops(w, res)
+}
+
+func (c *GitopsController) DeleteGitops(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	err := helpers.Store(r).DeleteProjectGitops(project.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SyncGitops synchronizes the project with its definition immediately.
+// With force query parameter the definition is applied even if it
+// was not changed, which discards the drift.
+func (c *GitopsController) SyncGitops(w http.ResponseWriter, r *http.Request) {
+	project := helpers.GetFromContext(r, "project").(db.Project)
+
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	res, err := c.reconciler.Sync(project.ID, force)
+
+	if err != nil {
+		log.Error(err)
+		helpers.WriteError(w, err)
+		return
+	}
+
+	writeProjectGitops(w, res)
+}
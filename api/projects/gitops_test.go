@@ -0,0 +1,40 @@
+package projects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGitopsManagedRoute(t *testing.T) {
+	managed := []string{
+		"/api/project/{project_id}/templates",
+		"/api/project/{project_id}/templates/{template_id}",
+		"/api/project/{project_id}/templates/{template_id}/perms/{perm_id}",
+		"/api/project/{project_id}/inventory/{inventory_id}",
+		"/api/project/{project_id}/environment",
+		"/api/project/{project_id}/schedules/{schedule_id}/active",
+		"/api/project/{project_id}/integrations/{integration_id}/matchers",
+		"/api/project/{project_id}/views/positions",
+	}
+
+	unmanaged := []string{
+		"/api/project/{project_id}/tasks",
+		"/api/project/{project_id}/keys/{key_id}",
+		"/api/project/{project_id}/repositories",
+		"/api/project/{project_id}/templates/{template_id}/stop_all_tasks",
+		"/api/project/{project_id}/templates/{template_id}/deployments/rollback",
+		"/api/project/{project_id}/schedules/validate",
+		"/api/project/{project_id}/inventory/{inventory_id}/terraform/aliases",
+		"/api/project/{project_id}/integrations/aliases",
+		"/api/project/{project_id}/gitops",
+	}
+
+	for _, p := range managed {
+		assert.True(t, isGitopsManagedRoute(p), p)
+	}
+
+	for _, p := range unmanaged {
+		assert.False(t, isGitopsManagedRoute(p), p)
+	}
+}
@@ -51,6 +51,18 @@ func GetIntegrations(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, integrations)
 }
 
+func GetIntegrationDeliveries(w http.ResponseWriter, r *http.Request) {
+	integration := helpers.GetFromContext(r, "integration").(db.Integration)
+	deliveries, err := helpers.Store(r).GetIntegrationDeliveries(integration.ProjectID, integration.ID, db.RetrieveQueryParams{Count: 200})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, deliveries)
+}
+
 func GetIntegrationRefs(w http.ResponseWriter, r *http.Request) {
 	integration_id, err := helpers.GetIntParam("integration_id", w, r)
 
@@ -88,9 +88,26 @@ func GetMustCanMiddleware(permissions db.ProjectUserPermission) mux.MiddlewareFu
 
 			userPerms := helpers.GetFromContext(r, "permissions").(db.ProjectUserPermission)
 
-			can := (userPerms & permissions) == permissions
+			if !me.Admin && r.Method != "GET" && r.Method != "HEAD" && !userPerms.Can(permissions) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetMustCanViewMiddleware ensures that the user has the permissions
+// for all request methods, including GET and HEAD.
+func GetMustCanViewMiddleware(permissions db.ProjectUserPermission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			me := helpers.GetFromContext(r, "user").(*db.User)
+
+			userPerms := helpers.GetFromContext(r, "permissions").(db.ProjectUserPermission)
 
-			if !me.Admin && r.Method != "GET" && r.Method != "HEAD" && !can {
+			if !me.Admin && !userPerms.Can(permissions) {
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
@@ -178,7 +195,7 @@ func GetUserRole(w http.ResponseWriter, r *http.Request) {
 		Permissions db.ProjectUserPermission `json:"permissions"`
 	}
 	permissions.Role = helpers.GetFromContext(r, "projectUserRole").(db.ProjectUserRole)
-	permissions.Permissions = permissions.Role.GetPermissions()
+	permissions.Permissions = helpers.GetFromContext(r, "permissions").(db.ProjectUserPermission)
 	helpers.WriteJSON(w, http.StatusOK, permissions)
 }
 
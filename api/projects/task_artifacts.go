@@ -0,0 +1,69 @@
+package projects
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/artifacts"
+	log "github.com/sirupsen/logrus"
+)
+
+type TaskArtifactController struct {
+	artifacts *artifacts.Service
+}
+
+func NewTaskArtifactController(artifacts *artifacts.Service) *TaskArtifactController {
+	return &TaskArtifactController{artifacts: artifacts}
+}
+
+func (c *TaskArtifactController) GetTaskArtifacts(w http.ResponseWriter, r *http.Request) {
+	task := helpers.GetFromContext(r, "task").(db.Task)
+
+	res, err := helpers.Store(r).GetTaskArtifacts(task.ProjectID, task.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+func (c *TaskArtifactController) DownloadTaskArtifact(w http.ResponseWriter, r *http.Request) {
+	task := helpers.GetFromContext(r, "task").(db.Task)
+
+	artifactID, err := helpers.GetIntParam("artifact_id", w, r)
+	if err != nil {
+		return
+	}
+
+	artifact, err := helpers.Store(r).GetTaskArtifact(task.ProjectID, task.ID, artifactID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	content, err := c.artifacts.Open(artifact)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	defer content.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(artifact.Name)))
+	w.Header().Set("Content-Length", strconv.FormatInt(artifact.Size, 10))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(w, content); err != nil {
+		log.WithError(err).WithField("artifact_id", artifact.ID).Error("Failed to send artifact")
+	}
+}
@@ -0,0 +1,176 @@
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// taskOutputStreamInterval is the period of reading new output lines.
+	// Output is written to the database by batches, so it is polled
+	// instead of subscribing to the task, which can run on another node.
+	taskOutputStreamInterval = time.Second
+
+	// taskOutputStreamKeepAlive is the period of comments which keep
+	// the connection open through proxies while the task is silent.
+	taskOutputStreamKeepAlive = 15 * time.Second
+
+	taskOutputStreamChunkSize = 1000
+)
+
+// getTaskOutputStreamStart returns the number of lines the client already has.
+// The event ID is the number of the line in the task output, so the client
+// continues from the last received event by Last-Event-ID or ?from=.
+func getTaskOutputStreamStart(r *http.Request) (int, error) {
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = r.Header.Get("Last-Event-ID")
+	}
+
+	if from == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(from)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid output ID %q", from)
+	}
+
+	return n, nil
+}
+
+func writeTaskStreamEvent(w http.ResponseWriter, id int, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id > 0 {
+		if _, err = fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// StreamTaskOutput sends output of the task as Server-Sent Events. It replays
+// the output after the given line and follows new lines until the task is finished.
+func StreamTaskOutput(w http.ResponseWriter, r *http.Request) {
+	task := helpers.GetFromContext(r, "task").(db.Task)
+	project := helpers.GetFromContext(r, "project").(db.Project)
+	store := helpers.Store(r)
+
+	offset, err := getTaskOutputStreamStart(r)
+	if err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.WriteErrorStatus(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("x-accel-buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(taskOutputStreamInterval)
+	defer ticker.Stop()
+
+	lastWrite := time.Now()
+	// The last lines are written to the database after the task is finished,
+	// so the output is read once more after the finished status is seen.
+	finishing := false
+
+	for {
+		for {
+			var output []db.TaskOutput
+			output, err = store.GetTaskOutputs(project.ID, task.ID, db.RetrieveQueryParams{
+				Offset: offset,
+				Count:  taskOutputStreamChunkSize,
+			})
+
+			if err != nil {
+				util.LogErrorF(err, log.Fields{"error": "Cannot get task output from database"})
+				return
+			}
+
+			for _, line := range output {
+				offset++
+
+				if err = writeTaskStreamEvent(w, offset, "output", map[string]any{
+					"time":   line.Time,
+					"output": line.Output,
+				}); err != nil {
+					return
+				}
+			}
+
+			if len(output) > 0 {
+				lastWrite = time.Now()
+				flusher.Flush()
+			}
+
+			if len(output) < taskOutputStreamChunkSize {
+				break
+			}
+		}
+
+		if finishing {
+			_ = writeTaskStreamEvent(w, 0, "end", map[string]any{
+				"status": task.Status,
+				"end":    task.End,
+			})
+			flusher.Flush()
+			return
+		}
+
+		status := task.Status
+
+		task, err = store.GetTask(project.ID, task.ID)
+		if err != nil {
+			util.LogErrorF(err, log.Fields{"error": "Cannot get task from database"})
+			return
+		}
+
+		if task.Status != status {
+			if err = writeTaskStreamEvent(w, 0, "status", map[string]any{
+				"status": task.Status,
+			}); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+			flusher.Flush()
+		}
+
+		finishing = task.Status.IsFinished()
+
+		if time.Since(lastWrite) >= taskOutputStreamKeepAlive {
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
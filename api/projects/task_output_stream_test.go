@@ -0,0 +1,74 @@
+package projects
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTaskOutput(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{
+		Name:      "Test",
+		Playbook:  "test.yml",
+		ProjectID: proj.ID,
+	})
+	require.NoError(t, err)
+
+	task, err := store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskSuccessStatus,
+	}, 0)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, line := range []string{"first", "second", "third"} {
+		_, err = store.CreateTaskOutput(db.TaskOutput{TaskID: task.ID, Time: now, Output: line})
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/project/1/tasks/1/stream?from=1", nil)
+	r = helpers.SetContextValue(r, "store", store)
+	r = helpers.SetContextValue(r, "project", proj)
+	r = helpers.SetContextValue(r, "task", task)
+	w := httptest.NewRecorder()
+
+	StreamTaskOutput(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("content-type"))
+
+	body := w.Body.String()
+	assert.NotContains(t, body, "first")
+	assert.Contains(t, body, "id: 2\nevent: output\n")
+	assert.Contains(t, body, "id: 3\nevent: output\n")
+	assert.Contains(t, body, "\"output\":\"third\"")
+	assert.True(t, strings.HasSuffix(body, "\n\n"))
+	assert.Contains(t, body, "event: end\ndata: {\"end\":null,\"status\":\"success\"}")
+
+	r = httptest.NewRequest(http.MethodGet, "/api/project/1/tasks/1/stream", nil)
+	r.Header.Set("Last-Event-ID", "x")
+	r = helpers.SetContextValue(r, "store", store)
+	r = helpers.SetContextValue(r, "project", proj)
+	r = helpers.SetContextValue(r, "task", task)
+	w = httptest.NewRecorder()
+
+	StreamTaskOutput(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
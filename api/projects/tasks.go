@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/common_errors"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/services/tasks"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
@@ -43,6 +45,7 @@ func AddTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newTask, err := taskPool(r).AddTask(
+		r.Context(),
 		taskObj,
 		&user.ID,
 		user.Username,
@@ -110,6 +113,8 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, task)
 }
 
+// GetTaskPermissionsMiddleware adds the template permissions of the user
+// for the template of the task to the project permissions.
 func GetTaskPermissionsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		project := helpers.GetFromContext(r, "project").(db.Project)
@@ -121,6 +126,7 @@ func GetTaskPermissionsMiddleware(next http.Handler) http.Handler {
 		perm, err := helpers.Store(r).GetTemplatePermission(project.ID, task.TemplateID, user.ID)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
+			return
 		}
 
 		permissions |= perm
@@ -130,6 +136,25 @@ func GetTaskPermissionsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// GetMustCanStopTaskMiddleware ensures that the user started the task
+// or has the permission to stop tasks of other users.
+func GetMustCanStopTaskMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := helpers.GetFromContext(r, "user").(*db.User)
+		task := helpers.GetFromContext(r, "task").(db.Task)
+		permissions := helpers.GetFromContext(r, "permissions").(db.ProjectUserPermission)
+
+		isOwnTask := task.UserID != nil && *task.UserID == user.ID
+
+		if !user.Admin && !isOwnTask && !permissions.Can(db.CanStopOthersTasks) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetTaskMiddleware is middleware that gets a task by id and sets the context to it or panics
 func GetTaskMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -290,16 +315,51 @@ func GetTaskRawOutput(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func ConfirmTask(w http.ResponseWriter, r *http.Request) {
+// taskDecision is an optional body of confirm and reject requests.
+type taskDecision struct {
+	Comment string `json:"comment"`
+}
+
+func readTaskDecision(r *http.Request) (res taskDecision, err error) {
+	if r.Body == nil {
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&res)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	return
+}
+
+func decideTask(w http.ResponseWriter, r *http.Request, approved bool) {
 	targetTask := helpers.GetFromContext(r, "task").(db.Task)
 	project := helpers.GetFromContext(r, "project").(db.Project)
+	user := helpers.GetFromContext(r, "user").(*db.User)
 
 	if targetTask.ProjectID != project.ID {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	err := taskPool(r).ConfirmTask(targetTask)
+	decision, err := readTaskDecision(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	pool := taskPool(r)
+
+	switch {
+	case targetTask.Status == task_logger.TaskWaitingApproval:
+		err = pool.DecideTaskApproval(targetTask, *user, approved, decision.Comment)
+	case approved:
+		err = pool.ConfirmTask(targetTask)
+	default:
+		err = pool.RejectTask(targetTask)
+	}
+
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
@@ -308,22 +368,31 @@ func ConfirmTask(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ConfirmTask confirms the Terraform plan of the task or approves the task
+// if its template requires approval.
+func ConfirmTask(w http.ResponseWriter, r *http.Request) {
+	decideTask(w, r, true)
+}
+
+// RejectTask rejects the Terraform plan of the task or the task itself
+// if its template requires approval.
 func RejectTask(w http.ResponseWriter, r *http.Request) {
-	targetTask := helpers.GetFromContext(r, "task").(db.Task)
+	decideTask(w, r, false)
+}
+
+// GetTaskApprovals returns decisions of approvers about the task.
+func GetTaskApprovals(w http.ResponseWriter, r *http.Request) {
+	task := helpers.GetFromContext(r, "task").(db.Task)
 	project := helpers.GetFromContext(r, "project").(db.Project)
 
-	if targetTask.ProjectID != project.ID {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+	approvals, err := helpers.Store(r).GetTaskApprovals(project.ID, task.ID)
 
-	err := taskPool(r).RejectTask(targetTask)
 	if err != nil {
 		helpers.WriteError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	helpers.WriteJSON(w, http.StatusOK, approvals)
 }
 
 func StopTask(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,74 @@
+package projects
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTaskPermissionsMiddleware_TemplateCanViewTaskOutput(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+	require.NoError(t, err)
+
+	_, err = store.CreateRole(db.Role{Slug: "auditor", Name: "Auditor", ProjectID: &proj.ID})
+	require.NoError(t, err)
+
+	user, err := store.CreateUserWithoutPassword(db.User{
+		Username: "auditor",
+		Name:     "Auditor",
+		Email:    "auditor@example.org",
+		Created:  tz.Now(),
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: user.ID, Role: "auditor"})
+	require.NoError(t, err)
+
+	allowed, err := store.CreateTemplate(db.Template{Name: "Allowed", Playbook: "test.yml", ProjectID: proj.ID})
+	require.NoError(t, err)
+
+	denied, err := store.CreateTemplate(db.Template{Name: "Denied", Playbook: "test.yml", ProjectID: proj.ID})
+	require.NoError(t, err)
+
+	_, err = store.CreateTemplateRole(db.TemplateRolePerm{
+		RoleSlug:    "auditor",
+		TemplateID:  allowed.ID,
+		ProjectID:   proj.ID,
+		Permissions: db.CanViewTaskOutput,
+	})
+	require.NoError(t, err)
+
+	handler := GetTaskPermissionsMiddleware(GetMustCanViewMiddleware(db.CanViewTaskOutput)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	))
+
+	request := func(tpl db.Template) int {
+		task, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: tpl.ID}, 0)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, "/api/project/1/tasks/1/output", nil)
+		r = helpers.SetContextValue(r, "store", store)
+		r = helpers.SetContextValue(r, "user", &user)
+		r = helpers.SetContextValue(r, "project", proj)
+		r = helpers.SetContextValue(r, "permissions", db.ProjectUserPermission(0))
+		r = helpers.SetContextValue(r, "task", task)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(allowed))
+	assert.Equal(t, http.StatusForbidden, request(denied))
+}
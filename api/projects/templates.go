@@ -68,6 +68,35 @@ func GetTemplateRefs(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, refs)
 }
 
+// GetTemplateVersions returns versions of the build template deployed to
+// environments. For deploy templates versions of their build template are returned.
+func GetTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	tpl := helpers.GetFromContext(r, "template").(db.Template)
+
+	buildTemplateID := tpl.ID
+
+	switch tpl.Type {
+	case db.TemplateBuild:
+	case db.TemplateDeploy:
+		if tpl.BuildTemplateID == nil {
+			helpers.WriteErrorStatus(w, "Deploy template has no build template", http.StatusBadRequest)
+			return
+		}
+		buildTemplateID = *tpl.BuildTemplateID
+	default:
+		helpers.WriteErrorStatus(w, "Template is not a build or deploy template", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := helpers.Store(r).GetEnvironmentVersions(tpl.ProjectID, buildTemplateID)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, versions)
+}
+
 // GetTemplates returns all templates for a project in a sort order
 func GetTemplates(w http.ResponseWriter, r *http.Request) {
 	project := helpers.GetFromContext(r, "project").(db.Project)
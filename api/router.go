@@ -15,8 +15,12 @@ import (
 	proApi "github.com/semaphoreui/semaphore/pro/api"
 	proProjects "github.com/semaphoreui/semaphore/pro/api/projects"
 	proFeatures "github.com/semaphoreui/semaphore/pro/pkg/features"
+	"github.com/semaphoreui/semaphore/services/artifacts"
+	"github.com/semaphoreui/semaphore/services/gitops"
+	"github.com/semaphoreui/semaphore/services/metrics"
 	"github.com/semaphoreui/semaphore/services/server"
 	taskServices "github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/services/tracing"
 
 	"github.com/semaphoreui/semaphore/api/debug"
 	"github.com/semaphoreui/semaphore/api/tasks"
@@ -95,16 +99,22 @@ func Route(
 	accessKeyService server.AccessKeyService,
 	environmentService server.EnvironmentService,
 	subscriptionService pro_interfaces.SubscriptionService,
+	artifactService *artifacts.Service,
 ) *mux.Router {
 
 	projectController := &projects.ProjectController{ProjectService: projectService}
 	runnerController := runners.NewRunnerController(store, taskPool, encryptionService)
+	runnerArtifactController := runners.NewArtifactController(taskPool, artifactService)
+	taskArtifactController := projects.NewTaskArtifactController(artifactService)
 	integrationController := NewIntegrationController(integrationService)
 	environmentController := projects.NewEnvironmentController(store, encryptionService, accessKeyService, environmentService)
 	secretStorageController := projects.NewSecretStorageController(store, secretStorageService)
 	repositoryController := projects.NewRepositoryController(accessKeyInstallationService)
 	keyController := projects.NewKeyController(accessKeyService)
 	projectsController := projects.NewProjectsController(accessKeyService)
+	backupController := projects.NewBackupController(encryptionService)
+	instanceBackupController := NewInstanceBackupController(encryptionService)
+	gitopsController := projects.NewGitopsController(gitops.NewReconciler(store, encryptionService, accessKeyInstallationService))
 	terraformController := proApi.NewTerraformController(encryptionService, terraformStore, store)
 	terraformInventoryController := proProjects.NewTerraformInventoryController(terraformStore)
 	userController := NewUserController(subscriptionService)
@@ -138,10 +148,19 @@ func Route(
 
 	r.Use(mux.CORSMethodMiddleware(r))
 
+	if util.Config.Tracing.IsEnabled() {
+		r.Use(tracing.Middleware)
+	}
+
 	pingRouter := r.Path(webPath + "api/ping").Subrouter()
 	pingRouter.Use(plainTextMiddleware)
 	pingRouter.Methods("GET", "HEAD").HandlerFunc(pongHandler)
 
+	// Metrics are served here unless they have the separate listen address.
+	if util.Config.Metrics.IsEnabled() && util.Config.Metrics.Listen == "" {
+		r.Path(webPath + "metrics").Handler(metrics.Handler(util.Config.Metrics.Token)).Methods("GET")
+	}
+
 	publicAPIRouter := r.PathPrefix(webPath + "api").Subrouter()
 	publicAPIRouter.Use(StoreMiddleware, JSONMiddleware)
 
@@ -156,6 +175,7 @@ func Route(
 	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/login", oidcLogin).Methods("GET")
 	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/redirect", oidcRedirect).Methods("GET")
 	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/redirect/{redirect_path:.*}", oidcRedirect).Methods("GET")
+	publicAPIRouter.HandleFunc("/auth/oidc/{provider}/backchannel_logout", oidcBackChannelLogout).Methods("POST")
 
 	internalAPI := publicAPIRouter.PathPrefix("/internal").Subrouter()
 	internalAPI.HandleFunc("/runners", runners.RegisterRunner).Methods("POST")
@@ -165,6 +185,11 @@ func Route(
 	runnersAPI.Path("").HandlerFunc(runnerController.GetRunner).Methods("GET", "HEAD")
 	runnersAPI.Path("").HandlerFunc(runnerController.UpdateRunner).Methods("PUT")
 	runnersAPI.Path("").HandlerFunc(runners.UnregisterRunner).Methods("DELETE")
+	runnersAPI.Path("/token").HandlerFunc(runners.RotateRunnerToken).Methods("POST")
+	runnersAPI.Path("/stream").HandlerFunc(runnerController.StreamRunner).Methods("GET")
+	runnersAPI.Path("/tasks/{task_id}/artifacts").HandlerFunc(runnerArtifactController.UploadArtifact).Methods("POST")
+	runnersAPI.Path("/tasks/{task_id}/build_artifacts").HandlerFunc(runnerArtifactController.GetBuildArtifacts).Methods("GET")
+	runnersAPI.Path("/tasks/{task_id}/build_artifacts/{artifact_id}").HandlerFunc(runnerArtifactController.DownloadBuildArtifact).Methods("GET")
 
 	publicWebHookRouter := r.PathPrefix(webPath + "api").Subrouter()
 	publicWebHookRouter.Use(StoreMiddleware, JSONMiddleware)
@@ -192,7 +217,7 @@ func Route(
 
 	authenticatedAPI.Path("/projects").HandlerFunc(projects.GetProjects).Methods("GET", "HEAD")
 	authenticatedAPI.Path("/projects").HandlerFunc(projectsController.AddProject).Methods("POST")
-	authenticatedAPI.Path("/projects/restore").HandlerFunc(projects.Restore).Methods("POST")
+	authenticatedAPI.Path("/projects/restore").HandlerFunc(backupController.Restore).Methods("POST")
 	authenticatedAPI.Path("/events").HandlerFunc(getAllEvents).Methods("GET", "HEAD")
 	authenticatedAPI.HandleFunc("/events/last", getLastEvents).Methods("GET", "HEAD")
 
@@ -220,15 +245,28 @@ func Route(
 
 	adminAPI.Path("/cache").HandlerFunc(clearCache).Methods("DELETE", "HEAD")
 
+	adminAPI.Path("/backup").HandlerFunc(instanceBackupController.CreateBackup).Methods("POST")
+	adminAPI.Path("/backup/restore").HandlerFunc(instanceBackupController.RestoreBackup).Methods("POST")
+
 	debugAPI := adminAPI.PathPrefix("/debug").Subrouter()
 	debugAPI.Path("/gc").HandlerFunc(debug.GC).Methods("POST")
 	debugAPI.Path("/pprof/dump").HandlerFunc(debug.Dump).Methods("POST")
 
+	adminAPI.Path("/runner_registration_tokens").HandlerFunc(getRunnerRegistrationTokens).Methods("GET", "HEAD")
+	adminAPI.Path("/runner_registration_tokens").HandlerFunc(addRunnerRegistrationToken).Methods("POST")
+
+	runnerRegistrationTokensAPI := adminAPI.PathPrefix("/runner_registration_tokens").Subrouter()
+	runnerRegistrationTokensAPI.Use(runnerRegistrationTokenMiddleware)
+	runnerRegistrationTokensAPI.Path("/{token_id}").HandlerFunc(getRunnerRegistrationToken).Methods("GET", "HEAD")
+	runnerRegistrationTokensAPI.Path("/{token_id}/revoke").HandlerFunc(revokeRunnerRegistrationToken).Methods("POST")
+	runnerRegistrationTokensAPI.Path("/{token_id}").HandlerFunc(deleteRunnerRegistrationToken).Methods("DELETE")
+
 	globalRunnersAPI := adminAPI.PathPrefix("/runners").Subrouter()
 	globalRunnersAPI.Use(globalRunnerMiddleware)
 	globalRunnersAPI.Path("/{runner_id}").HandlerFunc(getGlobalRunner).Methods("GET", "HEAD")
 	globalRunnersAPI.Path("/{runner_id}").HandlerFunc(updateGlobalRunner).Methods("PUT", "POST")
 	globalRunnersAPI.Path("/{runner_id}/active").HandlerFunc(setGlobalRunnerActive).Methods("POST")
+	globalRunnersAPI.Path("/{runner_id}/health").HandlerFunc(getGlobalRunnerHealth).Methods("GET", "HEAD")
 	globalRunnersAPI.Path("/{runner_id}").HandlerFunc(deleteGlobalRunner).Methods("DELETE")
 	globalRunnersAPI.Path("/{runner_id}/cache").HandlerFunc(clearGlobalRunnerCache).Methods("DELETE")
 
@@ -271,6 +309,25 @@ func Route(
 	projectGet.Use(projects.ProjectMiddleware)
 	projectGet.Methods("GET", "HEAD").HandlerFunc(projects.GetProject)
 
+	// Backup with secrets discloses secret values, so it is available only to project owners.
+	projectSecretsBackup := authenticatedAPI.Path("/project/{project_id}/backup").Subrouter()
+	projectSecretsBackup.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectSecretsBackup.Methods("POST").HandlerFunc(backupController.GetBackupWithSecrets)
+
+	projectRestore := authenticatedAPI.Path("/project/{project_id}/restore").Subrouter()
+	projectRestore.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectRestore.Methods("POST").HandlerFunc(backupController.RestoreInto)
+
+	projectGitops := authenticatedAPI.Path("/project/{project_id}/gitops").Subrouter()
+	projectGitops.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectGitops.Methods("GET", "HEAD").HandlerFunc(gitopsController.GetGitops)
+	projectGitops.Methods("PUT").HandlerFunc(gitopsController.SetGitops)
+	projectGitops.Methods("DELETE").HandlerFunc(gitopsController.DeleteGitops)
+
+	projectGitopsSync := authenticatedAPI.Path("/project/{project_id}/gitops/sync").Subrouter()
+	projectGitopsSync.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanUpdateProject))
+	projectGitopsSync.Methods("POST").HandlerFunc(gitopsController.SyncGitops)
+
 	//
 	// Start and Stop tasks
 	projectTaskStart := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
@@ -278,15 +335,18 @@ func Route(
 	projectTaskStart.Path("/tasks").HandlerFunc(projects.AddTask).Methods("POST")
 
 	projectTaskStop := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
-	projectTaskStop.Use(projects.ProjectMiddleware, projects.GetTaskMiddleware, projects.GetTaskPermissionsMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks))
+	projectTaskStop.Use(projects.ProjectMiddleware, projects.GetTaskMiddleware, projects.GetTaskPermissionsMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks), projects.GetMustCanStopTaskMiddleware)
 	projectTaskStop.HandleFunc("/tasks/{task_id}/stop", projects.StopTask).Methods("POST")
-	projectTaskStop.HandleFunc("/tasks/{task_id}/confirm", projects.ConfirmTask).Methods("POST")
-	projectTaskStop.HandleFunc("/tasks/{task_id}/reject", projects.RejectTask).Methods("POST")
+
+	projectTaskApproval := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectTaskApproval.Use(projects.ProjectMiddleware, projects.GetTaskMiddleware, projects.GetTaskPermissionsMiddleware, projects.GetMustCanMiddleware(db.CanApproveTasks))
+	projectTaskApproval.HandleFunc("/tasks/{task_id}/confirm", projects.ConfirmTask).Methods("POST")
+	projectTaskApproval.HandleFunc("/tasks/{task_id}/reject", projects.RejectTask).Methods("POST")
 
 	//
 	// Project resources CRUD
 	projectUserAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
-	projectUserAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanManageProjectResources))
+	projectUserAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanManageProjectResources), projects.GitopsLockMiddleware)
 
 	projectUserAPI.Path("/role").HandlerFunc(projects.GetUserRole).Methods("GET", "HEAD")
 
@@ -295,11 +355,15 @@ func Route(
 
 	projectUserAPI.Path("/users").HandlerFunc(projects.GetUsers).Methods("GET", "HEAD")
 
-	projectUserAPI.Path("/keys").HandlerFunc(projects.GetKeys).Methods("GET", "HEAD")
-	projectUserAPI.Path("/keys").HandlerFunc(keyController.AddKey).Methods("POST")
+	projectKeysAPI := projectUserAPI.Path("/keys").Subrouter()
+	projectKeysAPI.Use(projects.GetMustCanViewMiddleware(db.CanViewSecrets))
+	projectKeysAPI.Methods("GET", "HEAD").HandlerFunc(projects.GetKeys)
+	projectKeysAPI.Methods("POST").HandlerFunc(keyController.AddKey)
 
-	projectUserAPI.Path("/secret_storages").HandlerFunc(secretStorageController.GetSecretStorages).Methods("GET", "HEAD")
-	projectUserAPI.Path("/secret_storages").HandlerFunc(secretStorageController.Add).Methods("POST")
+	projectSecretStoragesAPI := projectUserAPI.Path("/secret_storages").Subrouter()
+	projectSecretStoragesAPI.Use(projects.GetMustCanViewMiddleware(db.CanViewSecrets))
+	projectSecretStoragesAPI.Methods("GET", "HEAD").HandlerFunc(secretStorageController.GetSecretStorages)
+	projectSecretStoragesAPI.Methods("POST").HandlerFunc(secretStorageController.Add)
 
 	projectUserAPI.Path("/repositories").HandlerFunc(projects.GetRepositories).Methods("GET", "HEAD")
 	projectUserAPI.Path("/repositories").HandlerFunc(projects.AddRepository).Methods("POST")
@@ -318,17 +382,13 @@ func Route(
 	projectUserAPI.Path("/templates").HandlerFunc(projects.GetTemplates).Methods("GET", "HEAD")
 	projectUserAPI.Path("/templates").HandlerFunc(projects.AddTemplate).Methods("POST")
 
-	projectUserAPI.Path("/schedules").HandlerFunc(projects.GetProjectSchedules).Methods("GET", "HEAD")
-	projectUserAPI.Path("/schedules").HandlerFunc(projects.AddSchedule).Methods("POST")
-	projectUserAPI.Path("/schedules/validate").HandlerFunc(projects.ValidateScheduleCronFormat).Methods("POST")
+	projectUserAPI.Path("/deployments").HandlerFunc(projects.GetDeployments).Methods("GET", "HEAD")
 
 	projectUserAPI.Path("/views").HandlerFunc(projects.GetViews).Methods("GET", "HEAD")
 	projectUserAPI.Path("/views").HandlerFunc(projects.AddView).Methods("POST")
 	projectUserAPI.Path("/views/positions").HandlerFunc(projects.SetViewPositions).Methods("POST")
 
-	projectUserAPI.Path("/integrations").HandlerFunc(projects.GetIntegrations).Methods("GET", "HEAD")
-	projectUserAPI.Path("/integrations").HandlerFunc(projects.AddIntegration).Methods("POST")
-	projectUserAPI.Path("/backup").HandlerFunc(projects.GetBackup).Methods("GET", "HEAD")
+	projectUserAPI.Path("/backup").HandlerFunc(backupController.GetBackup).Methods("GET", "HEAD")
 	projectUserAPI.Path("/notifications/test").HandlerFunc(projectController.SendTestNotification).Methods("POST")
 
 	projectUserAPI.Path("/runners").HandlerFunc(projectRunnerController.GetRunners).Methods("GET", "HEAD")
@@ -400,7 +460,7 @@ func Route(
 	//
 	// Project resources CRUD (continue)
 	projectKeyManagement := projectUserAPI.PathPrefix("/keys").Subrouter()
-	projectKeyManagement.Use(projects.KeyMiddleware)
+	projectKeyManagement.Use(projects.GetMustCanViewMiddleware(db.CanViewSecrets), projects.KeyMiddleware)
 
 	projectKeyManagement.HandleFunc("/{key_id}", projects.GetKeys).Methods("GET", "HEAD")
 	projectKeyManagement.HandleFunc("/{key_id}/refs", projects.GetKeyRefs).Methods("GET", "HEAD")
@@ -408,7 +468,7 @@ func Route(
 	projectKeyManagement.HandleFunc("/{key_id}", keyController.RemoveKey).Methods("DELETE")
 
 	projectSecretStorageManagement := projectUserAPI.PathPrefix("/secret_storages").Subrouter()
-	projectSecretStorageManagement.Use(projects.SecretStorageMiddleware)
+	projectSecretStorageManagement.Use(projects.GetMustCanViewMiddleware(db.CanViewSecrets), projects.SecretStorageMiddleware)
 	projectSecretStorageManagement.HandleFunc("/{storage_id}", secretStorageController.GetSecretStorage).Methods("GET", "HEAD")
 	projectSecretStorageManagement.HandleFunc("/{storage_id}/refs", secretStorageController.GetRefs).Methods("GET", "HEAD")
 	projectSecretStorageManagement.HandleFunc("/{storage_id}", secretStorageController.Update).Methods("PUT")
@@ -458,11 +518,12 @@ func Route(
 	projectTmplManagement.HandleFunc("/{template_id}", projects.RemoveTemplate).Methods("DELETE")
 	projectTmplManagement.HandleFunc("/{template_id}", projects.GetTemplate).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/refs", projects.GetTemplateRefs).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/versions", projects.GetTemplateVersions).Methods("GET", "HEAD")
+	projectTmplManagement.HandleFunc("/{template_id}/deployments", projects.GetTemplateDeployments).Methods("GET", "HEAD")
 	projectTmplManagement.HandleFunc("/{template_id}/tasks", projects.GetAllTasks).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/tasks/last", projects.GetLastTasks).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/schedules", projects.GetTemplateSchedules).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/stats", projects.GetTaskStats).Methods("GET")
-	projectTmplManagement.HandleFunc("/{template_id}/stop_all_tasks", taskController.StopAllTasks).Methods("POST")
 
 	projectTmplManagement.HandleFunc("/{template_id}/perms", templateController.GetTemplatePerms).Methods("GET")
 	projectTmplManagement.HandleFunc("/{template_id}/perms", templateController.AddTemplatePerm).Methods("POST")
@@ -470,6 +531,14 @@ func Route(
 	projectTmplManagement.HandleFunc("/{template_id}/perms/{perm_id}", templateController.UpdateTemplatePerm).Methods("PUT")
 	projectTmplManagement.HandleFunc("/{template_id}/perms/{perm_id}", templateController.DeleteTemplatePerm).Methods("DELETE")
 
+	projectTmplStopAPI := projectUserAPI.PathPrefix("/templates").Subrouter()
+	projectTmplStopAPI.Use(projects.TemplatesMiddleware, projects.GetMustCanMiddleware(db.CanStopOthersTasks))
+	projectTmplStopAPI.HandleFunc("/{template_id}/stop_all_tasks", taskController.StopAllTasks).Methods("POST")
+
+	projectTmplRollbackAPI := projectUserAPI.PathPrefix("/templates").Subrouter()
+	projectTmplRollbackAPI.Use(projects.TemplatesMiddleware, projects.GetMustCanMiddleware(db.CanRunProjectTasks))
+	projectTmplRollbackAPI.HandleFunc("/{template_id}/deployments/rollback", projects.RollbackDeployment).Methods("POST")
+
 	projectTmplInvManagement := projectTmplManagement.PathPrefix("/{template_id}/inventory").Subrouter()
 	projectTmplInvManagement.Use(projects.InventoryMiddleware)
 	projectTmplInvManagement.HandleFunc("/{inventory_id}/set_default", projects.SetTemplateInventory).Methods("POST")
@@ -479,15 +548,32 @@ func Route(
 	projectTaskManagement := projectUserAPI.PathPrefix("/tasks").Subrouter()
 	projectTaskManagement.Use(projects.GetTaskMiddleware)
 
-	projectTaskManagement.HandleFunc("/{task_id}/output", projects.GetTaskOutput).Methods("GET", "HEAD")
-	projectTaskManagement.HandleFunc("/{task_id}/raw_output", projects.GetTaskRawOutput).Methods("GET", "HEAD")
 	projectTaskManagement.HandleFunc("/{task_id}", projects.GetTask).Methods("GET", "HEAD")
 	projectTaskManagement.HandleFunc("/{task_id}", projects.RemoveTask).Methods("DELETE")
-	projectTaskManagement.HandleFunc("/{task_id}/stages", projects.GetTaskStages).Methods("GET", "HEAD")
-	projectTaskManagement.HandleFunc("/{task_id}/ansible/hosts", taskController.GetAnsibleTaskHosts).Methods("GET", "HEAD")
-	projectTaskManagement.HandleFunc("/{task_id}/ansible/errors", taskController.GetAnsibleTaskErrors).Methods("GET", "HEAD")
+	projectTaskManagement.HandleFunc("/{task_id}/approvals", projects.GetTaskApprovals).Methods("GET", "HEAD")
 
-	projectScheduleManagement := projectUserAPI.PathPrefix("/schedules").Subrouter()
+	projectTaskOutputAPI := projectUserAPI.PathPrefix("/tasks").Subrouter()
+	projectTaskOutputAPI.Use(projects.GetTaskMiddleware, projects.GetTaskPermissionsMiddleware, projects.GetMustCanViewMiddleware(db.CanViewTaskOutput))
+
+	projectTaskOutputAPI.HandleFunc("/{task_id}/output", projects.GetTaskOutput).Methods("GET", "HEAD")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/raw_output", projects.GetTaskRawOutput).Methods("GET", "HEAD")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/stream", projects.StreamTaskOutput).Methods("GET")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/stages", projects.GetTaskStages).Methods("GET", "HEAD")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/artifacts", taskArtifactController.GetTaskArtifacts).Methods("GET", "HEAD")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/artifacts/{artifact_id}", taskArtifactController.DownloadTaskArtifact).Methods("GET")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/ansible/hosts", taskController.GetAnsibleTaskHosts).Methods("GET", "HEAD")
+	projectTaskOutputAPI.HandleFunc("/{task_id}/ansible/errors", taskController.GetAnsibleTaskErrors).Methods("GET", "HEAD")
+
+	//
+	// Manage project schedules
+	projectSchedulesAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectSchedulesAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanManageSchedules), projects.GitopsLockMiddleware)
+
+	projectSchedulesAPI.Path("/schedules").HandlerFunc(projects.GetProjectSchedules).Methods("GET", "HEAD")
+	projectSchedulesAPI.Path("/schedules").HandlerFunc(projects.AddSchedule).Methods("POST")
+	projectSchedulesAPI.Path("/schedules/validate").HandlerFunc(projects.ValidateScheduleCronFormat).Methods("POST")
+
+	projectScheduleManagement := projectSchedulesAPI.PathPrefix("/schedules").Subrouter()
 	projectScheduleManagement.Use(projects.SchedulesMiddleware)
 	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.GetSchedule).Methods("GET", "HEAD")
 	projectScheduleManagement.HandleFunc("/{schedule_id}", projects.UpdateSchedule).Methods("PUT")
@@ -501,18 +587,26 @@ func Route(
 	projectViewManagement.HandleFunc("/{view_id}", projects.RemoveView).Methods("DELETE")
 	projectViewManagement.HandleFunc("/{view_id}/templates", projects.GetViewTemplates).Methods("GET", "HEAD")
 
-	projectIntegrationsAliasAPI := projectUserAPI.PathPrefix("/integrations").Subrouter()
-	projectIntegrationsAliasAPI.Use(projects.ProjectMiddleware)
+	//
+	// Manage project integrations
+	projectIntegrationsRootAPI := authenticatedAPI.PathPrefix("/project/{project_id}").Subrouter()
+	projectIntegrationsRootAPI.Use(projects.ProjectMiddleware, projects.GetMustCanMiddleware(db.CanManageIntegrations), projects.GitopsLockMiddleware)
+
+	projectIntegrationsRootAPI.Path("/integrations").HandlerFunc(projects.GetIntegrations).Methods("GET", "HEAD")
+	projectIntegrationsRootAPI.Path("/integrations").HandlerFunc(projects.AddIntegration).Methods("POST")
+
+	projectIntegrationsAliasAPI := projectIntegrationsRootAPI.PathPrefix("/integrations").Subrouter()
 	projectIntegrationsAliasAPI.HandleFunc("/aliases", projects.GetIntegrationAlias).Methods("GET", "HEAD")
 	projectIntegrationsAliasAPI.HandleFunc("/aliases", projects.AddIntegrationAlias).Methods("POST")
 	projectIntegrationsAliasAPI.HandleFunc("/aliases/{alias_id}", projects.RemoveIntegrationAlias).Methods("DELETE")
 
-	projectIntegrationsAPI := projectUserAPI.PathPrefix("/integrations").Subrouter()
-	projectIntegrationsAPI.Use(projects.ProjectMiddleware, projects.IntegrationMiddleware)
+	projectIntegrationsAPI := projectIntegrationsRootAPI.PathPrefix("/integrations").Subrouter()
+	projectIntegrationsAPI.Use(projects.IntegrationMiddleware)
 	projectIntegrationsAPI.HandleFunc("/{integration_id}", projects.UpdateIntegration).Methods("PUT")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}", projects.DeleteIntegration).Methods("DELETE")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}", projects.GetIntegration).Methods("GET")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/refs", projects.GetIntegrationRefs).Methods("GET", "HEAD")
+	projectIntegrationsAPI.HandleFunc("/{integration_id}/deliveries", projects.GetIntegrationDeliveries).Methods("GET", "HEAD")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/matchers", projects.GetIntegrationMatchers).Methods("GET", "HEAD")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/matchers", projects.AddIntegrationMatcher).Methods("POST")
 	projectIntegrationsAPI.HandleFunc("/{integration_id}/values", projects.GetIntegrationExtractValues).Methods("GET", "HEAD")
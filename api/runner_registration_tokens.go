@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+type runnerRegistrationTokenWithToken struct {
+	db.RunnerRegistrationToken
+	Token string `json:"token"`
+}
+
+func getRunnerRegistrationTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := helpers.Store(r).GetRunnerRegistrationTokens()
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// addRunnerRegistrationToken creates the registration token. The value of
+// the token is returned only by this request.
+func addRunnerRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	var token db.RunnerRegistrationToken
+	if !helpers.Bind(w, r, &token) {
+		return
+	}
+
+	store := helpers.Store(r)
+
+	if token.ProjectID != nil {
+		if _, err := store.GetProject(*token.ProjectID); err != nil {
+			helpers.WriteErrorStatus(w, "Project not found", http.StatusBadRequest)
+			return
+		}
+	}
+
+	token.Token = ""
+	token.Uses = 0
+	token.Revoked = false
+
+	newToken, err := store.CreateRunnerRegistrationToken(token)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, runnerRegistrationTokenWithToken{
+		RunnerRegistrationToken: newToken,
+		Token:                   newToken.Token,
+	})
+}
+
+func runnerRegistrationTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenID, err := helpers.GetIntParam("token_id", w, r)
+
+		if err != nil {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "token_id required",
+			})
+			return
+		}
+
+		token, err := helpers.Store(r).GetRunnerRegistrationToken(tokenID)
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		r = helpers.SetContextValue(r, "runner_registration_token", token)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getRunnerRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	token := helpers.GetFromContext(r, "runner_registration_token").(db.RunnerRegistrationToken)
+
+	helpers.WriteJSON(w, http.StatusOK, token)
+}
+
+func revokeRunnerRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	token := helpers.GetFromContext(r, "runner_registration_token").(db.RunnerRegistrationToken)
+
+	err := helpers.Store(r).RevokeRunnerRegistrationToken(token.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deleteRunnerRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	token := helpers.GetFromContext(r, "runner_registration_token").(db.RunnerRegistrationToken)
+
+	err := helpers.Store(r).DeleteRunnerRegistrationToken(token.ID)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -109,6 +109,19 @@ func getGlobalRunner(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, runner)
 }
 
+func getGlobalRunnerHealth(w http.ResponseWriter, r *http.Request) {
+	runner := helpers.GetFromContext(r, "runner").(*db.Runner)
+
+	records, err := helpers.Store(r).GetRunnerHealthHistory(runner.ID, db.RetrieveQueryParams{Count: 200})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, records)
+}
+
 func updateGlobalRunner(w http.ResponseWriter, r *http.Request) {
 	oldRunner := helpers.GetFromContext(r, "runner").(*db.Runner)
 
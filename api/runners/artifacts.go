@@ -0,0 +1,114 @@
+package runners
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/artifacts"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+// ArtifactController receives artifacts of tasks from runners.
+type ArtifactController struct {
+	taskPool  *tasks.TaskPool
+	artifacts *artifacts.Service
+}
+
+func NewArtifactController(taskPool *tasks.TaskPool, artifacts *artifacts.Service) *ArtifactController {
+	return &ArtifactController{
+		taskPool:  taskPool,
+		artifacts: artifacts,
+	}
+}
+
+// getRunnerTask returns the running task of the runner from the request
+// or writes the error.
+func (c *ArtifactController) getRunnerTask(w http.ResponseWriter, r *http.Request) *tasks.TaskRunner {
+	runner := helpers.GetFromContext(r, "runner").(db.Runner)
+
+	taskID, err := helpers.GetIntParam("task_id", w, r)
+	if err != nil {
+		return nil
+	}
+
+	tsk := c.taskPool.GetTask(taskID)
+
+	if tsk == nil || tsk.RunnerID != runner.ID {
+		helpers.WriteErrorStatus(w, "Task not found", http.StatusNotFound)
+		return nil
+	}
+
+	return tsk
+}
+
+// UploadArtifact stores the request body as the artifact of the task.
+// Runners can upload artifacts only of tasks they are running.
+func (c *ArtifactController) UploadArtifact(w http.ResponseWriter, r *http.Request) {
+	tsk := c.getRunnerTask(w, r)
+	if tsk == nil {
+		return
+	}
+
+	artifact, err := c.artifacts.Save(tsk.Task, r.URL.Query().Get("name"), r.Body)
+
+	if errors.Is(err, artifacts.ErrTooLarge) {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusCreated, artifact)
+}
+
+// GetBuildArtifacts returns artifacts of the build task deployed by the task.
+func (c *ArtifactController) GetBuildArtifacts(w http.ResponseWriter, r *http.Request) {
+	tsk := c.getRunnerTask(w, r)
+	if tsk == nil {
+		return
+	}
+
+	res, err := c.artifacts.GetBuildArtifacts(tsk.Task)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
+func (c *ArtifactController) DownloadBuildArtifact(w http.ResponseWriter, r *http.Request) {
+	tsk := c.getRunnerTask(w, r)
+	if tsk == nil {
+		return
+	}
+
+	artifactID, err := helpers.GetIntParam("artifact_id", w, r)
+	if err != nil {
+		return
+	}
+
+	content, err := c.artifacts.OpenBuildArtifact(tsk.Task, db.TaskArtifact{ID: artifactID})
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	defer content.Close() //nolint:errcheck
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(w, content); err != nil {
+		log.WithError(err).WithField("artifact_id", artifactID).Error("Failed to send artifact")
+	}
+}
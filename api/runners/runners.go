@@ -7,8 +7,10 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
@@ -108,25 +110,21 @@ func NewRunnerController(runnerRepo db.RunnerManager, taskPool *tasks.TaskPool,
 	}
 }
 
-func (c *RunnerController) GetRunner(w http.ResponseWriter, r *http.Request) {
-	runner := helpers.GetFromContext(r, "runner").(db.Runner)
-
-	clearCache := false
-
-	err := c.runnerRepo.TouchRunner(runner)
+// touchRunner updates the last activity time of the runner. It reports
+// whether the runner must clear its cache.
+func (c *RunnerController) touchRunner(runner db.Runner) (clearCache bool, err error) {
+	err = c.runnerRepo.TouchRunner(runner)
 	if err != nil {
-		log.WithFields(log.Fields{
-			"runner_id": runner.ID,
-			"context":   "runner",
-		}).WithError(err).Error("runner touch failed")
-		helpers.WriteError(w, err)
 		return
 	}
 
-	if runner.CleaningRequested != nil && (runner.Touched == nil || runner.CleaningRequested.After(*runner.Touched)) {
-		clearCache = true
-	}
+	clearCache = runner.CleaningRequested != nil && (runner.Touched == nil || runner.CleaningRequested.After(*runner.Touched))
+	return
+}
 
+// runnerState collects jobs assigned to the runner and access keys
+// required to run new jobs.
+func (c *RunnerController) runnerState(runner db.Runner, clearCache bool) runners.RunnerState {
 	data := runners.RunnerState{
 		AccessKeys: make(map[int]db.AccessKey),
 		ClearCache: clearCache,
@@ -140,6 +138,15 @@ func (c *RunnerController) GetRunner(w http.ResponseWriter, r *http.Request) {
 
 	for _, tsk := range tasks {
 		if tsk.RunnerID != runner.ID {
+			// The runner which was considered offline may still run the task
+			// which is now assigned to another runner.
+			if slices.Contains(tsk.LostRunnerIDs, runner.ID) {
+				data.CurrentJobs = append(data.CurrentJobs, runners.JobState{
+					ID:     tsk.Task.ID,
+					Status: task_logger.TaskStoppingStatus,
+					Kill:   true,
+				})
+			}
 			continue
 		}
 
@@ -155,6 +162,7 @@ func (c *RunnerController) GetRunner(w http.ResponseWriter, r *http.Request) {
 				InventoryRepository: tsk.Inventory.Repository,
 				Repository:          tsk.Repository,
 				Environment:         tsk.Environment,
+				TraceContext:        tsk.TraceContext,
 			})
 
 			if tsk.Inventory.SSHKeyID != nil {
@@ -199,74 +207,93 @@ func (c *RunnerController) GetRunner(w http.ResponseWriter, r *http.Request) {
 			data.CurrentJobs = append(data.CurrentJobs, runners.JobState{
 				ID:     tsk.Task.ID,
 				Status: tsk.Task.Status,
+				Kill:   tsk.Task.Status == task_logger.TaskStoppingStatus,
 			})
 		}
 	}
 
-	if runner.PublicKey != nil {
-
-		publicKey, err := loadPublicKey([]byte(*runner.PublicKey))
-		if err != nil {
-			helpers.WriteError(w, err)
-			return
-		}
+	return data
+}
 
-		message, err := json.Marshal(data)
-		if err != nil {
-			helpers.WriteError(w, err)
-			return
-		}
+// encryptForRunner encrypts the message with the public key of the runner.
+// The message is returned as is if the runner has no public key.
+func encryptForRunner(runner db.Runner, message []byte) (res []byte, encrypted bool, err error) {
+	if runner.PublicKey == nil {
+		res = message
+		return
+	}
 
-		encryptedBytes, err := chunkRSAEncrypt(publicKey, message)
-		if err != nil {
-			helpers.WriteError(w, err)
-			return
-		}
+	publicKey, err := loadPublicKey([]byte(*runner.PublicKey))
+	if err != nil {
+		return
+	}
 
-		w.Header().Set("Content-Type", "application/octet-stream")
+	res, err = chunkRSAEncrypt(publicKey, message)
+	encrypted = err == nil
+	return
+}
 
-		_, err = w.Write(encryptedBytes)
-		if err != nil {
-			helpers.WriteError(w, err)
-			return
-		}
+func (c *RunnerController) GetRunner(w http.ResponseWriter, r *http.Request) {
+	runner := helpers.GetFromContext(r, "runner").(db.Runner)
 
-	} else {
-		helpers.WriteJSON(w, http.StatusOK, data)
+	clearCache, err := c.touchRunner(runner)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"runner_id": runner.ID,
+			"context":   "runner",
+		}).WithError(err).Error("runner touch failed")
+		helpers.WriteError(w, err)
+		return
 	}
 
-}
-
-func (c *RunnerController) UpdateRunner(w http.ResponseWriter, r *http.Request) {
+	data := c.runnerState(runner, clearCache)
 
-	runner := helpers.GetFromContext(r, "runner").(db.Runner)
+	if runner.PublicKey == nil {
+		helpers.WriteJSON(w, http.StatusOK, data)
+		return
+	}
 
-	var body runners.RunnerProgress
+	message, err := json.Marshal(data)
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
 
-	if !helpers.Bind(w, r, &body) {
-		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
-			"error": "Invalid format",
-		})
+	encryptedBytes, _, err := encryptForRunner(runner, message)
+	if err != nil {
+		helpers.WriteError(w, err)
 		return
 	}
 
-	taskPool := c.taskPool
+	w.Header().Set("Content-Type", "application/octet-stream")
 
-	if body.Jobs == nil {
-		w.WriteHeader(http.StatusNoContent)
+	_, err = w.Write(encryptedBytes)
+	if err != nil {
+		helpers.WriteError(w, err)
 		return
 	}
+}
 
-	for _, job := range body.Jobs {
-		tsk := taskPool.GetTask(job.ID)
+// applyProgress writes logs and statuses of jobs reported by the runner.
+func (c *RunnerController) applyProgress(runner db.Runner, progress runners.RunnerProgress) error {
+	if progress.Capabilities != nil {
+		if err := c.runnerRepo.UpdateRunnerCapabilities(runner, *progress.Capabilities); err != nil {
+			return err
+		}
+	}
+
+	for _, job := range progress.Jobs {
+		tsk := c.taskPool.GetTask(job.ID)
 
 		if tsk == nil {
 			continue
 		}
 
 		if tsk.RunnerID != runner.ID {
-			helpers.WriteErrorStatus(w, "Task not assigned to this runner", http.StatusBadRequest)
-			return
+			if slices.Contains(tsk.LostRunnerIDs, runner.ID) {
+				continue
+			}
+			return db.NewValidationError("Task not assigned to this runner")
 		}
 
 		for _, logRecord := range job.LogRecords {
@@ -274,8 +301,7 @@ func (c *RunnerController) UpdateRunner(w http.ResponseWriter, r *http.Request)
 		}
 
 		if !job.Status.IsValid() {
-			helpers.WriteErrorStatus(w, "Invalid task status", http.StatusBadRequest)
-			return
+			return db.NewValidationError("Invalid task status")
 		}
 
 		tsk.SetStatus(job.Status)
@@ -285,31 +311,84 @@ func (c *RunnerController) UpdateRunner(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func RegisterRunner(w http.ResponseWriter, r *http.Request) {
-	var register runners.RunnerRegistration
+func (c *RunnerController) UpdateRunner(w http.ResponseWriter, r *http.Request) {
 
-	if !helpers.Bind(w, r, &register) {
+	runner := helpers.GetFromContext(r, "runner").(db.Runner)
+
+	var body runners.RunnerProgress
+
+	if !helpers.Bind(w, r, &body) {
 		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
 			"error": "Invalid format",
 		})
 		return
 	}
 
-	if util.Config.RunnerRegistrationToken == "" || register.RegistrationToken != util.Config.RunnerRegistrationToken {
+	if body.Jobs == nil && body.Capabilities == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := c.applyProgress(runner, body); err != nil {
+		helpers.WriteErrorStatus(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func RegisterRunner(w http.ResponseWriter, r *http.Request) {
+	var register runners.RunnerRegistration
+
+	if !helpers.Bind(w, r, &register) {
 		helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
-			"error": "Invalid registration token",
+			"error": "Invalid format",
 		})
 		return
 	}
 
-	runner, err := helpers.Store(r).CreateRunner(db.Runner{
+	store := helpers.Store(r)
+
+	newRunner := db.Runner{
 		Webhook:          register.Webhook,
 		MaxParallelTasks: register.MaxParallelTasks,
 		PublicKey:        register.PublicKey,
-	})
+	}
+
+	if register.Capabilities != nil {
+		newRunner.Capabilities = *register.Capabilities
+	}
+
+	description := "Runner registered with the registration token from the config"
+
+	// The registration token from the config registers global runners,
+	// tokens from the database register runners of their projects.
+	if util.Config.RunnerRegistrationToken == "" || register.RegistrationToken != util.Config.RunnerRegistrationToken {
+		token, err := store.UseRunnerRegistrationToken(register.RegistrationToken)
+
+		if errors.Is(err, db.ErrNotFound) {
+			helpers.WriteJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid registration token",
+			})
+			return
+		}
+
+		if err != nil {
+			helpers.WriteError(w, err)
+			return
+		}
+
+		newRunner.ProjectID = token.ProjectID
+		newRunner.Tag = token.Tag
+		newRunner.Labels = token.Labels
+
+		description = fmt.Sprintf("Runner registered with the registration token %s", token.Name)
+	}
+
+	runner, err := store.CreateRunner(newRunner)
 
 	if err != nil {
 		helpers.WriteJSON(w, http.StatusInternalServerError, map[string]string{
@@ -318,6 +397,18 @@ func RegisterRunner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	eventItem := helpers.EventLogItem{
+		ObjectType:  db.EventRunner,
+		ObjectID:    runner.ID,
+		Description: description,
+	}
+
+	if runner.ProjectID != nil {
+		eventItem.ProjectID = *runner.ProjectID
+	}
+
+	helpers.EventLog(r, helpers.EventLogCreate, eventItem)
+
 	log.WithFields(log.Fields{
 		"runner_id": runner.ID,
 		"context":   "runner",
@@ -332,6 +423,32 @@ func RegisterRunner(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, res)
 }
 
+// RotateRunnerToken replaces the token of the runner with a new one.
+// The old token stops working immediately.
+func RotateRunnerToken(w http.ResponseWriter, r *http.Request) {
+	runner := helpers.GetFromContext(r, "runner").(db.Runner)
+
+	rotated, err := helpers.Store(r).RotateRunnerToken(runner)
+
+	if err != nil {
+		helpers.WriteError(w, err)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"runner_id": runner.ID,
+		"context":   "runner",
+	}).Info("Runner token rotated")
+
+	var res struct {
+		Token string `json:"token"`
+	}
+
+	res.Token = rotated.Token
+
+	helpers.WriteJSON(w, http.StatusOK, res)
+}
+
 func UnregisterRunner(w http.ResponseWriter, r *http.Request) {
 
 	runner := helpers.GetFromContext(r, "runner").(db.Runner)
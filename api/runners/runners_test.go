@@ -0,0 +1,151 @@
+package runners
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	proServer "github.com/semaphoreui/semaphore/pro/services/server"
+	"github.com/semaphoreui/semaphore/services/runners"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnerController_LostRunner(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	state := tasks.NewMemoryTaskStateStore()
+	state.SetRunning(&tasks.TaskRunner{
+		Task:          db.Task{ID: 5, Status: task_logger.TaskRunningStatus},
+		RunnerID:      2,
+		LostRunnerIDs: []int{1},
+	})
+
+	pool := tasks.CreateTaskPool(store, state, nil, nil, nil, nil, nil)
+	controller := NewRunnerController(store, &pool, nil)
+
+	// The runner which came back online is requested to kill the reassigned task.
+	data := controller.runnerState(db.Runner{ID: 1}, false)
+	require.Len(t, data.CurrentJobs, 1)
+	assert.Equal(t, 5, data.CurrentJobs[0].ID)
+	assert.True(t, data.CurrentJobs[0].Kill)
+
+	assert.Empty(t, controller.runnerState(db.Runner{ID: 3}, false).CurrentJobs)
+
+	progress := runners.RunnerProgress{Jobs: []runners.JobProgress{
+		{ID: 5, Status: task_logger.TaskFailStatus},
+	}}
+
+	// Progress of the lost runner is ignored.
+	require.NoError(t, controller.applyProgress(db.Runner{ID: 1}, progress))
+	assert.Equal(t, task_logger.TaskRunningStatus, pool.GetTask(5).Task.Status)
+
+	assert.Error(t, controller.applyProgress(db.Runner{ID: 3}, progress))
+}
+
+func TestRunnerController_ApplyProgress_Capabilities(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	runner, err := store.CreateRunner(db.Runner{Name: "runner", Active: true})
+	require.NoError(t, err)
+	assert.Nil(t, runner.Capabilities.Apps)
+
+	pool := tasks.CreateTaskPool(store, tasks.NewMemoryTaskStateStore(), nil, nil, nil, nil, nil)
+	controller := NewRunnerController(store, &pool, nil)
+
+	require.NoError(t, controller.applyProgress(runner, runners.RunnerProgress{
+		Capabilities: &db.RunnerCapabilities{
+			OS:     "linux",
+			Apps:   map[db.TemplateApp]string{db.AppTerraform: "1.5.7"},
+			Labels: map[string]string{"zone": "eu"},
+		},
+	}))
+
+	runner, err = store.GetGlobalRunner(runner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "linux", runner.Capabilities.OS)
+	assert.Equal(t, "1.5.7", runner.Capabilities.Apps[db.AppTerraform])
+	assert.Equal(t, "eu", runner.Capabilities.Labels["zone"])
+}
+
+func TestRegisterRunner_RegistrationToken(t *testing.T) {
+	store := sql.CreateTestStore()
+	util.Config = &util.ConfigType{}
+
+	project, err := store.CreateProject(db.Project{Name: "project"})
+	require.NoError(t, err)
+
+	token, err := store.CreateRunnerRegistrationToken(db.RunnerRegistrationToken{
+		Name:      "ci",
+		ProjectID: &project.ID,
+		MaxUses:   1,
+		Tag:       "linux",
+		Labels:    db.RunnerLabels{"zone": "eu"},
+	})
+	require.NoError(t, err)
+
+	register := func(registrationToken string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(runners.RunnerRegistration{RegistrationToken: registrationToken, MaxParallelTasks: 2})
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodPost, "/api/internal/runners", bytes.NewReader(body))
+		r = helpers.SetContextValue(r, "store", store)
+		r = helpers.SetContextValue(r, "log_writer", proServer.NewLogWriteService())
+
+		w := httptest.NewRecorder()
+		RegisterRunner(w, r)
+		return w
+	}
+
+	w := register(token.Token)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+	runner, err := store.GetRunnerByToken(res.Token)
+	require.NoError(t, err)
+	require.NotNil(t, runner.ProjectID)
+	assert.Equal(t, project.ID, *runner.ProjectID)
+	assert.Equal(t, "linux", runner.Tag)
+	assert.Equal(t, "eu", runner.SelectorLabels()["zone"])
+
+	// The registration is recorded to the event log of the project.
+	events, err := store.GetEvents(project.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, db.EventRunner, *events[0].ObjectType)
+	assert.Equal(t, runner.ID, *events[0].ObjectID)
+	assert.Contains(t, *events[0].Description, "ci")
+
+	// The token can be used only once.
+	assert.Equal(t, http.StatusBadRequest, register(token.Token).Code)
+	assert.Equal(t, http.StatusBadRequest, register("").Code)
+
+	// The runner can replace its token.
+	r := httptest.NewRequest(http.MethodPost, "/api/internal/runners/token", nil)
+	r = helpers.SetContextValue(r, "store", store)
+	r = helpers.SetContextValue(r, "runner", runner)
+
+	w = httptest.NewRecorder()
+	RotateRunnerToken(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+	_, err = store.GetRunnerByToken(runner.Token)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	rotated, err := store.GetRunnerByToken(res.Token)
+	require.NoError(t, err)
+	assert.Equal(t, runner.ID, rotated.ID)
+}
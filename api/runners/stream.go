@@ -0,0 +1,175 @@
+package runners
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/runners"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// streamTouchInterval is how often the server updates the last activity
+	// time of the connected runner and reloads it to pick up cache clean requests.
+	streamTouchInterval = 5 * time.Second
+
+	streamWriteWait  = 10 * time.Second
+	streamPongWait   = 60 * time.Second
+	streamPingPeriod = (streamPongWait * 9) / 10
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Runners are not browsers, they are authenticated by the token.
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// StreamRunner upgrades the connection of the runner to the websocket.
+// The server pushes the state of the runner when the task pool reports
+// changes of tasks or the runner is reloaded, in the same format as GetRunner returns it. The runner sends its progress in the same
+// format as it sends it to UpdateRunner.
+func (c *RunnerController) StreamRunner(w http.ResponseWriter, r *http.Request) {
+	runner := helpers.GetFromContext(r, "runner").(db.Runner)
+	store := helpers.Store(r)
+
+	ws, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.WithError(err).WithField("runner_id", runner.ID).Error("Failed to upgrade runner connection")
+		return
+	}
+
+	defer ws.Close() //nolint:errcheck
+
+	logger := log.WithFields(log.Fields{
+		"runner_id": runner.ID,
+		"context":   "runner_stream",
+	})
+
+	logger.Info("Runner stream connected")
+
+	done := make(chan struct{})
+
+	go c.readProgress(ws, runner, logger, done)
+
+	touchTicker := time.NewTicker(streamTouchInterval)
+	pingTicker := time.NewTicker(streamPingPeriod)
+
+	defer func() {
+		touchTicker.Stop()
+		pingTicker.Stop()
+		logger.Info("Runner stream disconnected")
+	}()
+
+	write := func(messageType int, data []byte) bool {
+		_ = ws.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		if err := ws.WriteMessage(messageType, data); err != nil {
+			logger.WithError(err).Warn("Failed to write to runner stream")
+			return false
+		}
+		return true
+	}
+
+	var lastState []byte
+	clearCache, err := c.touchRunner(runner)
+	if err != nil {
+		logger.WithError(err).Error("runner touch failed")
+		return
+	}
+
+	for {
+		// The channel is taken before the state is built, so changes
+		// made while the state is built are not missed.
+		changed := c.taskPool.RunnerStateChanged()
+
+		state, err := json.Marshal(c.runnerState(runner, clearCache))
+		if err != nil {
+			logger.WithError(err).Error("Failed to marshal runner state")
+			return
+		}
+
+		if !bytes.Equal(state, lastState) {
+			message, encrypted, err := encryptForRunner(runner, state)
+			if err != nil {
+				logger.WithError(err).Error("Failed to encrypt runner state")
+				return
+			}
+
+			messageType := websocket.TextMessage
+			if encrypted {
+				messageType = websocket.BinaryMessage
+			}
+
+			if !write(messageType, message) {
+				return
+			}
+
+			lastState = state
+			// The runner clears the cache once.
+			clearCache = false
+		}
+
+		select {
+		case <-done:
+			return
+		case <-changed:
+		case <-pingTicker.C:
+			if !write(websocket.PingMessage, nil) {
+				return
+			}
+		case <-touchTicker.C:
+			// Reload the runner to pick up cache clean requests.
+			runner, err = store.GetRunnerByToken(runner.Token)
+			if err != nil {
+				logger.WithError(err).Warn("Runner not found")
+				return
+			}
+
+			clearCache, err = c.touchRunner(runner)
+			if err != nil {
+				logger.WithError(err).Error("runner touch failed")
+				return
+			}
+		}
+	}
+}
+
+// readProgress applies progress messages of the runner until the stream is closed.
+func (c *RunnerController) readProgress(ws *websocket.Conn, runner db.Runner, logger *log.Entry, done chan struct{}) {
+	defer close(done)
+
+	_ = ws.SetReadDeadline(time.Now().Add(streamPongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.WithError(err).Warn("Runner stream closed")
+			}
+			return
+		}
+
+		_ = ws.SetReadDeadline(time.Now().Add(streamPongWait))
+
+		var progress runners.RunnerProgress
+		if err = json.Unmarshal(message, &progress); err != nil {
+			logger.WithError(err).Warn("Invalid progress message")
+			return
+		}
+
+		if err = c.applyProgress(runner, progress); err != nil {
+			logger.WithError(err).Warn("Invalid progress message")
+			return
+		}
+	}
+}
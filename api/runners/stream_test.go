@@ -0,0 +1,105 @@
+package runners
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/semaphoreui/semaphore/api/helpers"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/services/runners"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decryptForTest(t *testing.T, privateKeyPem []byte, message []byte) []byte {
+	block, _ := pem.Decode(privateKeyPem)
+	require.NotNil(t, block)
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	require.NoError(t, err)
+
+	var res []byte
+	for i := 0; i < len(message); i += privateKey.Size() {
+		chunk, err := rsa.DecryptPKCS1v15(rand.Reader, privateKey, message[i:i+privateKey.Size()])
+		require.NoError(t, err)
+		res = append(res, chunk...)
+	}
+
+	return res
+}
+
+func TestRunnerController_StreamRunner(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	var privateKey bytes.Buffer
+	publicKey, err := util.GeneratePrivateKey(&privateKey)
+	require.NoError(t, err)
+
+	runner, err := store.CreateRunner(db.Runner{Name: "streaming", Active: true, PublicKey: &publicKey})
+	require.NoError(t, err)
+
+	pool := tasks.CreateTaskPool(store, tasks.NewMemoryTaskStateStore(), nil, nil, nil, nil, nil)
+	controller := NewRunnerController(store, &pool, nil)
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, helpers.SetContextValue(r, "store", store))
+		})
+	})
+
+	runnersAPI := r.PathPrefix("/api/internal/runners").Subrouter()
+	runnersAPI.Use(RunnerMiddleware)
+	runnersAPI.Path("/stream").HandlerFunc(controller.StreamRunner).Methods("GET")
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	address := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/internal/runners/stream"
+
+	_, resp, err := websocket.DefaultDialer.Dial(address, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	header := http.Header{}
+	header.Set("X-Runner-Token", runner.Token)
+
+	ws, _, err := websocket.DefaultDialer.Dial(address, header)
+	require.NoError(t, err)
+	defer ws.Close() //nolint:errcheck
+
+	// The state is pushed right after connecting and encrypted with the key of the runner.
+	messageType, message, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+
+	var state runners.RunnerState
+	require.NoError(t, json.Unmarshal(decryptForTest(t, privateKey.Bytes(), message), &state))
+	assert.Empty(t, state.NewJobs)
+	assert.Empty(t, state.CurrentJobs)
+	assert.False(t, state.ClearCache)
+
+	touched, err := store.GetGlobalRunner(runner.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, touched.Touched)
+
+	// Progress of unknown tasks is ignored, invalid progress closes the stream.
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"Jobs":[{"ID":100,"Status":"running"}]}`)))
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{`)))
+
+	_, _, err = ws.ReadMessage()
+	assert.Error(t, err)
+}
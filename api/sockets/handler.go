@@ -1,12 +1,16 @@
 package sockets
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
 	"github.com/semaphoreui/semaphore/api/helpers"
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/tz"
-	"net/http"
-	"time"
+	"github.com/semaphoreui/semaphore/services/metrics"
+	"github.com/semaphoreui/semaphore/util"
 
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
@@ -42,8 +46,22 @@ type connection struct {
 	ws     *websocket.Conn
 	send   chan []byte
 	userID int
+	admin  bool
+	store  db.Store
+
+	// subscriptions are changed only by the hub.
+	subscriptions map[subscription]bool
+}
+
+// clientMessage is the message from the client which subscribes the connection
+// to the project or to the task of the project.
+type clientMessage struct {
+	Type string `json:"type"`
+	subscription
 }
 
+var errAccessDenied = errors.New("project not found or access denied")
+
 func (c *connection) log(level log.Level, err error, msg string) {
 	log.WithError(err).WithFields(log.Fields{
 		"context": "websocket",
@@ -88,7 +106,6 @@ func (c *connection) readPump() {
 
 	for {
 		_, message, err := c.ws.ReadMessage()
-		fmt.Println(string(message))
 
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
@@ -96,9 +113,99 @@ func (c *connection) readPump() {
 			}
 			break
 		}
+
+		c.handleMessage(message)
 	}
 }
 
+// reply sends the message to this connection only. It goes through the hub
+// because the hub closes the send channel.
+func (c *connection) reply(msg map[string]any) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		c.logError(err, "Failed to marshal reply")
+		return
+	}
+
+	h.broadcast <- &sendRequest{conn: c, msg: b}
+}
+
+func (c *connection) replyError(s subscription, err error) {
+	c.reply(map[string]any{
+		"type":       "error",
+		"error":      err.Error(),
+		"project_id": s.ProjectID,
+		"task_id":    s.TaskID,
+	})
+}
+
+// checkAccess checks that the user is a member of the project
+// and the task belongs to the project. Subscriptions to tasks
+// require the permission to view the task output.
+func (c *connection) checkAccess(s subscription) (err error) {
+	if s.ProjectID <= 0 || s.TaskID < 0 {
+		return errors.New("invalid project or task ID")
+	}
+
+	db.StoreSession(c.store, util.RandString(12), func() {
+		if c.admin {
+			_, err = c.store.GetProject(s.ProjectID)
+		} else {
+			var projectUser db.ProjectUser
+			projectUser, err = c.store.GetProjectUser(s.ProjectID, c.userID)
+
+			if err == nil && s.TaskID > 0 {
+				var permissions db.ProjectUserPermission
+				permissions, err = db.GetProjectRolePermissions(c.store, s.ProjectID, projectUser.Role)
+				if err == nil && !permissions.Can(db.CanViewTaskOutput) {
+					err = errAccessDenied
+				}
+			}
+		}
+
+		if err == nil && s.TaskID > 0 {
+			_, err = c.store.GetTask(s.ProjectID, s.TaskID)
+		}
+	})
+
+	if errors.Is(err, db.ErrNotFound) {
+		err = errAccessDenied
+	}
+
+	return
+}
+
+func (c *connection) handleMessage(message []byte) {
+	var msg clientMessage
+
+	if err := json.Unmarshal(message, &msg); err != nil {
+		c.replyError(subscription{}, errors.New("invalid message"))
+		return
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		if err := c.checkAccess(msg.subscription); err != nil {
+			c.logDebug(err, "Subscription rejected")
+			c.replyError(msg.subscription, err)
+			return
+		}
+
+		h.subscribe <- &subscribeRequest{conn: c, subscription: msg.subscription}
+	case "unsubscribe":
+		h.subscribe <- &subscribeRequest{conn: c, subscription: msg.subscription, unsubscribe: true}
+	default:
+		c.replyError(msg.subscription, errors.New("unknown message type"))
+		return
+	}
+
+	c.reply(map[string]any{
+		"type":       msg.Type + "d",
+		"project_id": msg.ProjectID,
+		"task_id":    msg.TaskID,
+	})
+}
+
 // write writes a message with the given message type and payload.
 func (c *connection) write(mt int, payload []byte) error {
 
@@ -167,21 +274,45 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	c := &connection{
-		send:   make(chan []byte, connectionChannelSize),
-		ws:     ws,
-		userID: user.ID,
+		send:          make(chan []byte, connectionChannelSize),
+		ws:            ws,
+		userID:        user.ID,
+		admin:         user.Admin,
+		store:         helpers.Store(r),
+		subscriptions: make(map[subscription]bool),
 	}
 
 	h.register <- c
 
+	metrics.WebsocketConnected()
+	defer metrics.WebsocketDisconnected()
+
 	go c.writePump()
 	c.readPump()
 }
 
 // Message allows a message to be sent to the websockets, called in API task logging
 func Message(userID int, message []byte) {
+	req := &sendRequest{msg: message}
+
+	if userID > 0 {
+		req.userIDs = []int{userID}
+	}
+
+	h.broadcast <- req
+}
+
+// TaskMessage sends the message about the task to the users. Connections
+// subscribed to other projects or tasks do not receive it.
+func TaskMessage(userIDs []int, projectID int, taskID int, message []byte) {
+	if len(userIDs) == 0 {
+		return
+	}
+
 	h.broadcast <- &sendRequest{
-		userID: userID,
-		msg:    message,
+		userIDs:   userIDs,
+		projectID: projectID,
+		taskID:    taskID,
+		msg:       message,
 	}
 }
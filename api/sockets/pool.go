@@ -1,6 +1,10 @@
 package sockets
 
-import log "github.com/sirupsen/logrus"
+import (
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
 
 // hub maintains the set of active connections and broadcasts messages to the
 // connections.
@@ -16,20 +20,62 @@ type hub struct {
 
 	// Unregister requests from connections.
 	unregister chan *connection
+
+	// Subscribe and unsubscribe requests from the connections.
+	subscribe chan *subscribeRequest
 }
 
 type sendRequest struct {
-	userID int
-	msg    []byte
+	// conn is the only recipient of replies to the client messages.
+	conn *connection
+	// userIDs of recipients, nil means all users.
+	userIDs   []int
+	projectID int
+	taskID    int
+	msg       []byte
+}
+
+// subscription to messages of the project or of the task of the project.
+type subscription struct {
+	ProjectID int `json:"project_id"`
+	TaskID    int `json:"task_id,omitempty"`
+}
+
+type subscribeRequest struct {
+	conn         *connection
+	subscription subscription
+	unsubscribe  bool
 }
 
 var h = hub{
 	broadcast:   make(chan *sendRequest),
 	register:    make(chan *connection),
 	unregister:  make(chan *connection),
+	subscribe:   make(chan *subscribeRequest),
 	connections: make(map[*connection]bool),
 }
 
+// accepts checks if the message must be sent to the connection.
+// Connections without subscriptions receive all messages addressed
+// to the user, subscribed connections receive only messages of
+// their projects and tasks.
+func (c *connection) accepts(m *sendRequest) bool {
+	if m.conn != nil {
+		return m.conn == c
+	}
+
+	if m.userIDs != nil && !slices.Contains(m.userIDs, c.userID) {
+		return false
+	}
+
+	if m.projectID == 0 || len(c.subscriptions) == 0 {
+		return true
+	}
+
+	return c.subscriptions[subscription{ProjectID: m.projectID}] ||
+		c.subscriptions[subscription{ProjectID: m.projectID, TaskID: m.taskID}]
+}
+
 func (h *hub) run() {
 	for {
 		select {
@@ -40,9 +86,19 @@ func (h *hub) run() {
 				delete(h.connections, c)
 				close(c.send)
 			}
+		case s := <-h.subscribe:
+			if _, ok := h.connections[s.conn]; !ok {
+				continue
+			}
+
+			if s.unsubscribe {
+				delete(s.conn.subscriptions, s.subscription)
+			} else {
+				s.conn.subscriptions[s.subscription] = true
+			}
 		case m := <-h.broadcast:
 			for conn := range h.connections {
-				if m.userID > 0 && m.userID != conn.userID {
+				if !conn.accepts(m) {
 					continue
 				}
 
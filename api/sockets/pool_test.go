@@ -0,0 +1,98 @@
+package sockets
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnection_Accepts(t *testing.T) {
+	c := &connection{userID: 1, subscriptions: make(map[subscription]bool)}
+	other := &connection{userID: 1, subscriptions: make(map[subscription]bool)}
+
+	taskMsg := &sendRequest{userIDs: []int{1, 2}, projectID: 1, taskID: 5}
+
+	// Connections without subscriptions receive messages addressed to the user.
+	assert.True(t, c.accepts(taskMsg))
+	assert.True(t, c.accepts(&sendRequest{}))
+	assert.False(t, c.accepts(&sendRequest{userIDs: []int{2}, projectID: 1, taskID: 5}))
+
+	c.subscriptions[subscription{ProjectID: 2}] = true
+	assert.False(t, c.accepts(taskMsg))
+	assert.True(t, c.accepts(&sendRequest{userIDs: []int{1}}))
+
+	c.subscriptions[subscription{ProjectID: 1, TaskID: 5}] = true
+	assert.True(t, c.accepts(taskMsg))
+	assert.False(t, c.accepts(&sendRequest{userIDs: []int{1}, projectID: 1, taskID: 6}))
+
+	// Replies are sent only to the connection which requested them.
+	assert.True(t, c.accepts(&sendRequest{conn: c}))
+	assert.False(t, other.accepts(&sendRequest{conn: c}))
+}
+
+func TestConnection_CheckAccess(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "p@ssw0rd",
+		User: db.User{Username: "dev", Name: "Developer", Email: "dev@example.com"},
+	})
+	require.NoError(t, err)
+
+	member, err := store.CreateProject(db.Project{Name: "Member"})
+	require.NoError(t, err)
+	foreign, err := store.CreateProject(db.Project{Name: "Foreign"})
+	require.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: member.ID, UserID: user.ID, Role: db.ProjectTaskRunner})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{Name: "Test", Playbook: "test.yml", ProjectID: foreign.ID})
+	require.NoError(t, err)
+	task, err := store.CreateTask(db.Task{ProjectID: foreign.ID, TemplateID: tpl.ID}, 0)
+	require.NoError(t, err)
+
+	c := &connection{userID: user.ID, store: store}
+
+	assert.NoError(t, c.checkAccess(subscription{ProjectID: member.ID}))
+	assert.ErrorIs(t, c.checkAccess(subscription{ProjectID: foreign.ID}), errAccessDenied)
+	// The task of another project is not found in the project of the user.
+	assert.ErrorIs(t, c.checkAccess(subscription{ProjectID: member.ID, TaskID: task.ID}), errAccessDenied)
+	assert.Error(t, c.checkAccess(subscription{}))
+
+	admin := &connection{userID: user.ID, admin: true, store: store}
+	assert.NoError(t, admin.checkAccess(subscription{ProjectID: foreign.ID, TaskID: task.ID}))
+}
+
+func TestConnection_CheckAccessTaskOutput(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd:  "p@ssw0rd",
+		User: db.User{Username: "auditor", Name: "Auditor", Email: "auditor@example.com"},
+	})
+	require.NoError(t, err)
+
+	proj, err := store.CreateProject(db.Project{Name: "Project"})
+	require.NoError(t, err)
+
+	_, err = store.CreateRole(db.Role{Slug: "auditor", Name: "Auditor", ProjectID: &proj.ID, Permissions: db.CanViewSecrets})
+	require.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: user.ID, Role: "auditor"})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{Name: "Test", Playbook: "test.yml", ProjectID: proj.ID})
+	require.NoError(t, err)
+	task, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: tpl.ID}, 0)
+	require.NoError(t, err)
+
+	c := &connection{userID: user.ID, store: store}
+
+	// The role can not view the task output, so it can only follow the project.
+	assert.NoError(t, c.checkAccess(subscription{ProjectID: proj.ID}))
+	assert.ErrorIs(t, c.checkAccess(subscription{ProjectID: proj.ID, TaskID: task.ID}), errAccessDenied)
+}
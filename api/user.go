@@ -3,9 +3,12 @@ package api
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/semaphoreui/semaphore/api/helpers"
@@ -64,20 +67,49 @@ func getAPITokens(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSON(w, http.StatusOK, tokens)
 }
 
+// apiTokenRequest contains optional restrictions of the new API token.
+type apiTokenRequest struct {
+	Name      string            `json:"name"`
+	ExpiresAt *time.Time        `json:"expires_at"`
+	ProjectID *int              `json:"project_id"`
+	Scopes    db.APITokenScopes `json:"scopes"`
+}
+
 func createAPIToken(w http.ResponseWriter, r *http.Request) {
 	user := helpers.GetFromContext(r, "user").(*db.User)
+
+	var req apiTokenRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.ProjectID != nil && !user.Admin {
+		if _, err := helpers.Store(r).GetProjectUser(*req.ProjectID, user.ID); err != nil {
+			helpers.WriteErrorStatus(w, "You are not a member of the project", http.StatusBadRequest)
+			return
+		}
+	}
+
 	tokenID := make([]byte, 32)
 	if _, err := io.ReadFull(rand.Reader, tokenID); err != nil {
 		panic(err)
 	}
 
 	token, err := helpers.Store(r).CreateAPIToken(db.APIToken{
-		ID:      strings.ToLower(base64.URLEncoding.EncodeToString(tokenID)),
-		UserID:  user.ID,
-		Expired: false,
+		ID:        strings.ToLower(base64.URLEncoding.EncodeToString(tokenID)),
+		UserID:    user.ID,
+		Expired:   false,
+		Name:      req.Name,
+		ExpiresAt: req.ExpiresAt,
+		ProjectID: req.ProjectID,
+		Scopes:    req.Scopes,
 	})
 	if err != nil {
-		panic(err)
+		helpers.WriteError(w, err)
+		return
 	}
 
 	helpers.WriteJSON(w, http.StatusCreated, token)
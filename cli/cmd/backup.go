@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type backupArgs struct {
+	file       string
+	passphrase string
+}
+
+var targetBackupArgs backupArgs
+
+func init() {
+	backupCmd.PersistentFlags().StringVar(&targetBackupArgs.file, "file", "", "Path of the backup archive")
+	backupCmd.PersistentFlags().StringVar(&targetBackupArgs.passphrase, "passphrase", "", "Passphrase which encrypts secrets in the archive, SEMAPHORE_BACKUP_PASSPHRASE is used if omitted")
+	rootCmd.AddCommand(backupCmd)
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backup and restore the whole instance",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+		os.Exit(0)
+	},
+}
+
+func getBackupPassphrase() string {
+	if targetBackupArgs.passphrase != "" {
+		return targetBackupArgs.passphrase
+	}
+	return os.Getenv("SEMAPHORE_BACKUP_PASSPHRASE")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/services/instance_backup"
+	"github.com/semaphoreui/semaphore/services/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	backupCmd.AddCommand(backupCreateCmd)
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create archive with all projects, users, runners and settings",
+	Run: func(cmd *cobra.Command, args []string) {
+		passphrase := getBackupPassphrase()
+
+		if targetBackupArgs.file == "" || passphrase == "" {
+			fmt.Println("Arguments --file and --passphrase required")
+			fmt.Println("Use command `semaphore backup create --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		f, err := os.OpenFile(targetBackupArgs.file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		err = instance_backup.Create(store, f, instance_backup.Options{
+			Passphrase:        passphrase,
+			EncryptionService: server.NewAccessKeyEncryptionService(store, store, store),
+		})
+
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			_ = os.Remove(targetBackupArgs.file)
+			log.Errorf("failed to create backup: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Backup saved to %s\n", targetBackupArgs.file)
+	},
+}
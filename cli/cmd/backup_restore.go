@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/services/instance_backup"
+	"github.com/semaphoreui/semaphore/services/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	backupCmd.AddCommand(backupRestoreCmd)
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore archive into the instance without projects",
+	Run: func(cmd *cobra.Command, args []string) {
+		passphrase := getBackupPassphrase()
+
+		if targetBackupArgs.file == "" || passphrase == "" {
+			fmt.Println("Arguments --file and --passphrase required")
+			fmt.Println("Use command `semaphore backup restore --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		f, err := os.Open(targetBackupArgs.file)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		defer f.Close() //nolint:errcheck
+
+		res, err := instance_backup.Restore(store, f, instance_backup.Options{
+			Passphrase:        passphrase,
+			EncryptionService: server.NewAccessKeyEncryptionService(store, store, store),
+		})
+
+		if err != nil {
+			log.Errorf("failed to restore %s: %v", targetBackupArgs.file, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Users: %d\n", res.Users)
+		fmt.Printf("Projects: %d\n", res.Projects)
+		fmt.Printf("Roles: %d\n", res.Roles)
+		fmt.Printf("Options: %d\n", res.Options)
+		fmt.Printf("Runners: %d\n", res.Runners)
+		fmt.Printf("API tokens: %d\n", res.Tokens)
+
+		if len(res.ExistingUsers) > 0 {
+			fmt.Printf("Existing users kept unchanged: %s\n", strings.Join(res.ExistingUsers, ", "))
+		}
+
+		if res.IntegrationAlias != "" {
+			fmt.Printf("Global integration alias (set global_integration_alias in the configuration): %s\n", res.IntegrationAlias)
+		}
+	},
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/factory"
+	"github.com/semaphoreui/semaphore/services/db_migration"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var migrateDbArgs struct {
+	from   string
+	to     string
+	resume bool
+}
+
+func init() {
+	migrateDbCmd.PersistentFlags().StringVar(&migrateDbArgs.from, "from", "", "Path of the config file of the source database")
+	migrateDbCmd.PersistentFlags().StringVar(&migrateDbArgs.to, "to", "", "Path of the config file of the target database")
+	migrateDbCmd.PersistentFlags().BoolVar(&migrateDbArgs.resume, "resume", false, "Continue the interrupted migration")
+
+	rootCmd.AddCommand(migrateDbCmd)
+}
+
+// openMigrationStore connects to the database of the config file
+// and applies migrations. Stores read the global config, so it is
+// returned to be restored before the store is closed.
+func openMigrationStore(configPath string) (db.Store, *util.ConfigType) {
+	util.ConfigInit(configPath, false)
+
+	store := factory.CreateStore()
+	store.Connect("migrate-db")
+
+	if err := db.Migrate(store, nil); err != nil {
+		log.Errorf("failed to migrate database of %s: %v", configPath, err)
+		os.Exit(1)
+	}
+
+	return store, util.Config
+}
+
+var migrateDbCmd = &cobra.Command{
+	Use:   "migrate-db",
+	Short: "Copy all data to the database of another dialect",
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateDbArgs.from == "" || migrateDbArgs.to == "" {
+			fmt.Println("Arguments --from and --to required")
+			fmt.Println("Use command `semaphore migrate-db --help` for details.")
+			os.Exit(1)
+		}
+
+		if err := migrateDb(); err != nil {
+			log.Errorf("failed to migrate data: %v", err)
+			log.Error("Run the command with --resume to continue the interrupted migration")
+			os.Exit(1)
+		}
+
+		fmt.Println("Migration completed")
+	},
+}
+
+func migrateDb() error {
+	source, sourceConfig := openMigrationStore(migrateDbArgs.from)
+	target, targetConfig := openMigrationStore(migrateDbArgs.to)
+
+	defer func() {
+		util.Config = targetConfig
+		target.Close("migrate-db")
+		util.Config = sourceConfig
+		source.Close("migrate-db")
+	}()
+
+	// Secrets are copied encrypted.
+	if sourceConfig.AccessKeyEncryption != targetConfig.AccessKeyEncryption {
+		return fmt.Errorf("access_key_encryption of both configs must be equal")
+	}
+
+	res, err := db_migration.NewMigrator(source, target, db_migration.Options{
+		Resume: migrateDbArgs.resume,
+	}).Run()
+
+	if res != nil {
+		for _, e := range res.Entities {
+			fmt.Printf("%s: %d copied", e.Table, e.Target)
+			if e.Skipped > 0 {
+				fmt.Printf(", %d skipped", e.Skipped)
+			}
+			fmt.Println()
+		}
+	}
+
+	return err
+}
@@ -11,13 +11,15 @@ import (
 
 	"github.com/semaphoreui/semaphore/db"
 	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/services/server"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 type projectImportArgs struct {
-	dir  string
-	file string
+	dir        string
+	file       string
+	passphrase string
 }
 
 var targetProjectImportArgs projectImportArgs
@@ -25,6 +27,7 @@ var targetProjectImportArgs projectImportArgs
 func init() {
 	projectImportCmd.PersistentFlags().StringVar(&targetProjectImportArgs.dir, "dir", "", "Directory path with project backups to import")
 	projectImportCmd.PersistentFlags().StringVar(&targetProjectImportArgs.file, "file", "", "Backup file path to import")
+	projectImportCmd.PersistentFlags().StringVar(&targetProjectImportArgs.passphrase, "passphrase", "", "Passphrase to decrypt secrets included in backups, SEMAPHORE_BACKUP_PASSPHRASE is used if omitted")
 	projectCmd.AddCommand(projectImportCmd)
 }
 
@@ -92,9 +95,22 @@ var projectImportCmd = &cobra.Command{
 		// sort for deterministic order
 		sort.Strings(files)
 
+		passphrase := targetProjectImportArgs.passphrase
+		if passphrase == "" {
+			passphrase = os.Getenv("SEMAPHORE_BACKUP_PASSPHRASE")
+		}
+
+		var secrets *projectService.SecretOptions
+		if passphrase != "" {
+			secrets = &projectService.SecretOptions{
+				Passphrase:        passphrase,
+				EncryptionService: server.NewAccessKeyEncryptionService(store, store, store),
+			}
+		}
+
 		okCount := 0
 		for _, f := range files {
-			if err := importProjectFromFile(f, user, store); err != nil {
+			if err := importProjectFromFile(f, user, store, secrets); err != nil {
 				log.Errorf("failed to import %s: %v", f, err)
 				continue
 			}
@@ -134,7 +150,7 @@ func resolveImportUser(store db.Store) (res db.User, err error) {
 	return
 }
 
-func importProjectFromFile(path string, user db.User, store db.Store) error {
+func importProjectFromFile(path string, user db.User, store db.Store, secrets *projectService.SecretOptions) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return err
@@ -146,6 +162,6 @@ func importProjectFromFile(path string, user db.User, store db.Store) error {
 	if err := backup.Verify(); err != nil {
 		return err
 	}
-	_, err = backup.Restore(user, store)
+	_, err = backup.RestoreWithSecrets(user, store, secrets)
 	return err
 }
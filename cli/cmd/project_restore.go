@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/services/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type projectRestoreArgs struct {
+	projectID  int
+	file       string
+	dryRun     bool
+	passphrase string
+}
+
+var targetProjectRestoreArgs projectRestoreArgs
+
+func init() {
+	projectRestoreCmd.PersistentFlags().IntVar(&targetProjectRestoreArgs.projectID, "project", 0, "ID of the existing project to restore into")
+	projectRestoreCmd.PersistentFlags().StringVar(&targetProjectRestoreArgs.file, "file", "", "Backup file path to restore")
+	projectRestoreCmd.PersistentFlags().BoolVar(&targetProjectRestoreArgs.dryRun, "dry-run", false, "Print changes without applying them")
+	projectRestoreCmd.PersistentFlags().StringVar(&targetProjectRestoreArgs.passphrase, "passphrase", "", "Passphrase to decrypt secrets included in the backup, SEMAPHORE_BACKUP_PASSPHRASE is used if omitted")
+	projectCmd.AddCommand(projectRestoreCmd)
+}
+
+var projectRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore backup into the existing project",
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if targetProjectRestoreArgs.projectID == 0 || targetProjectRestoreArgs.file == "" {
+			fmt.Println("Arguments --project and --file required")
+			fmt.Println("Use command `semaphore project restore --help` for details.")
+			os.Exit(1)
+		}
+
+		store := createStore("")
+		defer store.Close("")
+
+		if _, err := store.GetProject(targetProjectRestoreArgs.projectID); err != nil {
+			log.Errorf("cannot find project %d: %v", targetProjectRestoreArgs.projectID, err)
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(targetProjectRestoreArgs.file)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		var backup projectService.BackupFormat
+		if err = backup.Unmarshal(string(data)); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		if err = backup.Verify(); err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+
+		passphrase := targetProjectRestoreArgs.passphrase
+		if passphrase == "" {
+			passphrase = os.Getenv("SEMAPHORE_BACKUP_PASSPHRASE")
+		}
+
+		options := projectService.RestoreIntoOptions{
+			DryRun: targetProjectRestoreArgs.dryRun,
+		}
+
+		if passphrase != "" {
+			options.Secrets = &projectService.SecretOptions{
+				Passphrase:        passphrase,
+				EncryptionService: server.NewAccessKeyEncryptionService(store, store, store),
+			}
+		}
+
+		diff, err := backup.RestoreInto(targetProjectRestoreArgs.projectID, store, options)
+		if err != nil {
+			log.Errorf("failed to restore %s: %v", targetProjectRestoreArgs.file, err)
+			os.Exit(1)
+		}
+
+		for _, c := range diff.Changes {
+			fmt.Printf("%-7s %s/%s\n", c.Action, c.Kind, c.Name)
+		}
+
+		if diff.DryRun {
+			fmt.Printf("Changes to apply: %d (dry run)\n", len(diff.Changes))
+		} else {
+			fmt.Printf("Changes applied: %d\n", len(diff.Changes))
+		}
+	},
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/syslog"
 	"net/http"
@@ -20,8 +21,15 @@ import (
 	proFactory "github.com/semaphoreui/semaphore/pro/db/factory"
 	proServer "github.com/semaphoreui/semaphore/pro/services/server"
 	proTasks "github.com/semaphoreui/semaphore/pro/services/tasks"
+	"github.com/semaphoreui/semaphore/services/artifacts"
+	"github.com/semaphoreui/semaphore/services/gitops"
+	"github.com/semaphoreui/semaphore/services/ldap_sync"
+	"github.com/semaphoreui/semaphore/services/metrics"
+	"github.com/semaphoreui/semaphore/services/runner_health"
+	"github.com/semaphoreui/semaphore/services/runner_provisioning"
 	"github.com/semaphoreui/semaphore/services/schedules"
 	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/services/tracing"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -84,11 +92,29 @@ func initSyslog(conf *util.SyslogConfig) {
 	}
 }
 
+// initTracing starts the export of traces if it is enabled.
+// It returns the function which flushes the remaining spans.
+func initTracing(serviceName string) func() {
+	shutdown, err := tracing.Init(util.Config.Tracing, serviceName)
+	if err != nil {
+		log.WithError(err).WithField("context", "tracing").Fatal("Failed to initialize tracing")
+	}
+
+	return func() {
+		if err := shutdown(context.Background()); err != nil {
+			log.WithError(err).WithField("context", "tracing").Error("Failed to flush traces")
+		}
+	}
+}
+
 func runService() {
 	store := createStore("root")
 
 	initSyslog(util.Config.Log.Channels.Syslog)
 
+	shutdownTracing := initTracing("semaphore")
+	defer shutdownTracing()
+
 	state := proTasks.NewTaskStateStore()
 	terraformStore := proFactory.NewTerraformStore(store)
 	ansibleTaskRepo := proFactory.NewAnsibleTaskRepository(store)
@@ -119,6 +145,28 @@ func runService() {
 		logWriteService,
 	)
 
+	if util.Config.RunnerProvisioning.IsEnabled() {
+		provisioner := runner_provisioning.NewProvisioner(
+			store,
+			runner_provisioning.NewContainerProvider(util.Config.RunnerProvisioning),
+			util.Config.RunnerProvisioning,
+		)
+
+		if err := provisioner.Cleanup(); err != nil {
+			log.WithError(err).Error("Failed to remove ephemeral runners")
+		}
+
+		taskPool.SetRunnerProvisioner(provisioner)
+	}
+
+	artifactStorage, artifactErr := artifacts.NewStorage(util.Config.Artifacts)
+	if artifactErr != nil {
+		log.WithError(artifactErr).WithField("context", "artifacts").Fatal("Failed to initialize artifact storage")
+	}
+
+	artifactService := artifacts.NewService(store, artifactStorage, util.Config.Artifacts)
+	taskPool.SetArtifactTransfer(artifactService)
+
 	schedulePool := schedules.CreateSchedulePool(
 		store,
 		&taskPool,
@@ -146,6 +194,27 @@ func runService() {
 	go sockets.StartWS()
 	go schedulePool.Run()
 	go taskPool.Run()
+	go artifactService.Run()
+
+	if util.Config.LdapEnable && util.Config.LdapGroupSync.IsEnabled() {
+		go ldap_sync.NewGroupSync(store, util.Config.LdapGroupSync).Run(state)
+	}
+
+	if util.Config.Gitops.IsEnabled() {
+		go gitops.NewReconciler(store, encryptionService, accessKeyInstallationService).Run(state)
+	}
+
+	if util.Config.RunnerHeartbeatTimeoutSec > 0 {
+		go runner_health.NewMonitor(store, state).Run()
+	}
+
+	if util.Config.Metrics.IsEnabled() {
+		metrics.RegisterTaskPool(&taskPool, store)
+
+		if util.Config.Metrics.Listen != "" {
+			go metrics.Serve(util.Config.Metrics)
+		}
+	}
 
 	route := api.Route(
 		store,
@@ -160,6 +229,7 @@ func runService() {
 		accessKeyService,
 		environmentService,
 		subscriptionService,
+		artifactService,
 	)
 
 	route.Use(func(next http.Handler) http.Handler {
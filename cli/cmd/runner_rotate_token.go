@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	runnerCmd.AddCommand(runnerRotateTokenCmd)
+}
+
+func rotateRunnerToken() {
+	configFile := util.ConfigInit(persistentFlags.configPath, persistentFlags.noConfig)
+
+	taskPool := createRunnerJobPool()
+	err := taskPool.RotateToken(configFile)
+	if err != nil {
+		panic(err)
+	}
+}
+
+var runnerRotateTokenCmd = &cobra.Command{
+	Use:   "rotate-token",
+	Short: "Replace the runner token with a new one",
+	Run: func(cmd *cobra.Command, args []string) {
+		rotateRunnerToken()
+	},
+}
@@ -20,6 +20,9 @@ func runRunner() {
 
 	configFile := util.ConfigInit(persistentFlags.configPath, persistentFlags.noConfig)
 
+	shutdownTracing := initTracing("semaphore-runner")
+	defer shutdownTracing()
+
 	taskPool := createRunnerJobPool()
 
 	// If --register is passed, try to register the runner if not already registered
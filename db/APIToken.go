@@ -1,6 +1,71 @@
 package db
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// APITokenScope limits operations which can be performed with an API token.
+type APITokenScope string
+
+const (
+	// APITokenScopeReadOnly allows only read requests.
+	APITokenScopeReadOnly APITokenScope = "read_only"
+	// APITokenScopeRunTasks allows to start, stop, confirm and reject tasks.
+	APITokenScopeRunTasks APITokenScope = "run_tasks"
+	// APITokenScopeManageResources allows to create, update and delete
+	// project resources like templates, inventories, keys and schedules.
+	APITokenScopeManageResources APITokenScope = "manage_resources"
+)
+
+func (s APITokenScope) IsValid() bool {
+	switch s {
+	case APITokenScopeReadOnly, APITokenScopeRunTasks, APITokenScopeManageResources:
+		return true
+	}
+	return false
+}
+
+// APITokenScopes is stored in the database as JSON array.
+type APITokenScopes []APITokenScope
+
+func (s *APITokenScopes) Scan(value any) error {
+	var data []byte
+
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for APITokenScopes")
+	}
+
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+
+	return json.Unmarshal(data, s)
+}
+
+// Value implements the driver.Valuer interface for APITokenScopes
+func (s APITokenScopes) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+
+	res, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(res), nil
+}
 
 // APIToken is given to a user to allow API access
 type APIToken struct {
@@ -8,4 +73,49 @@ type APIToken struct {
 	Created time.Time `db:"created" json:"created"`
 	Expired bool      `db:"expired" json:"expired"`
 	UserID  int       `db:"user_id" json:"user_id"`
+
+	Name string `db:"name" json:"name"`
+	// ExpiresAt is the time after which the token can not be used.
+	// Token without ExpiresAt never expires.
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	// ProjectID restricts the token to the single project.
+	ProjectID *int `db:"project_id" json:"project_id,omitempty"`
+	// Scopes limits the operations allowed for the token.
+	// Token without scopes has the full power of its user.
+	Scopes APITokenScopes `db:"scopes" json:"scopes"`
+
+	LastUsed   *time.Time `db:"last_used" json:"last_used,omitempty"`
+	LastUsedIP string     `db:"last_used_ip" json:"last_used_ip,omitempty"`
+}
+
+func (token APIToken) Validate() error {
+	for _, scope := range token.Scopes {
+		if !scope.IsValid() {
+			return &ValidationError{"unknown token scope " + string(scope)}
+		}
+	}
+
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(token.Created) {
+		return &ValidationError{"token expiration time must be in the future"}
+	}
+
+	return nil
+}
+
+// IsActive returns false if the token was revoked or its expiration time passed.
+func (token APIToken) IsActive(now time.Time) bool {
+	if token.Expired {
+		return false
+	}
+
+	return token.ExpiresAt == nil || now.Before(*token.ExpiresAt)
+}
+
+// IsScoped returns true if the token is limited to the specific operations.
+func (token APIToken) IsScoped() bool {
+	return len(token.Scopes) > 0
+}
+
+func (token APIToken) HasScope(scope APITokenScope) bool {
+	return slices.Contains(token.Scopes, scope)
 }
@@ -0,0 +1,14 @@
+package db
+
+// Deployment is the task of the deploy template with the version
+// of the build it has deployed.
+type Deployment struct {
+	TaskWithTpl
+	// TargetInventoryID is the inventory which the task has run on.
+	TargetInventoryID *int `json:"target_inventory_id"`
+	// IncomingVersion is the version of the deployed build.
+	IncomingVersion *string `json:"incoming_version"`
+	// IncomingBuildTaskID is the task of the build template which
+	// has been deployed.
+	IncomingBuildTaskID *int `json:"incoming_build_task_id"`
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"time"
+)
+
+// EnvironmentVersion is the version of the build template which is currently
+// deployed to the environment. It is updated by successful deploy tasks.
+type EnvironmentVersion struct {
+	ID              int `db:"id" json:"id"`
+	ProjectID       int `db:"project_id" json:"project_id"`
+	EnvironmentID   int `db:"environment_id" json:"environment_id"`
+	BuildTemplateID int `db:"build_template_id" json:"build_template_id"`
+	// TemplateID is the deploy template which has deployed the version.
+	TemplateID int `db:"template_id" json:"template_id"`
+
+	// TaskID and BuildTaskID are nil if the tasks have been deleted.
+	TaskID      *int    `db:"task_id" json:"task_id"`
+	BuildTaskID *int    `db:"build_task_id" json:"build_task_id"`
+	Version     *string `db:"version" json:"version"`
+
+	Deployed time.Time `db:"deployed" json:"deployed"`
+}
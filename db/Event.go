@@ -62,6 +62,7 @@ const (
 	EventTemplate                EventObjectType = "template"
 	EventUser                    EventObjectType = "user"
 	EventView                    EventObjectType = "view"
+	EventRunner                  EventObjectType = "runner"
 	EventIntegration             EventObjectType = "integration"
 	EventIntegrationExtractValue EventObjectType = "integrationextractvalue"
 	EventIntegrationMatcher      EventObjectType = "integrationmatcher"
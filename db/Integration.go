@@ -3,6 +3,7 @@ package db
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 type IntegrationAuthMethod string
@@ -98,12 +99,44 @@ type Integration struct {
 	AuthHeader   string                `db:"auth_header" json:"auth_header"`
 	AuthSecret   AccessKey             `db:"-" json:"-" backup:"-"`
 	Searchable   bool                  `db:"searchable" json:"searchable"`
+
+	// RateLimit is the maximum number of deliveries per minute which can
+	// trigger a task. Zero means no limit.
+	RateLimit int `db:"rate_limit" json:"rate_limit"`
+	// DebounceSec is the coalescing window in seconds. Only the latest payload
+	// received within the window triggers a task. Zero disables debouncing.
+	DebounceSec int `db:"debounce_sec" json:"debounce_sec"`
+	// CancelQueued stops queued tasks of the integration when a newer delivery triggers a task.
+	CancelQueued bool `db:"cancel_queued" json:"cancel_queued"`
+
 	//TaskParams   MapStringAnyField     `db:"task_params" json:"task_params"`
 
 	TaskParamsID *int        `db:"task_params_id" json:"-" backup:"-"`
 	TaskParams   *TaskParams `db:"-" json:"task_params,omitempty" backup:"task_params"`
 }
 
+type IntegrationDeliveryStatus string
+
+const (
+	IntegrationDeliveryTriggered   IntegrationDeliveryStatus = "triggered"
+	IntegrationDeliveryDebounced   IntegrationDeliveryStatus = "debounced"
+	IntegrationDeliverySuperseded  IntegrationDeliveryStatus = "superseded"
+	IntegrationDeliveryRateLimited IntegrationDeliveryStatus = "rate_limited"
+	IntegrationDeliveryFailed      IntegrationDeliveryStatus = "failed"
+)
+
+// IntegrationDelivery is a record of the integration delivery history.
+// It is created for every delivery which passed authentication and matching.
+type IntegrationDelivery struct {
+	ID            int                       `db:"id" json:"id"`
+	ProjectID     int                       `db:"project_id" json:"project_id"`
+	IntegrationID int                       `db:"integration_id" json:"integration_id"`
+	Created       time.Time                 `db:"created" json:"created"`
+	Status        IntegrationDeliveryStatus `db:"status" json:"status"`
+	TaskID        *int                      `db:"task_id" json:"task_id"`
+	Message       string                    `db:"message" json:"message"`
+}
+
 func (alias IntegrationAlias) ToAlias() Alias {
 	return Alias{
 		ID:        alias.ID,
@@ -116,6 +149,15 @@ func (env *Integration) Validate() error {
 	if env.Name == "" {
 		return &ValidationError{"No Name set for integration"}
 	}
+
+	if env.RateLimit < 0 {
+		return &ValidationError{"Rate limit can not be negative"}
+	}
+
+	if env.DebounceSec < 0 {
+		return &ValidationError{"Debounce window can not be negative"}
+	}
+
 	return nil
 }
 
@@ -116,6 +116,21 @@ func GetMigrations(dialect string) []Migration {
 		{Version: "2.16.8"},
 		{Version: "2.17.0"},
 		{Version: "2.17.1"},
+		{Version: "2.18.0"},
+		{Version: "2.18.1"},
+		{Version: "2.18.2"},
+		{Version: "2.18.3"},
+		{Version: "2.18.4"},
+		{Version: "2.18.5"},
+		{Version: "2.18.6"},
+		{Version: "2.18.7"},
+		{Version: "2.18.8"},
+		{Version: "2.18.9"},
+		{Version: "2.18.10"},
+		{Version: "2.18.11"},
+		{Version: "2.18.12"},
+		{Version: "2.18.13"},
+		{Version: "2.18.14"},
 	}
 
 	return append(initScripts, commonScripts...)
@@ -1,9 +1,27 @@
 package db
 
 import (
+	"regexp"
 	"time"
 )
 
+// containerImageRegexp matches OCI image references, for example
+// "registry.example.com:5000/team/ansible:2.18@sha256:...". The reference
+// is passed to the container engine CLI, so it must not look like an option.
+var containerImageRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/:@+-]*$`)
+
+// ValidateContainerImage returns an error if the image is not empty and
+// is not a valid OCI image reference.
+func ValidateContainerImage(image string) error {
+	if image == "" {
+		return nil
+	}
+	if len(image) > 500 || !containerImageRegexp.MatchString(image) {
+		return &ValidationError{"container image " + image + " is invalid"}
+	}
+	return nil
+}
+
 // Project is the top level structure in Semaphore
 type Project struct {
 	ID                     int       `db:"id" json:"id" backup:"-"`
@@ -14,4 +32,10 @@ type Project struct {
 	MaxParallelTasks       int       `db:"max_parallel_tasks" json:"max_parallel_tasks,omitempty"`
 	Type                   string    `db:"type" json:"type"`
 	DefaultSecretStorageID *int      `db:"default_secret_storage_id" json:"default_secret_storage_id,omitempty" backup:"-"`
+	// ContainerImage is the default OCI image which local tasks of the project run in.
+	ContainerImage string `db:"container_image" json:"container_image,omitempty"`
+}
+
+func (project *Project) Validate() error {
+	return ValidateContainerImage(project.ContainerImage)
 }
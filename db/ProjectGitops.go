@@ -0,0 +1,45 @@
+package db
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// ProjectGitops binds the project to the definition file stored in
+// the project repository. The definition uses the project backup format
+// and is applied to the project by the GitOps reconciler.
+type ProjectGitops struct {
+	ProjectID    int    `db:"project_id" json:"project_id"`
+	RepositoryID int    `db:"repository_id" json:"repository_id" binding:"required"`
+	Path         string `db:"path" json:"path" binding:"required"`
+	// LockManaged forbids changing objects described by the definition
+	// through the API and UI.
+	LockManaged bool `db:"lock_managed" json:"lock_managed"`
+
+	// LastCommit is the hash of the commit applied to the project.
+	LastCommit *string    `db:"last_commit" json:"last_commit"`
+	LastSync   *time.Time `db:"last_sync" json:"last_sync"`
+	LastError  *string    `db:"last_error" json:"last_error"`
+	// Drift contains JSON encoded changes which differ the project
+	// from the definition of the last applied commit.
+	Drift *string `db:"drift" json:"-"`
+}
+
+func (g *ProjectGitops) Validate() error {
+	if g.RepositoryID == 0 {
+		return &ValidationError{"repository required"}
+	}
+
+	if g.Path == "" {
+		return &ValidationError{"path required"}
+	}
+
+	p := path.Clean(strings.ReplaceAll(g.Path, "\\", "/"))
+
+	if path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return &ValidationError{"path must be relative to the repository root"}
+	}
+
+	return nil
+}
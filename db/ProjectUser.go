@@ -1,5 +1,7 @@
 package db
 
+import "errors"
+
 type ProjectUserRole string
 
 const (
@@ -17,13 +19,63 @@ const (
 	CanUpdateProject
 	CanManageProjectResources
 	CanManageProjectUsers
+	// CanViewSecrets allows to list access keys and secret storages.
+	// Secret values are never returned by API.
+	CanViewSecrets
+	CanManageSchedules
+	CanManageIntegrations
+	// CanApproveTasks allows to confirm or reject tasks waiting for confirmation,
+	// for example Terraform plans.
+	CanApproveTasks
+	CanStopOthersTasks
+	CanViewTaskOutput
 )
 
+// AllProjectPermissions contains all known permission flags.
+const AllProjectPermissions = CanRunProjectTasks |
+	CanUpdateProject |
+	CanManageProjectResources |
+	CanManageProjectUsers |
+	CanViewSecrets |
+	CanManageSchedules |
+	CanManageIntegrations |
+	CanApproveTasks |
+	CanStopOthersTasks |
+	CanViewTaskOutput
+
 var rolePermissions = map[ProjectUserRole]ProjectUserPermission{
-	ProjectOwner:      CanRunProjectTasks | CanManageProjectResources | CanUpdateProject | CanManageProjectUsers,
-	ProjectManager:    CanRunProjectTasks | CanManageProjectResources,
-	ProjectTaskRunner: CanRunProjectTasks,
-	ProjectGuest:      0,
+	ProjectOwner: AllProjectPermissions,
+	ProjectManager: CanRunProjectTasks | CanManageProjectResources | CanViewSecrets | CanManageSchedules |
+		CanManageIntegrations | CanApproveTasks | CanStopOthersTasks | CanViewTaskOutput,
+	ProjectTaskRunner: CanRunProjectTasks | CanViewSecrets | CanApproveTasks | CanStopOthersTasks | CanViewTaskOutput,
+	ProjectGuest:      CanViewSecrets | CanViewTaskOutput,
+}
+
+// Can returns true if all the given permission flags are set.
+func (p ProjectUserPermission) Can(permissions ProjectUserPermission) bool {
+	return (p & permissions) == permissions
+}
+
+// IsValid returns false if the permission contains unknown flags.
+func (p ProjectUserPermission) IsValid() bool {
+	return p >= 0 && (p & ^AllProjectPermissions) == 0
+}
+
+// WithLegacyImplied extends permissions created before fine-grained flags
+// were introduced. It grants new flags which were implied by the old ones,
+// so existing custom roles keep their access.
+func (p ProjectUserPermission) WithLegacyImplied() ProjectUserPermission {
+	res := p | CanViewSecrets | CanViewTaskOutput
+
+	if p.Can(CanRunProjectTasks) {
+		res |= CanApproveTasks | CanStopOthersTasks
+	}
+
+	if p.Can(CanManageProjectResources) {
+		res |= CanManageSchedules | CanManageIntegrations
+	}
+
+	return res
 }
 
 func (r ProjectUserRole) IsValid() bool {
@@ -39,9 +91,22 @@ type ProjectUser struct {
 }
 
 func (r ProjectUserRole) Can(permissions ProjectUserPermission) bool {
-	return (rolePermissions[r] & permissions) == permissions
+	return rolePermissions[r].Can(permissions)
 }
 
 func (r ProjectUserRole) GetPermissions() ProjectUserPermission {
 	return rolePermissions[r]
 }
+
+// GetProjectRolePermissions returns permissions of the role in the project.
+// Custom roles of the project and global roles take precedence over built-in roles.
+func GetProjectRolePermissions(d Store, projectID int, role ProjectUserRole) (ProjectUserPermission, error) {
+	customRole, err := d.GetProjectOrGlobalRoleBySlug(projectID, string(role))
+	if errors.Is(err, ErrNotFound) {
+		return role.GetPermissions(), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return customRole.Permissions, nil
+}
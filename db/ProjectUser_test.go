@@ -10,3 +10,20 @@ func TestProjectUsers_RoleCan(t *testing.T) {
 	assert.True(t, ProjectManager.Can(CanManageProjectResources))
 	assert.False(t, ProjectManager.Can(CanUpdateProject))
 }
+
+func TestProjectUserPermission_IsValid(t *testing.T) {
+	assert.True(t, AllProjectPermissions.IsValid())
+	assert.True(t, (CanViewSecrets | CanViewTaskOutput).IsValid())
+	assert.False(t, (CanViewTaskOutput << 1).IsValid())
+	assert.False(t, ProjectUserPermission(-1).IsValid())
+}
+
+func TestProjectUserPermission_WithLegacyImplied(t *testing.T) {
+	runner := CanRunProjectTasks.WithLegacyImplied()
+	assert.True(t, runner.Can(CanApproveTasks|CanStopOthersTasks|CanViewTaskOutput))
+	assert.False(t, runner.Can(CanManageSchedules))
+
+	manager := (CanRunProjectTasks | CanManageProjectResources).WithLegacyImplied()
+	assert.True(t, manager.Can(CanManageSchedules|CanManageIntegrations|CanViewSecrets))
+	assert.False(t, manager.Can(CanManageProjectUsers))
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateContainerImage(t *testing.T) {
+	for _, image := range []string{
+		"",
+		"alpine",
+		"alpine/ansible:2.18",
+		"registry.example.com:5000/team/ansible:2.18",
+		"hashicorp/terraform@sha256:0123456789abcdef",
+	} {
+		assert.NoError(t, ValidateContainerImage(image), image)
+	}
+
+	for _, image := range []string{
+		"--privileged",
+		"-v=/:/host",
+		"alpine --privileged",
+		"alpine\tbash",
+		"alpine\n",
+	} {
+		assert.Error(t, ValidateContainerImage(image), image)
+	}
+}
+
+func TestTemplate_ValidateContainerImage(t *testing.T) {
+	tpl := Template{Name: "Test", Playbook: "test.sh", App: AppBash, ContainerImage: "--privileged"}
+	assert.Error(t, tpl.Validate())
+
+	tpl.ContainerImage = "alpine"
+	assert.NoError(t, tpl.Validate())
+}
@@ -11,6 +11,9 @@ func ValidateRole(role Role) error {
 	if role.Name == "" {
 		return &ValidationError{Message: "Role name cannot be empty"}
 	}
+	if !role.Permissions.IsValid() {
+		return &ValidationError{Message: "Role permissions contain unknown flags"}
+	}
 	return nil
 }
 
@@ -21,3 +24,13 @@ type TemplateRolePerm struct {
 	ProjectID   int                   `db:"project_id" json:"project_id"`
 	Permissions ProjectUserPermission `db:"permissions" json:"permissions"`
 }
+
+func (perm TemplateRolePerm) Validate() error {
+	if perm.RoleSlug == "" {
+		return &ValidationError{Message: "Role slug cannot be empty"}
+	}
+	if !perm.Permissions.IsValid() {
+		return &ValidationError{Message: "Template permissions contain unknown flags"}
+	}
+	return nil
+}
@@ -1,20 +1,50 @@
 package db
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
 
 type RunnerState string
 
+// RunnerHealth is the state of the runner detected by the heartbeat monitor.
+type RunnerHealth string
+
+const (
+	// RunnerHealthUnknown is the health of the runner which has not been checked yet.
+	RunnerHealthUnknown RunnerHealth = ""
+	RunnerHealthOnline  RunnerHealth = "online"
+	// RunnerHealthOffline is the health of the runner which has not contacted
+	// the server longer than the heartbeat timeout.
+	RunnerHealthOffline RunnerHealth = "offline"
+)
+
 type Runner struct {
-	ID                int        `db:"id" json:"id"`
-	Token             string     `db:"token" json:"-"`
-	ProjectID         *int       `db:"project_id" json:"project_id"`
-	Webhook           string     `db:"webhook" json:"webhook"`
-	MaxParallelTasks  int        `db:"max_parallel_tasks" json:"max_parallel_tasks"`
-	Active            bool       `db:"active" json:"active"`
-	Name              string     `db:"name" json:"name"`
-	Tag               string     `db:"tag" json:"tag"`
-	Touched           *time.Time `db:"touched" json:"touched"`
-	CleaningRequested *time.Time `db:"cleaning_requested" json:"cleaning_requested"`
+	ID                int          `db:"id" json:"id"`
+	Token             string       `db:"token" json:"-"`
+	ProjectID         *int         `db:"project_id" json:"project_id"`
+	Webhook           string       `db:"webhook" json:"webhook"`
+	MaxParallelTasks  int          `db:"max_parallel_tasks" json:"max_parallel_tasks"`
+	Active            bool         `db:"active" json:"active"`
+	Name              string       `db:"name" json:"name"`
+	Tag               string       `db:"tag" json:"tag"`
+	Touched           *time.Time   `db:"touched" json:"touched"`
+	CleaningRequested *time.Time   `db:"cleaning_requested" json:"cleaning_requested"`
+	Health            RunnerHealth `db:"health" json:"health"`
+
+	// Ephemeral runners are started by the server for a single task
+	// and deleted when the task is finished.
+	Ephemeral bool `db:"ephemeral" json:"ephemeral"`
+
+	// Capabilities are reported by the runner at registration and on start.
+	Capabilities RunnerCapabilities `db:"capabilities" json:"capabilities"`
+
+	// Labels are preset by the registration token of the runner.
+	// They override labels reported by the runner.
+	Labels RunnerLabels `db:"labels" json:"labels"`
 
 	PublicKey *string `db:"public_key" json:"-"`
 }
@@ -23,3 +53,139 @@ type RunnerTag struct {
 	Tag             string `db:"-" json:"tag"`
 	NumberOfRunners int    `db:"-" json:"number_of_runners"`
 }
+
+// RunnerHealthRecord is a record of the runner health history.
+// It is created every time the health of the runner changes.
+type RunnerHealthRecord struct {
+	ID       int          `db:"id" json:"id"`
+	RunnerID int          `db:"runner_id" json:"runner_id"`
+	Created  time.Time    `db:"created" json:"created"`
+	Health   RunnerHealth `db:"health" json:"health"`
+	Message  string       `db:"message" json:"message"`
+}
+
+// RunnerLabels are labels which runner selectors of templates are matched against.
+type RunnerLabels map[string]string
+
+func (l *RunnerLabels) Scan(value any) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, l)
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	default:
+		return errors.New("unsupported type for RunnerLabels")
+	}
+}
+
+// Value implements the driver.Valuer interface for RunnerLabels
+func (l RunnerLabels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(l)
+}
+
+// RunnerCapabilities describes the environment of the runner.
+type RunnerCapabilities struct {
+	OS   string `json:"os,omitempty"`
+	Arch string `json:"arch,omitempty"`
+	// Apps maps apps installed on the runner, like ansible or terraform,
+	// to their versions. It is nil if the runner has not reported apps.
+	Apps map[TemplateApp]string `json:"apps,omitempty"`
+	// Labels are free-form labels from the runner config.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func (c *RunnerCapabilities) Scan(value any) error {
+	if value == nil {
+		*c = RunnerCapabilities{}
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return errors.New("unsupported type for RunnerCapabilities")
+	}
+}
+
+// Value implements the driver.Valuer interface for RunnerCapabilities
+func (c RunnerCapabilities) Value() (driver.Value, error) {
+	if c.OS == "" && c.Arch == "" && c.Apps == nil && c.Labels == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// DetectableApps are apps which runners detect and report in their capabilities.
+var DetectableApps = []TemplateApp{
+	AppAnsible,
+	AppTerraform,
+	AppTofu,
+	AppTerragrunt,
+	AppPulumi,
+	AppPython,
+	AppPowerShell,
+	AppBash,
+}
+
+// HasApp returns true if the app is installed on the runner. The empty app
+// is ansible. Runners which have not reported apps are expected to have all
+// apps, and apps which runners can not detect, like custom apps from the
+// config, are expected to be installed on every runner.
+func (c RunnerCapabilities) HasApp(app TemplateApp) bool {
+	if app == "" {
+		app = AppAnsible
+	}
+	if c.Apps == nil || !slices.Contains(DetectableApps, app) {
+		return true
+	}
+	_, ok := c.Apps[app]
+	return ok
+}
+
+// SelectorLabels returns labels which runner selectors of templates are
+// matched against: the free-form labels, os, arch and app.<name> with
+// the version of every installed app.
+func (c RunnerCapabilities) SelectorLabels() map[string]string {
+	labels := make(map[string]string)
+
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+
+	if c.OS != "" {
+		labels["os"] = c.OS
+	}
+
+	if c.Arch != "" {
+		labels["arch"] = c.Arch
+	}
+
+	for app, version := range c.Apps {
+		labels["app."+string(app)] = version
+	}
+
+	return labels
+}
+
+// SelectorLabels returns labels of the capabilities of the runner
+// with the preset labels of the runner.
+func (r Runner) SelectorLabels() map[string]string {
+	labels := r.Capabilities.SelectorLabels()
+
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+
+	return labels
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"time"
+)
+
+// RunnerRegistrationToken allows runners to register on the server.
+// Runners registered with the project token belong to the project.
+type RunnerRegistrationToken struct {
+	ID        int    `db:"id" json:"id"`
+	ProjectID *int   `db:"project_id" json:"project_id"`
+	Name      string `db:"name" json:"name"`
+	Token     string `db:"token" json:"-"`
+
+	Created time.Time `db:"created" json:"created"`
+	// Expires is the time after which the token can not be used.
+	// The token does not expire if it is nil.
+	Expires *time.Time `db:"expires" json:"expires"`
+
+	// MaxUses is the number of runners which can be registered
+	// with the token. 0 means no limit.
+	MaxUses int `db:"max_uses" json:"max_uses"`
+	Uses    int `db:"uses" json:"uses"`
+
+	// Tag and Labels are assigned to runners registered with the token.
+	Tag    string       `db:"tag" json:"tag"`
+	Labels RunnerLabels `db:"labels" json:"labels"`
+
+	Revoked bool `db:"revoked" json:"revoked"`
+}
+
+func (t *RunnerRegistrationToken) Validate() error {
+	if t.Name == "" {
+		return &ValidationError{"name can not be empty"}
+	}
+
+	if t.MaxUses < 0 {
+		return &ValidationError{"max uses can not be negative"}
+	}
+
+	for k := range t.Labels {
+		if k == "" {
+			return &ValidationError{"label name can not be empty"}
+		}
+	}
+
+	return nil
+}
+
+// IsUsable returns true if a runner can be registered with the token.
+func (t *RunnerRegistrationToken) IsUsable(now time.Time) bool {
+	if t.Revoked {
+		return false
+	}
+
+	if t.Expires != nil && !now.Before(*t.Expires) {
+		return false
+	}
+
+	return t.MaxUses == 0 || t.Uses < t.MaxUses
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"strings"
+)
+
+type selectorOperator string
+
+const (
+	selectorEquals    selectorOperator = "="
+	selectorNotEquals selectorOperator = "!="
+	selectorExists    selectorOperator = "exists"
+	selectorNotExists selectorOperator = "!exists"
+)
+
+type selectorRequirement struct {
+	key      string
+	operator selectorOperator
+	value    string
+}
+
+// RunnerSelector is a list of requirements to labels of the runner
+// which must all be met.
+type RunnerSelector []selectorRequirement
+
+// ParseRunnerSelector parses the comma-separated list of requirements:
+// "key=value" (or "key==value"), "key!=value", "key" which requires the label
+// to exist and "!key" which requires the label to be absent.
+// For example, "os=linux,app.terraform,gpu!=none".
+func ParseRunnerSelector(selector string) (res RunnerSelector, err error) {
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+
+		if part == "" {
+			continue
+		}
+
+		var req selectorRequirement
+
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			req = selectorRequirement{key: kv[0], operator: selectorNotEquals, value: kv[1]}
+		case strings.Contains(part, "=="):
+			kv := strings.SplitN(part, "==", 2)
+			req = selectorRequirement{key: kv[0], operator: selectorEquals, value: kv[1]}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			req = selectorRequirement{key: kv[0], operator: selectorEquals, value: kv[1]}
+		case strings.HasPrefix(part, "!"):
+			req = selectorRequirement{key: part[1:], operator: selectorNotExists}
+		default:
+			req = selectorRequirement{key: part, operator: selectorExists}
+		}
+
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+
+		if req.key == "" || strings.ContainsAny(req.key, "!= ") {
+			err = &ValidationError{"invalid runner selector requirement: " + part}
+			return
+		}
+
+		res = append(res, req)
+	}
+
+	return
+}
+
+// Matches returns true if the labels meet all requirements of the selector.
+func (s RunnerSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+
+		switch req.operator {
+		case selectorEquals:
+			if !ok || value != req.value {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && value == req.value {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunnerSelector(t *testing.T) {
+	labels := RunnerCapabilities{
+		OS:     "linux",
+		Arch:   "amd64",
+		Apps:   map[TemplateApp]string{AppTerraform: "1.5.7"},
+		Labels: map[string]string{"zone": "eu", "gpu": ""},
+	}.SelectorLabels()
+
+	cases := map[string]bool{
+		"":                                true,
+		"os=linux":                        true,
+		"os == linux , arch=amd64":        true,
+		"os=windows":                      false,
+		"app.terraform":                   true,
+		"app.terraform=1.5.7,zone!=us":    true,
+		"app.tofu":                        false,
+		"!app.tofu,gpu":                   true,
+		"zone!=eu":                        false,
+		"!zone":                           false,
+		"app.terraform=1.5.7,app.ansible": false,
+	}
+
+	for selector, expected := range cases {
+		s, err := ParseRunnerSelector(selector)
+		require.NoError(t, err, selector)
+		assert.Equal(t, expected, s.Matches(labels), selector)
+	}
+
+	for _, selector := range []string{"=linux", "!", "os linux", "!=x"} {
+		_, err := ParseRunnerSelector(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestRunnerCapabilities_HasApp(t *testing.T) {
+	assert.True(t, RunnerCapabilities{}.HasApp(AppAnsible))
+	assert.False(t, RunnerCapabilities{Apps: map[TemplateApp]string{}}.HasApp(AppAnsible))
+	assert.True(t, RunnerCapabilities{Apps: map[TemplateApp]string{AppAnsible: ""}}.HasApp(AppAnsible))
+
+	// The empty app is ansible.
+	assert.True(t, RunnerCapabilities{Apps: map[TemplateApp]string{AppAnsible: ""}}.HasApp(""))
+	assert.False(t, RunnerCapabilities{Apps: map[TemplateApp]string{AppBash: ""}}.HasApp(""))
+
+	// Custom apps can not be detected by runners.
+	assert.True(t, RunnerCapabilities{Apps: map[TemplateApp]string{AppBash: ""}}.HasApp("ruby"))
+}
@@ -22,6 +22,20 @@ type Session struct {
 
 	VerificationMethod SessionVerificationMethod `db:"verification_method" json:"verification_method"`
 	Verified           bool                      `db:"verified" json:"verified"`
+
+	// OidcProvider is ID of the OIDC provider used for login.
+	// It is empty for sessions created by other login methods.
+	OidcProvider string `db:"oidc_provider" json:"-"`
+	// OidcSubject and OidcSessionID are sub and sid claims of the ID token,
+	// they identify sessions terminated by back-channel logout.
+	OidcSubject   string `db:"oidc_subject" json:"-"`
+	OidcSessionID string `db:"oidc_session_id" json:"-"`
+	// OidcIDToken is passed to the provider as a hint on logout.
+	OidcIDToken *string `db:"oidc_id_token" json:"-"`
+	// OidcRefreshToken is stored encrypted by the cookie keys.
+	OidcRefreshToken *string `db:"oidc_refresh_token" json:"-"`
+	// OidcTokenExpiry is the time after which the session must be refreshed.
+	OidcTokenExpiry *time.Time `db:"oidc_token_expiry" json:"-"`
 }
 
 func (s *Session) IsVerified() bool {
@@ -231,9 +231,14 @@ type UserManager interface {
 	GetUsers(params RetrieveQueryParams) ([]User, error)
 	CreateUserWithoutPassword(user User) (User, error)
 	CreateUser(user UserWithPwd) (User, error)
+	// ImportUser creates the user keeping its password hash and creation time.
+	// It is used to restore users from instance backups.
+	ImportUser(user User) (User, error)
 	DeleteUser(userID int) error
 	UpdateUser(user UserWithPwd) error
 	SetUserPassword(userID int, password string) error
+	// SetUserLdap marks the external user as the user which came from LDAP.
+	SetUserLdap(userID int) error
 	AddTotpVerification(userID int, url string, recoveryHash string) (UserTotp, error)
 	DeleteTotpVerification(userID int, totpID int) error
 	AddEmailOtpVerification(userID int, code string) (UserEmailOtp, error)
@@ -366,6 +371,12 @@ type IntegrationManager interface {
 	GetIntegrationAliases(projectID int, integrationID *int) ([]IntegrationAlias, error)
 	GetIntegrationsByAlias(alias string) ([]Integration, IntegrationAliasLevel, error)
 	DeleteIntegrationAlias(projectID int, aliasID int) error
+
+	CreateIntegrationDelivery(delivery IntegrationDelivery) (IntegrationDelivery, error)
+	UpdateIntegrationDelivery(delivery IntegrationDelivery) error
+	GetIntegrationDeliveries(projectID int, integrationID int, params RetrieveQueryParams) ([]IntegrationDelivery, error)
+	// DeleteIntegrationDeliveries deletes deliveries of all integrations created before the time.
+	DeleteIntegrationDeliveries(before time.Time) error
 }
 
 // SessionManager handles session-related operations
@@ -376,6 +387,10 @@ type SessionManager interface {
 	TouchSession(userID int, sessionID int) error
 	SetSessionVerificationMethod(userID int, sessionID int, verificationMethod SessionVerificationMethod) error
 	VerifySession(userID int, sessionID int) error
+	UpdateSessionOidcToken(userID int, sessionID int, refreshToken string, expiry *time.Time) error
+	// ExpireOidcSessions expires sessions created by the OIDC provider which
+	// match the sid claim or, if sid is empty, the sub claim.
+	ExpireOidcSessions(provider string, subject string, sid string) error
 }
 
 // TokenManager handles token-related operations
@@ -385,6 +400,7 @@ type TokenManager interface {
 	GetAPIToken(tokenID string) (APIToken, error)
 	ExpireAPIToken(userID int, tokenID string) error
 	DeleteAPIToken(userID int, tokenID string) error
+	TouchAPIToken(userID int, tokenID string, ip string) error
 }
 
 // TaskManager handles task-related operations
@@ -405,6 +421,15 @@ type TaskManager interface {
 	GetTaskStageResult(projectID int, taskID int, stageID int) (TaskStageResult, error)
 	GetTaskStageOutputs(projectID int, taskID int, stageID int) ([]TaskOutput, error)
 	GetTaskStats(projectID int, templateID *int, unit TaskStatUnit, filter TaskFilter) ([]TaskStat, error)
+	CreateTaskApproval(approval TaskApproval) (TaskApproval, error)
+	GetTaskApprovals(projectID int, taskID int) ([]TaskApproval, error)
+	// GetDeployments returns the last successful task of every pair of
+	// the deploy template and the inventory.
+	GetDeployments(projectID int) ([]TaskWithTpl, error)
+	// GetDeploymentTasks returns tasks of the deploy template which have run on
+	// the inventory. The inventory of the template is used for tasks which have
+	// not overridden it. nil inventoryID matches tasks without the inventory.
+	GetDeploymentTasks(projectID int, templateID int, inventoryID *int, params RetrieveQueryParams) ([]TaskWithTpl, error)
 }
 
 type AnsibleTaskRepository interface {
@@ -449,9 +474,49 @@ type RunnerManager interface {
 	UpdateRunner(runner Runner) error
 	CreateRunner(runner Runner) (Runner, error)
 	TouchRunner(runner Runner) (err error)
+	UpdateRunnerCapabilities(runner Runner, capabilities RunnerCapabilities) error
 	ClearRunnerCache(runner Runner) (err error)
 	GetRunnerTags(projectID int) ([]RunnerTag, error)
 	GetRunnerCount() (int, error)
+	// SetRunnerHealth updates the health of the runner and records
+	// the change to the runner health history.
+	SetRunnerHealth(runner Runner, health RunnerHealth, message string) (RunnerHealthRecord, error)
+	GetRunnerHealthHistory(runnerID int, params RetrieveQueryParams) ([]RunnerHealthRecord, error)
+	// DeleteRunnerHealthHistory deletes health records of all runners created before the time.
+	DeleteRunnerHealthHistory(before time.Time) error
+	// RotateRunnerToken replaces the token of the runner with a new one.
+	RotateRunnerToken(runner Runner) (Runner, error)
+}
+
+// RunnerRegistrationTokenManager handles runner registration tokens
+type RunnerRegistrationTokenManager interface {
+	GetRunnerRegistrationTokens() ([]RunnerRegistrationToken, error)
+	GetRunnerRegistrationToken(tokenID int) (RunnerRegistrationToken, error)
+	CreateRunnerRegistrationToken(token RunnerRegistrationToken) (RunnerRegistrationToken, error)
+	RevokeRunnerRegistrationToken(tokenID int) error
+	DeleteRunnerRegistrationToken(tokenID int) error
+	// UseRunnerRegistrationToken counts the use of the token. It returns
+	// ErrNotFound if the token does not exist or can not be used anymore.
+	UseRunnerRegistrationToken(token string) (RunnerRegistrationToken, error)
+}
+
+// EnvironmentVersionManager tracks versions deployed to environments
+type EnvironmentVersionManager interface {
+	GetEnvironmentVersions(projectID int, buildTemplateID int) ([]EnvironmentVersion, error)
+	// SetEnvironmentVersion replaces the version of the build template
+	// deployed to the environment.
+	SetEnvironmentVersion(version EnvironmentVersion) error
+}
+
+// TaskArtifactManager handles files uploaded by tasks
+type TaskArtifactManager interface {
+	CreateTaskArtifact(artifact TaskArtifact) (TaskArtifact, error)
+	GetTaskArtifacts(projectID int, taskID int) ([]TaskArtifact, error)
+	GetTaskArtifact(projectID int, taskID int, artifactID int) (TaskArtifact, error)
+	DeleteTaskArtifact(artifactID int) error
+	// GetStaleTaskArtifacts returns artifacts of deleted tasks and artifacts
+	// created before createdBefore if it is not nil.
+	GetStaleTaskArtifacts(createdBefore *time.Time) ([]TaskArtifact, error)
 }
 
 // EventManager handles event-related operations
@@ -481,6 +546,31 @@ type RoleRepository interface {
 	DeleteRole(slug string) error
 }
 
+// TransferManager gives access to objects of the database regardless of
+// the dialect. It is used to move data between databases.
+type TransferManager interface {
+	// ExportObjects calls fn for each object of the entity in a stable order.
+	// bucketID is the ID of the parent object if object IDs are unique only
+	// within the parent, like in BoltDB. It is zero otherwise.
+	ExportObjects(props ObjectProps, fn func(bucketID int, object any) error) error
+	CountObjects(props ObjectProps) (int, error)
+	// ImportObjects inserts objects in a single transaction keeping their
+	// primary keys. Objects with zero ID get new IDs.
+	ImportObjects(props ObjectProps, objects []any) error
+	// UpdateObjectColumns sets columns of the object with the given primary key.
+	UpdateObjectColumns(props ObjectProps, objectID any, columns map[string]any) error
+}
+
+type ProjectGitopsManager interface {
+	GetProjectGitops(projectID int) (ProjectGitops, error)
+	GetAllProjectGitops() ([]ProjectGitops, error)
+	// SetProjectGitops creates or updates GitOps settings of the project.
+	// Sync status of the existing settings is kept.
+	SetProjectGitops(gitops ProjectGitops) (ProjectGitops, error)
+	UpdateProjectGitopsStatus(gitops ProjectGitops) error
+	DeleteProjectGitops(projectID int) error
+}
+
 // Store is the main interface that aggregates all specialized interfaces
 type Store interface {
 	ConnectionManager
@@ -501,9 +591,20 @@ type Store interface {
 	ScheduleManager
 	ViewManager
 	RunnerManager
+	RunnerRegistrationTokenManager
+	TaskArtifactManager
+	EnvironmentVersionManager
 	EventManager
 	SecretStorageRepository
 	RoleRepository
+	ProjectGitopsManager
+	TransferManager
+}
+
+// TransactionalStore is implemented by stores which can apply several changes atomically.
+type TransactionalStore interface {
+	// Transaction runs fn with the store whose changes are applied only if fn succeeds.
+	Transaction(fn func(store Store) error) error
 }
 
 var AccessKeyProps = ObjectProps{
@@ -553,6 +654,14 @@ var IntegrationAliasProps = ObjectProps{
 	PrimaryColumnName: "id",
 }
 
+var IntegrationDeliveryProps = ObjectProps{
+	TableName:            "project__integration_delivery",
+	Type:                 reflect.TypeOf(IntegrationDelivery{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+	SortInverted:         true,
+}
+
 var EnvironmentProps = ObjectProps{
 	TableName:             "project__environment",
 	Type:                  reflect.TypeOf(Environment{}),
@@ -613,6 +722,13 @@ var ProjectProps = ObjectProps{
 	IsGlobal:              true,
 }
 
+var ProjectGitopsProps = ObjectProps{
+	TableName:         "project__gitops",
+	Type:              reflect.TypeOf(ProjectGitops{}),
+	PrimaryColumnName: "project_id",
+	IsGlobal:          true,
+}
+
 var ScheduleProps = ObjectProps{
 	TableName:         "project__schedule",
 	Type:              reflect.TypeOf(Schedule{}),
@@ -669,6 +785,21 @@ var TaskOutputProps = ObjectProps{
 	Type:      reflect.TypeOf(TaskOutput{}),
 }
 
+var TaskArtifactProps = ObjectProps{
+	TableName:            "task__artifact",
+	Type:                 reflect.TypeOf(TaskArtifact{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "name",
+	IsGlobal:             true,
+}
+
+var EnvironmentVersionProps = ObjectProps{
+	TableName:            "project__environment_version",
+	Type:                 reflect.TypeOf(EnvironmentVersion{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "environment_id",
+}
+
 var TaskStageProps = ObjectProps{
 	TableName: "task__stage",
 	Type:      reflect.TypeOf(TaskStage{}),
@@ -679,6 +810,13 @@ var TaskStageResultProps = ObjectProps{
 	Type:      reflect.TypeOf(TaskStageResult{}),
 }
 
+var TaskApprovalProps = ObjectProps{
+	TableName:            "task__approval",
+	Type:                 reflect.TypeOf(TaskApproval{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+}
+
 var ViewProps = ObjectProps{
 	TableName:            "project__view",
 	Type:                 reflect.TypeOf(View{}),
@@ -695,6 +833,24 @@ var GlobalRunnerProps = ObjectProps{
 	IsGlobal:             true,
 }
 
+var RunnerHealthProps = ObjectProps{
+	TableName:            "runner__health",
+	Type:                 reflect.TypeOf(RunnerHealthRecord{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+	SortInverted:         true,
+	IsGlobal:             true,
+}
+
+var RunnerRegistrationTokenProps = ObjectProps{
+	TableName:            "runner__registration_token",
+	Type:                 reflect.TypeOf(RunnerRegistrationToken{}),
+	PrimaryColumnName:    "id",
+	DefaultSortingColumn: "id",
+	SortInverted:         true,
+	IsGlobal:             true,
+}
+
 var OptionProps = ObjectProps{
 	TableName:         "option",
 	Type:              reflect.TypeOf(Option{}),
@@ -715,6 +871,38 @@ var UserTotpProps = ObjectProps{
 	PrimaryColumnName: "id",
 }
 
+var UserEmailOtpProps = ObjectProps{
+	TableName:         "user__email_otp",
+	Type:              reflect.TypeOf(UserEmailOtp{}),
+	PrimaryColumnName: "id",
+}
+
+var TemplateRolePermProps = ObjectProps{
+	TableName:         "project__template_role",
+	Type:              reflect.TypeOf(TemplateRolePerm{}),
+	PrimaryColumnName: "id",
+}
+
+var EventProps = ObjectProps{
+	TableName:         "event",
+	Type:              reflect.TypeOf(Event{}),
+	PrimaryColumnName: "id",
+	IsGlobal:          true,
+	SortInverted:      true,
+}
+
+var AnsibleTaskHostProps = ObjectProps{
+	TableName:         "task__ansible_host",
+	Type:              reflect.TypeOf(AnsibleTaskHost{}),
+	PrimaryColumnName: "id",
+}
+
+var AnsibleTaskErrorProps = ObjectProps{
+	TableName:         "task__ansible_error",
+	Type:              reflect.TypeOf(AnsibleTaskError{}),
+	PrimaryColumnName: "id",
+}
+
 func (p ObjectProps) GetReferringFieldsFrom(t reflect.Type) (fields []string, err error) {
 	if p.ReferringColumnSuffix == "" {
 		err = errors.New("referring column suffix is not set")
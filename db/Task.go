@@ -45,11 +45,14 @@ type Task struct {
 	Status task_logger.TaskStatus `db:"status" json:"status"`
 
 	// override variables
-	Playbook    string  `db:"playbook" json:"playbook"`
-	Environment string  `db:"environment" json:"environment,omitempty"`
-	Secret      string  `db:"-" json:"secret,omitempty"`
-	Arguments   *string `db:"arguments" json:"arguments,omitempty"`
-	GitBranch   *string `db:"git_branch" json:"git_branch,omitempty"`
+	Playbook    string `db:"playbook" json:"playbook"`
+	Environment string `db:"environment" json:"environment,omitempty"`
+	Secret      string `db:"-" json:"secret,omitempty"`
+	// HasSecret is true if the task was created with secret variables.
+	// Secrets are not stored, so the task can not be run after a restart.
+	HasSecret bool    `db:"has_secret" json:"-"`
+	Arguments *string `db:"arguments" json:"arguments,omitempty"`
+	GitBranch *string `db:"git_branch" json:"git_branch,omitempty"`
 
 	UserID        *int `db:"user_id" json:"user_id,omitempty"`
 	IntegrationID *int `db:"integration_id" json:"integration_id,omitempty"`
@@ -126,7 +129,10 @@ func (task *Task) PreUpdate(gorp.SqlExecutor) error {
 	return nil
 }
 
-func (task *Task) GetIncomingVersion(d Store) *string {
+// GetIncomingBuildTask returns the task of the build template which is
+// deployed by the task. Deploy tasks can refer to other deploy tasks,
+// the chain is followed until the task of the build template.
+func (task *Task) GetIncomingBuildTask(d Store) *Task {
 	if task.BuildTaskID == nil {
 		return nil
 	}
@@ -143,10 +149,20 @@ func (task *Task) GetIncomingVersion(d Store) *string {
 	}
 
 	if tpl.Type == TemplateBuild {
-		return buildTask.Version
+		return &buildTask
+	}
+
+	return buildTask.GetIncomingBuildTask(d)
+}
+
+func (task *Task) GetIncomingVersion(d Store) *string {
+	buildTask := task.GetIncomingBuildTask(d)
+
+	if buildTask == nil {
+		return nil
 	}
 
-	return buildTask.GetIncomingVersion(d)
+	return buildTask.Version
 }
 
 func (task *Task) GetUrl() *string {
@@ -236,6 +252,18 @@ type TaskStageWithResult struct {
 	Result        any           `db:"-" json:"result"`
 }
 
+// TaskApproval is a decision of a user about a task which requires approval
+// before it can be started.
+type TaskApproval struct {
+	ID        int       `db:"id" json:"id"`
+	TaskID    int       `db:"task_id" json:"task_id"`
+	ProjectID int       `db:"project_id" json:"project_id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	Approved  bool      `db:"approved" json:"approved"`
+	Comment   string    `db:"comment" json:"comment"`
+	Created   time.Time `db:"created" json:"created"`
+}
+
 type TaskStageResult struct {
 	ID      int    `db:"id" json:"id"`
 	TaskID  int    `db:"task_id" json:"task_id"`
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"time"
+)
+
+// TaskArtifact is a file produced by the task and uploaded to the artifact storage.
+type TaskArtifact struct {
+	ID        int `db:"id" json:"id"`
+	ProjectID int `db:"project_id" json:"project_id"`
+	TaskID    int `db:"task_id" json:"task_id"`
+	// Name is the path of the file relative to the repository directory.
+	Name string `db:"name" json:"name"`
+	Size int64  `db:"size" json:"size"`
+	// Checksum is the hex encoded SHA-256 of the file.
+	Checksum string    `db:"checksum" json:"checksum"`
+	Created  time.Time `db:"created" json:"created"`
+
+	// StorageKey identifies the file in the artifact storage.
+	StorageKey string `db:"storage_key" json:"-"`
+}
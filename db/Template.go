@@ -2,6 +2,9 @@ package db
 
 import (
 	"encoding/json"
+	"path"
+	"slices"
+	"strings"
 )
 
 type TemplateType string
@@ -12,6 +15,17 @@ const (
 	TemplateDeploy TemplateType = "deploy"
 )
 
+// TemplateRunnerFailurePolicy defines what happens to the task when
+// its runner goes offline.
+type TemplateRunnerFailurePolicy string
+
+const (
+	// RunnerFailureFail fails the task.
+	RunnerFailureFail TemplateRunnerFailurePolicy = ""
+	// RunnerFailureRequeue starts the task again on another runner.
+	RunnerFailureRequeue TemplateRunnerFailurePolicy = "requeue"
+)
+
 type TemplateApp string
 
 const (
@@ -158,6 +172,47 @@ type Template struct {
 
 	AllowOverrideBranchInTask bool `db:"allow_override_branch_in_task" json:"allow_override_branch_in_task,omitempty"`
 	AllowParallelTasks        bool `db:"allow_parallel_tasks" json:"allow_parallel_tasks,omitempty"`
+
+	// RequiredApprovals is the number of distinct users, other than the requester,
+	// who must approve a task before it starts. Zero disables approval.
+	RequiredApprovals int `db:"required_approvals" json:"required_approvals,omitempty"`
+	// ApprovalTimeout is the number of minutes after which a task which is still
+	// waiting for approval is rejected automatically. Zero means no timeout.
+	ApprovalTimeout int `db:"approval_timeout" json:"approval_timeout,omitempty"`
+
+	RunnerFailurePolicy TemplateRunnerFailurePolicy `db:"runner_failure_policy" json:"runner_failure_policy,omitempty"`
+
+	// RunnerSelector restricts runners of the template by their labels,
+	// see ParseRunnerSelector for the format.
+	RunnerSelector string `db:"runner_selector" json:"runner_selector,omitempty"`
+	// RunnerAffinity prefers the runner which has run the repository
+	// of the template last time and has it cached.
+	RunnerAffinity bool `db:"runner_affinity" json:"runner_affinity,omitempty"`
+
+	// Artifacts are glob patterns of files which are uploaded to the server
+	// after the task is finished, separated by commas or new lines. Patterns
+	// are relative to the repository directory, "**" matches any directories.
+	Artifacts string `db:"artifacts" json:"artifacts,omitempty"`
+
+	// ContainerImage is the OCI image which local tasks of the template run in.
+	// The container image of the project is used if it is empty.
+	ContainerImage string `db:"container_image" json:"container_image,omitempty"`
+}
+
+// ArtifactPatterns returns glob patterns of artifacts of the template.
+func (tpl *Template) ArtifactPatterns() []string {
+	var patterns []string
+
+	for _, p := range strings.FieldsFunc(tpl.Artifacts, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns
 }
 
 type TemplateWithPerms struct {
@@ -207,12 +262,38 @@ func (tpl *Template) Validate() error {
 		return &ValidationError{"template playbook can not be empty"}
 	}
 
+	if tpl.RequiredApprovals < 0 || tpl.ApprovalTimeout < 0 {
+		return &ValidationError{"template approval settings can not be negative"}
+	}
+
+	switch tpl.RunnerFailurePolicy {
+	case RunnerFailureFail, RunnerFailureRequeue:
+	default:
+		return &ValidationError{"template runner failure policy is invalid"}
+	}
+
+	if _, err := ParseRunnerSelector(tpl.RunnerSelector); err != nil {
+		return err
+	}
+
+	for _, pattern := range tpl.ArtifactPatterns() {
+		if _, err := path.Match(pattern, ""); err != nil ||
+			path.IsAbs(pattern) ||
+			slices.Contains(strings.Split(pattern, "/"), "..") {
+			return &ValidationError{"template artifact pattern " + pattern + " is invalid"}
+		}
+	}
+
 	if tpl.Arguments != nil {
 		if !json.Valid([]byte(*tpl.Arguments)) {
 			return &ValidationError{"template arguments must be valid JSON"}
 		}
 	}
 
+	if err := ValidateContainerImage(tpl.ContainerImage); err != nil {
+		return err
+	}
+
 	return nil
 }
 
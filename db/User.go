@@ -15,6 +15,7 @@ type User struct {
 	Password string    `db:"password" json:"-"` // password hash
 	Admin    bool      `db:"admin" json:"admin"`
 	External bool      `db:"external" json:"external"`
+	Ldap     bool      `db:"ldap" json:"ldap"` // external user which came from LDAP, not from OpenID or email login
 	Alert    bool      `db:"alert" json:"alert"`
 	Pro      bool      `db:"pro" json:"pro"`
 
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -36,7 +38,7 @@ func TestMarshalObject_UserWithPwd(t *testing.T) {
 	require.NoError(t, err)
 
 	str := string(bytes)
-	expected := `{"id":0,"created":"0001-01-01T00:00:00Z","username":"fiftin","name":"","email":"","password":"345345234523452345234","admin":false,"external":false,"alert":false,"pro":false}`
+	expected := `{"id":0,"created":"0001-01-01T00:00:00Z","username":"fiftin","name":"","email":"","password":"345345234523452345234","admin":false,"external":false,"ldap":false,"alert":false,"pro":false}`
 	assert.Equal(t, expected, str)
 
 	fmt.Println(str)
@@ -211,6 +213,43 @@ func TestBoltDb_CreateAPIToken(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBoltDb_CreateScopedAPIToken(t *testing.T) {
+	store := CreateTestStore()
+
+	projectID := 3
+	expiresAt := tz.Now().Add(time.Hour)
+
+	token, err := store.CreateAPIToken(db.APIToken{
+		ID:        "a349gyhgqirgysfgsfg34973dsfad",
+		UserID:    1,
+		Name:      "CI",
+		ExpiresAt: &expiresAt,
+		ProjectID: &projectID,
+		Scopes:    db.APITokenScopes{db.APITokenScopeRunTasks},
+	})
+	require.NoError(t, err)
+
+	err = store.TouchAPIToken(1, token.ID, "10.0.0.1")
+	require.NoError(t, err)
+
+	token2, err := store.GetAPIToken(token.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "CI", token2.Name)
+	assert.Equal(t, projectID, *token2.ProjectID)
+	assert.True(t, token2.HasScope(db.APITokenScopeRunTasks))
+	assert.Equal(t, "10.0.0.1", token2.LastUsedIP)
+	assert.NotNil(t, token2.LastUsed)
+	assert.True(t, token2.IsActive(tz.Now()))
+	assert.False(t, token2.IsActive(expiresAt.Add(time.Second)))
+
+	_, err = store.CreateAPIToken(db.APIToken{
+		ID:     "b349gyhgqirgysfgsfg34973dsfad",
+		UserID: 1,
+		Scopes: db.APITokenScopes{"admin"},
+	})
+	assert.Error(t, err)
+}
+
 func TestBoltDb_GetRepositoryRefs(t *testing.T) {
 	store := CreateTestStore()
 
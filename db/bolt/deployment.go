@@ -0,0 +1,94 @@
+package bolt
+
+import (
+	"slices"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// deploymentKey identifies the deploy template and the inventory.
+type deploymentKey struct {
+	templateID  int
+	inventoryID int
+}
+
+// getDeploymentInventory returns the inventory which the task has run on. Tasks
+// record it when they are created, older tasks fall back to the inventory
+// of the template.
+func (d *BoltDb) getDeploymentInventory(task db.Task, templates map[int]db.Template) *int {
+	if task.InventoryID != nil {
+		return task.InventoryID
+	}
+
+	tpl, ok := templates[task.TemplateID]
+	if !ok {
+		tpl, _ = d.getRawTemplate(task.ProjectID, task.TemplateID)
+		templates[task.TemplateID] = tpl
+	}
+
+	return tpl.InventoryID
+}
+
+func (d *BoltDb) GetDeployments(projectID int) (deployments []db.TaskWithTpl, err error) {
+	deployments = make([]db.TaskWithTpl, 0)
+
+	tasks, err := d.getTasks(projectID, nil, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	templates := make(map[int]db.Template)
+	found := make(map[deploymentKey]bool)
+
+	// Tasks are sorted from the newest to the oldest.
+	for _, task := range tasks {
+		if task.TemplateType != db.TemplateDeploy || task.Status != task_logger.TaskSuccessStatus {
+			continue
+		}
+
+		key := deploymentKey{templateID: task.TemplateID}
+		if inventoryID := d.getDeploymentInventory(task.Task, templates); inventoryID != nil {
+			key.inventoryID = *inventoryID
+		}
+
+		if found[key] {
+			continue
+		}
+
+		found[key] = true
+		deployments = append(deployments, task)
+	}
+
+	return
+}
+
+func (d *BoltDb) GetDeploymentTasks(projectID int, templateID int, inventoryID *int, params db.RetrieveQueryParams) (res []db.TaskWithTpl, err error) {
+	res = make([]db.TaskWithTpl, 0)
+
+	tasks, err := d.getTasks(projectID, &templateID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	templates := make(map[int]db.Template)
+
+	for _, task := range tasks {
+		if params.Count > 0 && len(res) >= params.Count {
+			break
+		}
+
+		if params.TaskFilter != nil && len(params.TaskFilter.Status) > 0 && !slices.Contains(params.TaskFilter.Status, task.Status) {
+			continue
+		}
+
+		taskInventoryID := d.getDeploymentInventory(task.Task, templates)
+
+		if inventoryID == nil && taskInventoryID == nil ||
+			inventoryID != nil && taskInventoryID != nil && *inventoryID == *taskInventoryID {
+			res = append(res, task)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,68 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+func TestGetDeployments(t *testing.T) {
+	store := CreateTestStore()
+
+	staging := 1
+	production := 2
+
+	deploy, err := store.CreateTemplate(db.Template{
+		ProjectID:   1,
+		Type:        db.TemplateDeploy,
+		Name:        "Deploy",
+		Playbook:    "deploy.yml",
+		InventoryID: &staging,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tasks []db.Task
+
+	for _, inventoryID := range []*int{nil, nil, &production} {
+		task, err := store.CreateTask(db.Task{
+			ProjectID:   1,
+			TemplateID:  deploy.ID,
+			InventoryID: inventoryID,
+			Status:      task_logger.TaskSuccessStatus,
+		}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	deployments, err := store.GetDeployments(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deployments) != 2 || deployments[0].ID != tasks[2].ID || deployments[1].ID != tasks[1].ID {
+		t.Fatal("the last task of every inventory must be returned", deployments)
+	}
+
+	history, err := store.GetDeploymentTasks(1, deploy.ID, &staging, db.RetrieveQueryParams{Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 1 || history[0].ID != tasks[1].ID {
+		t.Fatal("tasks of the inventory must be returned", history)
+	}
+
+	history, err = store.GetDeploymentTasks(1, deploy.ID, nil, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 0 {
+		t.Fatal("tasks without the inventory must be returned", history)
+	}
+}
@@ -0,0 +1,37 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *BoltDb) GetEnvironmentVersions(projectID int, buildTemplateID int) (versions []db.EnvironmentVersion, err error) {
+	versions = make([]db.EnvironmentVersion, 0)
+
+	err = d.getObjects(projectID, db.EnvironmentVersionProps, db.RetrieveQueryParams{}, func(i any) bool {
+		return i.(db.EnvironmentVersion).BuildTemplateID == buildTemplateID
+	}, &versions)
+
+	return
+}
+
+func (d *BoltDb) SetEnvironmentVersion(version db.EnvironmentVersion) error {
+	var current []db.EnvironmentVersion
+
+	err := d.getObjects(version.ProjectID, db.EnvironmentVersionProps, db.RetrieveQueryParams{}, func(i any) bool {
+		v := i.(db.EnvironmentVersion)
+		return v.EnvironmentID == version.EnvironmentID && v.BuildTemplateID == version.BuildTemplateID
+	}, &current)
+
+	if err != nil {
+		return err
+	}
+
+	if len(current) == 0 {
+		_, err = d.createObject(version.ProjectID, db.EnvironmentVersionProps, version)
+		return err
+	}
+
+	version.ID = current[0].ID
+
+	return d.updateObject(version.ProjectID, db.EnvironmentVersionProps, version)
+}
@@ -0,0 +1,44 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestSetEnvironmentVersion(t *testing.T) {
+	store := CreateTestStore()
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		err := store.SetEnvironmentVersion(db.EnvironmentVersion{
+			ProjectID:       1,
+			EnvironmentID:   2,
+			BuildTemplateID: 3,
+			TemplateID:      4,
+			Version:         &version,
+			Deployed:        time.Now(),
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	versions, err := store.GetEnvironmentVersions(1, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(versions) != 1 || *versions[0].Version != "1.1.0" {
+		t.Fatal("the version must be replaced", versions)
+	}
+
+	versions, err = store.GetEnvironmentVersions(1, 4)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(versions) != 0 {
+		t.Fatal("versions of other build templates must not be returned", versions)
+	}
+}
@@ -74,6 +74,10 @@ func (d *BoltDb) DeleteGlobalRunner(runnerID int) error {
 			return db.ErrNotFound
 		}
 
+		if err = d.deleteRunnerHealthTx(tx, runnerID); err != nil {
+			return err
+		}
+
 		return d.deleteObject(0, db.GlobalRunnerProps, intObjectID(runnerID), tx)
 	})
 }
@@ -108,6 +112,10 @@ func (d *BoltDb) ClearRunnerCache(runner db.Runner) (err error) {
 	return d.updateRunner(runner, func(targetRunner *db.Runner, foundRunner db.Runner) {
 		now := tz.Now()
 		targetRunner.CleaningRequested = &now
+		targetRunner.Health = foundRunner.Health
+		targetRunner.Capabilities = foundRunner.Capabilities
+		targetRunner.Ephemeral = foundRunner.Ephemeral
+		targetRunner.Labels = foundRunner.Labels
 	})
 }
 
@@ -115,6 +123,10 @@ func (d *BoltDb) TouchRunner(runner db.Runner) (err error) {
 	return d.updateRunner(runner, func(targetRunner *db.Runner, foundRunner db.Runner) {
 		now := tz.Now()
 		targetRunner.Touched = &now
+		targetRunner.Health = foundRunner.Health
+		targetRunner.Capabilities = foundRunner.Capabilities
+		targetRunner.Ephemeral = foundRunner.Ephemeral
+		targetRunner.Labels = foundRunner.Labels
 	})
 }
 
@@ -122,11 +134,41 @@ func (d *BoltDb) UpdateRunner(runner db.Runner) (err error) {
 	return d.updateRunner(runner, func(targetRunner *db.Runner, foundRunner db.Runner) {
 		targetRunner.PublicKey = foundRunner.PublicKey
 		targetRunner.Token = foundRunner.Token
+		targetRunner.Health = foundRunner.Health
+		targetRunner.Capabilities = foundRunner.Capabilities
+		targetRunner.Ephemeral = foundRunner.Ephemeral
+		targetRunner.Labels = foundRunner.Labels
 	})
 }
 
+func (d *BoltDb) UpdateRunnerCapabilities(runner db.Runner, capabilities db.RunnerCapabilities) (err error) {
+	return d.updateRunner(runner, func(targetRunner *db.Runner, foundRunner db.Runner) {
+		*targetRunner = foundRunner
+		targetRunner.Capabilities = capabilities
+	})
+}
+
+func (d *BoltDb) RotateRunnerToken(runner db.Runner) (res db.Runner, err error) {
+	token := base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+
+	err = d.updateRunner(runner, func(targetRunner *db.Runner, foundRunner db.Runner) {
+		*targetRunner = foundRunner
+		targetRunner.Token = token
+	})
+	if err != nil {
+		return
+	}
+
+	res = runner
+	res.Token = token
+	return
+}
+
 func (d *BoltDb) CreateRunner(runner db.Runner) (newRunner db.Runner, err error) {
-	runner.Token = base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	// Restored runners keep their tokens.
+	if runner.Token == "" {
+		runner.Token = base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
 
 	res, err := d.createObject(0, db.GlobalRunnerProps, runner)
 
@@ -0,0 +1,72 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) CreateIntegrationDelivery(delivery db.IntegrationDelivery) (db.IntegrationDelivery, error) {
+	delivery.Created = tz.Now()
+
+	newDelivery, err := d.createObject(delivery.ProjectID, db.IntegrationDeliveryProps, delivery)
+	if err != nil {
+		return db.IntegrationDelivery{}, err
+	}
+
+	return newDelivery.(db.IntegrationDelivery), nil
+}
+
+func (d *BoltDb) UpdateIntegrationDelivery(delivery db.IntegrationDelivery) error {
+	var curr db.IntegrationDelivery
+	err := d.getObject(delivery.ProjectID, db.IntegrationDeliveryProps, intObjectID(delivery.ID), &curr)
+	if err != nil {
+		return err
+	}
+
+	curr.Status = delivery.Status
+	curr.TaskID = delivery.TaskID
+	curr.Message = delivery.Message
+
+	return d.updateObject(delivery.ProjectID, db.IntegrationDeliveryProps, curr)
+}
+
+func (d *BoltDb) GetIntegrationDeliveries(projectID int, integrationID int, params db.RetrieveQueryParams) (deliveries []db.IntegrationDelivery, err error) {
+	deliveries = make([]db.IntegrationDelivery, 0)
+
+	err = d.getObjects(projectID, db.IntegrationDeliveryProps, params, func(i any) bool {
+		return i.(db.IntegrationDelivery).IntegrationID == integrationID
+	}, &deliveries)
+
+	return
+}
+
+func (d *BoltDb) DeleteIntegrationDeliveries(before time.Time) error {
+	projects, err := d.GetAllProjects()
+	if err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		for _, project := range projects {
+			var deliveries []db.IntegrationDelivery
+
+			err := d.getObjectsTx(tx, project.ID, db.IntegrationDeliveryProps, db.RetrieveQueryParams{}, func(i any) bool {
+				return i.(db.IntegrationDelivery).Created.Before(before)
+			}, &deliveries)
+			if err != nil {
+				return err
+			}
+
+			for _, delivery := range deliveries {
+				if err = d.deleteObject(project.ID, db.IntegrationDeliveryProps, intObjectID(delivery.ID), tx); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,129 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func TestIntegrationDeliveries(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: tz.Now(),
+		Name:    "Test1",
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	first, err := store.CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     proj.ID,
+		IntegrationID: 1,
+		Status:        db.IntegrationDeliveryDebounced,
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     proj.ID,
+		IntegrationID: 1,
+		Status:        db.IntegrationDeliveryRateLimited,
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     proj.ID,
+		IntegrationID: 2,
+		Status:        db.IntegrationDeliveryTriggered,
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	first.Status = db.IntegrationDeliverySuperseded
+	err = store.UpdateIntegrationDelivery(first)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	deliveries, err := store.GetIntegrationDeliveries(proj.ID, 1, db.RetrieveQueryParams{})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(deliveries) != 2 {
+		t.Fatal("expected 2 deliveries, got", len(deliveries))
+	}
+
+	// the newest delivery goes first
+	if deliveries[0].Status != db.IntegrationDeliveryRateLimited {
+		t.Fatal("invalid order of deliveries")
+	}
+
+	if deliveries[1].Status != db.IntegrationDeliverySuperseded {
+		t.Fatal("delivery was not updated")
+	}
+}
+
+func TestDeleteIntegrationDeliveries(t *testing.T) {
+	store := CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{
+		Created: tz.Now(),
+		Name:    "Test1",
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = store.CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     proj.ID,
+		IntegrationID: 1,
+		Status:        db.IntegrationDeliveryTriggered,
+	})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = store.DeleteIntegrationDeliveries(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	deliveries, err := store.GetIntegrationDeliveries(proj.ID, 1, db.RetrieveQueryParams{})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(deliveries) != 1 {
+		t.Fatal("new deliveries must be kept")
+	}
+
+	if err = store.DeleteIntegrationDeliveries(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	deliveries, err = store.GetIntegrationDeliveries(proj.ID, 1, db.RetrieveQueryParams{})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(deliveries) != 0 {
+		t.Fatal("old deliveries must be deleted")
+	}
+}
@@ -57,6 +57,8 @@ func (d *BoltDb) ApplyMigration(m db.Migration) (err error) {
 		err = migration_2_17_0{migration{d.db}}.Apply()
 	case "2.17.2":
 		err = migration_2_17_2{migration{d.db}}.Apply()
+	case "2.18.1":
+		err = migration_2_18_1{migration{d.db}}.Apply()
 	}
 
 	if err != nil {
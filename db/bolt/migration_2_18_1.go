@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"encoding/json"
+
+	"github.com/semaphoreui/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+type migration_2_18_1 struct {
+	migration
+}
+
+// Apply grants fine-grained permissions implied by the legacy permission flags to existing roles.
+func (d migration_2_18_1) Apply() error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("role"))
+		if b == nil {
+			return nil
+		}
+
+		updated := make(map[string][]byte)
+
+		err := b.ForEach(func(slug, body []byte) error {
+			role := make(map[string]any)
+			if err := json.Unmarshal(body, &role); err != nil {
+				return err
+			}
+
+			perms, _ := role["permissions"].(float64)
+			role["permissions"] = db.ProjectUserPermission(perms).WithLegacyImplied()
+
+			j, err := json.Marshal(role)
+			if err != nil {
+				return err
+			}
+
+			updated[string(slug)] = j
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for slug, j := range updated {
+			if err = b.Put([]byte(slug), j); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
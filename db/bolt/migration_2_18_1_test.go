@@ -0,0 +1,56 @@
+package bolt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestMigration_2_18_1_Apply(t *testing.T) {
+	store := CreateTestStore()
+
+	err := store.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("role"))
+		if err != nil {
+			return err
+		}
+
+		err = b.Put([]byte("runner"), []byte(`{"slug":"runner","name":"Runner","permissions":1}`))
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte("viewer"), []byte(`{"slug":"viewer","name":"Viewer","permissions":0}`))
+	})
+
+	assert.NoError(t, err)
+
+	err = migration_2_18_1{migration{store.db}}.Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runner, viewer []byte
+	err = store.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte("role"))
+		runner = b.Get([]byte("runner"))
+		viewer = b.Get([]byte("viewer"))
+		return nil
+	})
+
+	assert.NoError(t, err)
+
+	var res db.Role
+	err = json.Unmarshal(runner, &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "Runner", res.Name)
+	assert.True(t, res.Permissions.Can(db.CanRunProjectTasks|db.CanApproveTasks|db.CanStopOthersTasks|db.CanViewTaskOutput))
+	assert.False(t, res.Permissions.Can(db.CanManageSchedules))
+
+	err = json.Unmarshal(viewer, &res)
+	assert.NoError(t, err)
+	assert.Equal(t, db.CanViewSecrets|db.CanViewTaskOutput, res.Permissions)
+}
@@ -6,6 +6,10 @@ import (
 )
 
 func (d *BoltDb) CreateProject(project db.Project) (db.Project, error) {
+	if err := project.Validate(); err != nil {
+		return db.Project{}, err
+	}
+
 	project.Created = tz.Now()
 
 	newProject, err := d.createObject(0, db.ProjectProps, project)
@@ -57,5 +61,9 @@ func (d *BoltDb) DeleteProject(projectID int) error {
 }
 
 func (d *BoltDb) UpdateProject(project db.Project) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
 	return d.updateObject(0, db.ProjectProps, project)
 }
@@ -0,0 +1,69 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) GetProjectGitops(projectID int) (gitops db.ProjectGitops, err error) {
+	err = d.getObject(0, db.ProjectGitopsProps, intObjectID(projectID), &gitops)
+	return
+}
+
+func (d *BoltDb) GetAllProjectGitops() (res []db.ProjectGitops, err error) {
+	res = make([]db.ProjectGitops, 0)
+	err = d.getObjects(0, db.ProjectGitopsProps, db.RetrieveQueryParams{}, nil, &res)
+	return
+}
+
+func (d *BoltDb) SetProjectGitops(gitops db.ProjectGitops) (res db.ProjectGitops, err error) {
+	if err = gitops.Validate(); err != nil {
+		return
+	}
+
+	existing, err := d.GetProjectGitops(gitops.ProjectID)
+
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		res = gitops
+		res.LastCommit = nil
+		res.LastSync = nil
+		res.LastError = nil
+		res.Drift = nil
+		_, err = d.createObject(0, db.ProjectGitopsProps, res)
+	case err == nil:
+		res = existing
+		// The new file must be applied regardless of the last applied commit.
+		if existing.RepositoryID != gitops.RepositoryID || existing.Path != gitops.Path {
+			res.LastCommit = nil
+		}
+		res.RepositoryID = gitops.RepositoryID
+		res.Path = gitops.Path
+		res.LockManaged = gitops.LockManaged
+		err = d.updateObject(0, db.ProjectGitopsProps, res)
+	}
+
+	return
+}
+
+func (d *BoltDb) UpdateProjectGitopsStatus(gitops db.ProjectGitops) error {
+	existing, err := d.GetProjectGitops(gitops.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	existing.LastCommit = gitops.LastCommit
+	existing.LastSync = gitops.LastSync
+	existing.LastError = gitops.LastError
+	existing.Drift = gitops.Drift
+
+	return d.updateObject(0, db.ProjectGitopsProps, existing)
+}
+
+func (d *BoltDb) DeleteProjectGitops(projectID int) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return d.deleteObject(0, db.ProjectGitopsProps, intObjectID(projectID), tx)
+	})
+}
@@ -0,0 +1,50 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectGitops(t *testing.T) {
+	store := CreateTestStore()
+
+	_, err := store.GetProjectGitops(1)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	_, err = store.SetProjectGitops(db.ProjectGitops{ProjectID: 1, RepositoryID: 2, Path: "../project.yml"})
+	assert.Error(t, err)
+
+	_, err = store.SetProjectGitops(db.ProjectGitops{ProjectID: 1, RepositoryID: 2, Path: "semaphore/project.yml"})
+	require.NoError(t, err)
+
+	commit := "abc"
+	now := tz.Now()
+	err = store.UpdateProjectGitopsStatus(db.ProjectGitops{ProjectID: 1, LastCommit: &commit, LastSync: &now})
+	require.NoError(t, err)
+
+	gitops, err := store.SetProjectGitops(db.ProjectGitops{ProjectID: 1, RepositoryID: 2, Path: "semaphore/project.yml", LockManaged: true})
+	require.NoError(t, err)
+	assert.True(t, gitops.LockManaged)
+	require.NotNil(t, gitops.LastCommit)
+	assert.Equal(t, commit, *gitops.LastCommit)
+
+	// changing the definition file resets the applied commit
+	gitops, err = store.SetProjectGitops(db.ProjectGitops{ProjectID: 1, RepositoryID: 2, Path: "project.json"})
+	require.NoError(t, err)
+	assert.Nil(t, gitops.LastCommit)
+
+	all, err := store.GetAllProjectGitops()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "project.json", all[0].Path)
+	assert.NotNil(t, all[0].LastSync)
+
+	require.NoError(t, store.DeleteProjectGitops(1))
+
+	_, err = store.GetProjectGitops(1)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
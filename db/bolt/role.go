@@ -45,10 +45,18 @@ func (d *BoltDb) GetGlobalRoles() (roles []db.Role, err error) {
 }
 
 func (d *BoltDb) UpdateRole(role db.Role) error {
+	if err := db.ValidateRole(role); err != nil {
+		return err
+	}
+
 	return d.updateObject(0, db.RoleProps, role)
 }
 
 func (d *BoltDb) CreateRole(role db.Role) (newRole db.Role, err error) {
+	if err = db.ValidateRole(role); err != nil {
+		return
+	}
+
 	newRoleInterface, err := d.createObject(0, db.RoleProps, role)
 	if err != nil {
 		return
@@ -0,0 +1,93 @@
+package bolt
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) SetRunnerHealth(runner db.Runner, health db.RunnerHealth, message string) (record db.RunnerHealthRecord, err error) {
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		var origRunner db.Runner
+
+		err := d.getObjectTx(tx, 0, db.GlobalRunnerProps, intObjectID(runner.ID), &origRunner)
+		if err != nil {
+			return err
+		}
+
+		origRunner.Health = health
+
+		err = d.updateObjectTx(tx, 0, db.GlobalRunnerProps, origRunner)
+		if err != nil {
+			return err
+		}
+
+		newRecord, err := d.createObjectTx(tx, 0, db.RunnerHealthProps, db.RunnerHealthRecord{
+			RunnerID: runner.ID,
+			Created:  tz.Now(),
+			Health:   health,
+			Message:  message,
+		})
+		if err != nil {
+			return err
+		}
+
+		record = newRecord.(db.RunnerHealthRecord)
+		return nil
+	})
+
+	return
+}
+
+func (d *BoltDb) GetRunnerHealthHistory(runnerID int, params db.RetrieveQueryParams) (records []db.RunnerHealthRecord, err error) {
+	records = make([]db.RunnerHealthRecord, 0)
+
+	err = d.getObjects(0, db.RunnerHealthProps, params, func(i any) bool {
+		return i.(db.RunnerHealthRecord).RunnerID == runnerID
+	}, &records)
+
+	return
+}
+
+func (d *BoltDb) DeleteRunnerHealthHistory(before time.Time) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		var records []db.RunnerHealthRecord
+
+		err := d.getObjectsTx(tx, 0, db.RunnerHealthProps, db.RetrieveQueryParams{}, func(i any) bool {
+			return i.(db.RunnerHealthRecord).Created.Before(before)
+		}, &records)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err = d.deleteObject(0, db.RunnerHealthProps, intObjectID(record.ID), tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteRunnerHealthTx deletes the health history of the runner.
+func (d *BoltDb) deleteRunnerHealthTx(tx *bbolt.Tx, runnerID int) error {
+	var records []db.RunnerHealthRecord
+
+	err := d.getObjectsTx(tx, 0, db.RunnerHealthProps, db.RetrieveQueryParams{}, func(i any) bool {
+		return i.(db.RunnerHealthRecord).RunnerID == runnerID
+	}, &records)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err = d.deleteObject(0, db.RunnerHealthProps, intObjectID(record.ID), tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestRunnerHealth(t *testing.T) {
+	store := CreateTestStore()
+
+	runner, err := store.CreateRunner(db.Runner{Name: "runner1", Active: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	other, err := store.CreateRunner(db.Runner{Name: "runner2", Active: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.SetRunnerHealth(runner, db.RunnerHealthOnline, "Runner is online"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.SetRunnerHealth(runner, db.RunnerHealthOffline, "Runner has never contacted the server"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.SetRunnerHealth(other, db.RunnerHealthOffline, "Runner has never contacted the server"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Touching the runner keeps its health.
+	if err = store.TouchRunner(runner); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	runner, err = store.GetGlobalRunner(runner.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if runner.Health != db.RunnerHealthOffline {
+		t.Fatal("runner health must be updated")
+	}
+
+	history, err := store.GetRunnerHealthHistory(runner.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(history) != 2 {
+		t.Fatal("history must contain only changes of the runner")
+	}
+
+	if history[0].Health != db.RunnerHealthOffline {
+		t.Fatal("latest change must be first")
+	}
+
+	if err = store.DeleteGlobalRunner(runner.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	history, err = store.GetRunnerHealthHistory(runner.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(history) != 0 {
+		t.Fatal("history must be deleted with the runner")
+	}
+
+	history, err = store.GetRunnerHealthHistory(other.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(history) != 1 {
+		t.Fatal("history of other runners must be kept")
+	}
+}
+
+func TestDeleteRunnerHealthHistory(t *testing.T) {
+	store := CreateTestStore()
+
+	runner, err := store.CreateRunner(db.Runner{Name: "runner", Active: true})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.SetRunnerHealth(runner, db.RunnerHealthOnline, "Runner is online"); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = store.DeleteRunnerHealthHistory(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	history, err := store.GetRunnerHealthHistory(runner.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(history) != 1 {
+		t.Fatal("new records must be kept")
+	}
+
+	if err = store.DeleteRunnerHealthHistory(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	history, err = store.GetRunnerHealthHistory(runner.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(history) != 0 {
+		t.Fatal("old records must be deleted")
+	}
+}
@@ -65,6 +65,10 @@ func (d *BoltDb) DeleteRunner(projectID int, runnerID int) error {
 		if runner.ProjectID == nil || *runner.ProjectID != projectID {
 			return db.ErrNotFound
 		}
+		if err = d.deleteRunnerHealthTx(tx, runnerID); err != nil {
+			return err
+		}
+
 		return d.deleteObject(0, db.GlobalRunnerProps, intObjectID(runnerID), tx)
 	})
 }
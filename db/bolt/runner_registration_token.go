@@ -0,0 +1,88 @@
+package bolt
+
+import (
+	"encoding/base64"
+
+	"github.com/gorilla/securecookie"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) GetRunnerRegistrationTokens() (tokens []db.RunnerRegistrationToken, err error) {
+	tokens = make([]db.RunnerRegistrationToken, 0)
+	err = d.getObjects(0, db.RunnerRegistrationTokenProps, db.RetrieveQueryParams{}, nil, &tokens)
+	return
+}
+
+func (d *BoltDb) GetRunnerRegistrationToken(tokenID int) (token db.RunnerRegistrationToken, err error) {
+	err = d.getObject(0, db.RunnerRegistrationTokenProps, intObjectID(tokenID), &token)
+	return
+}
+
+func (d *BoltDb) CreateRunnerRegistrationToken(token db.RunnerRegistrationToken) (newToken db.RunnerRegistrationToken, err error) {
+	err = token.Validate()
+	if err != nil {
+		return
+	}
+
+	// Restored tokens keep their values.
+	if token.Token == "" {
+		token.Token = base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
+	if token.Created.IsZero() {
+		token.Created = tz.Now()
+	}
+
+	res, err := d.createObject(0, db.RunnerRegistrationTokenProps, token)
+	if err != nil {
+		return
+	}
+
+	newToken = res.(db.RunnerRegistrationToken)
+	return
+}
+
+func (d *BoltDb) RevokeRunnerRegistrationToken(tokenID int) error {
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		var token db.RunnerRegistrationToken
+
+		err := d.getObjectTx(tx, 0, db.RunnerRegistrationTokenProps, intObjectID(tokenID), &token)
+		if err != nil {
+			return err
+		}
+
+		token.Revoked = true
+
+		return d.updateObjectTx(tx, 0, db.RunnerRegistrationTokenProps, token)
+	})
+}
+
+func (d *BoltDb) DeleteRunnerRegistrationToken(tokenID int) error {
+	return d.deleteObject(0, db.RunnerRegistrationTokenProps, intObjectID(tokenID), nil)
+}
+
+func (d *BoltDb) UseRunnerRegistrationToken(token string) (res db.RunnerRegistrationToken, err error) {
+	err = d.db.Update(func(tx *bbolt.Tx) error {
+		var tokens []db.RunnerRegistrationToken
+
+		err := d.getObjectsTx(tx, 0, db.RunnerRegistrationTokenProps, db.RetrieveQueryParams{}, func(i any) bool {
+			return i.(db.RunnerRegistrationToken).Token == token
+		}, &tokens)
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 || !tokens[0].IsUsable(tz.Now()) {
+			return db.ErrNotFound
+		}
+
+		res = tokens[0]
+		res.Uses++
+
+		return d.updateObjectTx(tx, 0, db.RunnerRegistrationTokenProps, res)
+	})
+
+	return
+}
@@ -0,0 +1,118 @@
+package bolt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestRunnerRegistrationToken(t *testing.T) {
+	store := CreateTestStore()
+
+	token, err := store.CreateRunnerRegistrationToken(db.RunnerRegistrationToken{
+		Name:    "ci",
+		MaxUses: 2,
+		Tag:     "linux",
+		Labels:  db.RunnerLabels{"zone": "eu"},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if token.Token == "" {
+		t.Fatal("token must be generated")
+	}
+
+	for i := 1; i <= 2; i++ {
+		used, err := store.UseRunnerRegistrationToken(token.Token)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if used.Uses != i || used.Tag != "linux" || used.Labels["zone"] != "eu" {
+			t.Fatal("invalid token", used)
+		}
+	}
+
+	if _, err = store.UseRunnerRegistrationToken(token.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("token must be exhausted")
+	}
+
+	expired := time.Now().Add(-time.Minute)
+
+	token, err = store.CreateRunnerRegistrationToken(db.RunnerRegistrationToken{Name: "expired", Expires: &expired})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.UseRunnerRegistrationToken(token.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("token must be expired")
+	}
+
+	token, err = store.CreateRunnerRegistrationToken(db.RunnerRegistrationToken{Name: "revoked"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = store.RevokeRunnerRegistrationToken(token.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.UseRunnerRegistrationToken(token.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("token must be revoked")
+	}
+
+	if _, err = store.CreateRunnerRegistrationToken(db.RunnerRegistrationToken{}); err == nil {
+		t.Fatal("token without name must be invalid")
+	}
+
+	tokens, err := store.GetRunnerRegistrationTokens()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(tokens) != 3 {
+		t.Fatal("invalid number of tokens", len(tokens))
+	}
+
+	if err = store.DeleteRunnerRegistrationToken(token.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.GetRunnerRegistrationToken(token.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("token must be deleted")
+	}
+}
+
+func TestRotateRunnerToken(t *testing.T) {
+	store := CreateTestStore()
+
+	runner, err := store.CreateRunner(db.Runner{Name: "runner1", Active: true, Labels: db.RunnerLabels{"zone": "eu"}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rotated, err := store.RotateRunnerToken(runner)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if rotated.Token == runner.Token {
+		t.Fatal("token must be changed")
+	}
+
+	if _, err = store.GetRunnerByToken(runner.Token); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("old token must not work")
+	}
+
+	found, err := store.GetRunnerByToken(rotated.Token)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if found.ID != runner.ID || found.Labels["zone"] != "eu" {
+		t.Fatal("invalid runner", found)
+	}
+}
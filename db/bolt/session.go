@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 )
 
 type globalToken struct {
@@ -30,6 +31,11 @@ func (d *BoltDb) CreateSession(session db.Session) (db.Session, error) {
 
 func (d *BoltDb) CreateAPIToken(token db.APIToken) (db.APIToken, error) {
 	token.Created = db.GetParsedTime(tz.Now())
+
+	if err := token.Validate(); err != nil {
+		return db.APIToken{}, err
+	}
+
 	// create token in bucket "token_<user id>"
 	newToken, err := d.createObject(token.UserID, db.TokenProps, token)
 	if err != nil {
@@ -66,6 +72,19 @@ func (d *BoltDb) ExpireAPIToken(userID int, tokenID string) (err error) {
 	return
 }
 
+func (d *BoltDb) TouchAPIToken(userID int, tokenID string, ip string) (err error) {
+	var token db.APIToken
+	err = d.getObject(userID, db.TokenProps, strObjectID(tokenID), &token)
+	if err != nil {
+		return
+	}
+	now := tz.Now()
+	token.LastUsed = &now
+	token.LastUsedIP = ip
+	err = d.updateObject(userID, db.TokenProps, token)
+	return
+}
+
 func (d *BoltDb) DeleteAPIToken(userID int, tokenID string) (err error) {
 	var tokens []db.APIToken
 
@@ -88,6 +107,10 @@ func (d *BoltDb) DeleteAPIToken(userID int, tokenID string) (err error) {
 
 func (d *BoltDb) GetSession(userID int, sessionID int) (session db.Session, err error) {
 	err = d.getObject(userID, db.SessionProps, intObjectID(sessionID), &session)
+	if err == nil && session.Expired {
+		session = db.Session{}
+		err = db.ErrNotFound
+	}
 	return
 }
 
@@ -117,6 +140,56 @@ func (d *BoltDb) VerifySession(userID int, sessionID int) (err error) {
 	return
 }
 
+func (d *BoltDb) UpdateSessionOidcToken(userID int, sessionID int, refreshToken string, expiry *time.Time) (err error) {
+	var session db.Session
+	err = d.getObject(userID, db.SessionProps, intObjectID(sessionID), &session)
+	if err != nil {
+		return
+	}
+	session.OidcRefreshToken = &refreshToken
+	session.OidcTokenExpiry = expiry
+	err = d.updateObject(userID, db.SessionProps, session)
+	return
+}
+
+func (d *BoltDb) ExpireOidcSessions(provider string, subject string, sid string) error {
+	users, err := d.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		var sessions []db.Session
+
+		err = d.getObjects(user.ID, db.SessionProps, db.RetrieveQueryParams{}, func(i any) bool {
+			session := i.(db.Session)
+
+			if session.Expired || session.OidcProvider != provider {
+				return false
+			}
+
+			if sid != "" {
+				return session.OidcSessionID == sid
+			}
+
+			return session.OidcSubject == subject
+		}, &sessions)
+
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			session.Expired = true
+			if err = d.updateObject(user.ID, db.SessionProps, session); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (d *BoltDb) TouchSession(userID int, sessionID int) (err error) {
 	var session db.Session
 	err = d.getObject(userID, db.SessionProps, intObjectID(sessionID), &session)
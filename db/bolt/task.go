@@ -1,6 +1,7 @@
 package bolt
 
 import (
+	"slices"
 	"time"
 
 	"github.com/semaphoreui/semaphore/db"
@@ -168,6 +169,11 @@ func (d *BoltDb) getTasks(projectID int, templateID *int, params db.RetrieveQuer
 			return false
 		}
 
+		if params.TaskFilter != nil && len(params.TaskFilter.Status) > 0 &&
+			!slices.Contains(params.TaskFilter.Status, task.Status) {
+			return false
+		}
+
 		return true
 	}, &tasks)
 
@@ -255,6 +261,14 @@ func (d *BoltDb) deleteTaskWithOutputs(projectID int, taskID int, checkTaskExist
 	if err == bbolt.ErrBucketNotFound {
 		err = nil
 	}
+	if err != nil {
+		return
+	}
+
+	err = tx.DeleteBucket(makeBucketId(db.TaskApprovalProps, taskID))
+	if err == bbolt.ErrBucketNotFound {
+		err = nil
+	}
 
 	return
 }
@@ -0,0 +1,28 @@
+package bolt
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func (d *BoltDb) CreateTaskApproval(approval db.TaskApproval) (db.TaskApproval, error) {
+	approval.Created = tz.Now()
+
+	newApproval, err := d.createObject(approval.TaskID, db.TaskApprovalProps, approval)
+	if err != nil {
+		return db.TaskApproval{}, err
+	}
+	return newApproval.(db.TaskApproval), nil
+}
+
+func (d *BoltDb) GetTaskApprovals(projectID int, taskID int) (approvals []db.TaskApproval, err error) {
+	// check if task exists in the project
+	_, err = d.GetTask(projectID, taskID)
+	if err != nil {
+		return
+	}
+
+	approvals = make([]db.TaskApproval, 0)
+	err = d.getObjects(taskID, db.TaskApprovalProps, db.RetrieveQueryParams{}, nil, &approvals)
+	return
+}
@@ -0,0 +1,71 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskApprovals(t *testing.T) {
+	invID := 0
+
+	store := CreateTestStore()
+
+	tpl, err := store.CreateTemplate(db.Template{
+		ProjectID:         0,
+		Name:              "Deploy",
+		Playbook:          "deploy.yml",
+		InventoryID:       &invID,
+		RequiredApprovals: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := store.CreateTask(db.Task{
+		ProjectID:  0,
+		TemplateID: tpl.ID,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateTaskApproval(db.TaskApproval{
+		TaskID:   task.ID,
+		UserID:   1,
+		Approved: true,
+		Comment:  "looks good",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.CreateTaskApproval(db.TaskApproval{
+		TaskID:   task.ID,
+		UserID:   2,
+		Approved: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	approvals, err := store.GetTaskApprovals(0, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, approvals, 2)
+	assert.Equal(t, "looks good", approvals[0].Comment)
+	assert.True(t, approvals[0].Approved)
+	assert.False(t, approvals[1].Approved)
+	assert.False(t, approvals[0].Created.IsZero())
+
+	err = store.DeleteTaskWithOutputs(0, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.GetTaskApprovals(0, task.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
@@ -0,0 +1,79 @@
+package bolt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+func (d *BoltDb) CreateTaskArtifact(artifact db.TaskArtifact) (res db.TaskArtifact, err error) {
+	newArtifact, err := d.createObject(0, db.TaskArtifactProps, artifact)
+	if err != nil {
+		return
+	}
+
+	res = newArtifact.(db.TaskArtifact)
+	return
+}
+
+func (d *BoltDb) GetTaskArtifacts(projectID int, taskID int) (artifacts []db.TaskArtifact, err error) {
+	artifacts = make([]db.TaskArtifact, 0)
+
+	err = d.getObjects(0, db.TaskArtifactProps, db.RetrieveQueryParams{}, func(i any) bool {
+		artifact := i.(db.TaskArtifact)
+		return artifact.ProjectID == projectID && artifact.TaskID == taskID
+	}, &artifacts)
+
+	return
+}
+
+func (d *BoltDb) GetTaskArtifact(projectID int, taskID int, artifactID int) (artifact db.TaskArtifact, err error) {
+	err = d.getObject(0, db.TaskArtifactProps, intObjectID(artifactID), &artifact)
+	if err != nil {
+		return
+	}
+
+	if artifact.ProjectID != projectID || artifact.TaskID != taskID {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *BoltDb) DeleteTaskArtifact(artifactID int) error {
+	return d.deleteObject(0, db.TaskArtifactProps, intObjectID(artifactID), nil)
+}
+
+func (d *BoltDb) GetStaleTaskArtifacts(createdBefore *time.Time) (artifacts []db.TaskArtifact, err error) {
+	artifacts = make([]db.TaskArtifact, 0)
+
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		var all []db.TaskArtifact
+
+		err := d.getObjectsTx(tx, 0, db.TaskArtifactProps, db.RetrieveQueryParams{}, nil, &all)
+		if err != nil {
+			return err
+		}
+
+		for _, artifact := range all {
+			if createdBefore != nil && artifact.Created.Before(*createdBefore) {
+				artifacts = append(artifacts, artifact)
+				continue
+			}
+
+			var task db.Task
+			err = d.getObjectTx(tx, 0, db.TaskProps, intObjectID(artifact.TaskID), &task)
+			if errors.Is(err, db.ErrNotFound) {
+				artifacts = append(artifacts, artifact)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
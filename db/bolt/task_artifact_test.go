@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func TestGetStaleTaskArtifacts(t *testing.T) {
+	store := CreateTestStore()
+
+	task, err := store.CreateTask(db.Task{ProjectID: 1, TemplateID: 1}, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	now := time.Now()
+
+	artifact, err := store.CreateTaskArtifact(db.TaskArtifact{ProjectID: 1, TaskID: task.ID, Name: "report.xml", Created: now})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	orphan, err := store.CreateTaskArtifact(db.TaskArtifact{ProjectID: 1, TaskID: task.ID + 1, Name: "report.xml", Created: now})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err = store.GetTaskArtifact(1, task.ID+1, artifact.ID); !errors.Is(err, db.ErrNotFound) {
+		t.Fatal("artifact of another task must not be found")
+	}
+
+	stale, err := store.GetStaleTaskArtifacts(nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(stale) != 1 || stale[0].ID != orphan.ID {
+		t.Fatal("only artifact of the deleted task must be stale", stale)
+	}
+
+	createdBefore := now.Add(time.Minute)
+
+	stale, err = store.GetStaleTaskArtifacts(&createdBefore)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(stale) != 2 {
+		t.Fatal("expired artifacts must be stale", stale)
+	}
+}
@@ -0,0 +1,151 @@
+package bolt
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"go.etcd.io/bbolt"
+)
+
+// eventsBucket keeps events marshaled by JSON tags.
+const eventsBucket = "events"
+
+var errTransferTarget = errors.New("BoltDB can not be the target of the data transfer")
+
+var bucketIDRegexp = regexp.MustCompile(`^_(\d{10})$`)
+
+type transferBucket struct {
+	id     int
+	bucket *bbolt.Bucket
+}
+
+// getTransferBuckets returns buckets of the entity ordered by the bucket ID.
+func getTransferBuckets(tx *bbolt.Tx, props db.ObjectProps) (res []transferBucket, err error) {
+	if props.TableName == db.EventProps.TableName {
+		if b := tx.Bucket([]byte(eventsBucket)); b != nil {
+			res = append(res, transferBucket{bucket: b})
+		}
+		return
+	}
+
+	if props.IsGlobal {
+		if b := tx.Bucket([]byte(props.TableName)); b != nil {
+			res = append(res, transferBucket{bucket: b})
+		}
+		return
+	}
+
+	// Buckets are iterated in the byte order, so zero-padded IDs are sorted.
+	err = tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+		suffix, ok := strings.CutPrefix(string(name), props.TableName)
+		if !ok {
+			return nil
+		}
+
+		m := bucketIDRegexp.FindStringSubmatch(suffix)
+		if m == nil {
+			return nil
+		}
+
+		id, err2 := strconv.Atoi(m[1])
+		if err2 != nil {
+			return err2
+		}
+
+		res = append(res, transferBucket{id: id, bucket: b})
+		return nil
+	})
+
+	return
+}
+
+func unmarshalTransferObject(props db.ObjectProps, key []byte, value []byte) (any, error) {
+	ptr := reflect.New(props.Type).Interface()
+
+	if props.TableName != db.EventProps.TableName {
+		err := unmarshalObject(value, ptr, nil)
+		return reflect.ValueOf(ptr).Elem().Interface(), err
+	}
+
+	// Events have no ID in the body, it is the key of the bucket.
+	evt := ptr.(*db.Event)
+
+	if err := json.Unmarshal(value, evt); err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(string(key))
+	if err != nil {
+		return nil, err
+	}
+
+	evt.ID = id
+
+	return *evt, nil
+}
+
+func (d *BoltDb) ExportObjects(props db.ObjectProps, fn func(bucketID int, object any) error) error {
+	return d.db.View(func(tx *bbolt.Tx) error {
+		buckets, err := getTransferBuckets(tx, props)
+		if err != nil {
+			return err
+		}
+
+		for _, b := range buckets {
+			c := b.bucket.Cursor()
+
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				// Nested buckets have no value.
+				if v == nil {
+					continue
+				}
+
+				obj, err2 := unmarshalTransferObject(props, k, v)
+				if err2 != nil {
+					return err2
+				}
+
+				if err2 = fn(b.id, obj); err2 != nil {
+					return err2
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *BoltDb) CountObjects(props db.ObjectProps) (n int, err error) {
+	err = d.db.View(func(tx *bbolt.Tx) error {
+		buckets, err2 := getTransferBuckets(tx, props)
+		if err2 != nil {
+			return err2
+		}
+
+		for _, b := range buckets {
+			c := b.bucket.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				if v != nil {
+					n++
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func (d *BoltDb) ImportObjects(props db.ObjectProps, objects []any) error {
+	return errTransferTarget
+}
+
+func (d *BoltDb) UpdateObjectColumns(props db.ObjectProps, objectID any, columns map[string]any) error {
+	return errTransferTarget
+}
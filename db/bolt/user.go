@@ -75,6 +75,39 @@ func (d *BoltDb) CreateUser(user db.UserWithPwd) (newUser db.User, err error) {
 	return
 }
 
+func (d *BoltDb) ImportUser(user db.User) (newUser db.User, err error) {
+
+	err = db.ValidateUser(user)
+	if err != nil {
+		return
+	}
+
+	_, err = d.GetUserByLoginOrEmail(user.Username, user.Email)
+
+	if err == nil {
+		err = fmt.Errorf("user already exists")
+		return
+	}
+
+	if err != db.ErrNotFound {
+		return
+	}
+
+	user.ID = 0
+	user.Totp = nil
+	user.EmailOtp = nil
+	user.Created = db.GetParsedTime(user.Created)
+
+	usr, err := d.createObject(0, db.UserProps, user)
+
+	if err != nil {
+		return
+	}
+
+	newUser = usr.(db.User)
+	return
+}
+
 func (d *BoltDb) DeleteUser(userID int) error {
 	projects, err := d.GetProjects(userID)
 	if err != nil {
@@ -91,24 +124,24 @@ func (d *BoltDb) DeleteUser(userID int) error {
 }
 
 func (d *BoltDb) UpdateUser(user db.UserWithPwd) error {
-	var password string
+	oldUser, err := d.GetUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	password := oldUser.Password
 
 	if user.Pwd != "" {
 		var pwdHash []byte
-		pwdHash, err := bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
+		pwdHash, err = bcrypt.GenerateFromPassword([]byte(user.Pwd), 11)
 		if err != nil {
 			return err
 		}
 		password = string(pwdHash)
-	} else {
-		oldUser, err := d.GetUser(user.ID)
-		if err != nil {
-			return err
-		}
-		password = oldUser.Password
 	}
 
 	user.Password = password
+	user.Ldap = oldUser.Ldap
 
 	return d.updateObject(0, db.UserProps, user)
 }
@@ -126,6 +159,15 @@ func (d *BoltDb) SetUserPassword(userID int, password string) error {
 	return d.updateObject(0, db.UserProps, user)
 }
 
+func (d *BoltDb) SetUserLdap(userID int) error {
+	user, err := d.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	user.Ldap = true
+	return d.updateObject(0, db.UserProps, user)
+}
+
 func (d *BoltDb) CreateProjectUser(projectUser db.ProjectUser) (db.ProjectUser, error) {
 	newProjectUser, err := d.createObject(projectUser.ProjectID, db.ProjectUserProps, projectUser)
 
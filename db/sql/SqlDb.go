@@ -25,6 +25,8 @@ import (
 type SqlDbConnection struct {
 	sql     *gorp.DbMap
 	dialect string
+	// tx is set if queries of the connection run in the transaction.
+	tx *gorp.Transaction
 }
 
 type SqlDb struct {
@@ -35,6 +37,42 @@ func (d *SqlDb) Sql() *gorp.DbMap {
 	return d.connection.sql
 }
 
+// executor returns the transaction if the store is bound to one.
+func (d *SqlDb) executor() gorp.SqlExecutor {
+	return d.connection.executor()
+}
+
+// Transaction runs fn with the store whose queries run in the single transaction.
+// The transaction is committed if fn succeeds and rolled back otherwise.
+func (d *SqlDb) Transaction(fn func(store db.Store) error) (err error) {
+	if d.connection.tx != nil {
+		return fn(d)
+	}
+
+	tx, err := d.Sql().Begin()
+	if err != nil {
+		return
+	}
+
+	txStore := &SqlDb{connection: d.connection}
+	txStore.connection.tx = tx
+
+	if err = fn(txStore); err != nil {
+		handleRollbackError(tx.Rollback())
+		return
+	}
+
+	return tx.Commit()
+}
+
+// executor returns the transaction if the connection is bound to one.
+func (d *SqlDbConnection) executor() gorp.SqlExecutor {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.sql
+}
+
 func (d *SqlDbConnection) Connect() {
 	sqlDb, err := connect()
 	if err != nil {
@@ -186,16 +224,16 @@ func (d *SqlDbConnection) Insert(primaryKeyColumnName string, query string, args
 		var err error
 		if primaryKeyColumnName != "" {
 			query += " returning " + primaryKeyColumnName
-			err = d.sql.QueryRow(d.PrepareQuery(query), formattedArgs...).Scan(&insertId)
+			err = d.executor().QueryRow(d.PrepareQuery(query), formattedArgs...).Scan(&insertId)
 		} else {
-			_, err = d.sql.Exec(d.PrepareQuery(query), formattedArgs...)
+			_, err = d.executor().Exec(d.PrepareQuery(query), formattedArgs...)
 		}
 
 		if err != nil {
 			return 0, err
 		}
 	default:
-		res, err := d.sql.Exec(d.PrepareQuery(query), formattedArgs...)
+		res, err := d.executor().Exec(d.PrepareQuery(query), formattedArgs...)
 		if err != nil {
 			return 0, err
 		}
@@ -211,7 +249,7 @@ func (d *SqlDbConnection) Insert(primaryKeyColumnName string, query string, args
 
 func (d *SqlDbConnection) Exec(query string, args ...any) (sql.Result, error) {
 	q := d.PrepareQuery(query)
-	return d.sql.Exec(q, args...)
+	return d.executor().Exec(q, args...)
 }
 
 func (d *SqlDbConnection) ExecTx(tx *gorp.Transaction, query string, args ...any) (sql.Result, error) {
@@ -220,7 +258,7 @@ func (d *SqlDbConnection) ExecTx(tx *gorp.Transaction, query string, args ...any
 }
 
 func (d *SqlDbConnection) SelectOne(holder any, query string, args ...any) error {
-	err := d.sql.SelectOne(holder, d.PrepareQuery(query), args...)
+	err := d.executor().SelectOne(holder, d.PrepareQuery(query), args...)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		err = db.ErrNotFound
@@ -231,7 +269,7 @@ func (d *SqlDbConnection) SelectOne(holder any, query string, args ...any) error
 
 func (d *SqlDbConnection) SelectAll(i any, query string, args ...any) ([]any, error) {
 	q := d.PrepareQuery(query)
-	return d.sql.Select(i, q, args...)
+	return d.executor().Select(i, q, args...)
 }
 
 func (d *SqlDbConnection) DeleteObject(projectID int, props db.ObjectProps, objectID any) error {
@@ -422,8 +460,26 @@ func (d *SqlDb) execTx(tx *gorp.Transaction, query string, args ...any) (sql.Res
 	return tx.Exec(q, args...)
 }
 
+// insertTx is like insert, but runs the query in the transaction.
+func (d *SqlDb) insertTx(tx *gorp.Transaction, primaryKeyColumnName string, query string, args ...any) (int, error) {
+	formattedArgs := formatArgs(args)
+
+	if _, ok := d.Sql().Dialect.(gorp.PostgresDialect); ok {
+		insertID, err := tx.SelectInt(d.PrepareQuery(query+" returning "+primaryKeyColumnName), formattedArgs...)
+		return int(insertID), err
+	}
+
+	res, err := tx.Exec(d.PrepareQuery(query), formattedArgs...)
+	if err != nil {
+		return 0, err
+	}
+
+	insertID, err := res.LastInsertId()
+	return int(insertID), err
+}
+
 func (d *SqlDb) selectOne(holder any, query string, args ...any) error {
-	err := d.Sql().SelectOne(holder, d.PrepareQuery(query), args...)
+	err := d.executor().SelectOne(holder, d.PrepareQuery(query), args...)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		err = db.ErrNotFound
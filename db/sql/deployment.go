@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+)
+
+// deploymentInventory is the inventory which the task has run on. Tasks
+// record it when they are created, older tasks fall back to the inventory
+// of the template.
+const deploymentInventory = "coalesce(task.inventory_id, tpl.inventory_id)"
+
+func (d *SqlDb) getDeploymentTasks(projectID int, q squirrel.SelectBuilder) (tasks []db.TaskWithTpl, err error) {
+	tasks = make([]db.TaskWithTpl, 0)
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return
+	}
+
+	var taskIDs []int
+	_, err = d.selectAll(&taskIDs, query, args...)
+	if err != nil || len(taskIDs) == 0 {
+		return
+	}
+
+	err = d.getTasks(projectID, nil, taskIDs, db.RetrieveQueryParams{}, &tasks)
+	return
+}
+
+func (d *SqlDb) GetDeployments(projectID int) ([]db.TaskWithTpl, error) {
+	q := squirrel.Select("max(task.id)").
+		From("task").
+		Join("project__template as tpl on task.template_id=tpl.id").
+		Where("tpl.project_id=? and tpl.type=? and task.status=?", projectID, db.TemplateDeploy, task_logger.TaskSuccessStatus).
+		GroupBy("task.template_id", deploymentInventory)
+
+	return d.getDeploymentTasks(projectID, q)
+}
+
+func (d *SqlDb) GetDeploymentTasks(projectID int, templateID int, inventoryID *int, params db.RetrieveQueryParams) ([]db.TaskWithTpl, error) {
+	q := squirrel.Select("task.id").
+		From("task").
+		Join("project__template as tpl on task.template_id=tpl.id").
+		Where("tpl.project_id=? and task.template_id=?", projectID, templateID).
+		OrderBy("task.id desc")
+
+	if inventoryID == nil {
+		q = q.Where(deploymentInventory + " is null")
+	} else {
+		q = q.Where(deploymentInventory+"=?", *inventoryID)
+	}
+
+	if params.TaskFilter != nil && len(params.TaskFilter.Status) > 0 {
+		q = q.Where(squirrel.Eq{"task.status": params.TaskFilter.Status})
+	}
+
+	if params.Count > 0 {
+		q = q.Limit(uint64(params.Count))
+	}
+
+	return d.getDeploymentTasks(projectID, q)
+}
@@ -0,0 +1,61 @@
+package sql
+
+import (
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetEnvironmentVersions(projectID int, buildTemplateID int) (versions []db.EnvironmentVersion, err error) {
+	versions = make([]db.EnvironmentVersion, 0)
+
+	err = d.getObjects(projectID, db.EnvironmentVersionProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.build_template_id=?", buildTemplateID)
+	}, &versions)
+
+	return
+}
+
+func (d *SqlDb) SetEnvironmentVersion(version db.EnvironmentVersion) (err error) {
+	var current db.EnvironmentVersion
+
+	err = d.selectOne(
+		&current,
+		"select * from project__environment_version where project_id=? and environment_id=? and build_template_id=?",
+		version.ProjectID,
+		version.EnvironmentID,
+		version.BuildTemplateID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		_, err = d.insert(
+			"id",
+			"insert into project__environment_version "+
+				"(project_id, environment_id, build_template_id, template_id, task_id, build_task_id, version, deployed) "+
+				"values (?, ?, ?, ?, ?, ?, ?, ?)",
+			version.ProjectID,
+			version.EnvironmentID,
+			version.BuildTemplateID,
+			version.TemplateID,
+			version.TaskID,
+			version.BuildTaskID,
+			version.Version,
+			version.Deployed)
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	_, err = d.exec(
+		"update project__environment_version set template_id=?, task_id=?, build_task_id=?, version=?, deployed=? where id=?",
+		version.TemplateID,
+		version.TaskID,
+		version.BuildTaskID,
+		version.Version,
+		version.Deployed,
+		current.ID)
+
+	return
+}
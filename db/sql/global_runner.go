@@ -104,12 +104,40 @@ func (d *SqlDb) UpdateRunner(runner db.Runner) (err error) {
 	return
 }
 
-func (d *SqlDb) CreateRunner(runner db.Runner) (newRunner db.Runner, err error) {
+func (d *SqlDb) UpdateRunnerCapabilities(runner db.Runner, capabilities db.RunnerCapabilities) (err error) {
+	_, err = d.exec(
+		"update `runner` set `capabilities`=? where id=?",
+		capabilities,
+		runner.ID)
+
+	return
+}
+
+func (d *SqlDb) RotateRunnerToken(runner db.Runner) (res db.Runner, err error) {
 	token := base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
 
+	result, err := d.exec("update `runner` set `token`=? where id=?", token, runner.ID)
+
+	err = checkAffected(result, err)
+	if err != nil {
+		return
+	}
+
+	res = runner
+	res.Token = token
+	return
+}
+
+func (d *SqlDb) CreateRunner(runner db.Runner) (newRunner db.Runner, err error) {
+	// Restored runners keep their tokens.
+	token := runner.Token
+	if token == "" {
+		token = base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
 	insertID, err := d.insert(
 		"id",
-		"insert into `runner` (project_id, token, webhook, max_parallel_tasks, `name`, `active`, public_key, tag) values (?, ?, ?, ?, ?, ?, ?, ?)",
+		"insert into `runner` (project_id, token, webhook, max_parallel_tasks, `name`, `active`, public_key, tag, capabilities, ephemeral, labels) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		runner.ProjectID,
 		token,
 		runner.Webhook,
@@ -117,7 +145,10 @@ func (d *SqlDb) CreateRunner(runner db.Runner) (newRunner db.Runner, err error)
 		runner.Name,
 		runner.Active,
 		runner.PublicKey,
-		runner.Tag)
+		runner.Tag,
+		runner.Capabilities,
+		runner.Ephemeral,
+		runner.Labels)
 
 	if err != nil {
 		return
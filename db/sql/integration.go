@@ -15,7 +15,7 @@ func (d *SqlDb) CreateIntegration(integration db.Integration) (newIntegration db
 	if integration.TaskParams != nil {
 		params := *integration.TaskParams
 		params.ProjectID = integration.ProjectID
-		err = d.Sql().Insert(&params)
+		err = d.executor().Insert(&params)
 		if err != nil {
 			return
 		}
@@ -25,8 +25,8 @@ func (d *SqlDb) CreateIntegration(integration db.Integration) (newIntegration db
 	insertID, err := d.insert(
 		"id",
 		"insert into project__integration "+
-			"(project_id, name, template_id, auth_method, auth_secret_id, auth_header, searchable, task_params_id) values "+
-			"(?, ?, ?, ?, ?, ?, ?, ?)",
+			"(project_id, name, template_id, auth_method, auth_secret_id, auth_header, searchable, task_params_id, rate_limit, debounce_sec, cancel_queued) values "+
+			"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		integration.ProjectID,
 		integration.Name,
 		integration.TemplateID,
@@ -34,7 +34,10 @@ func (d *SqlDb) CreateIntegration(integration db.Integration) (newIntegration db
 		integration.AuthSecretID,
 		integration.AuthHeader,
 		integration.Searchable,
-		integration.TaskParamsID)
+		integration.TaskParamsID,
+		integration.RateLimit,
+		integration.DebounceSec,
+		integration.CancelQueued)
 
 	if err != nil {
 		return
@@ -130,10 +133,10 @@ func (d *SqlDb) UpdateIntegration(integration db.Integration) (err error) {
 		params.ProjectID = integration.ProjectID
 
 		if curr.TaskParamsID == nil {
-			err = d.Sql().Insert(&params)
+			err = d.executor().Insert(&params)
 		} else {
 			params.ID = *curr.TaskParamsID
-			_, err = d.Sql().Update(&params)
+			_, err = d.executor().Update(&params)
 		}
 
 		if err != nil {
@@ -151,7 +154,10 @@ func (d *SqlDb) UpdateIntegration(integration db.Integration) (err error) {
 			"auth_secret_id=?, "+
 			"auth_header=?, "+
 			"searchable=?, "+
-			"task_params_id=? "+
+			"task_params_id=?, "+
+			"rate_limit=?, "+
+			"debounce_sec=?, "+
+			"cancel_queued=? "+
 			"where project_id=? AND `id`=?",
 		integration.Name,
 		integration.TemplateID,
@@ -160,6 +166,9 @@ func (d *SqlDb) UpdateIntegration(integration db.Integration) (err error) {
 		integration.AuthHeader,
 		integration.Searchable,
 		integration.TaskParamsID,
+		integration.RateLimit,
+		integration.DebounceSec,
+		integration.CancelQueued,
 		integration.ProjectID,
 		integration.ID)
 
@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func (d *SqlDb) CreateIntegrationDelivery(delivery db.IntegrationDelivery) (newDelivery db.IntegrationDelivery, err error) {
+	delivery.Created = tz.Now()
+
+	insertID, err := d.insert(
+		"id",
+		"insert into project__integration_delivery "+
+			"(project_id, integration_id, created, status, task_id, message) values "+
+			"(?, ?, ?, ?, ?, ?)",
+		delivery.ProjectID,
+		delivery.IntegrationID,
+		delivery.Created,
+		delivery.Status,
+		delivery.TaskID,
+		delivery.Message)
+
+	if err != nil {
+		return
+	}
+
+	newDelivery = delivery
+	newDelivery.ID = insertID
+	return
+}
+
+func (d *SqlDb) UpdateIntegrationDelivery(delivery db.IntegrationDelivery) error {
+	_, err := d.exec(
+		"update project__integration_delivery set status=?, task_id=?, message=? "+
+			"where project_id=? and integration_id=? and id=?",
+		delivery.Status,
+		delivery.TaskID,
+		delivery.Message,
+		delivery.ProjectID,
+		delivery.IntegrationID,
+		delivery.ID)
+
+	return err
+}
+
+func (d *SqlDb) GetIntegrationDeliveries(projectID int, integrationID int, params db.RetrieveQueryParams) (deliveries []db.IntegrationDelivery, err error) {
+	deliveries = make([]db.IntegrationDelivery, 0)
+
+	err = d.getObjects(projectID, db.IntegrationDeliveryProps, params, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.integration_id=?", integrationID)
+	}, &deliveries)
+
+	return
+}
+
+func (d *SqlDb) DeleteIntegrationDeliveries(before time.Time) error {
+	_, err := d.exec("delete from project__integration_delivery where created<?", before)
+	return err
+}
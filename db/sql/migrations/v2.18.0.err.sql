@@ -0,0 +1,5 @@
+drop table project__integration_delivery;
+
+alter table `project__integration` drop column `rate_limit`;
+alter table `project__integration` drop column `debounce_sec`;
+alter table `project__integration` drop column `cancel_queued`;
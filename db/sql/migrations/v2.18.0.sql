@@ -0,0 +1,18 @@
+alter table `project__integration` add `rate_limit` int not null default 0;
+alter table `project__integration` add `debounce_sec` int not null default 0;
+alter table `project__integration` add `cancel_queued` boolean not null default false;
+
+create table project__integration_delivery
+(
+    `id`             integer primary key autoincrement,
+    `project_id`     int          not null,
+    `integration_id` int          not null,
+    `created`        datetime     not null,
+    `status`         varchar(50)  not null,
+    `task_id`        int null,
+    `message`        varchar(1000) not null default '',
+
+    foreign key (`project_id`) references project (`id`) on delete cascade,
+    foreign key (`integration_id`) references project__integration (`id`) on delete cascade,
+    foreign key (`task_id`) references task (`id`) on delete set null
+);
@@ -0,0 +1,2 @@
+update `role` set `permissions` = `permissions` & 15;
+update `project__template_role` set `permissions` = `permissions` & 15;
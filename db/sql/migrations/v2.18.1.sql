@@ -0,0 +1,11 @@
+-- Grant fine-grained permissions implied by the legacy permission flags:
+-- view secrets (16) and view task output (512) for everyone,
+-- approve tasks (128) and stop others' tasks (256) for task runners (1),
+-- manage schedules (32) and manage integrations (64) for resource managers (4).
+update `role` set `permissions` = `permissions` | 384 where (`permissions` & 1) = 1;
+update `role` set `permissions` = `permissions` | 96 where (`permissions` & 4) = 4;
+update `role` set `permissions` = `permissions` | 528;
+
+update `project__template_role` set `permissions` = `permissions` | 384 where (`permissions` & 1) = 1;
+update `project__template_role` set `permissions` = `permissions` | 96 where (`permissions` & 4) = 4;
+update `project__template_role` set `permissions` = `permissions` | 528;
@@ -0,0 +1,2 @@
+alter table `project__template` drop column `artifacts`;
+drop table task__artifact;
@@ -0,0 +1,15 @@
+alter table `project__template` add `artifacts` varchar(2000) not null default '';
+
+create table task__artifact
+(
+    `id`          integer primary key autoincrement,
+    `project_id`  int           not null,
+    `task_id`     int           not null,
+    `name`        varchar(1000) not null,
+    `size`        bigint        not null default 0,
+    `checksum`    varchar(64)   not null default '',
+    `created`     datetime      not null,
+    `storage_key` varchar(1000) not null
+);
+
+create index task__artifact_task_id on task__artifact (`task_id`);
@@ -0,0 +1 @@
+drop table project__environment_version;
@@ -0,0 +1,20 @@
+create table project__environment_version
+(
+    `id`                integer primary key autoincrement,
+    `project_id`        int         not null,
+    `environment_id`    int         not null,
+    `build_template_id` int         not null,
+    `template_id`       int         not null,
+    `task_id`           int,
+    `build_task_id`     int,
+    `version`           varchar(20),
+    `deployed`          datetime    not null,
+
+    unique (`environment_id`, `build_template_id`),
+    foreign key (`project_id`) references project (`id`) on delete cascade,
+    foreign key (`environment_id`) references project__environment (`id`) on delete cascade,
+    foreign key (`build_template_id`) references project__template (`id`) on delete cascade,
+    foreign key (`template_id`) references project__template (`id`) on delete cascade,
+    foreign key (`task_id`) references task (`id`) on delete set null,
+    foreign key (`build_task_id`) references task (`id`) on delete set null
+);
@@ -0,0 +1,2 @@
+alter table `project` drop column `container_image`;
+alter table `project__template` drop column `container_image`;
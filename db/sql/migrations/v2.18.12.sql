@@ -0,0 +1,2 @@
+alter table `project` add `container_image` varchar(500) not null default '';
+alter table `project__template` add `container_image` varchar(500) not null default '';
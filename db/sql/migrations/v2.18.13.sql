@@ -0,0 +1 @@
+alter table `task` add `has_secret` boolean not null default false;
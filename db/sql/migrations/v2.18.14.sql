@@ -0,0 +1 @@
+alter table `user` add `ldap` boolean not null default false;
@@ -0,0 +1,4 @@
+drop table task__approval;
+
+alter table `project__template` drop column `required_approvals`;
+alter table `project__template` drop column `approval_timeout`;
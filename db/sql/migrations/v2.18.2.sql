@@ -0,0 +1,17 @@
+alter table `project__template` add `required_approvals` int not null default 0;
+alter table `project__template` add `approval_timeout` int not null default 0;
+
+create table task__approval
+(
+    `id`         integer primary key autoincrement,
+    `task_id`    int           not null,
+    `project_id` int           not null,
+    `user_id`    int           not null,
+    `approved`   boolean       not null,
+    `comment`    varchar(1000) not null default '',
+    `created`    datetime      not null,
+
+    foreign key (`task_id`) references task (`id`) on delete cascade,
+    foreign key (`project_id`) references project (`id`) on delete cascade,
+    foreign key (`user_id`) references `user` (`id`) on delete cascade
+);
@@ -0,0 +1,6 @@
+alter table `user__token` drop column `name`;
+alter table `user__token` drop column `expires_at`;
+alter table `user__token` drop column `project_id`;
+alter table `user__token` drop column `scopes`;
+alter table `user__token` drop column `last_used`;
+alter table `user__token` drop column `last_used_ip`;
@@ -0,0 +1,6 @@
+alter table `user__token` add `name` varchar(255) not null default '';
+alter table `user__token` add `expires_at` datetime null;
+alter table `user__token` add `project_id` int null;
+alter table `user__token` add `scopes` varchar(255) not null default '';
+alter table `user__token` add `last_used` datetime null;
+alter table `user__token` add `last_used_ip` varchar(64) not null default '';
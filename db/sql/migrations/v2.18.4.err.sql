@@ -0,0 +1,6 @@
+alter table `session` drop column `oidc_provider`;
+alter table `session` drop column `oidc_subject`;
+alter table `session` drop column `oidc_session_id`;
+alter table `session` drop column `oidc_id_token`;
+alter table `session` drop column `oidc_refresh_token`;
+alter table `session` drop column `oidc_token_expiry`;
@@ -0,0 +1,6 @@
+alter table `session` add `oidc_provider` varchar(255) not null default '';
+alter table `session` add `oidc_subject` varchar(255) not null default '';
+alter table `session` add `oidc_session_id` varchar(255) not null default '';
+alter table `session` add `oidc_id_token` text null;
+alter table `session` add `oidc_refresh_token` text null;
+alter table `session` add `oidc_token_expiry` datetime null;
@@ -0,0 +1 @@
+drop table project__gitops;
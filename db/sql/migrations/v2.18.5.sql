@@ -0,0 +1,14 @@
+create table project__gitops
+(
+    `project_id`    int           not null primary key,
+    `repository_id` int           not null,
+    `path`          varchar(1000) not null,
+    `lock_managed`  boolean       not null default false,
+    `last_commit`   varchar(64),
+    `last_sync`     datetime,
+    `last_error`    text,
+    `drift`         text,
+
+    foreign key (`project_id`) references project (`id`) on delete cascade,
+    foreign key (`repository_id`) references project__repository (`id`) on delete cascade
+);
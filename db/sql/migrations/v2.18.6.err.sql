@@ -0,0 +1,4 @@
+drop table runner__health;
+
+alter table `runner` drop column `health`;
+alter table `project__template` drop column `runner_failure_policy`;
@@ -0,0 +1,13 @@
+alter table `runner` add `health` varchar(20) not null default '';
+alter table `project__template` add `runner_failure_policy` varchar(20) not null default '';
+
+create table runner__health
+(
+    `id`        integer primary key autoincrement,
+    `runner_id` int           not null,
+    `created`   datetime      not null,
+    `health`    varchar(20)   not null,
+    `message`   varchar(1000) not null default '',
+
+    foreign key (`runner_id`) references runner (`id`) on delete cascade
+);
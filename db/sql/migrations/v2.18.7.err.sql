@@ -0,0 +1,3 @@
+alter table `runner` drop column `capabilities`;
+alter table `project__template` drop column `runner_selector`;
+alter table `project__template` drop column `runner_affinity`;
@@ -0,0 +1,3 @@
+alter table `runner` add `capabilities` text;
+alter table `project__template` add `runner_selector` varchar(1000) not null default '';
+alter table `project__template` add `runner_affinity` boolean not null default false;
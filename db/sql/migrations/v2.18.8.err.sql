@@ -0,0 +1 @@
+alter table `runner` drop column `ephemeral`;
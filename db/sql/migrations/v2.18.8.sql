@@ -0,0 +1 @@
+alter table `runner` add `ephemeral` boolean not null default false;
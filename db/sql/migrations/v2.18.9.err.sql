@@ -0,0 +1,2 @@
+alter table `runner` drop column `labels`;
+drop table runner__registration_token;
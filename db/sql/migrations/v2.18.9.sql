@@ -0,0 +1,19 @@
+alter table `runner` add `labels` text;
+
+create table runner__registration_token
+(
+    `id`         integer primary key autoincrement,
+    `project_id` int,
+    `name`       varchar(255) not null,
+    `token`      varchar(255) not null,
+    `created`    datetime     not null,
+    `expires`    datetime,
+    `max_uses`   int          not null default 0,
+    `uses`       int          not null default 0,
+    `tag`        varchar(255) not null default '',
+    `labels`     text,
+    `revoked`    boolean      not null default false,
+
+    unique (`token`),
+    foreign key (`project_id`) references project (`id`) on delete cascade
+);
@@ -7,12 +7,16 @@ import (
 )
 
 func (d *SqlDb) CreateProject(project db.Project) (newProject db.Project, err error) {
+	if err = project.Validate(); err != nil {
+		return
+	}
+
 	project.Created = tz.Now()
 
 	insertId, err := d.insert(
 		"id",
-		"insert into project(name, created, type, alert, alert_chat, max_parallel_tasks) values (?, ?, ?, ?, ?, ?)",
-		project.Name, project.Created, project.Type, project.Alert, project.AlertChat, project.MaxParallelTasks)
+		"insert into project(name, created, type, alert, alert_chat, max_parallel_tasks, container_image) values (?, ?, ?, ?, ?, ?, ?)",
+		project.Name, project.Created, project.Type, project.Alert, project.AlertChat, project.MaxParallelTasks, project.ContainerImage)
 
 	if err != nil {
 		return
@@ -110,12 +114,17 @@ func (d *SqlDb) DeleteProject(projectID int) error {
 }
 
 func (d *SqlDb) UpdateProject(project db.Project) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
 	_, err := d.exec(
-		"update project set name=?, alert=?, alert_chat=?, max_parallel_tasks=? where id=?",
+		"update project set name=?, alert=?, alert_chat=?, max_parallel_tasks=?, container_image=? where id=?",
 		project.Name,
 		project.Alert,
 		project.AlertChat,
 		project.MaxParallelTasks,
+		project.ContainerImage,
 		project.ID)
 	return err
 }
@@ -0,0 +1,81 @@
+package sql
+
+import (
+	"errors"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+func (d *SqlDb) GetProjectGitops(projectID int) (gitops db.ProjectGitops, err error) {
+	err = d.selectOne(&gitops, "select * from project__gitops where project_id=?", projectID)
+	return
+}
+
+func (d *SqlDb) GetAllProjectGitops() (res []db.ProjectGitops, err error) {
+	res = make([]db.ProjectGitops, 0)
+	_, err = d.selectAll(&res, "select * from project__gitops order by project_id")
+	return
+}
+
+func (d *SqlDb) SetProjectGitops(gitops db.ProjectGitops) (res db.ProjectGitops, err error) {
+	if err = gitops.Validate(); err != nil {
+		return
+	}
+
+	existing, err := d.GetProjectGitops(gitops.ProjectID)
+
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		_, err = d.insert(
+			"", // project_id is not auto-generated
+			"insert into project__gitops (project_id, repository_id, `path`, lock_managed) values (?, ?, ?, ?)",
+			gitops.ProjectID,
+			gitops.RepositoryID,
+			gitops.Path,
+			gitops.LockManaged)
+		if err != nil {
+			return
+		}
+		res = gitops
+		res.LastCommit = nil
+		res.LastSync = nil
+		res.LastError = nil
+		res.Drift = nil
+	case err == nil:
+		// The new file must be applied regardless of the last applied commit.
+		if existing.RepositoryID != gitops.RepositoryID || existing.Path != gitops.Path {
+			existing.LastCommit = nil
+		}
+
+		_, err = d.exec(
+			"update project__gitops set repository_id=?, `path`=?, lock_managed=?, last_commit=? where project_id=?",
+			gitops.RepositoryID,
+			gitops.Path,
+			gitops.LockManaged,
+			existing.LastCommit,
+			gitops.ProjectID)
+		if err != nil {
+			return
+		}
+		res = existing
+		res.RepositoryID = gitops.RepositoryID
+		res.Path = gitops.Path
+		res.LockManaged = gitops.LockManaged
+	}
+
+	return
+}
+
+func (d *SqlDb) UpdateProjectGitopsStatus(gitops db.ProjectGitops) error {
+	return validateMutationResult(d.exec(
+		"update project__gitops set last_commit=?, last_sync=?, last_error=?, drift=? where project_id=?",
+		gitops.LastCommit,
+		gitops.LastSync,
+		gitops.LastError,
+		gitops.Drift,
+		gitops.ProjectID))
+}
+
+func (d *SqlDb) DeleteProjectGitops(projectID int) error {
+	return validateMutationResult(d.exec("delete from project__gitops where project_id=?", projectID))
+}
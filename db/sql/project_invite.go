@@ -44,7 +44,7 @@ func (d *SqlDb) GetProjectInvites(projectID int, params db.RetrieveQueryParams)
 		return
 	}
 
-	rows, err := d.Sql().Query(d.PrepareQuery(query), args...)
+	rows, err := d.executor().Query(d.PrepareQuery(query), args...)
 	if err != nil {
 		return
 	}
@@ -21,6 +21,10 @@ func (d *SqlDb) GetGlobalRoles() ([]db.Role, error) {
 }
 
 func (d *SqlDb) UpdateRole(role db.Role) error {
+	if err := db.ValidateRole(role); err != nil {
+		return err
+	}
+
 	_, err := d.exec(
 		"update `role` set name=?, permissions=? where slug=?",
 		role.Name,
@@ -30,6 +34,10 @@ func (d *SqlDb) UpdateRole(role db.Role) error {
 }
 
 func (d *SqlDb) CreateRole(role db.Role) (db.Role, error) {
+	if err := db.ValidateRole(role); err != nil {
+		return db.Role{}, err
+	}
+
 	_, err := d.insert(
 		"",
 		"insert into `role` (slug, name, permissions, project_id) values (?, ?, ?, ?)",
@@ -64,7 +64,7 @@ func (d *SqlDb) GetRunnerCount() (res int, err error) {
 		return
 	}
 
-	cnt, err := d.Sql().SelectInt(query, args...)
+	cnt, err := d.executor().SelectInt(query, args...)
 
 	res = int(cnt)
 
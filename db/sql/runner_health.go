@@ -0,0 +1,64 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func (d *SqlDb) SetRunnerHealth(runner db.Runner, health db.RunnerHealth, message string) (record db.RunnerHealthRecord, err error) {
+	tx, err := d.Sql().Begin()
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			handleRollbackError(tx.Rollback())
+		}
+	}()
+
+	_, err = d.execTx(tx, "update `runner` set `health`=? where id=?", health, runner.ID)
+	if err != nil {
+		return
+	}
+
+	record = db.RunnerHealthRecord{
+		RunnerID: runner.ID,
+		Created:  tz.Now(),
+		Health:   health,
+		Message:  message,
+	}
+
+	record.ID, err = d.insertTx(
+		tx,
+		"id",
+		"insert into runner__health (runner_id, created, health, message) values (?, ?, ?, ?)",
+		record.RunnerID,
+		record.Created,
+		record.Health,
+		record.Message)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (d *SqlDb) GetRunnerHealthHistory(runnerID int, params db.RetrieveQueryParams) (records []db.RunnerHealthRecord, err error) {
+	records = make([]db.RunnerHealthRecord, 0)
+
+	err = d.getObjects(0, db.RunnerHealthProps, params, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.runner_id=?", runnerID)
+	}, &records)
+
+	return
+}
+
+func (d *SqlDb) DeleteRunnerHealthHistory(before time.Time) error {
+	_, err := d.exec("delete from runner__health where created<?", before)
+	return err
+}
@@ -0,0 +1,124 @@
+package sql
+
+import (
+	"database/sql"
+	"encoding/base64"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/gorilla/securecookie"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func (d *SqlDb) GetRunnerRegistrationTokens() (tokens []db.RunnerRegistrationToken, err error) {
+	tokens = make([]db.RunnerRegistrationToken, 0)
+	err = d.getObjects(0, db.RunnerRegistrationTokenProps, db.RetrieveQueryParams{}, nil, &tokens)
+	return
+}
+
+func (d *SqlDb) GetRunnerRegistrationToken(tokenID int) (token db.RunnerRegistrationToken, err error) {
+	err = d.getObject(0, db.RunnerRegistrationTokenProps, tokenID, &token)
+	return
+}
+
+func (d *SqlDb) CreateRunnerRegistrationToken(token db.RunnerRegistrationToken) (newToken db.RunnerRegistrationToken, err error) {
+	err = token.Validate()
+	if err != nil {
+		return
+	}
+
+	// Restored tokens keep their values.
+	if token.Token == "" {
+		token.Token = base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
+	if token.Created.IsZero() {
+		token.Created = tz.Now()
+	}
+
+	insertID, err := d.insert(
+		"id",
+		"insert into runner__registration_token (project_id, `name`, token, created, expires, max_uses, uses, tag, labels, revoked) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		token.ProjectID,
+		token.Name,
+		token.Token,
+		token.Created,
+		token.Expires,
+		token.MaxUses,
+		token.Uses,
+		token.Tag,
+		token.Labels,
+		token.Revoked)
+
+	if err != nil {
+		return
+	}
+
+	newToken = token
+	newToken.ID = insertID
+	return
+}
+
+func (d *SqlDb) RevokeRunnerRegistrationToken(tokenID int) error {
+	if _, err := d.GetRunnerRegistrationToken(tokenID); err != nil {
+		return err
+	}
+
+	_, err := d.exec("update runner__registration_token set revoked=? where id=?", true, tokenID)
+	return err
+}
+
+func (d *SqlDb) DeleteRunnerRegistrationToken(tokenID int) error {
+	return d.deleteObject(0, db.RunnerRegistrationTokenProps, tokenID)
+}
+
+func (d *SqlDb) UseRunnerRegistrationToken(token string) (res db.RunnerRegistrationToken, err error) {
+	var tokens []db.RunnerRegistrationToken
+
+	err = d.getObjects(0, db.RunnerRegistrationTokenProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.token=?", token)
+	}, &tokens)
+
+	if err != nil {
+		return
+	}
+
+	if len(tokens) == 0 || !tokens[0].IsUsable(tz.Now()) {
+		err = db.ErrNotFound
+		return
+	}
+
+	res = tokens[0]
+
+	// The condition prevents concurrent registrations from exceeding the limit.
+	result, err := d.exec(
+		"update runner__registration_token set uses=uses+1 where id=? and revoked=? and (max_uses=0 or uses<max_uses)",
+		res.ID,
+		false)
+
+	err = checkAffected(result, err)
+	if err != nil {
+		return
+	}
+
+	res.Uses++
+	return
+}
+
+// checkAffected returns ErrNotFound if the statement has not changed any row.
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return db.ErrNotFound
+	}
+
+	return nil
+}
@@ -10,7 +10,7 @@ func (d *SqlDb) CreateSchedule(schedule db.Schedule) (newSchedule db.Schedule, e
 	if schedule.TaskParamsID != nil {
 		params := schedule.TaskParams
 		params.ProjectID = schedule.ProjectID
-		err = d.Sql().Insert(&params)
+		err = d.executor().Insert(&params)
 		if err != nil {
 			return
 		}
@@ -62,10 +62,10 @@ func (d *SqlDb) UpdateSchedule(schedule db.Schedule) (err error) {
 		params.ProjectID = schedule.ProjectID
 
 		if curr.TaskParamsID == nil {
-			err = d.Sql().Insert(&params)
+			err = d.executor().Insert(&params)
 		} else {
 			params.ID = *curr.TaskParamsID
-			_, err = d.Sql().Update(&params)
+			_, err = d.executor().Update(&params)
 		}
 
 		if err != nil {
@@ -6,6 +6,7 @@ import (
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/tz"
 	"regexp"
+	"time"
 )
 
 func (d *SqlDb) SetSessionVerificationMethod(userID int, sessionID int, verificationMethod db.SessionVerificationMethod) error {
@@ -19,13 +20,18 @@ func (d *SqlDb) VerifySession(userID int, sessionID int) error {
 }
 
 func (d *SqlDb) CreateSession(session db.Session) (db.Session, error) {
-	err := d.Sql().Insert(&session)
+	err := d.executor().Insert(&session)
 	return session, err
 }
 
 func (d *SqlDb) CreateAPIToken(token db.APIToken) (db.APIToken, error) {
 	token.Created = db.GetParsedTime(tz.Now())
-	err := d.Sql().Insert(&token)
+
+	if err := token.Validate(); err != nil {
+		return db.APIToken{}, err
+	}
+
+	err := d.executor().Insert(&token)
 	return token, err
 }
 
@@ -35,6 +41,12 @@ func (d *SqlDb) GetAPIToken(tokenID string) (token db.APIToken, err error) {
 	return
 }
 
+func (d *SqlDb) TouchAPIToken(userID int, tokenID string, ip string) error {
+	_, err := d.exec("update user__token set last_used=?, last_used_ip=? where id=? and user_id=?", tz.Now(), ip, tokenID, userID)
+
+	return err
+}
+
 func (d *SqlDb) ExpireAPIToken(userID int, tokenID string) error {
 	return validateMutationResult(d.exec("update user__token set expired=true where id=? and user_id=?", tokenID, userID))
 }
@@ -70,6 +82,24 @@ func (d *SqlDb) ExpireSession(userID int, sessionID int) error {
 	return validateMutationResult(res, err)
 }
 
+func (d *SqlDb) UpdateSessionOidcToken(userID int, sessionID int, refreshToken string, expiry *time.Time) error {
+	_, err := d.exec(
+		"update session set oidc_refresh_token=?, oidc_token_expiry=? where id=? and user_id=?",
+		refreshToken, expiry, sessionID, userID)
+
+	return err
+}
+
+func (d *SqlDb) ExpireOidcSessions(provider string, subject string, sid string) (err error) {
+	if sid != "" {
+		_, err = d.exec("update session set expired=true where oidc_provider=? and oidc_session_id=?", provider, sid)
+	} else {
+		_, err = d.exec("update session set expired=true where oidc_provider=? and oidc_subject=?", provider, subject)
+	}
+
+	return
+}
+
 func (d *SqlDb) TouchSession(userID int, sessionID int) error {
 	_, err := d.exec("update session set last_active=? where id=? and user_id=?", tz.Now(), sessionID, userID)
 
@@ -127,7 +127,7 @@ func (d *SqlDb) clearTasks(projectID int, templateID int, maxTasks int) {
 
 	if rand.Intn(10) == 0 { // randomly recalculate number of tasks for the template
 		var n int64
-		n, err = d.Sql().SelectInt("SELECT count(*) FROM task WHERE template_id=?", templateID)
+		n, err = d.executor().SelectInt("SELECT count(*) FROM task WHERE template_id=?", templateID)
 		if err != nil {
 			return
 		}
@@ -167,7 +167,7 @@ func (d *SqlDb) clearTasks(projectID int, templateID int, maxTasks int) {
 }
 
 func (d *SqlDb) CreateTask(task db.Task, maxTasks int) (newTask db.Task, err error) {
-	err = d.Sql().Insert(&task)
+	err = d.executor().Insert(&task)
 	newTask = task
 
 	if err != nil {
@@ -0,0 +1,45 @@
+package sql
+
+import (
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+)
+
+func (d *SqlDb) CreateTaskApproval(approval db.TaskApproval) (newApproval db.TaskApproval, err error) {
+	approval.Created = tz.Now()
+
+	insertID, err := d.insert(
+		"id",
+		"insert into task__approval "+
+			"(task_id, project_id, user_id, approved, comment, created) values "+
+			"(?, ?, ?, ?, ?, ?)",
+		approval.TaskID,
+		approval.ProjectID,
+		approval.UserID,
+		approval.Approved,
+		approval.Comment,
+		approval.Created)
+
+	if err != nil {
+		return
+	}
+
+	newApproval = approval
+	newApproval.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTaskApprovals(projectID int, taskID int) (approvals []db.TaskApproval, err error) {
+	if err = d.validateTask(projectID, taskID); err != nil {
+		return
+	}
+
+	approvals = make([]db.TaskApproval, 0)
+
+	err = d.getObjects(projectID, db.TaskApprovalProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.task_id=?", taskID)
+	}, &approvals)
+
+	return
+}
@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// taskArtifactProps is used to get artifacts by the project, because artifacts
+// are global only in BoltDB.
+var taskArtifactProps = makePropsNonGlobal(db.TaskArtifactProps)
+
+func (d *SqlDb) CreateTaskArtifact(artifact db.TaskArtifact) (res db.TaskArtifact, err error) {
+	insertID, err := d.insert(
+		"id",
+		"insert into task__artifact (project_id, task_id, `name`, `size`, checksum, created, storage_key) values (?, ?, ?, ?, ?, ?, ?)",
+		artifact.ProjectID,
+		artifact.TaskID,
+		artifact.Name,
+		artifact.Size,
+		artifact.Checksum,
+		artifact.Created,
+		artifact.StorageKey)
+
+	if err != nil {
+		return
+	}
+
+	res = artifact
+	res.ID = insertID
+	return
+}
+
+func (d *SqlDb) GetTaskArtifacts(projectID int, taskID int) (artifacts []db.TaskArtifact, err error) {
+	artifacts = make([]db.TaskArtifact, 0)
+
+	err = d.getObjects(0, db.TaskArtifactProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return q.Where("pe.project_id=? and pe.task_id=?", projectID, taskID)
+	}, &artifacts)
+
+	return
+}
+
+func (d *SqlDb) GetTaskArtifact(projectID int, taskID int, artifactID int) (artifact db.TaskArtifact, err error) {
+	err = d.getObject(projectID, taskArtifactProps, artifactID, &artifact)
+	if err != nil {
+		return
+	}
+
+	if artifact.TaskID != taskID {
+		err = db.ErrNotFound
+	}
+
+	return
+}
+
+func (d *SqlDb) DeleteTaskArtifact(artifactID int) error {
+	return d.deleteObject(0, db.TaskArtifactProps, artifactID)
+}
+
+func (d *SqlDb) GetStaleTaskArtifacts(createdBefore *time.Time) (artifacts []db.TaskArtifact, err error) {
+	artifacts = make([]db.TaskArtifact, 0)
+
+	err = d.getObjects(0, db.TaskArtifactProps, db.RetrieveQueryParams{}, func(q squirrel.SelectBuilder) squirrel.SelectBuilder {
+		if createdBefore != nil {
+			return q.Where("(pe.task_id not in (select id from task) or pe.created < ?)", *createdBefore)
+		}
+
+		return q.Where("pe.task_id not in (select id from task)")
+	}, &artifacts)
+
+	return
+}
@@ -23,13 +23,15 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 			"playbook, arguments, allow_override_args_in_task, description, `type`, "+
 			"start_version, build_template_id, view_id, autorun, survey_vars, "+
 			"suppress_success_alerts, app, git_branch, runner_tag, task_params, "+
-			"allow_override_branch_in_task, allow_parallel_tasks)"+
+			"allow_override_branch_in_task, allow_parallel_tasks, required_approvals, approval_timeout, runner_failure_policy, "+
+			"runner_selector, runner_affinity, artifacts, container_image)"+
 			"values ("+
 			"?, ?, ?, ?, ?, "+
 			"?, ?, ?, ?, ?, "+
 			"?, ?, ?, ?, ?, "+
 			"?, ?, ?, ?, ?,"+
-			"?, ?)",
+			"?, ?, ?, ?, ?,"+
+			"?, ?, ?, ?)",
 		template.ProjectID,
 		template.InventoryID,
 		template.RepositoryID,
@@ -56,6 +58,14 @@ func (d *SqlDb) CreateTemplate(template db.Template) (newTemplate db.Template, e
 
 		template.AllowOverrideBranchInTask,
 		template.AllowParallelTasks,
+		template.RequiredApprovals,
+		template.ApprovalTimeout,
+		template.RunnerFailurePolicy,
+
+		template.RunnerSelector,
+		template.RunnerAffinity,
+		template.Artifacts,
+		template.ContainerImage,
 	)
 
 	if err != nil {
@@ -107,7 +117,14 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 		"task_params=?, "+
 		"runner_tag=?, "+
 		"allow_override_branch_in_task=?, "+
-		"allow_parallel_tasks=? "+
+		"allow_parallel_tasks=?, "+
+		"required_approvals=?, "+
+		"approval_timeout=?, "+
+		"runner_failure_policy=?, "+
+		"runner_selector=?, "+
+		"runner_affinity=?, "+
+		"artifacts=?, "+
+		"container_image=? "+
 		"where id=? and project_id=?",
 		template.InventoryID,
 		template.RepositoryID,
@@ -130,6 +147,13 @@ func (d *SqlDb) UpdateTemplate(template db.Template) error {
 		template.RunnerTag,
 		template.AllowOverrideBranchInTask,
 		template.AllowParallelTasks,
+		template.RequiredApprovals,
+		template.ApprovalTimeout,
+		template.RunnerFailurePolicy,
+		template.RunnerSelector,
+		template.RunnerAffinity,
+		template.Artifacts,
+		template.ContainerImage,
 
 		template.ID,
 		template.ProjectID,
@@ -201,6 +225,8 @@ func (d *SqlDb) getTemplates(projectID int, userID *int, filter db.TemplateFilte
 		"pt.task_params",
 		"pt.allow_override_branch_in_task",
 		"pt.allow_parallel_tasks",
+		"pt.required_approvals",
+		"pt.approval_timeout",
 		"(SELECT `id` FROM `task` WHERE template_id = pt.id ORDER BY `id` DESC LIMIT 1) last_task_id",
 	}
 
@@ -464,6 +490,11 @@ func (d *SqlDb) GetTemplateRoles(projectID int, templateID int) (roles []db.Temp
 	return
 }
 func (d *SqlDb) CreateTemplateRole(role db.TemplateRolePerm) (newRole db.TemplateRolePerm, err error) {
+	err = role.Validate()
+	if err != nil {
+		return
+	}
+
 	insertID, err := d.insert(
 		"id",
 		"insert into project__template_role (project_id, template_id, role_slug, permissions) values (?, ?, ?, ?)",
@@ -485,6 +516,10 @@ func (d *SqlDb) DeleteTemplateRole(projectID int, templateID int, id int) error
 	return err
 }
 func (d *SqlDb) UpdateTemplateRole(role db.TemplateRolePerm) error {
+	if err := role.Validate(); err != nil {
+		return err
+	}
+
 	_, err := d.exec(
 		"update project__template_role set permissions=? "+
 			"where project_id=? and template_id=? and id=?",
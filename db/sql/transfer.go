@@ -0,0 +1,264 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp/v3"
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// transferBatchSize is the number of objects read by a single query during export.
+const transferBatchSize = 1000
+
+// getFieldIndexes maps db tags of the type to indexes of the fields.
+// Fields of embedded structs without tag are included.
+func getFieldIndexes(t reflect.Type, prefix []int, res map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		index := append(slices.Clone(prefix), i)
+		tag := f.Tag.Get("db")
+
+		switch {
+		case tag == "-":
+		case tag != "":
+			res[tag] = index
+		case f.Anonymous && f.Type.Kind() == reflect.Struct:
+			getFieldIndexes(f.Type, index, res)
+		}
+	}
+}
+
+// getTransferColumns returns columns of the table which have fields
+// in the type of the entity and indexes of these fields.
+func (d *SqlDb) getTransferColumns(props db.ObjectProps) (columns []string, fields map[string][]int, err error) {
+	rows, err := d.Sql().Db.Query(d.PrepareQuery("select * from `" + props.TableName + "` limit 0"))
+	if err != nil {
+		return
+	}
+	defer rows.Close() //nolint:errcheck
+
+	tableColumns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+
+	fields = make(map[string][]int)
+	getFieldIndexes(props.Type, nil, fields)
+
+	for _, c := range tableColumns {
+		if _, ok := fields[c]; ok {
+			columns = append(columns, c)
+		}
+	}
+
+	if len(columns) == 0 {
+		err = fmt.Errorf("table %s has no columns of %s", props.TableName, props.Type.Name())
+	}
+
+	return
+}
+
+func quoteColumns(columns []string) []string {
+	res := make([]string, len(columns))
+	for i, c := range columns {
+		res[i] = "`" + c + "`"
+	}
+	return res
+}
+
+// getTransferValue converts the field to the value which can be passed to the driver.
+func getTransferValue(v reflect.Value) (any, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	// Some types implement driver.Valuer by the pointer receiver.
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	if valuer, ok := ptr.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC(), nil
+	}
+
+	return v.Interface(), nil
+}
+
+func (d *SqlDb) ExportObjects(props db.ObjectProps, fn func(bucketID int, object any) error) error {
+	columns, fields, err := d.getTransferColumns(props)
+	if err != nil {
+		return err
+	}
+
+	// Objects with integer ID are read in pages by ID, other tables
+	// are read by offset in the order of all columns.
+	byID := false
+	if slices.Contains(columns, "id") {
+		switch props.Type.FieldByIndex(fields["id"]).Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			byID = true
+		}
+	}
+
+	query := "select " + strings.Join(quoteColumns(columns), ", ") + " from `" + props.TableName + "`"
+
+	if byID {
+		query += " where `id` > ? order by `id` limit ?"
+	} else {
+		query += " order by " + strings.Join(quoteColumns(columns), ", ") + " limit ? offset ?"
+	}
+
+	var lastID int64
+	offset := 0
+
+	for {
+		args := []any{lastID, transferBatchSize}
+		if !byID {
+			args = []any{transferBatchSize, offset}
+		}
+
+		objects := reflect.New(reflect.SliceOf(props.Type))
+
+		if _, err = d.selectAll(objects.Interface(), query, args...); err != nil {
+			return err
+		}
+
+		n := objects.Elem().Len()
+
+		for i := 0; i < n; i++ {
+			obj := objects.Elem().Index(i)
+
+			if byID {
+				lastID = obj.FieldByIndex(fields["id"]).Int()
+			}
+
+			if err = fn(0, obj.Interface()); err != nil {
+				return err
+			}
+		}
+
+		if n < transferBatchSize {
+			return nil
+		}
+
+		offset += n
+	}
+}
+
+func (d *SqlDb) CountObjects(props db.ObjectProps) (int, error) {
+	count, err := d.executor().SelectInt(d.PrepareQuery("select count(*) from `" + props.TableName + "`"))
+	return int(count), err
+}
+
+func (d *SqlDb) ImportObjects(props db.ObjectProps, objects []any) (err error) {
+	if len(objects) == 0 {
+		return
+	}
+
+	columns, fields, err := d.getTransferColumns(props)
+	if err != nil {
+		return
+	}
+
+	tx, err := d.Sql().Begin()
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			handleRollbackError(tx.Rollback())
+		}
+	}()
+
+	for _, obj := range objects {
+		v := reflect.ValueOf(obj)
+
+		var insertColumns []string
+		var args []any
+
+		for _, c := range columns {
+			f := v.FieldByIndex(fields[c])
+
+			// The object without ID gets the new one.
+			if c == "id" && f.IsZero() {
+				continue
+			}
+
+			var value any
+			value, err = getTransferValue(f)
+			if err != nil {
+				return
+			}
+
+			insertColumns = append(insertColumns, c)
+			args = append(args, value)
+		}
+
+		query := "insert into `" + props.TableName + "` (" + strings.Join(quoteColumns(insertColumns), ", ") +
+			") values (" + strings.TrimSuffix(strings.Repeat("?, ", len(insertColumns)), ", ") + ")"
+
+		if _, err = d.execTx(tx, query, formatArgs(args)...); err != nil {
+			return
+		}
+	}
+
+	// Postgres does not move the sequence when the ID is specified explicitly.
+	if _, ok := d.Sql().Dialect.(gorp.PostgresDialect); ok && slices.Contains(columns, "id") {
+		_, err = tx.Exec("select setval(pg_get_serial_sequence('\"" + props.TableName + "\"', 'id'), " +
+			"(select coalesce(max(\"id\"), 1) from \"" + props.TableName + "\"))")
+		if err != nil {
+			return
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (d *SqlDb) UpdateObjectColumns(props db.ObjectProps, objectID any, columns map[string]any) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	primaryColumnName := props.PrimaryColumnName
+	if primaryColumnName == "" {
+		primaryColumnName = "id"
+	}
+
+	names := make([]string, 0, len(columns))
+	for c := range columns {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+
+	sets := make([]string, len(names))
+	args := make([]any, 0, len(names)+1)
+
+	for i, c := range names {
+		sets[i] = "`" + c + "` = ?"
+		args = append(args, columns[c])
+	}
+
+	args = append(args, objectID)
+
+	_, err := d.exec(
+		"update `"+props.TableName+"` set "+strings.Join(sets, ", ")+" where `"+primaryColumnName+"` = ?",
+		formatArgs(args)...)
+
+	return err
+}
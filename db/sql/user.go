@@ -19,7 +19,7 @@ func (d *SqlDb) CreateUserWithoutPassword(user db.User) (newUser db.User, err er
 	user.Password = ""
 	user.Created = db.GetParsedTime(tz.Now())
 
-	err = d.Sql().Insert(&user)
+	err = d.executor().Insert(&user)
 
 	if err != nil {
 		return
@@ -45,7 +45,7 @@ func (d *SqlDb) CreateUser(user db.UserWithPwd) (newUser db.User, err error) {
 	user.Password = string(pwdHash)
 	user.Created = db.GetParsedTime(tz.Now())
 
-	err = d.Sql().Insert(&user.User)
+	err = d.executor().Insert(&user.User)
 
 	if err != nil {
 		return
@@ -55,6 +55,25 @@ func (d *SqlDb) CreateUser(user db.UserWithPwd) (newUser db.User, err error) {
 	return
 }
 
+func (d *SqlDb) ImportUser(user db.User) (newUser db.User, err error) {
+	err = db.ValidateUser(user)
+	if err != nil {
+		return
+	}
+
+	user.ID = 0
+	user.Created = db.GetParsedTime(user.Created)
+
+	err = d.executor().Insert(&user)
+
+	if err != nil {
+		return
+	}
+
+	newUser = user
+	return
+}
+
 func (d *SqlDb) DeleteUser(userID int) error {
 	res, err := d.exec("delete from `user` where id=?", userID)
 	return validateMutationResult(res, err)
@@ -105,6 +124,11 @@ func (d *SqlDb) SetUserPassword(userID int, password string) error {
 	return err
 }
 
+func (d *SqlDb) SetUserLdap(userID int) error {
+	res, err := d.exec("update `user` set ldap=true where id=?", userID)
+	return validateMutationResult(res, err)
+}
+
 func (d *SqlDb) CreateProjectUser(projectUser db.ProjectUser) (newProjectUser db.ProjectUser, err error) {
 	_, err = d.exec(
 		"insert into project__user (project_id, user_id, `role`) values (?, ?, ?)",
@@ -220,7 +244,7 @@ func (d *SqlDb) GetUser(userID int) (user db.User, err error) {
 
 func (d *SqlDb) GetProUserCount() (count int, err error) {
 
-	cnt, err := d.Sql().SelectInt(d.PrepareQuery("select count(*) from `user` where pro"))
+	cnt, err := d.executor().SelectInt(d.PrepareQuery("select count(*) from `user` where pro"))
 
 	count = int(cnt)
 
@@ -229,7 +253,7 @@ func (d *SqlDb) GetProUserCount() (count int, err error) {
 
 func (d *SqlDb) GetUserCount() (count int, err error) {
 
-	cnt, err := d.Sql().SelectInt(d.PrepareQuery("select count(*) from `user`"))
+	cnt, err := d.executor().SelectInt(d.PrepareQuery("select count(*) from `user`"))
 
 	count = int(cnt)
 
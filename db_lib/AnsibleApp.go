@@ -72,6 +72,10 @@ func (t *AnsibleApp) Log(msg string) {
 func (t *AnsibleApp) Clear() {
 }
 
+func (t *AnsibleApp) StopContainers() error {
+	return t.Playbook.Container.Stop()
+}
+
 func (t *AnsibleApp) InstallRequirements(args LocalAppInstallingArgs) error {
 	if err := t.installCollectionsRequirements(); err != nil {
 		return err
@@ -16,9 +16,12 @@ type AnsiblePlaybook struct {
 	TemplateID int
 	Repository db.Repository
 	Logger     task_logger.Logger
+	// Container is nil if the playbook runs on the host.
+	Container *Container
 }
 
-func (p AnsiblePlaybook) makeCmd(command string, args []string, environmentVars []string) *exec.Cmd {
+// makeCmd creates the command, tty must be true if it is started with a pseudo-terminal.
+func (p AnsiblePlaybook) makeCmd(command string, args []string, environmentVars []string, tty bool) *exec.Cmd {
 	cmd := exec.Command(command, args...) //nolint: gas
 	cmd.Dir = p.GetFullPath()
 
@@ -33,11 +36,11 @@ func (p AnsiblePlaybook) makeCmd(command string, args []string, environmentVars
 
 	cmd.SysProcAttr = util.Config.GetSysProcAttr()
 
-	return cmd
+	return p.Container.wrap(cmd, tty)
 }
 
 func (p AnsiblePlaybook) runCmd(command string, args []string) error {
-	cmd := p.makeCmd(command, args, nil)
+	cmd := p.makeCmd(command, args, nil, false)
 	p.Logger.LogCmd(cmd)
 	err := cmd.Run()
 	// Wait for all log processing to complete before returning
@@ -46,7 +49,7 @@ func (p AnsiblePlaybook) runCmd(command string, args []string) error {
 }
 
 func (p AnsiblePlaybook) RunPlaybook(args []string, environmentVars []string, inputs map[string]string, cb func(*os.Process)) error {
-	cmd := p.makeCmd("ansible-playbook", args, environmentVars)
+	cmd := p.makeCmd("ansible-playbook", args, environmentVars, true)
 	p.Logger.LogCmd(cmd)
 
 	ptmx, err := pty.Start(cmd)
@@ -6,6 +6,14 @@ import (
 )
 
 func CreateApp(template db.Template, repository db.Repository, inventory db.Inventory, logger task_logger.Logger) LocalApp {
+	container := NewContainer(template.ContainerImage, template.ProjectID)
+
+	// Local repositories are used in place, outside the project directory.
+	container.Mount(repository.GetFullPath(template.ID))
+	if inventory.Repository != nil {
+		container.Mount(inventory.Repository.GetFullPath(template.ID))
+	}
+
 	switch template.App {
 	case db.AppAnsible:
 		return &AnsibleApp{
@@ -16,6 +24,7 @@ func CreateApp(template db.Template, repository db.Repository, inventory db.Inve
 				TemplateID: template.ID,
 				Repository: repository,
 				Logger:     logger,
+				Container:  container,
 			},
 		}
 	case db.AppTerraform, db.AppTofu, db.AppTerragrunt:
@@ -25,6 +34,7 @@ func CreateApp(template db.Template, repository db.Repository, inventory db.Inve
 			Logger:     logger,
 			Name:       string(template.App),
 			Inventory:  inventory,
+			Container:  container,
 		}
 	default:
 		return &ShellApp{
@@ -32,6 +42,7 @@ func CreateApp(template db.Template, repository db.Repository, inventory db.Inve
 			Repository: repository,
 			Logger:     logger,
 			App:        template.App,
+			Container:  container,
 		}
 	}
 }
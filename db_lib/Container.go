@@ -0,0 +1,215 @@
+package db_lib
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// containerLabel marks containers of tasks, so they can be removed
+// when the task is stopped.
+const containerLabel = "io.semaphoreui.task-container"
+
+// containerEngineEnvVars are passed to the container engine CLI,
+// but not into the container.
+var containerEngineEnvVars = []string{
+	"DOCKER_HOST",
+	"DOCKER_CONTEXT",
+	"DOCKER_CONFIG",
+	"DOCKER_CERT_PATH",
+	"DOCKER_TLS_VERIFY",
+	"CONTAINER_HOST",
+	"CONTAINER_CONNECTION",
+	"XDG_RUNTIME_DIR",
+}
+
+// ContainerizedApp is implemented by apps which can run inside containers.
+type ContainerizedApp interface {
+	// StopContainers removes running containers of the app.
+	StopContainers() error
+}
+
+// Container runs commands of an app inside an OCI image with the Docker or Podman CLI.
+// The temporary directory of the project, which contains the repository, the inventory
+// and the keys, is mounted into the container at the same path, so paths passed
+// to the command are valid inside the container.
+type Container struct {
+	Image     string
+	ProjectID int
+	// label marks containers of the app.
+	label string
+	// dirs are host directories used by the app outside the project directory,
+	// like local repositories. They are mounted at the same paths.
+	dirs []string
+}
+
+// NewContainer returns nil if the image is empty, commands run on the host in this case.
+func NewContainer(image string, projectID int) *Container {
+	if image == "" {
+		return nil
+	}
+
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	return &Container{
+		Image:     image,
+		ProjectID: projectID,
+		label:     containerLabel + "=" + hex.EncodeToString(id),
+	}
+}
+
+// Mount makes the host directory available inside the container at the same path.
+// Directories inside the temporary directory of the project are already mounted.
+func (c *Container) Mount(dir string) {
+	if c == nil || dir == "" {
+		return
+	}
+
+	c.dirs = append(c.dirs, dir)
+}
+
+// isInsideDir returns true if the path is inside the directory.
+func isInsideDir(path string, dir string) bool {
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(dir)+string(filepath.Separator))
+}
+
+// wrap returns the command which runs cmd inside the container.
+// The environment of cmd is passed into the container by names,
+// so values of variables are not visible in arguments of the engine.
+// tty must be true if the command is started with a pseudo-terminal.
+func (c *Container) wrap(cmd *exec.Cmd, tty bool) *exec.Cmd {
+	if c == nil {
+		return cmd
+	}
+
+	conf := util.Config.ContainerExecution
+	projectDir := util.Config.GetProjectTmpDir(c.ProjectID)
+
+	args := []string{"run", "--rm", "--interactive", "--label", c.label, "--entrypoint", ""}
+
+	if tty {
+		args = append(args, "--tty")
+	}
+
+	if runtime.GOOS != "windows" {
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+
+	if conf != nil && conf.CPUs != "" {
+		args = append(args, "--cpus", conf.CPUs)
+	}
+
+	if conf != nil && conf.Memory != "" {
+		args = append(args, "--memory", conf.Memory)
+	}
+
+	if conf != nil && conf.Network != "" {
+		args = append(args, "--network", conf.Network)
+	}
+
+	args = append(args, "--volume", projectDir+":"+projectDir)
+
+	for _, dir := range c.dirs {
+		if !isInsideDir(dir, projectDir) {
+			args = append(args, "--volume", dir+":"+dir)
+		}
+	}
+
+	var engineEnv []string
+
+	for _, e := range cmd.Env {
+		name, value, _ := strings.Cut(e, "=")
+
+		switch name {
+		case "PATH":
+			// PATH of the image is used inside the container.
+			engineEnv = append(engineEnv, e)
+			continue
+		case "HOME":
+			// The engine uses HOME of the server for its own configuration.
+			args = append(args, "--env", e)
+			continue
+		case "SSH_AUTH_SOCK":
+			// The agent socket of global keys is outside the project directory.
+			if !isInsideDir(value, projectDir) {
+				args = append(args, "--volume", value+":"+value)
+			}
+		}
+
+		engineEnv = append(engineEnv, e)
+		args = append(args, "--env", name)
+	}
+
+	for _, name := range containerEngineEnvVars {
+		if v := os.Getenv(name); v != "" {
+			engineEnv = append(engineEnv, name+"="+v)
+		}
+	}
+
+	if home := os.Getenv("HOME"); home != "" {
+		engineEnv = append(engineEnv, "HOME="+home)
+	}
+
+	if cmd.Dir != "" {
+		args = append(args, "--workdir", cmd.Dir)
+	}
+
+	// "--" ends options of the engine, so the image can not be parsed as an option.
+	args = append(args, "--", c.Image)
+	args = append(args, cmd.Args...)
+
+	res := exec.Command(conf.GetEngine(), args...) //nolint: gas
+	res.Dir = cmd.Dir
+	res.Env = engineEnv
+	res.SysProcAttr = cmd.SysProcAttr
+
+	return res
+}
+
+// Stop removes running containers of the app, it is called when the task is stopped.
+func (c *Container) Stop() error {
+	if c == nil {
+		return nil
+	}
+
+	engine := util.Config.ContainerExecution.GetEngine()
+
+	out, err := exec.Command(engine, "ps", "--all", "--quiet", "--filter", "label="+c.label).Output() //nolint: gas
+	if err != nil {
+		return fmt.Errorf("can not list task containers: %w", err)
+	}
+
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err = exec.Command(engine, append([]string{"rm", "--force"}, ids...)...).Run(); err != nil { //nolint: gas
+		return fmt.Errorf("can not remove task containers: %w", err)
+	}
+
+	return nil
+}
+
+// CheckContainerExecution returns an error if the container image of the template
+// is invalid or the template requires a container, but container execution is not enabled.
+func CheckContainerExecution(template db.Template) error {
+	if err := db.ValidateContainerImage(template.ContainerImage); err != nil {
+		return err
+	}
+	if template.ContainerImage == "" || util.Config.ContainerExecution.IsEnabled() {
+		return nil
+	}
+	return errors.New("the template runs in the container image " + template.ContainerImage +
+		", but container execution is not enabled")
+}
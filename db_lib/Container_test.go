@@ -0,0 +1,173 @@
+package db_lib
+
+import (
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// containsArgs checks if args contain the sequence of arguments
+func containsArgs(args []string, seq []string) bool {
+	for i := 0; i+len(seq) <= len(args); i++ {
+		if slices.Equal(args[i:i+len(seq)], seq) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewContainer_EmptyImage(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: "/tmp/semaphore"}
+
+	container := NewContainer("", 1)
+	if container != nil {
+		t.Fatal("container must be nil for empty image")
+	}
+
+	cmd := exec.Command("ansible-playbook", "site.yml")
+	if container.wrap(cmd, false) != cmd {
+		t.Fatal("command must not be wrapped")
+	}
+}
+
+func TestContainer_Wrap(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp/semaphore",
+		ContainerExecution: &util.ContainerExecutionConfig{
+			Enabled: true,
+			Engine:  "podman",
+			CPUs:    "1.5",
+			Memory:  "512m",
+		},
+	}
+
+	container := NewContainer("alpine/ansible:2.18", 3)
+
+	cmd := exec.Command("ansible-playbook", "site.yml")
+	cmd.Dir = "/tmp/semaphore/project_3/repository_1_template_2"
+	cmd.Env = []string{
+		"PATH=/usr/bin",
+		"HOME=/tmp/semaphore/project_3",
+		"SEMAPHORE_SECRET=secret",
+		"SSH_AUTH_SOCK=/tmp/semaphore/ssh-agent-1.sock",
+	}
+
+	res := container.wrap(cmd, true)
+
+	if res.Args[0] != "podman" || res.Args[1] != "run" {
+		t.Fatal("command must be run by the engine", res.Args)
+	}
+
+	args := res.Args[2:]
+
+	expected := [][]string{
+		{"--cpus", "1.5"},
+		{"--memory", "512m"},
+		{"--volume", "/tmp/semaphore/project_3:/tmp/semaphore/project_3"},
+		{"--volume", "/tmp/semaphore/ssh-agent-1.sock:/tmp/semaphore/ssh-agent-1.sock"},
+		{"--env", "HOME=/tmp/semaphore/project_3"},
+		{"--env", "SEMAPHORE_SECRET"},
+		{"--env", "SSH_AUTH_SOCK"},
+		{"--workdir", "/tmp/semaphore/project_3/repository_1_template_2"},
+		{"--", "alpine/ansible:2.18", "ansible-playbook", "site.yml"},
+	}
+
+	for _, e := range expected {
+		if !containsArgs(args, e) {
+			t.Fatal("missing arguments", e, args)
+		}
+	}
+
+	if !slices.Contains(args, "--tty") {
+		t.Fatal("tty must be allocated")
+	}
+
+	for _, a := range args {
+		if a == "PATH" || a == "SEMAPHORE_SECRET=secret" {
+			t.Fatal("unexpected argument", a)
+		}
+	}
+
+	if !slices.Contains(res.Env, "SEMAPHORE_SECRET=secret") {
+		t.Fatal("environment must be passed to the engine")
+	}
+
+	if slices.Contains(res.Env, "HOME=/tmp/semaphore/project_3") {
+		t.Fatal("engine must use HOME of the server")
+	}
+}
+
+func TestContainer_WrapProjectSocket(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath:            "/tmp/semaphore",
+		ContainerExecution: &util.ContainerExecutionConfig{Enabled: true},
+	}
+
+	container := NewContainer("alpine/ansible:2.18", 3)
+
+	cmd := exec.Command("bash", "deploy.sh")
+	cmd.Env = []string{"SSH_AUTH_SOCK=/tmp/semaphore/project_3/ssh-agent-1.sock"}
+
+	res := container.wrap(cmd, false)
+
+	if res.Args[0] != "docker" {
+		t.Fatal("docker must be used by default", res.Args)
+	}
+
+	if slices.Contains(res.Args, "/tmp/semaphore/project_3/ssh-agent-1.sock:/tmp/semaphore/project_3/ssh-agent-1.sock") {
+		t.Fatal("socket in the project directory must not be mounted separately")
+	}
+
+	if slices.Contains(res.Args, "--tty") {
+		t.Fatal("tty must not be allocated")
+	}
+}
+
+func TestCheckContainerExecution(t *testing.T) {
+	util.Config = &util.ConfigType{}
+
+	if err := CheckContainerExecution(db.Template{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckContainerExecution(db.Template{ContainerImage: "alpine"}); err == nil {
+		t.Fatal("error expected if container execution is disabled")
+	}
+
+	util.Config.ContainerExecution = &util.ContainerExecutionConfig{Enabled: true}
+
+	if err := CheckContainerExecution(db.Template{ContainerImage: "alpine"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContainer_WrapLocalRepository(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath:            "/tmp/semaphore",
+		ContainerExecution: &util.ContainerExecutionConfig{Enabled: true},
+	}
+
+	container := NewContainer("alpine/ansible:2.18", 3)
+	container.Mount("/srv/playbooks")
+	container.Mount("/tmp/semaphore/project_3/repository_1_template_2")
+
+	cmd := exec.Command("ansible-playbook", "site.yml")
+	cmd.Dir = "/srv/playbooks"
+
+	res := container.wrap(cmd, false)
+
+	if !containsArgs(res.Args, []string{"--volume", "/srv/playbooks:/srv/playbooks"}) {
+		t.Fatal("local repository must be mounted", res.Args)
+	}
+
+	if !containsArgs(res.Args, []string{"--workdir", "/srv/playbooks"}) {
+		t.Fatal("command must run in the local repository", res.Args)
+	}
+
+	if slices.Contains(res.Args, "/tmp/semaphore/project_3/repository_1_template_2:/tmp/semaphore/project_3/repository_1_template_2") {
+		t.Fatal("repository in the project directory must not be mounted separately")
+	}
+}
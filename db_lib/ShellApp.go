@@ -16,7 +16,9 @@ type ShellApp struct {
 	Template   db.Template
 	Repository db.Repository
 	App        db.TemplateApp
-	reader     bashReader
+	// Container is nil if the app runs on the host.
+	Container *Container
+	reader    bashReader
 }
 
 type bashReader struct {
@@ -49,9 +51,9 @@ func (t *ShellApp) makeCmd(command string, args []string, environmentVars []stri
 	cmd.Env = append(cmd.Env, fmt.Sprintf("PWD=%s", cmd.Dir))
 	cmd.Env = append(cmd.Env, environmentVars...)
 
-	cmd.SysProcAttr = util.Config.GetSysProcAttr()
+	cmd.SysProcAttr = util.Config.GetProcessGroupSysProcAttr()
 
-	return cmd
+	return t.Container.wrap(cmd, false)
 }
 
 func (t *ShellApp) runCmd(command string, args []string) error {
@@ -77,6 +79,10 @@ func (t *ShellApp) SetLogger(logger task_logger.Logger) task_logger.Logger {
 func (t *ShellApp) Clear() {
 }
 
+func (t *ShellApp) StopContainers() error {
+	return t.Container.Stop()
+}
+
 func (t *ShellApp) InstallRequirements(args LocalAppInstallingArgs) error {
 	return nil
 }
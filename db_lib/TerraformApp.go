@@ -24,6 +24,7 @@ type TerraformApp struct {
 	Name             string          // Name is the name of the terraform binary
 	PlanHasNoChanges bool            // PlanHasNoChanges is true if terraform plan has no changes
 	backendFilename  string          // backendFilename is the name of the backend file
+	Container        *Container      // Container is nil if the app runs on the host
 }
 
 type terraformReader struct {
@@ -80,9 +81,9 @@ func (t *TerraformApp) makeCmd(command string, args []string, environmentVars []
 		cmd.Env = append(cmd.Env, environmentVars...)
 	}
 
-	cmd.SysProcAttr = util.Config.GetSysProcAttr()
+	cmd.SysProcAttr = util.Config.GetProcessGroupSysProcAttr()
 
-	return cmd
+	return t.Container.wrap(cmd, false)
 }
 
 func (t *TerraformApp) runCmd(command string, args []string) error {
@@ -213,6 +214,10 @@ func (t *TerraformApp) Clear() {
 	}
 }
 
+func (t *TerraformApp) StopContainers() error {
+	return t.Container.Stop()
+}
+
 func (t *TerraformApp) InstallRequirements(args LocalAppInstallingArgs) (err error) {
 
 	tpl := args.TplParams.(*db.TerraformTemplateParams)
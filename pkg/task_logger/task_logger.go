@@ -9,10 +9,15 @@ type TaskStatus string
 
 const (
 	TaskWaitingStatus       TaskStatus = "waiting"
+	TaskWaitingApproval     TaskStatus = "waiting_approval"
 	TaskStartingStatus      TaskStatus = "starting"
 	TaskWaitingConfirmation TaskStatus = "waiting_confirmation"
 	TaskConfirmed           TaskStatus = "confirmed"
 	TaskRejected            TaskStatus = "rejected"
+	// TaskApprovalRejected is the final status of the task rejected by approvers
+	// or not approved in time. TaskRejected is used for rejected Terraform plans,
+	// the task keeps running after it.
+	TaskApprovalRejected TaskStatus = "approval_rejected"
 	TaskRunningStatus       TaskStatus = "running"
 	TaskStoppingStatus      TaskStatus = "stopping"
 	TaskStoppedStatus       TaskStatus = "stopped"
@@ -23,6 +28,7 @@ const (
 func UnfinishedTaskStatuses() []TaskStatus {
 	return []TaskStatus{
 		TaskWaitingStatus,
+		TaskWaitingApproval,
 		TaskStartingStatus,
 		TaskWaitingConfirmation,
 		TaskConfirmed,
@@ -35,6 +41,7 @@ func UnfinishedTaskStatuses() []TaskStatus {
 func (s TaskStatus) IsValid() bool {
 	switch s {
 	case TaskWaitingStatus,
+		TaskWaitingApproval,
 		TaskStartingStatus,
 		TaskWaitingConfirmation,
 		TaskConfirmed,
@@ -43,14 +50,15 @@ func (s TaskStatus) IsValid() bool {
 		TaskStoppingStatus,
 		TaskStoppedStatus,
 		TaskSuccessStatus,
-		TaskFailStatus:
+		TaskFailStatus,
+		TaskApprovalRejected:
 		return true
 	}
 	return false
 }
 
 func (s TaskStatus) IsNotifiable() bool {
-	return s == TaskSuccessStatus || s == TaskFailStatus || s == TaskWaitingConfirmation
+	return s == TaskSuccessStatus || s == TaskFailStatus || s == TaskWaitingConfirmation || s == TaskWaitingApproval
 }
 
 func (s TaskStatus) Format() (res string) {
@@ -60,9 +68,9 @@ func (s TaskStatus) Format() (res string) {
 		res += "❌"
 	case TaskSuccessStatus:
 		res += "✅"
-	case TaskStoppedStatus:
+	case TaskStoppedStatus, TaskApprovalRejected:
 		res += "⏹️"
-	case TaskWaitingConfirmation:
+	case TaskWaitingConfirmation, TaskWaitingApproval:
 		res += "⚠️"
 	default:
 		res += "❓"
@@ -75,10 +83,14 @@ func (s TaskStatus) Format() (res string) {
 		res += " STARTING"
 	case TaskWaitingConfirmation:
 		res += " WAITING_CONFIRMATION"
+	case TaskWaitingApproval:
+		res += " WAITING_APPROVAL"
 	case TaskConfirmed:
 		res += " CONFIRMED"
 	case TaskRejected:
 		res += " REJECTED"
+	case TaskApprovalRejected:
+		res += " APPROVAL_REJECTED"
 	case TaskRunningStatus:
 		res += " RUNNING"
 	case TaskStoppingStatus:
@@ -97,7 +109,7 @@ func (s TaskStatus) Format() (res string) {
 }
 
 func (s TaskStatus) IsFinished() bool {
-	return s == TaskStoppedStatus || s == TaskSuccessStatus || s == TaskFailStatus
+	return s == TaskStoppedStatus || s == TaskSuccessStatus || s == TaskFailStatus || s == TaskApprovalRejected
 }
 
 type StatusListener func(status TaskStatus)
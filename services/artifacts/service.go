@@ -0,0 +1,223 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/random"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// cleanupInterval is the period of the removal of stale artifacts.
+const cleanupInterval = time.Hour
+
+// ErrTooLarge is returned when the artifact exceeds the maximum file size.
+var ErrTooLarge = errors.New("artifact is too large")
+
+// Service stores files uploaded by tasks and removes them when their
+// tasks are deleted or the retention period ends.
+type Service struct {
+	store       db.Store
+	storage     Storage
+	maxFileSize int64
+	retention   time.Duration
+}
+
+func NewService(store db.Store, storage Storage, conf *util.ArtifactsConfig) *Service {
+	s := &Service{
+		store:       store,
+		storage:     storage,
+		maxFileSize: conf.GetMaxFileSize(),
+	}
+
+	if conf != nil && conf.RetentionDays > 0 {
+		s.retention = time.Duration(conf.RetentionDays) * 24 * time.Hour
+	}
+
+	return s
+}
+
+// cleanName returns the slash separated path of the artifact relative
+// to the repository directory.
+func cleanName(name string) (string, error) {
+	name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+
+	if name == "." || path.IsAbs(name) || slices.Contains(strings.Split(name, "/"), "..") {
+		return "", db.NewValidationError("invalid artifact name " + name)
+	}
+
+	return name, nil
+}
+
+// UploadArtifact stores the file of the task.
+func (s *Service) UploadArtifact(task db.Task, name string, r io.Reader) error {
+	_, err := s.Save(task, name, r)
+	return err
+}
+
+func (s *Service) Save(task db.Task, name string, r io.Reader) (artifact db.TaskArtifact, err error) {
+	name, err = cleanName(name)
+	if err != nil {
+		return
+	}
+
+	key := fmt.Sprintf("%d/%d/%s", task.ProjectID, task.ID, random.String(16))
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+
+	// One byte more than the limit is read to detect too large files.
+	body := io.TeeReader(io.LimitReader(r, s.maxFileSize+1), io.MultiWriter(hash, counter))
+
+	ctx := context.Background()
+
+	if err = s.storage.Put(ctx, key, body); err != nil {
+		return
+	}
+
+	if counter.n > s.maxFileSize {
+		s.deleteFile(key)
+		err = ErrTooLarge
+		return
+	}
+
+	artifact, err = s.store.CreateTaskArtifact(db.TaskArtifact{
+		ProjectID:  task.ProjectID,
+		TaskID:     task.ID,
+		Name:       name,
+		Size:       counter.n,
+		Checksum:   hex.EncodeToString(hash.Sum(nil)),
+		Created:    tz.Now(),
+		StorageKey: key,
+	})
+
+	if err != nil {
+		s.deleteFile(key)
+	}
+
+	return
+}
+
+// GetBuildArtifacts returns artifacts of the build task deployed by the task.
+// It logs a warning if the build template collects artifacts, but the build
+// task has none, for example because they were removed after the retention period.
+func (s *Service) GetBuildArtifacts(task db.Task) ([]db.TaskArtifact, error) {
+	buildTask := task.GetIncomingBuildTask(s.store)
+
+	if buildTask == nil {
+		return []db.TaskArtifact{}, nil
+	}
+
+	artifacts, err := s.store.GetTaskArtifacts(buildTask.ProjectID, buildTask.ID)
+	if err != nil || len(artifacts) > 0 {
+		return artifacts, err
+	}
+
+	tpl, err := s.store.GetTemplate(buildTask.ProjectID, buildTask.TemplateID)
+	if err != nil {
+		return artifacts, err
+	}
+
+	if len(tpl.ArtifactPatterns()) > 0 {
+		log.WithFields(log.Fields{
+			"context":       "artifacts",
+			"task_id":       task.ID,
+			"build_task_id": buildTask.ID,
+		}).Warn("Build task has no artifacts, they may have been removed after the retention period")
+	}
+
+	return artifacts, nil
+}
+
+// OpenBuildArtifact returns the content of the artifact of the build task
+// deployed by the task.
+func (s *Service) OpenBuildArtifact(task db.Task, artifact db.TaskArtifact) (io.ReadCloser, error) {
+	buildTask := task.GetIncomingBuildTask(s.store)
+
+	if buildTask == nil {
+		return nil, db.ErrNotFound
+	}
+
+	artifact, err := s.store.GetTaskArtifact(buildTask.ProjectID, buildTask.ID, artifact.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Open(artifact)
+}
+
+// Open returns the content of the artifact.
+func (s *Service) Open(artifact db.TaskArtifact) (io.ReadCloser, error) {
+	return s.storage.Get(context.Background(), artifact.StorageKey)
+}
+
+func (s *Service) Delete(artifact db.TaskArtifact) error {
+	if err := s.storage.Delete(context.Background(), artifact.StorageKey); err != nil {
+		return err
+	}
+
+	return s.store.DeleteTaskArtifact(artifact.ID)
+}
+
+func (s *Service) deleteFile(key string) {
+	if err := s.storage.Delete(context.Background(), key); err != nil {
+		log.WithError(err).WithField("key", key).Error("Failed to delete artifact file")
+	}
+}
+
+// Cleanup deletes artifacts of deleted tasks and artifacts older than the retention period.
+func (s *Service) Cleanup(now time.Time) error {
+	var createdBefore *time.Time
+	if s.retention > 0 {
+		t := now.Add(-s.retention)
+		createdBefore = &t
+	}
+
+	artifacts, err := s.store.GetStaleTaskArtifacts(createdBefore)
+	if err != nil {
+		return err
+	}
+
+	for _, artifact := range artifacts {
+		if err = s.Delete(artifact); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Run() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		db.StoreSession(s.store, "artifacts cleanup", func() {
+			if err := s.Cleanup(tz.Now()); err != nil {
+				log.WithError(err).Error("Artifacts cleanup failed")
+			}
+		})
+
+		<-ticker.C
+	}
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
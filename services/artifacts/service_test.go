@@ -0,0 +1,155 @@
+package artifacts
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Save(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "artifacts"})
+	require.NoError(t, err)
+
+	task, err := store.CreateTask(db.Task{ProjectID: proj.ID}, 0)
+	require.NoError(t, err)
+
+	s := NewService(store, NewFileStorage(t.TempDir()), &util.ArtifactsConfig{MaxFileSize: 1})
+
+	artifact, err := s.Save(task, "dist/app.tar.gz", strings.NewReader("content"))
+	require.NoError(t, err)
+	assert.Equal(t, "dist/app.tar.gz", artifact.Name)
+	assert.Equal(t, int64(7), artifact.Size)
+	assert.Equal(t, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73", artifact.Checksum)
+
+	saved, err := store.GetTaskArtifact(proj.ID, task.ID, artifact.ID)
+	require.NoError(t, err)
+
+	content, err := s.Open(saved)
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "content", string(data))
+
+	_, err = s.Save(task, "../secret", strings.NewReader("content"))
+	var validationErr *db.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+
+	_, err = s.Save(task, "large.bin", strings.NewReader(strings.Repeat("a", 1024*1024+1)))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	artifacts, err := store.GetTaskArtifacts(proj.ID, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, artifacts, 1)
+}
+
+func TestService_Cleanup(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "artifacts"})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "build", Playbook: "build.yml"})
+	require.NoError(t, err)
+
+	task, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: tpl.ID}, 0)
+	require.NoError(t, err)
+
+	deletedTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: tpl.ID}, 0)
+	require.NoError(t, err)
+
+	s := NewService(store, NewFileStorage(t.TempDir()), &util.ArtifactsConfig{RetentionDays: 7})
+
+	artifact, err := s.Save(task, "report.xml", strings.NewReader("report"))
+	require.NoError(t, err)
+
+	deletedArtifact, err := s.Save(deletedTask, "report.xml", strings.NewReader("report"))
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteTaskWithOutputs(proj.ID, deletedTask.ID))
+
+	require.NoError(t, s.Cleanup(tz.Now()))
+
+	_, err = store.GetTaskArtifact(proj.ID, deletedTask.ID, deletedArtifact.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	_, err = s.Open(deletedArtifact)
+	assert.Error(t, err)
+
+	_, err = store.GetTaskArtifact(proj.ID, task.ID, artifact.ID)
+	require.NoError(t, err)
+
+	// Artifacts are removed after the retention period.
+	require.NoError(t, s.Cleanup(tz.Now().Add(8*24*time.Hour)))
+
+	_, err = store.GetTaskArtifact(proj.ID, task.ID, artifact.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestService_GetBuildArtifacts(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "artifacts"})
+	require.NoError(t, err)
+
+	build, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "build", Playbook: "build.yml", Type: db.TemplateBuild})
+	require.NoError(t, err)
+
+	deploy, err := store.CreateTemplate(db.Template{
+		ProjectID:       proj.ID,
+		Name:            "deploy",
+		Playbook:        "deploy.yml",
+		Type:            db.TemplateDeploy,
+		BuildTemplateID: &build.ID,
+	})
+	require.NoError(t, err)
+
+	buildTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: build.ID}, 0)
+	require.NoError(t, err)
+
+	deployTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: deploy.ID, BuildTaskID: &buildTask.ID}, 0)
+	require.NoError(t, err)
+
+	// The redeploy refers to the previous deploy task.
+	redeployTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: deploy.ID, BuildTaskID: &deployTask.ID}, 0)
+	require.NoError(t, err)
+
+	s := NewService(store, NewFileStorage(t.TempDir()), &util.ArtifactsConfig{})
+
+	artifact, err := s.Save(buildTask, "dist/app.tar.gz", strings.NewReader("app"))
+	require.NoError(t, err)
+
+	deployArtifact, err := s.Save(deployTask, "deploy.log", strings.NewReader("log"))
+	require.NoError(t, err)
+
+	artifacts, err := s.GetBuildArtifacts(redeployTask)
+	require.NoError(t, err)
+	require.Len(t, artifacts, 1)
+	assert.Equal(t, artifact.ID, artifacts[0].ID)
+
+	content, err := s.OpenBuildArtifact(redeployTask, artifacts[0])
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "app", string(data))
+
+	// Only artifacts of the build task are available.
+	_, err = s.OpenBuildArtifact(redeployTask, deployArtifact)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	artifacts, err = s.GetBuildArtifacts(buildTask)
+	require.NoError(t, err)
+	assert.Empty(t, artifacts)
+}
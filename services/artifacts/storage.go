@@ -0,0 +1,77 @@
+package artifacts
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// Storage keeps files of artifacts.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage returns the S3 storage if it is configured and
+// the file storage otherwise.
+func NewStorage(conf *util.ArtifactsConfig) (Storage, error) {
+	if conf != nil && conf.S3 != nil {
+		return NewS3Storage(conf.S3)
+	}
+
+	return NewFileStorage(conf.GetPath()), nil
+}
+
+// FileStorage keeps artifacts in the local directory.
+type FileStorage struct {
+	root string
+}
+
+func NewFileStorage(root string) *FileStorage {
+	return &FileStorage{root: root}
+}
+
+func (s *FileStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *FileStorage) Put(ctx context.Context, key string, r io.Reader) (err error) {
+	filePath := s.path(key)
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return
+	}
+
+	_, err = io.Copy(f, r)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(filePath)
+	}
+
+	return
+}
+
+func (s *FileStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s *FileStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package db_migration
+
+import (
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// reference describes the column which contains ID of another entity.
+type reference struct {
+	column string
+	// entity is the table name of the referenced entity.
+	entity string
+	// byType maps values of typeColumn to referenced entities
+	// for polymorphic references, like objects of events.
+	typeColumn string
+	byType     map[string]string
+	// scopeColumn contains ID of the bucket of the referenced object if the
+	// object itself is not in the same bucket, for example, project ID of tasks.
+	scopeColumn string
+	// deferred references are set after all objects are copied,
+	// because they are cyclic or refer to objects of the same entity.
+	deferred bool
+	// required references can not be cleared if the referenced object is
+	// missing. References by non-pointer fields are always required.
+	required bool
+}
+
+type entity struct {
+	props db.ObjectProps
+	refs  []reference
+}
+
+// projectRef is required because clearing it makes the object global.
+func projectRef() reference {
+	return reference{column: "project_id", entity: db.ProjectProps.TableName, required: true}
+}
+
+func userRef(column string) reference {
+	return reference{column: column, entity: db.UserProps.TableName}
+}
+
+func taskRef() reference {
+	return reference{column: "task_id", entity: db.TaskProps.TableName}
+}
+
+func ref(column string, props db.ObjectProps) reference {
+	return reference{column: column, entity: props.TableName}
+}
+
+// projectScopedRef refers to the object of the project from the object
+// which is not stored in the bucket of the project.
+func projectScopedRef(column string, props db.ObjectProps) reference {
+	return reference{column: column, entity: props.TableName, scopeColumn: "project_id"}
+}
+
+// entities lists copied entities in order of their dependencies.
+var entities = []entity{
+	{props: db.OptionProps},
+	{props: db.UserProps},
+	{props: db.UserTotpProps, refs: []reference{userRef("user_id")}},
+	{props: db.UserEmailOtpProps, refs: []reference{userRef("user_id")}},
+	{props: db.ProjectProps, refs: []reference{
+		{column: "default_secret_storage_id", entity: db.SecretStorageProps.TableName, scopeColumn: "id", deferred: true},
+	}},
+	{props: db.RoleProps, refs: []reference{projectRef()}},
+	{props: db.ProjectUserProps, refs: []reference{projectRef(), userRef("user_id")}},
+	{props: db.ProjectInviteProps, refs: []reference{projectRef(), userRef("user_id"), userRef("inviter_user_id")}},
+	{props: db.SecretStorageProps, refs: []reference{projectRef()}},
+	{props: db.EnvironmentProps, refs: []reference{
+		projectRef(),
+		ref("secret_storage_id", db.SecretStorageProps),
+	}},
+	{props: db.AccessKeyProps, refs: []reference{
+		projectRef(),
+		{column: "user_id", entity: db.UserProps.TableName, required: true},
+		ref("storage_id", db.SecretStorageProps),
+		ref("source_storage_id", db.SecretStorageProps),
+		ref("environment_id", db.EnvironmentProps),
+	}},
+	{props: db.RepositoryProps, refs: []reference{projectRef(), ref("ssh_key_id", db.AccessKeyProps)}},
+	{props: db.ViewProps, refs: []reference{projectRef()}},
+	{props: db.InventoryProps, refs: []reference{
+		projectRef(),
+		ref("ssh_key_id", db.AccessKeyProps),
+		ref("become_key_id", db.AccessKeyProps),
+		ref("repository_id", db.RepositoryProps),
+		{column: "template_id", entity: db.TemplateProps.TableName, deferred: true},
+	}},
+	{props: db.TemplateProps, refs: []reference{
+		projectRef(),
+		ref("inventory_id", db.InventoryProps),
+		ref("repository_id", db.RepositoryProps),
+		ref("environment_id", db.EnvironmentProps),
+		ref("view_id", db.ViewProps),
+		{column: "build_template_id", entity: db.TemplateProps.TableName, deferred: true},
+	}},
+	{props: db.TemplateVaultProps, refs: []reference{
+		projectRef(),
+		ref("template_id", db.TemplateProps),
+		ref("vault_key_id", db.AccessKeyProps),
+	}},
+	{props: db.TemplateRolePermProps, refs: []reference{projectRef(), ref("template_id", db.TemplateProps)}},
+	{props: db.TaskParamsProps, refs: []reference{projectRef(), ref("inventory_id", db.InventoryProps)}},
+	{props: db.ScheduleProps, refs: []reference{
+		projectRef(),
+		ref("template_id", db.TemplateProps),
+		ref("repository_id", db.RepositoryProps),
+		ref("task_params_id", db.TaskParamsProps),
+	}},
+	{props: db.IntegrationProps, refs: []reference{
+		projectRef(),
+		ref("template_id", db.TemplateProps),
+		ref("auth_secret_id", db.AccessKeyProps),
+		ref("task_params_id", db.TaskParamsProps),
+	}},
+	{props: db.IntegrationExtractValueProps, refs: []reference{ref("integration_id", db.IntegrationProps)}},
+	{props: db.IntegrationMatcherProps, refs: []reference{ref("integration_id", db.IntegrationProps)}},
+	{props: db.IntegrationAliasProps, refs: []reference{projectRef(), ref("integration_id", db.IntegrationProps)}},
+	{props: db.TerraformInventoryAliasProps, refs: []reference{
+		projectRef(),
+		ref("inventory_id", db.InventoryProps),
+		ref("auth_key_id", db.AccessKeyProps),
+	}},
+	{props: db.ProjectGitopsProps, refs: []reference{
+		projectRef(),
+		projectScopedRef("repository_id", db.RepositoryProps),
+	}},
+	{props: db.GlobalRunnerProps, refs: []reference{projectRef()}},
+	{props: db.RunnerHealthProps, refs: []reference{{column: "runner_id", entity: db.GlobalRunnerProps.TableName, required: true}}},
+	{props: db.RunnerRegistrationTokenProps, refs: []reference{projectRef()}},
+	{props: db.TaskProps, refs: []reference{
+		projectRef(),
+		userRef("user_id"),
+		projectScopedRef("template_id", db.TemplateProps),
+		projectScopedRef("inventory_id", db.InventoryProps),
+		projectScopedRef("integration_id", db.IntegrationProps),
+		projectScopedRef("schedule_id", db.ScheduleProps),
+		{column: "build_task_id", entity: db.TaskProps.TableName, deferred: true},
+	}},
+	{props: db.TaskStageProps, refs: []reference{taskRef()}},
+	{props: db.TaskArtifactProps, refs: []reference{projectRef(), taskRef()}},
+	{props: db.EnvironmentVersionProps, refs: []reference{
+		projectRef(),
+		ref("environment_id", db.EnvironmentProps),
+		ref("build_template_id", db.TemplateProps),
+		ref("template_id", db.TemplateProps),
+		taskRef(),
+		ref("build_task_id", db.TaskProps),
+	}},
+	{props: db.TaskStageResultProps, refs: []reference{taskRef(), ref("stage_id", db.TaskStageProps)}},
+	{props: db.TaskOutputProps, refs: []reference{taskRef(), ref("stage_id", db.TaskStageProps)}},
+	{props: db.TaskApprovalProps, refs: []reference{taskRef(), projectRef(), userRef("user_id")}},
+	{props: db.AnsibleTaskHostProps, refs: []reference{taskRef(), projectRef()}},
+	{props: db.AnsibleTaskErrorProps, refs: []reference{taskRef(), projectRef()}},
+	{props: db.IntegrationDeliveryProps, refs: []reference{
+		projectRef(),
+		ref("integration_id", db.IntegrationProps),
+		taskRef(),
+	}},
+	{props: db.TerraformInventoryStateProps, refs: []reference{
+		projectRef(),
+		ref("inventory_id", db.InventoryProps),
+		taskRef(),
+	}},
+	{props: db.EventProps, refs: []reference{
+		projectRef(),
+		userRef("user_id"),
+		projectScopedRef("integration_id", db.IntegrationProps),
+		{
+			column:      "object_id",
+			typeColumn:  "object_type",
+			scopeColumn: "project_id",
+			byType: map[string]string{
+				string(db.EventTask):                    db.TaskProps.TableName,
+				string(db.EventEnvironment):             db.EnvironmentProps.TableName,
+				string(db.EventInventory):               db.InventoryProps.TableName,
+				string(db.EventKey):                     db.AccessKeyProps.TableName,
+				string(db.EventProject):                 db.ProjectProps.TableName,
+				string(db.EventRepository):              db.RepositoryProps.TableName,
+				string(db.EventSchedule):                db.ScheduleProps.TableName,
+				string(db.EventTemplate):                db.TemplateProps.TableName,
+				string(db.EventUser):                    db.UserProps.TableName,
+				string(db.EventView):                    db.ViewProps.TableName,
+				string(db.EventIntegration):             db.IntegrationProps.TableName,
+				string(db.EventIntegrationExtractValue): db.IntegrationExtractValueProps.TableName,
+				string(db.EventIntegrationMatcher):      db.IntegrationMatcherProps.TableName,
+			},
+		},
+	}},
+	{props: db.SessionProps, refs: []reference{userRef("user_id")}},
+	{props: db.TokenProps, refs: []reference{userRef("user_id"), projectRef()}},
+}
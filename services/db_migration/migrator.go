@@ -0,0 +1,515 @@
+package db_migration
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// batchSize is the number of objects inserted in a single transaction.
+const batchSize = 500
+
+type Options struct {
+	// Resume continues the interrupted migration. Objects which are
+	// already in the target database are skipped.
+	Resume bool
+}
+
+type EntityResult struct {
+	Table string `json:"table"`
+	// Source is the number of objects which must be in the target database.
+	Source int `json:"source"`
+	// Skipped is the number of objects which refer to missing objects.
+	Skipped int `json:"skipped"`
+	Target  int `json:"target"`
+}
+
+type Result struct {
+	Entities []EntityResult `json:"entities"`
+}
+
+// entityIDs keeps the mapping of IDs of the entity.
+type entityIDs struct {
+	// hasID is true if the entity has the integer primary key "id".
+	hasID bool
+	// scoped entities have IDs unique only within buckets of the source.
+	scoped bool
+	// inverted IDs of the source are counted down from bolt.MaxID.
+	inverted bool
+	// referenced entities keep IDs of copied objects to check references.
+	referenced bool
+
+	// byBucket maps source IDs of scoped entities to target IDs.
+	byBucket map[int]map[int]int
+	known    map[int]struct{}
+}
+
+// Migrator copies all objects from one database to another.
+// IDs of objects are preserved. BoltDB keeps some IDs unique only within
+// the project or other parent object, such objects get new IDs if they
+// conflict with objects of other parents, references are updated accordingly.
+// IDs of tasks and other objects which BoltDB counts down are converted
+// to the sequential ones.
+type Migrator struct {
+	source  db.Store
+	target  db.Store
+	options Options
+
+	// remap is true if the source keeps IDs unique only within buckets.
+	remap bool
+	ids   map[string]*entityIDs
+}
+
+func NewMigrator(source db.Store, target db.Store, options Options) *Migrator {
+	return &Migrator{
+		source:  source,
+		target:  target,
+		options: options,
+	}
+}
+
+// findField returns the field of the struct by the db tag.
+func findField(v reflect.Value, column string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+
+		if tag == column {
+			return v.Field(i), true
+		}
+
+		if tag == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if res, ok := findField(v.Field(i), column); ok {
+				return res, true
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// getIntField returns the value of the int or *int field.
+func getIntField(v reflect.Value, column string) (int, bool) {
+	f, ok := findField(v, column)
+	if !ok {
+		return 0, false
+	}
+
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return 0, false
+		}
+		f = f.Elem()
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(f.Int()), f.Int() != 0
+	default:
+		return 0, false
+	}
+}
+
+func setIntField(f reflect.Value, value int) {
+	if f.Kind() == reflect.Ptr {
+		ptr := reflect.New(f.Type().Elem())
+		ptr.Elem().SetInt(int64(value))
+		f.Set(ptr)
+		return
+	}
+
+	f.SetInt(int64(value))
+}
+
+func (m *Migrator) init() {
+	m.remap = m.source.GetDialect() == util.DbDriverBolt
+	m.ids = make(map[string]*entityIDs)
+
+	for _, e := range entities {
+		ids := &entityIDs{
+			scoped:   m.remap && !e.props.IsGlobal,
+			inverted: m.remap && e.props.SortInverted,
+			known:    make(map[int]struct{}),
+		}
+
+		if e.props.PrimaryColumnName == "id" || e.props.PrimaryColumnName == "" {
+			if f, ok := findField(reflect.New(e.props.Type).Elem(), "id"); ok && f.Kind() == reflect.Int {
+				ids.hasID = true
+			}
+		}
+
+		m.ids[e.props.TableName] = ids
+	}
+
+	for _, e := range entities {
+		for _, r := range e.refs {
+			if r.entity != "" {
+				m.ids[r.entity].referenced = true
+			}
+		}
+	}
+}
+
+// sourceID converts the ID of the source to the sequential one.
+func (ids *entityIDs) sourceID(id int) int {
+	if ids.inverted {
+		return bolt.MaxID - id
+	}
+	return id
+}
+
+// scan assigns target IDs to objects of scoped entities. The first object
+// with the ID keeps it, the next ones get IDs after the maximal one.
+// The assignment depends only on the source, so it is the same on resume.
+func (m *Migrator) scan(e entity) error {
+	ids := m.ids[e.props.TableName]
+
+	if !ids.hasID || !ids.scoped {
+		return nil
+	}
+
+	type object struct {
+		bucket int
+		id     int
+	}
+
+	var objects []object
+	used := make(map[int]bool)
+	maxID := 0
+
+	err := m.source.ExportObjects(e.props, func(bucketID int, obj any) error {
+		id, ok := getIntField(reflect.ValueOf(obj), "id")
+		if !ok {
+			return nil
+		}
+
+		id = ids.sourceID(id)
+		objects = append(objects, object{bucket: bucketID, id: id})
+		maxID = max(maxID, id)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ids.byBucket = make(map[int]map[int]int)
+
+	for _, o := range objects {
+		if ids.byBucket[o.bucket] == nil {
+			ids.byBucket[o.bucket] = make(map[int]int)
+		}
+
+		id := o.id
+		if used[id] {
+			maxID++
+			id = maxID
+		}
+
+		used[id] = true
+		ids.byBucket[o.bucket][o.id] = id
+	}
+
+	return nil
+}
+
+// mapID returns the target ID of the object of the entity.
+func (m *Migrator) mapID(table string, bucketID int, id int) (int, bool) {
+	ids := m.ids[table]
+
+	if !m.remap {
+		return id, true
+	}
+
+	id = ids.sourceID(id)
+
+	if !ids.scoped {
+		return id, true
+	}
+
+	res, ok := ids.byBucket[bucketID][id]
+	return res, ok
+}
+
+// prepare maps the ID and references of the object to the target ones.
+// It returns false if the object refers to the missing object.
+// Deferred references are cleared unless withDeferred is set.
+func (m *Migrator) prepare(e entity, bucketID int, obj any, withDeferred bool) (reflect.Value, bool) {
+	orig := reflect.ValueOf(obj)
+
+	v := reflect.New(orig.Type()).Elem()
+	v.Set(orig)
+
+	if m.ids[e.props.TableName].hasID {
+		if id, ok := getIntField(orig, "id"); ok {
+			newID, found := m.mapID(e.props.TableName, bucketID, id)
+			if !found {
+				return v, false
+			}
+			f, _ := findField(v, "id")
+			setIntField(f, newID)
+		}
+	}
+
+	for _, r := range e.refs {
+		f, ok := findField(v, r.column)
+		if !ok {
+			continue
+		}
+
+		id, ok := getIntField(orig, r.column)
+		if !ok {
+			continue
+		}
+
+		if r.deferred && !withDeferred {
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+
+		scope := bucketID
+		if r.scopeColumn != "" {
+			scope, _ = getIntField(orig, r.scopeColumn)
+		}
+
+		if r.typeColumn != "" {
+			// Objects of events can be deleted, so the reference is not checked.
+			typeField, _ := findField(orig, r.typeColumn)
+			if typeField.Kind() == reflect.Ptr {
+				if typeField.IsNil() {
+					continue
+				}
+				typeField = typeField.Elem()
+			}
+
+			table, known := r.byType[typeField.String()]
+			if !known {
+				continue
+			}
+
+			if newID, found := m.mapID(table, scope, id); found {
+				setIntField(f, newID)
+			} else {
+				f.Set(reflect.Zero(f.Type()))
+			}
+
+			continue
+		}
+
+		newID, found := m.mapID(r.entity, scope, id)
+		if found {
+			_, found = m.ids[r.entity].known[newID]
+		}
+
+		if !found {
+			if r.required || f.Kind() != reflect.Ptr {
+				return v, false
+			}
+
+			f.Set(reflect.Zero(f.Type()))
+			continue
+		}
+
+		setIntField(f, newID)
+	}
+
+	return v, true
+}
+
+func (m *Migrator) copyEntity(e entity) (res EntityResult, err error) {
+	res.Table = e.props.TableName
+	ids := m.ids[e.props.TableName]
+
+	// Objects are exported in the stable order and imported by transactions,
+	// so objects in the target are the first objects of the source.
+	skip := 0
+	if m.options.Resume {
+		skip, err = m.target.CountObjects(e.props)
+		if err != nil {
+			return
+		}
+	}
+
+	var batch []any
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err2 := m.target.ImportObjects(e.props, batch)
+		batch = batch[:0]
+		return err2
+	}
+
+	err = m.source.ExportObjects(e.props, func(bucketID int, obj any) error {
+		v, ok := m.prepare(e, bucketID, obj, false)
+
+		if !ok {
+			res.Skipped++
+			log.WithFields(log.Fields{
+				"context": "migrate-db",
+				"table":   e.props.TableName,
+				"bucket":  bucketID,
+			}).Debug("Object refers to the missing object and is not copied")
+			return nil
+		}
+
+		res.Source++
+
+		if ids.hasID && ids.referenced {
+			if id, hasID := getIntField(v, "id"); hasID {
+				ids.known[id] = struct{}{}
+			}
+		}
+
+		if skip > 0 {
+			skip--
+			return nil
+		}
+
+		batch = append(batch, v.Interface())
+
+		if len(batch) >= batchSize {
+			return flush()
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		err = flush()
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to copy %s: %w", e.props.TableName, err)
+	}
+
+	return
+}
+
+// setDeferred sets deferred references of copied objects.
+func (m *Migrator) setDeferred(e entity) error {
+	var deferred []reference
+	for _, r := range e.refs {
+		if r.deferred {
+			deferred = append(deferred, r)
+		}
+	}
+
+	if len(deferred) == 0 {
+		return nil
+	}
+
+	ids := m.ids[e.props.TableName]
+
+	return m.source.ExportObjects(e.props, func(bucketID int, obj any) error {
+		v, ok := m.prepare(e, bucketID, obj, true)
+		if !ok {
+			return nil
+		}
+
+		id, _ := getIntField(v, "id")
+		if _, copied := ids.known[id]; !copied {
+			return nil
+		}
+
+		columns := make(map[string]any)
+		for _, r := range deferred {
+			if value, isSet := getIntField(v, r.column); isSet {
+				columns[r.column] = value
+			}
+		}
+
+		return m.target.UpdateObjectColumns(e.props, id, columns)
+	})
+}
+
+func (m *Migrator) checkTargetEmpty() error {
+	for _, e := range entities {
+		n, err := m.target.CountObjects(e.props)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			return db.NewValidationError(fmt.Sprintf(
+				"target database is not empty: %s has %d objects",
+				e.props.TableName, n))
+		}
+	}
+
+	return nil
+}
+
+// Run copies all entities. It can be called again with Options.Resume
+// if it was interrupted. Counts of objects are verified at the end.
+func (m *Migrator) Run() (*Result, error) {
+	if m.target.GetDialect() == util.DbDriverBolt {
+		return nil, db.NewValidationError("BoltDB can not be the target database")
+	}
+
+	m.init()
+
+	if !m.options.Resume {
+		if err := m.checkTargetEmpty(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range entities {
+		if err := m.scan(e); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.props.TableName, err)
+		}
+	}
+
+	res := &Result{}
+
+	for _, e := range entities {
+		entityRes, err := m.copyEntity(e)
+		if err != nil {
+			return res, err
+		}
+
+		log.WithFields(log.Fields{
+			"context": "migrate-db",
+			"table":   e.props.TableName,
+			"copied":  entityRes.Source,
+			"skipped": entityRes.Skipped,
+		}).Info("Objects copied")
+
+		res.Entities = append(res.Entities, entityRes)
+	}
+
+	for _, e := range entities {
+		if err := m.setDeferred(e); err != nil {
+			return res, fmt.Errorf("failed to update references of %s: %w", e.props.TableName, err)
+		}
+	}
+
+	var mismatched []string
+
+	for i, e := range entities {
+		n, err := m.target.CountObjects(e.props)
+		if err != nil {
+			return res, err
+		}
+
+		res.Entities[i].Target = n
+
+		if n != res.Entities[i].Source {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%d of %d)", e.props.TableName, n, res.Entities[i].Source))
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return res, fmt.Errorf("object counts do not match: %v", mismatched)
+	}
+
+	return res, nil
+}
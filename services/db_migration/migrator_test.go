@@ -0,0 +1,195 @@
+package db_migration
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createBoltProject creates the project with templates and tasks.
+// Templates and inventories of different projects have the same IDs in BoltDB.
+func createBoltProject(t *testing.T, store db.Store, name string, userID int) {
+	proj, err := store.CreateProject(db.Project{Name: name})
+	require.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: userID, Role: db.ProjectOwner})
+	require.NoError(t, err)
+
+	inv, err := store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+		Name:      name + " hosts",
+		Type:      db.InventoryStatic,
+		Inventory: "localhost",
+	})
+	require.NoError(t, err)
+
+	build, err := store.CreateTemplate(db.Template{
+		ProjectID:   proj.ID,
+		Name:        name + " build",
+		Playbook:    "build.yml",
+		Type:        db.TemplateBuild,
+		InventoryID: &inv.ID,
+	})
+	require.NoError(t, err)
+
+	deploy, err := store.CreateTemplate(db.Template{
+		ProjectID:       proj.ID,
+		Name:            name + " deploy",
+		Playbook:        "deploy.yml",
+		Type:            db.TemplateDeploy,
+		InventoryID:     &inv.ID,
+		BuildTemplateID: &build.ID,
+	})
+	require.NoError(t, err)
+
+	buildTask, err := store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: build.ID,
+		UserID:     &userID,
+		Status:     "success",
+	}, 0)
+	require.NoError(t, err)
+
+	deployTask, err := store.CreateTask(db.Task{
+		ProjectID:   proj.ID,
+		TemplateID:  deploy.ID,
+		BuildTaskID: &buildTask.ID,
+		Status:      "success",
+	}, 0)
+	require.NoError(t, err)
+
+	_, err = store.CreateTaskOutput(db.TaskOutput{TaskID: deployTask.ID, Output: name + " output"})
+	require.NoError(t, err)
+}
+
+func TestMigrator_BoltToSql(t *testing.T) {
+	source := bolt.CreateTestStore()
+
+	user, err := source.CreateUser(db.UserWithPwd{
+		Pwd:  "p@ssw0rd",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	require.NoError(t, err)
+
+	createBoltProject(t, source, "first", user.ID)
+	createBoltProject(t, source, "second", user.ID)
+
+	target := sql.CreateTestStore()
+
+	res, err := NewMigrator(source, target, Options{}).Run()
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	for _, e := range res.Entities {
+		assert.Equal(t, e.Source, e.Target, e.Table)
+		counts[e.Table] = e.Target
+	}
+
+	assert.Equal(t, 1, counts[db.UserProps.TableName])
+	assert.Equal(t, 2, counts[db.ProjectProps.TableName])
+	assert.Equal(t, 4, counts[db.TemplateProps.TableName])
+	assert.Equal(t, 4, counts[db.TaskProps.TableName])
+	assert.Equal(t, 2, counts[db.TaskOutputProps.TableName])
+
+	projects, err := target.GetAllProjects()
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+
+	for _, proj := range projects {
+		inventories, err := target.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+		require.NoError(t, err)
+		require.Len(t, inventories, 1)
+		assert.Equal(t, proj.Name+" hosts", inventories[0].Name)
+
+		templates, err := target.GetTemplates(proj.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+		require.NoError(t, err)
+		require.Len(t, templates, 2)
+
+		byName := make(map[string]db.Template)
+		for _, tpl := range templates {
+			require.NotNil(t, tpl.InventoryID)
+			assert.Equal(t, inventories[0].ID, *tpl.InventoryID)
+			byName[tpl.Name] = tpl
+		}
+
+		deploy := byName[proj.Name+" deploy"]
+		require.NotNil(t, deploy.BuildTemplateID)
+		assert.Equal(t, byName[proj.Name+" build"].ID, *deploy.BuildTemplateID)
+
+		tasks, err := target.GetProjectTasks(proj.ID, db.RetrieveQueryParams{})
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+
+		for _, task := range tasks {
+			if task.TemplateType != db.TemplateDeploy {
+				assert.Equal(t, byName[proj.Name+" build"].ID, task.TemplateID)
+				continue
+			}
+
+			assert.Equal(t, deploy.ID, task.TemplateID)
+
+			require.NotNil(t, task.BuildTaskID)
+			buildTask, err := target.GetTask(proj.ID, *task.BuildTaskID)
+			require.NoError(t, err)
+			assert.Equal(t, byName[proj.Name+" build"].ID, buildTask.TemplateID)
+
+			outputs, err := target.GetTaskOutputs(proj.ID, task.ID, db.RetrieveQueryParams{})
+			require.NoError(t, err)
+			require.Len(t, outputs, 1)
+			assert.Equal(t, proj.Name+" output", outputs[0].Output)
+		}
+	}
+
+	// The non-empty target is rejected unless the migration is resumed.
+	_, err = NewMigrator(source, target, Options{}).Run()
+	var validationErr *db.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	res, err = NewMigrator(source, target, Options{Resume: true}).Run()
+	require.NoError(t, err)
+	for _, e := range res.Entities {
+		assert.Equal(t, counts[e.Table], e.Target, e.Table)
+	}
+}
+
+func TestMigrator_SqlToSql(t *testing.T) {
+	source := sql.CreateTestStore()
+
+	user, err := source.CreateUser(db.UserWithPwd{
+		Pwd:  "p@ssw0rd",
+		User: db.User{Username: "admin", Name: "Admin", Email: "admin@example.com", Admin: true},
+	})
+	require.NoError(t, err)
+
+	createBoltProject(t, source, "first", user.ID)
+
+	// API tokens have string IDs.
+	token, err := source.CreateAPIToken(db.APIToken{ID: "abcdefghijklmnopqrstuvwxyz123456", UserID: user.ID})
+	require.NoError(t, err)
+
+	target := sql.CreateTestStore()
+
+	res, err := NewMigrator(source, target, Options{}).Run()
+	require.NoError(t, err)
+
+	for _, e := range res.Entities {
+		assert.Equal(t, e.Source, e.Target, e.Table)
+	}
+
+	tokens, err := target.GetAPITokens(user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, token.ID, tokens[0].ID)
+
+	projects, err := target.GetAllProjects()
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+
+	tasks, err := target.GetProjectTasks(projects[0].ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
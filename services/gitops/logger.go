@@ -0,0 +1,49 @@
+package gitops
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	log "github.com/sirupsen/logrus"
+)
+
+// logger writes messages of Git operations to the application log.
+type logger struct {
+	entry *log.Entry
+}
+
+func newLogger(projectID int) task_logger.Logger {
+	return &logger{
+		entry: log.WithField("context", "gitops").WithField("project_id", projectID),
+	}
+}
+
+func (l *logger) Log(msg string) {
+	l.entry.Debug(msg)
+}
+
+func (l *logger) Logf(format string, a ...any) {
+	l.Log(fmt.Sprintf(format, a...))
+}
+
+func (l *logger) LogWithTime(_ time.Time, msg string) {
+	l.Log(msg)
+}
+
+func (l *logger) LogfWithTime(_ time.Time, format string, a ...any) {
+	l.Logf(format, a...)
+}
+
+func (l *logger) LogCmd(_ *exec.Cmd) {}
+
+func (l *logger) SetStatus(_ task_logger.TaskStatus) {}
+
+func (l *logger) AddStatusListener(_ task_logger.StatusListener) {}
+
+func (l *logger) AddLogListener(_ task_logger.LogListener) {}
+
+func (l *logger) SetCommit(_, _ string) {}
+
+func (l *logger) WaitLog() {}
@@ -0,0 +1,277 @@
+package gitops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db_lib"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/services/server"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// repositoryDirName is the directory inside the project tmp directory
+// where the repository with the project definition is cloned.
+const repositoryDirName = "gitops"
+
+// syncLock serializes synchronizations started by the background
+// reconciler and by the API.
+var syncLock sync.Mutex
+
+// Reconciler applies project definitions stored in repositories
+// to the projects and detects drift of the projects from their definitions.
+type Reconciler struct {
+	store             db.Store
+	encryptionService server.AccessKeyEncryptionService
+	keyInstaller      db_lib.AccessKeyInstaller
+}
+
+func NewReconciler(
+	store db.Store,
+	encryptionService server.AccessKeyEncryptionService,
+	keyInstaller db_lib.AccessKeyInstaller,
+) *Reconciler {
+	return &Reconciler{
+		store:             store,
+		encryptionService: encryptionService,
+		keyInstaller:      keyInstaller,
+	}
+}
+
+// Leader reports whether this server is the leader of the HA cluster.
+// It is implemented by tasks.TaskStateStore.
+type Leader interface {
+	IsLeader() bool
+}
+
+// Run periodically synchronizes all projects with GitOps settings.
+// Only the leader of the HA cluster does it, so servers do not write
+// the same projects concurrently.
+func (r *Reconciler) Run(leader Leader) {
+	ticker := time.NewTicker(util.Config.Gitops.GetSyncInterval())
+	defer ticker.Stop()
+
+	for {
+		if leader.IsLeader() {
+			db.StoreSession(r.store, "gitops sync", func() {
+				if err := r.SyncAll(); err != nil {
+					log.WithError(err).Error("GitOps synchronization failed")
+				}
+			})
+		}
+
+		<-ticker.C
+	}
+}
+
+// SyncAll synchronizes all projects with GitOps settings.
+// Errors of the single projects are stored in their sync status.
+func (r *Reconciler) SyncAll() error {
+	all, err := r.store.GetAllProjectGitops()
+	if err != nil {
+		return err
+	}
+
+	for _, gitops := range all {
+		res, err := r.Sync(gitops.ProjectID, false)
+		if err != nil {
+			log.WithError(err).WithField("project_id", gitops.ProjectID).Error("GitOps synchronization failed")
+			continue
+		}
+
+		if res.LastError != nil {
+			log.WithField("project_id", gitops.ProjectID).Warn("GitOps synchronization failed: " + *res.LastError)
+		}
+	}
+
+	return nil
+}
+
+// Sync fetches the definition of the project from the repository.
+// The definition is applied if it was changed since the last synchronization
+// or force is set, otherwise changes of the project made outside
+// the repository are stored as drift.
+// Returned error means the sync status can not be stored, errors of the
+// synchronization itself are stored in the LastError field.
+func (r *Reconciler) Sync(projectID int, force bool) (gitops db.ProjectGitops, err error) {
+	syncLock.Lock()
+	defer syncLock.Unlock()
+
+	gitops, err = r.store.GetProjectGitops(projectID)
+	if err != nil {
+		return
+	}
+
+	syncErr := r.sync(&gitops, force)
+
+	now := tz.Now()
+	gitops.LastSync = &now
+	gitops.LastError = nil
+
+	if syncErr != nil {
+		msg := syncErr.Error()
+		gitops.LastError = &msg
+	}
+
+	err = r.store.UpdateProjectGitopsStatus(gitops)
+	return
+}
+
+func (r *Reconciler) sync(gitops *db.ProjectGitops, force bool) error {
+	revision, data, err := r.fetch(*gitops)
+	if err != nil {
+		return err
+	}
+
+	definition, err := ParseDefinition(gitops.Path, data)
+	if err != nil {
+		return err
+	}
+
+	applied := !force && gitops.LastCommit != nil && *gitops.LastCommit == revision
+
+	diff, err := definition.RestoreInto(gitops.ProjectID, r.store, projectService.RestoreIntoOptions{
+		DryRun: applied,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !applied {
+		gitops.LastCommit = &revision
+		gitops.Drift = nil
+		return nil
+	}
+
+	gitops.Drift = nil
+	if len(diff.Changes) > 0 {
+		gitops.Drift = db.ObjectToJSON(diff.Changes)
+	}
+
+	return nil
+}
+
+// fetch returns revision and content of the definition file. The revision is
+// the commit hash for remote repositories and the content hash for local ones.
+func (r *Reconciler) fetch(gitops db.ProjectGitops) (revision string, data []byte, err error) {
+	repo, err := r.store.GetRepository(gitops.ProjectID, gitops.RepositoryID)
+	if err != nil {
+		return
+	}
+
+	if repo.GetType() == db.RepositoryLocal {
+		data, err = readDefinition(repo.GetFullPath(0), gitops.Path)
+		if err != nil {
+			return
+		}
+		sum := sha256.Sum256(data)
+		revision = hex.EncodeToString(sum[:])
+		return
+	}
+
+	err = r.encryptionService.DeserializeSecret(&repo.SSHKey)
+	if err != nil {
+		return
+	}
+
+	git := db_lib.GitRepository{
+		TmpDirName: repositoryDirName,
+		Repository: repo,
+		Logger:     newLogger(gitops.ProjectID),
+		Client:     db_lib.CreateDefaultGitClient(r.keyInstaller),
+	}
+
+	if err = updateRepository(git); err != nil {
+		return
+	}
+
+	revision, err = git.GetLastCommitHash()
+	if err != nil {
+		return
+	}
+
+	data, err = readDefinition(git.GetFullPath(), gitops.Path)
+	return
+}
+
+func updateRepository(git db_lib.GitRepository) error {
+	if git.CanBePulled() {
+		if err := git.Pull(); err == nil {
+			return nil
+		}
+	}
+
+	if err := os.RemoveAll(git.GetFullPath()); err != nil {
+		return err
+	}
+
+	return git.Clone()
+}
+
+// readDefinition reads the file which must be located inside the repository directory.
+// Symlinks are resolved before the check, so they can not point outside the repository.
+func readDefinition(repoDir string, filePath string) ([]byte, error) {
+	realRepoDir, err := filepath.EvalSymlinks(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath, err := filepath.EvalSymlinks(filepath.Join(repoDir, filepath.FromSlash(filePath)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("definition file %s not found in the repository", filePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(realRepoDir, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("definition path %s is outside of the repository", filePath)
+	}
+
+	return os.ReadFile(fullPath)
+}
+
+// ParseDefinition parses the project definition in the backup format.
+// Files with .yml and .yaml extensions are parsed as YAML, others as JSON.
+func ParseDefinition(filePath string, data []byte) (*projectService.BackupFormat, error) {
+	str := string(data)
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yml", ".yaml":
+		var obj any
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("invalid definition: %s", err.Error())
+		}
+
+		bytes, err := json.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("invalid definition: %s", err.Error())
+		}
+		str = string(bytes)
+	}
+
+	var definition projectService.BackupFormat
+
+	if err := definition.Unmarshal(str); err != nil {
+		return nil, fmt.Errorf("invalid definition: %s", err.Error())
+	}
+
+	if err := definition.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid definition: %s", err.Error())
+	}
+
+	return &definition, nil
+}
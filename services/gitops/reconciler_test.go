@@ -0,0 +1,179 @@
+package gitops
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/services/server"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDefinition = `
+meta:
+  name: Managed
+keys:
+  - name: None
+    type: none
+repositories:
+  - name: Repo
+    git_url: git@example.com:test/test
+    git_branch: master
+    ssh_key: None
+inventories:
+  - name: Hosts
+    type: static
+    inventory: localhost
+environments:
+  - name: Env
+    json: "{}"
+templates:
+  - name: Deploy
+    playbook: deploy.yml
+    app: ansible
+    repository: Repo
+    inventory: Hosts
+    environment: Env
+views: []
+`
+
+func TestParseDefinition(t *testing.T) {
+	definition, err := ParseDefinition("project.yml", []byte(testDefinition))
+	require.NoError(t, err)
+	require.Len(t, definition.Templates, 1)
+	assert.Equal(t, "Deploy", definition.Templates[0].Name)
+	assert.Equal(t, "Repo", definition.Templates[0].Repository)
+
+	_, err = ParseDefinition("project.json", []byte(testDefinition))
+	assert.Error(t, err)
+
+	_, err = ParseDefinition("project.yml", []byte(`
+templates:
+  - name: Deploy
+    repository: Missing
+`))
+	assert.Error(t, err)
+}
+
+func TestReadDefinition_OutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	require.NoError(t, os.Mkdir(repoDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.yml"), []byte("secret"), 0644))
+
+	_, err := readDefinition(repoDir, "../secret.yml")
+	assert.ErrorContains(t, err, "outside of the repository")
+}
+
+func TestReadDefinition_SymlinkOutsideRepository(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	require.NoError(t, os.Mkdir(repoDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.yml"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret.yml"), filepath.Join(repoDir, "project.yml")))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "real.yml"), []byte(testDefinition), 0644))
+	require.NoError(t, os.Symlink("real.yml", filepath.Join(repoDir, "link.yml")))
+
+	_, err := readDefinition(repoDir, "project.yml")
+	assert.ErrorContains(t, err, "outside of the repository")
+
+	data, err := readDefinition(repoDir, "link.yml")
+	require.NoError(t, err)
+	assert.Equal(t, testDefinition, string(data))
+}
+
+func TestReconciler_Sync(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	encryptionService := server.NewAccessKeyEncryptionService(store, store, store)
+	reconciler := NewReconciler(store, encryptionService, server.NewAccessKeyInstallationService(encryptionService))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "project.yml"), []byte(testDefinition), 0644))
+
+	proj, err := store.CreateProject(db.Project{Name: "Managed"})
+	require.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "Local",
+		ProjectID: &proj.ID,
+		Type:      db.AccessKeyNone,
+	})
+	require.NoError(t, err)
+
+	repo, err := store.CreateRepository(db.Repository{
+		ProjectID: proj.ID,
+		SSHKeyID:  key.ID,
+		Name:      "Definition",
+		GitURL:    dir,
+	})
+	require.NoError(t, err)
+
+	_, err = store.SetProjectGitops(db.ProjectGitops{
+		ProjectID:    proj.ID,
+		RepositoryID: repo.ID,
+		Path:         "project.yml",
+	})
+	require.NoError(t, err)
+
+	gitops, err := reconciler.Sync(proj.ID, false)
+	require.NoError(t, err)
+	assert.Nil(t, gitops.LastError)
+	assert.NotNil(t, gitops.LastCommit)
+	assert.NotNil(t, gitops.LastSync)
+	assert.Nil(t, gitops.Drift)
+
+	templates, err := store.GetTemplates(proj.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Deploy", templates[0].Name)
+
+	// Change made outside the repository is reported as drift.
+	_, err = store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+		Name:      "Manual",
+		Type:      db.InventoryStatic,
+	})
+	require.NoError(t, err)
+
+	gitops, err = reconciler.Sync(proj.ID, false)
+	require.NoError(t, err)
+	assert.Nil(t, gitops.LastError)
+	require.NotNil(t, gitops.Drift)
+
+	var drift []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(*gitops.Drift), &drift))
+	assert.Equal(t, []map[string]string{
+		{"kind": "inventories", "name": "Manual", "action": "delete"},
+	}, drift)
+
+	inventories, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, inventories, 2)
+
+	// Forced sync applies the definition again.
+	gitops, err = reconciler.Sync(proj.ID, true)
+	require.NoError(t, err)
+	assert.Nil(t, gitops.Drift)
+
+	inventories, err = store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	require.Len(t, inventories, 1)
+	assert.Equal(t, "Hosts", inventories[0].Name)
+
+	// Errors of the synchronization are stored in the status.
+	require.NoError(t, os.Remove(filepath.Join(dir, "project.yml")))
+
+	gitops, err = reconciler.Sync(proj.ID, false)
+	require.NoError(t, err)
+	require.NotNil(t, gitops.LastError)
+	assert.Contains(t, *gitops.LastError, "not found")
+}
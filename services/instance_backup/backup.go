@@ -0,0 +1,256 @@
+package instance_backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// Create writes the archive with all projects and the global state
+// of the instance: users, global roles, options, runners and API tokens.
+// The archive is gzip compressed tar and does not depend on the database dialect.
+// Task history and sessions are not included.
+func Create(store db.Store, w io.Writer, options Options) error {
+	if options.Passphrase == "" {
+		return db.NewValidationError("passphrase can not be empty")
+	}
+
+	instance, projects, err := load(store, options)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(instance, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = writeEntry(tw, instanceFileName, data); err != nil {
+		return err
+	}
+
+	for _, p := range instance.Projects {
+		if err = writeEntry(tw, projectFileName(p.ID), []byte(projects[p.ID])); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func projectFileName(projectID int) string {
+	return projectsDir + strconv.Itoa(projectID) + ".json"
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: tz.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
+
+// load collects the global state and backups of the projects.
+// Project backups are returned by the project ID.
+func load(store db.Store, options Options) (instance Instance, projects map[int]string, err error) {
+	dialect, _ := util.Config.GetDialect()
+
+	instance.Meta = Meta{
+		Format:  formatVersion,
+		Version: util.Version(),
+		Created: tz.Now(),
+		Dialect: dialect,
+	}
+
+	instance.IntegrationAlias = util.Config.IntegrationAlias
+
+	secrets := secretData{
+		Passwords: make(map[string]string),
+		Totp:      make(map[string]totpSecret),
+	}
+
+	users, err := store.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, u := range users {
+		instance.Users = append(instance.Users, User{
+			Username: u.Username,
+			Name:     u.Name,
+			Email:    u.Email,
+			Created:  u.Created,
+			Admin:    u.Admin,
+			External: u.External,
+			Ldap:     u.Ldap,
+			Alert:    u.Alert,
+			Pro:      u.Pro,
+		})
+
+		if u.Password != "" {
+			secrets.Passwords[u.Username] = u.Password
+		}
+
+		var user db.User
+		user, err = store.GetUser(u.ID)
+		if err != nil {
+			return
+		}
+
+		if user.Totp != nil {
+			secrets.Totp[u.Username] = totpSecret{
+				URL:          user.Totp.URL,
+				RecoveryHash: user.Totp.RecoveryHash,
+			}
+		}
+
+		var tokens []db.APIToken
+		tokens, err = store.GetAPITokens(u.ID)
+		if err != nil {
+			return
+		}
+
+		for _, t := range tokens {
+			if !t.IsActive(tz.Now()) {
+				continue
+			}
+
+			secrets.Tokens = append(secrets.Tokens, apiToken{
+				ID:        t.ID,
+				Username:  u.Username,
+				Name:      t.Name,
+				ExpiresAt: t.ExpiresAt,
+				ProjectID: t.ProjectID,
+				Scopes:    t.Scopes,
+			})
+		}
+	}
+
+	instance.Roles, err = store.GetGlobalRoles()
+	if err != nil {
+		return
+	}
+
+	instance.Options, err = store.GetOptions(db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	runners, err := store.GetAllRunners(false, false)
+	if err != nil {
+		return
+	}
+
+	for _, r := range runners {
+		instance.Runners = append(instance.Runners, Runner{
+			Name:             r.Name,
+			ProjectID:        r.ProjectID,
+			Webhook:          r.Webhook,
+			MaxParallelTasks: r.MaxParallelTasks,
+			Active:           r.Active,
+			Tag:              r.Tag,
+			PublicKey:        r.PublicKey,
+			Labels:           r.Labels,
+		})
+		secrets.RunnerTokens = append(secrets.RunnerTokens, r.Token)
+	}
+
+	allProjects, err := store.GetAllProjects()
+	if err != nil {
+		return
+	}
+
+	projects = make(map[int]string)
+
+	for _, p := range allProjects {
+		var project Project
+		project, err = loadProject(store, p)
+		if err != nil {
+			return
+		}
+
+		var backup *projectService.BackupFormat
+		backup, err = projectService.GetBackupWithSecrets(p.ID, store, options.projectSecrets())
+		if err != nil {
+			err = fmt.Errorf("failed to backup project %s: %w", p.Name, err)
+			return
+		}
+
+		projects[p.ID], err = backup.Marshal()
+		if err != nil {
+			return
+		}
+
+		instance.Projects = append(instance.Projects, project)
+	}
+
+	instance.Secrets, err = projectService.EncryptBackupData(secrets, options.Passphrase)
+	return
+}
+
+func loadProject(store db.Store, p db.Project) (project Project, err error) {
+	project = Project{
+		ID:      p.ID,
+		Name:    p.Name,
+		Members: make([]Member, 0),
+	}
+
+	users, err := store.GetProjectUsers(p.ID, db.RetrieveQueryParams{})
+	if err != nil {
+		return
+	}
+
+	for _, u := range users {
+		project.Members = append(project.Members, Member{
+			Username: u.Username,
+			Role:     u.Role,
+		})
+	}
+
+	gitops, err := store.GetProjectGitops(p.ID)
+
+	if errors.Is(err, db.ErrNotFound) {
+		err = nil
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	repo, err := store.GetRepository(p.ID, gitops.RepositoryID)
+	if err != nil {
+		return
+	}
+
+	project.Gitops = &Gitops{
+		Repository:  repo.Name,
+		Path:        gitops.Path,
+		LockManaged: gitops.LockManaged,
+	}
+
+	return
+}
@@ -0,0 +1,226 @@
+package instance_backup
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/services/server"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createSourceInstance(t *testing.T) (db.Store, server.AccessKeyEncryptionService) {
+	store := sql.CreateTestStore()
+	util.Config.TmpPath = "/tmp"
+
+	encryptionService := server.NewAccessKeyEncryptionService(store, store, store)
+
+	admin, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "p@ssw0rd",
+		User: db.User{
+			Username: "admin",
+			Name:     "Admin",
+			Email:    "admin@example.com",
+			Admin:    true,
+		},
+	})
+	require.NoError(t, err)
+
+	dev, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "d3v",
+		User: db.User{
+			Username: "dev",
+			Name:     "Developer",
+			Email:    "dev@example.com",
+		},
+	})
+	require.NoError(t, err)
+
+	proj, err := store.CreateProject(db.Project{Name: "Infra"})
+	require.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: admin.ID, Role: db.ProjectOwner})
+	require.NoError(t, err)
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: dev.ID, Role: db.ProjectTaskRunner})
+	require.NoError(t, err)
+
+	key := db.AccessKey{
+		Name:      "deploy",
+		ProjectID: &proj.ID,
+		Type:      db.AccessKeyLoginPassword,
+		LoginPassword: db.LoginPassword{
+			Login:    "root",
+			Password: "secret",
+		},
+	}
+	require.NoError(t, encryptionService.SerializeSecret(&key))
+	_, err = store.CreateAccessKey(key)
+	require.NoError(t, err)
+
+	_, err = store.CreateRunner(db.Runner{
+		Name:             "global",
+		Token:            "runner-token",
+		MaxParallelTasks: 2,
+		Active:           true,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateAPIToken(db.APIToken{
+		ID:        "api-token",
+		UserID:    dev.ID,
+		Name:      "CI",
+		ProjectID: &proj.ID,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.SetOption("test_option", "value"))
+
+	return store, encryptionService
+}
+
+func TestBackupRestore(t *testing.T) {
+	source, sourceEncryption := createSourceInstance(t)
+
+	var buf bytes.Buffer
+	err := Create(source, &buf, Options{
+		Passphrase:        "passphrase",
+		EncryptionService: sourceEncryption,
+	})
+	require.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "runner-token")
+
+	archiveData := buf.Bytes()
+
+	// Wrong passphrase is rejected before anything is restored.
+	target := bolt.CreateTestStore()
+	targetEncryption := server.NewAccessKeyEncryptionService(target, target, target)
+
+	_, err = Restore(target, bytes.NewReader(archiveData), Options{
+		Passphrase:        "wrong",
+		EncryptionService: targetEncryption,
+	})
+	var validationErr *db.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	users, err := target.GetUsers(db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	res, err := Restore(target, bytes.NewReader(archiveData), Options{
+		Passphrase:        "passphrase",
+		EncryptionService: targetEncryption,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Users)
+	assert.Equal(t, 1, res.Projects)
+	assert.Equal(t, 1, res.Runners)
+	assert.Equal(t, 1, res.Tokens)
+	assert.Empty(t, res.ExistingUsers)
+
+	sourceAdmin, err := source.GetUserByLoginOrEmail("admin", "")
+	require.NoError(t, err)
+	admin, err := target.GetUserByLoginOrEmail("admin", "")
+	require.NoError(t, err)
+	assert.Equal(t, sourceAdmin.Password, admin.Password)
+	assert.True(t, admin.Admin)
+
+	projects, err := target.GetAllProjects()
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, "Infra", projects[0].Name)
+
+	members, err := target.GetProjectUsers(projects[0].ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	roles := make(map[string]db.ProjectUserRole)
+	for _, m := range members {
+		roles[m.Username] = m.Role
+	}
+	assert.Equal(t, map[string]db.ProjectUserRole{
+		"admin": db.ProjectOwner,
+		"dev":   db.ProjectTaskRunner,
+	}, roles)
+
+	keys, err := target.GetAccessKeys(projects[0].ID, db.GetAccessKeyOptions{}, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	key, err := target.GetAccessKey(projects[0].ID, keys[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, targetEncryption.DeserializeSecret(&key))
+	assert.Equal(t, "secret", key.LoginPassword.Password)
+
+	runner, err := target.GetRunnerByToken("runner-token")
+	require.NoError(t, err)
+	assert.Equal(t, "global", runner.Name)
+
+	token, err := target.GetAPIToken("api-token")
+	require.NoError(t, err)
+	require.NotNil(t, token.ProjectID)
+	assert.Equal(t, projects[0].ID, *token.ProjectID)
+
+	value, err := target.GetOption("test_option")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// The instance with projects can not be restored again.
+	_, err = Restore(target, bytes.NewReader(archiveData), Options{
+		Passphrase:        "passphrase",
+		EncryptionService: targetEncryption,
+	})
+	assert.ErrorAs(t, err, &validationErr)
+}
+
+// failingTokenStore fails to create API tokens, which are restored last.
+type failingTokenStore struct {
+	db.Store
+}
+
+func (s failingTokenStore) CreateAPIToken(db.APIToken) (db.APIToken, error) {
+	return db.APIToken{}, errors.New("failed to create token")
+}
+
+func TestRestore_RetryAfterError(t *testing.T) {
+	source, sourceEncryption := createSourceInstance(t)
+
+	util.Config.IntegrationAlias = "global-alias"
+	defer func() { util.Config.IntegrationAlias = "" }()
+
+	var buf bytes.Buffer
+	err := Create(source, &buf, Options{
+		Passphrase:        "passphrase",
+		EncryptionService: sourceEncryption,
+	})
+	require.NoError(t, err)
+
+	target := bolt.CreateTestStore()
+	targetEncryption := server.NewAccessKeyEncryptionService(target, target, target)
+
+	_, err = Restore(failingTokenStore{target}, bytes.NewReader(buf.Bytes()), Options{
+		Passphrase:        "passphrase",
+		EncryptionService: targetEncryption,
+	})
+	require.Error(t, err)
+
+	projects, err := target.GetAllProjects()
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+
+	_, err = target.GetRunnerByToken("runner-token")
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	res, err := Restore(target, bytes.NewReader(buf.Bytes()), Options{
+		Passphrase:        "passphrase",
+		EncryptionService: targetEncryption,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Projects)
+	assert.Equal(t, 1, res.Runners)
+	assert.Equal(t, 1, res.Tokens)
+	assert.Equal(t, []string{"admin", "dev"}, res.ExistingUsers)
+	assert.Equal(t, "global-alias", res.IntegrationAlias)
+}
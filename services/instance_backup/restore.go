@@ -0,0 +1,564 @@
+package instance_backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxEntrySize limits the size of the single archive entry.
+const maxEntrySize = 1 << 30
+
+// archive is the parsed and verified content of the instance backup.
+type archive struct {
+	instance Instance
+	secrets  secretData
+	projects map[int]*projectService.BackupFormat
+}
+
+// readArchive parses entries while reading the archive, so only the parsed
+// content is kept in memory.
+func readArchive(r io.Reader, passphrase string) (res archive, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		err = db.NewValidationError("invalid backup archive: " + err.Error())
+		return
+	}
+	defer gz.Close() //nolint:errcheck
+
+	hasInstance := false
+	projects := make(map[string]*projectService.BackupFormat)
+
+	tr := tar.NewReader(gz)
+	for {
+		var hdr *tar.Header
+		hdr, err = tr.Next()
+		if errors.Is(err, io.EOF) {
+			err = nil
+			break
+		}
+		if err != nil {
+			err = db.NewValidationError("invalid backup archive: " + err.Error())
+			return
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if hdr.Size > maxEntrySize {
+			err = db.NewValidationError("backup archive entry " + hdr.Name + " is too large")
+			return
+		}
+
+		switch {
+		case hdr.Name == instanceFileName:
+			if err = json.NewDecoder(tr).Decode(&res.instance); err != nil {
+				err = db.NewValidationError("invalid " + instanceFileName + ": " + err.Error())
+				return
+			}
+			hasInstance = true
+		case strings.HasPrefix(hdr.Name, projectsDir):
+			var data []byte
+			if data, err = io.ReadAll(tr); err != nil {
+				return
+			}
+
+			backup := &projectService.BackupFormat{}
+			if err = backup.Unmarshal(string(data)); err != nil {
+				err = db.NewValidationError(fmt.Sprintf("invalid backup archive entry %s: %s", hdr.Name, err.Error()))
+				return
+			}
+			projects[hdr.Name] = backup
+		}
+	}
+
+	if !hasInstance {
+		err = db.NewValidationError("backup archive does not contain " + instanceFileName)
+		return
+	}
+
+	if res.instance.Meta.Format != formatVersion {
+		err = db.NewValidationError(fmt.Sprintf("unsupported backup format %d", res.instance.Meta.Format))
+		return
+	}
+
+	if res.instance.Secrets == nil {
+		err = db.NewValidationError("backup archive does not contain secrets")
+		return
+	}
+
+	if err = res.instance.Secrets.Decrypt(passphrase, &res.secrets); err != nil {
+		return
+	}
+
+	if len(res.secrets.RunnerTokens) != len(res.instance.Runners) {
+		err = db.NewValidationError("runner tokens do not match runners")
+		return
+	}
+
+	res.projects = make(map[int]*projectService.BackupFormat)
+
+	for _, p := range res.instance.Projects {
+		backup, ok := projects[projectFileName(p.ID)]
+		if !ok {
+			err = db.NewValidationError("backup archive does not contain project " + p.Name)
+			return
+		}
+
+		if err = backup.Verify(); err != nil {
+			err = db.NewValidationError(fmt.Sprintf("invalid backup of project %s: %s", p.Name, err.Error()))
+			return
+		}
+
+		if p.Gitops != nil && !hasRepository(backup, p.Gitops.Repository) {
+			err = db.NewValidationError(fmt.Sprintf("invalid backup of project %s: GitOps repository %s not found",
+				p.Name, p.Gitops.Repository))
+			return
+		}
+
+		res.projects[p.ID] = backup
+	}
+
+	return
+}
+
+func hasRepository(backup *projectService.BackupFormat, name string) bool {
+	for _, repo := range backup.Repositories {
+		if repo.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreState maps objects of the source instance to the restored ones.
+type restoreState struct {
+	store   db.Store
+	options Options
+	archive archive
+	result  RestoreResult
+
+	users    map[string]db.User
+	projects map[int]int
+
+	// undo removes restored projects, runners and API tokens if restore fails.
+	undo []func() error
+}
+
+func (s *restoreState) onRollback(f func() error) {
+	s.undo = append(s.undo, f)
+}
+
+func (s *restoreState) rollback() {
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		if err := s.undo[i](); err != nil {
+			log.WithError(err).Error("Failed to revert restored instance backup")
+		}
+	}
+}
+
+// Restore restores the archive created by Create into the instance which has
+// no projects. Users existing in the instance are kept unchanged. The whole
+// archive is validated before the instance is changed. Objects are restored
+// in order of their dependencies. If restore fails, restored projects, runners
+// and API tokens are removed. Users, global roles and options are kept, restoring
+// them again does not change them, so the archive can be restored again.
+func Restore(store db.Store, r io.Reader, options Options) (*RestoreResult, error) {
+	if options.Passphrase == "" {
+		return nil, db.NewValidationError("passphrase can not be empty")
+	}
+
+	a, err := readArchive(r, options.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := store.GetAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) > 0 {
+		return nil, db.NewValidationError("backup can be restored only into the instance without projects")
+	}
+
+	if err = checkProjectOwners(store, a.instance); err != nil {
+		return nil, err
+	}
+
+	s := &restoreState{
+		store:    store,
+		options:  options,
+		archive:  a,
+		users:    make(map[string]db.User),
+		projects: make(map[int]int),
+		result: RestoreResult{
+			ExistingUsers:    make([]string, 0),
+			IntegrationAlias: a.instance.IntegrationAlias,
+		},
+	}
+
+	steps := []func() error{
+		s.restoreUsers,
+		s.restoreRoles,
+		s.restoreOptions,
+		s.restoreProjects,
+		s.restoreRunners,
+		s.restoreTokens,
+	}
+
+	for _, step := range steps {
+		if err = step(); err != nil {
+			s.rollback()
+			return nil, err
+		}
+	}
+
+	if a.instance.IntegrationAlias != "" && a.instance.IntegrationAlias != util.Config.IntegrationAlias {
+		log.Warn("The global integration alias of the backup differs from the configuration, " +
+			"set global_integration_alias to " + a.instance.IntegrationAlias)
+	}
+
+	return &s.result, nil
+}
+
+// checkProjectOwners returns an error if the owner of the project can not be found.
+// The owner is the owner member of the project, an admin from the archive
+// or an admin of the instance.
+func checkProjectOwners(store db.Store, instance Instance) error {
+	users := make(map[string]bool)
+	hasAdmin := false
+
+	for _, u := range instance.Users {
+		users[u.Username] = true
+		hasAdmin = hasAdmin || u.Admin
+	}
+
+	if hasAdmin {
+		return nil
+	}
+
+	admins, err := store.GetAllAdmins()
+	if err != nil {
+		return err
+	}
+
+	if len(admins) > 0 {
+		return nil
+	}
+
+	for _, p := range instance.Projects {
+		if !slices.ContainsFunc(p.Members, func(m Member) bool {
+			return m.Role == db.ProjectOwner && users[m.Username]
+		}) {
+			return db.NewValidationError("no owner for project " + p.Name)
+		}
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreUsers() error {
+	for _, u := range s.archive.instance.Users {
+		existing, err := s.store.GetUserByLoginOrEmail(u.Username, u.Email)
+
+		if err == nil {
+			s.users[u.Username] = existing
+			s.result.ExistingUsers = append(s.result.ExistingUsers, u.Username)
+			continue
+		}
+
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+
+		user, err := s.store.ImportUser(db.User{
+			Username: u.Username,
+			Name:     u.Name,
+			Email:    u.Email,
+			Created:  u.Created,
+			Admin:    u.Admin,
+			External: u.External,
+			Ldap:     u.Ldap,
+			Alert:    u.Alert,
+			Pro:      u.Pro,
+			Password: s.archive.secrets.Passwords[u.Username],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore user %s: %w", u.Username, err)
+		}
+
+		if totp, ok := s.archive.secrets.Totp[u.Username]; ok {
+			if _, err = s.store.AddTotpVerification(user.ID, totp.URL, totp.RecoveryHash); err != nil {
+				return fmt.Errorf("failed to restore TOTP of user %s: %w", u.Username, err)
+			}
+		}
+
+		s.users[u.Username] = user
+		s.result.Users++
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreRoles() error {
+	for _, role := range s.archive.instance.Roles {
+		role.ProjectID = nil
+
+		_, err := s.store.GetGlobalRoleBySlug(role.Slug)
+
+		switch {
+		case err == nil:
+			err = s.store.UpdateRole(role)
+		case errors.Is(err, db.ErrNotFound):
+			_, err = s.store.CreateRole(role)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to restore role %s: %w", role.Slug, err)
+		}
+
+		s.result.Roles++
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreOptions() error {
+	for key, value := range s.archive.instance.Options {
+		if err := s.store.SetOption(key, value); err != nil {
+			return fmt.Errorf("failed to restore option %s: %w", key, err)
+		}
+		s.result.Options++
+	}
+
+	return nil
+}
+
+// projectOwner returns the user which restores the project. It is the
+// first owner of the project or the first admin if the project has no owners.
+func (s *restoreState) projectOwner(p Project) (db.User, error) {
+	for _, m := range p.Members {
+		if m.Role == db.ProjectOwner {
+			if user, ok := s.users[m.Username]; ok {
+				return user, nil
+			}
+		}
+	}
+
+	for _, u := range s.archive.instance.Users {
+		if u.Admin {
+			return s.users[u.Username], nil
+		}
+	}
+
+	admins, err := s.store.GetAllAdmins()
+	if err != nil {
+		return db.User{}, err
+	}
+
+	if len(admins) == 0 {
+		return db.User{}, fmt.Errorf("no owner for project %s", p.Name)
+	}
+
+	return admins[0], nil
+}
+
+func (s *restoreState) restoreProjects() error {
+	for _, p := range s.archive.instance.Projects {
+		owner, err := s.projectOwner(p)
+		if err != nil {
+			return err
+		}
+
+		project, err := s.archive.projects[p.ID].RestoreWithSecrets(owner, s.store, s.options.projectSecrets())
+		if err != nil {
+			return fmt.Errorf("failed to restore project %s: %w", p.Name, err)
+		}
+
+		projectID := project.ID
+		s.onRollback(func() error { return s.store.DeleteProject(projectID) })
+
+		s.projects[p.ID] = project.ID
+
+		if err = s.restoreMembers(project.ID, owner, p.Members); err != nil {
+			return fmt.Errorf("failed to restore members of project %s: %w", p.Name, err)
+		}
+
+		if err = s.restoreGitops(project.ID, p.Gitops); err != nil {
+			return fmt.Errorf("failed to restore GitOps settings of project %s: %w", p.Name, err)
+		}
+
+		s.result.Projects++
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreMembers(projectID int, owner db.User, members []Member) error {
+	ownerIsMember := false
+
+	for _, m := range members {
+		user, ok := s.users[m.Username]
+		if !ok {
+			continue
+		}
+
+		projectUser := db.ProjectUser{
+			ProjectID: projectID,
+			UserID:    user.ID,
+			Role:      m.Role,
+		}
+
+		if user.ID == owner.ID {
+			// RestoreWithSecrets adds the owner to the project.
+			ownerIsMember = true
+			if err := s.store.UpdateProjectUser(projectUser); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := s.store.CreateProjectUser(projectUser); err != nil {
+			return err
+		}
+	}
+
+	if !ownerIsMember {
+		return s.store.DeleteProjectUser(projectID, owner.ID)
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreGitops(projectID int, gitops *Gitops) error {
+	if gitops == nil {
+		return nil
+	}
+
+	repos, err := s.store.GetRepositories(projectID, db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if repo.Name != gitops.Repository {
+			continue
+		}
+
+		_, err = s.store.SetProjectGitops(db.ProjectGitops{
+			ProjectID:    projectID,
+			RepositoryID: repo.ID,
+			Path:         gitops.Path,
+			LockManaged:  gitops.LockManaged,
+		})
+		return err
+	}
+
+	return fmt.Errorf("repository %s not found", gitops.Repository)
+}
+
+// projectID maps the project ID of the source instance to the restored project.
+func (s *restoreState) projectID(sourceID *int) (*int, bool) {
+	if sourceID == nil {
+		return nil, true
+	}
+
+	id, ok := s.projects[*sourceID]
+	return &id, ok
+}
+
+func (s *restoreState) restoreRunners() error {
+	for i, r := range s.archive.instance.Runners {
+		token := s.archive.secrets.RunnerTokens[i]
+
+		if _, err := s.store.GetRunnerByToken(token); err == nil {
+			continue
+		}
+
+		projectID, ok := s.projectID(r.ProjectID)
+		if !ok {
+			log.WithField("runner", r.Name).Warn("Runner of the missing project is not restored")
+			continue
+		}
+
+		runner, err := s.store.CreateRunner(db.Runner{
+			Token:            token,
+			ProjectID:        projectID,
+			Webhook:          r.Webhook,
+			MaxParallelTasks: r.MaxParallelTasks,
+			Active:           r.Active,
+			Name:             r.Name,
+			Tag:              r.Tag,
+			PublicKey:        r.PublicKey,
+			Labels:           r.Labels,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore runner %s: %w", r.Name, err)
+		}
+
+		s.onRollback(func() error {
+			if runner.ProjectID != nil {
+				return s.store.DeleteRunner(*runner.ProjectID, runner.ID)
+			}
+			return s.store.DeleteGlobalRunner(runner.ID)
+		})
+
+		s.result.Runners++
+	}
+
+	return nil
+}
+
+func (s *restoreState) restoreTokens() error {
+	for _, t := range s.archive.secrets.Tokens {
+		user, ok := s.users[t.Username]
+		if !ok {
+			continue
+		}
+
+		if t.ExpiresAt != nil && !t.ExpiresAt.After(tz.Now()) {
+			continue
+		}
+
+		if _, err := s.store.GetAPIToken(t.ID); err == nil {
+			continue
+		}
+
+		projectID, ok := s.projectID(t.ProjectID)
+		if !ok {
+			continue
+		}
+
+		_, err := s.store.CreateAPIToken(db.APIToken{
+			ID:        t.ID,
+			UserID:    user.ID,
+			Name:      t.Name,
+			ExpiresAt: t.ExpiresAt,
+			ProjectID: projectID,
+			Scopes:    t.Scopes,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restore API token %s: %w", t.Name, err)
+		}
+
+		tokenID := t.ID
+		s.onRollback(func() error { return s.store.DeleteAPIToken(user.ID, tokenID) })
+
+		s.result.Tokens++
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package instance_backup
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	projectService "github.com/semaphoreui/semaphore/services/project"
+	"github.com/semaphoreui/semaphore/services/server"
+)
+
+// formatVersion is increased on incompatible changes of the archive layout.
+const formatVersion = 1
+
+const (
+	// instanceFileName is the archive entry with the global state.
+	instanceFileName = "instance.json"
+	// projectsDir contains project backups named by the project ID
+	// in the source instance.
+	projectsDir = "projects/"
+)
+
+// Options configures the instance backup and restore.
+type Options struct {
+	// Passphrase encrypts password hashes, tokens and secrets of the projects.
+	Passphrase        string
+	EncryptionService server.AccessKeyEncryptionService
+}
+
+func (o Options) projectSecrets() *projectService.SecretOptions {
+	return &projectService.SecretOptions{
+		Passphrase:        o.Passphrase,
+		EncryptionService: o.EncryptionService,
+	}
+}
+
+type Meta struct {
+	Format  int       `json:"format"`
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	// Dialect is the database dialect of the source instance.
+	// The archive does not depend on it.
+	Dialect string `json:"dialect"`
+}
+
+// Instance is the content of the instance.json archive entry.
+// Users and projects are referred by usernames and source IDs.
+type Instance struct {
+	Meta     Meta                          `json:"meta"`
+	Users    []User                        `json:"users"`
+	Roles    []db.Role                     `json:"roles"`
+	Options  map[string]string             `json:"options"`
+	Runners  []Runner                      `json:"runners"`
+	Projects []Project                     `json:"projects"`
+	Secrets  *projectService.BackupSecrets `json:"secrets"`
+	// IntegrationAlias is the global integration alias from the configuration.
+	IntegrationAlias string `json:"integration_alias,omitempty"`
+}
+
+type User struct {
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Created  time.Time `json:"created"`
+	Admin    bool      `json:"admin"`
+	External bool      `json:"external"`
+	Ldap     bool      `json:"ldap,omitempty"`
+	Alert    bool      `json:"alert"`
+	Pro      bool      `json:"pro"`
+}
+
+type Member struct {
+	Username string             `json:"username"`
+	Role     db.ProjectUserRole `json:"role"`
+}
+
+type Gitops struct {
+	Repository  string `json:"repository"`
+	Path        string `json:"path"`
+	LockManaged bool   `json:"lock_managed"`
+}
+
+type Project struct {
+	// ID is the project ID in the source instance.
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Members []Member `json:"members"`
+	Gitops  *Gitops  `json:"gitops,omitempty"`
+}
+
+type Runner struct {
+	Name             string  `json:"name"`
+	ProjectID        *int    `json:"project_id,omitempty"`
+	Webhook          string  `json:"webhook"`
+	MaxParallelTasks int     `json:"max_parallel_tasks"`
+	Active           bool    `json:"active"`
+	Tag              string  `json:"tag"`
+	PublicKey        *string `json:"public_key,omitempty"`
+
+	Labels db.RunnerLabels `json:"labels,omitempty"`
+}
+
+// secretData is the plaintext of Instance.Secrets.
+type secretData struct {
+	// Passwords contains password hashes by username.
+	Passwords map[string]string `json:"passwords"`
+	// Totp contains TOTP settings by username.
+	Totp map[string]totpSecret `json:"totp"`
+	// RunnerTokens contains tokens of Instance.Runners in the same order.
+	RunnerTokens []string   `json:"runner_tokens"`
+	Tokens       []apiToken `json:"tokens"`
+}
+
+type totpSecret struct {
+	URL          string `json:"url"`
+	RecoveryHash string `json:"recovery_hash"`
+}
+
+type apiToken struct {
+	ID        string            `json:"id"`
+	Username  string            `json:"username"`
+	Name      string            `json:"name"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+	ProjectID *int              `json:"project_id,omitempty"`
+	Scopes    db.APITokenScopes `json:"scopes,omitempty"`
+}
+
+// RestoreResult describes objects restored from the archive.
+type RestoreResult struct {
+	Users    int `json:"users"`
+	Projects int `json:"projects"`
+	Roles    int `json:"roles"`
+	Options  int `json:"options"`
+	Runners  int `json:"runners"`
+	Tokens   int `json:"tokens"`
+	// ExistingUsers are users which already existed and were kept unchanged.
+	ExistingUsers []string `json:"existing_users"`
+	// IntegrationAlias is the global integration alias of the source instance.
+	// It is stored in the configuration, so it is not restored.
+	IntegrationAlias string `json:"integration_alias,omitempty"`
+}
@@ -0,0 +1,89 @@
+package ldap_sync
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// Connect opens connection to the configured LDAP server and binds
+// with the read only user.
+func Connect() (*ldap.Conn, error) {
+	if !util.Config.LdapEnable {
+		return nil, fmt.Errorf("LDAP not configured")
+	}
+
+	var l *ldap.Conn
+	var err error
+	if util.Config.LdapNeedTLS {
+		l, err = ldap.DialTLS("tcp", util.Config.LdapServer, &tls.Config{
+			InsecureSkipVerify: true,
+		})
+	} else {
+		l, err = ldap.Dial("tcp", util.Config.LdapServer)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = l.Bind(util.Config.LdapBindDN, util.Config.LdapBindPassword); err != nil {
+		l.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// findUserDN returns DN of the LDAP user or empty string if the user
+// does not exist in LDAP.
+func findUserDN(l *ldap.Conn, username string) (string, error) {
+	sr, err := l.Search(ldap.NewSearchRequest(
+		util.Config.LdapSearchDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(util.Config.LdapSearchFilter, ldap.EscapeFilter(username)),
+		[]string{util.Config.LdapMappings.DN},
+		nil,
+	))
+	if err != nil {
+		return "", err
+	}
+
+	if len(sr.Entries) < 1 {
+		return "", nil
+	}
+
+	if len(sr.Entries) > 1 {
+		return "", fmt.Errorf("too many entries returned")
+	}
+
+	return sr.Entries[0].DN, nil
+}
+
+// findUserGroups returns DNs of the groups the user is member of.
+func findUserGroups(l *ldap.Conn, conf *util.LdapGroupSyncConfig, userDN string) ([]string, error) {
+	searchDN := conf.SearchDN
+	if searchDN == "" {
+		searchDN = util.Config.LdapSearchDN
+	}
+
+	sr, err := l.Search(ldap.NewSearchRequest(
+		searchDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(conf.GetSearchFilter(), ldap.EscapeFilter(userDN)),
+		[]string{"dn"},
+		nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(sr.Entries))
+	for _, entry := range sr.Entries {
+		groups = append(groups, entry.DN)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,171 @@
+package ldap_sync
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/membership"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// GroupSync synchronizes the admin flag and project memberships of external
+// users with their LDAP groups. Only projects mentioned in the mappings are
+// managed, memberships in other projects are never touched. External users
+// which did not come from LDAP, like users of OpenID providers, are skipped.
+// LDAP users which were removed from LDAP lose the synchronized memberships
+// and the admin flag.
+type GroupSync struct {
+	store db.Store
+	conf  *util.LdapGroupSyncConfig
+}
+
+func NewGroupSync(store db.Store, conf *util.LdapGroupSyncConfig) *GroupSync {
+	return &GroupSync{
+		store: store,
+		conf:  conf,
+	}
+}
+
+// Leader reports whether this server is the leader of the HA cluster.
+// It is implemented by tasks.TaskStateStore.
+type Leader interface {
+	IsLeader() bool
+}
+
+// Run periodically synchronizes all external users.
+// Only the leader of the HA cluster does it.
+func (s *GroupSync) Run(leader Leader) {
+	ticker := time.NewTicker(s.conf.GetSyncInterval())
+	defer ticker.Stop()
+
+	for {
+		if leader.IsLeader() {
+			db.StoreSession(s.store, "ldap group sync", func() {
+				if err := s.SyncAll(); err != nil {
+					log.WithError(err).Error("LDAP group synchronization failed")
+				}
+			})
+		}
+
+		<-ticker.C
+	}
+}
+
+// SyncAll synchronizes all external users which exist in LDAP.
+func (s *GroupSync) SyncAll() error {
+	l, err := Connect()
+	if err != nil {
+		return err
+	}
+	defer l.Close() //nolint:errcheck
+
+	users, err := s.store.GetUsers(db.RetrieveQueryParams{})
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if !user.External {
+			continue
+		}
+
+		if err = s.syncUser(l, user); err != nil {
+			log.WithError(err).WithField("user", user.Username).Error("Failed to sync LDAP groups of user")
+		}
+	}
+
+	return nil
+}
+
+// SyncUser looks up groups of the user in LDAP and applies them.
+func (s *GroupSync) SyncUser(user db.User) error {
+	l, err := Connect()
+	if err != nil {
+		return err
+	}
+	defer l.Close() //nolint:errcheck
+
+	return s.syncUser(l, user)
+}
+
+func (s *GroupSync) syncUser(l *ldap.Conn, user db.User) error {
+	userDN, err := findUserDN(l, user.Username)
+	if err != nil {
+		return err
+	}
+
+	if userDN == "" {
+		return s.applyMissing(user)
+	}
+
+	// The user is found in LDAP but was created before LDAP users were flagged.
+	if !user.Ldap {
+		if err = s.store.SetUserLdap(user.ID); err != nil {
+			return err
+		}
+		user.Ldap = true
+	}
+
+	groups, err := findUserGroups(l, s.conf, userDN)
+	if err != nil {
+		return err
+	}
+
+	return s.ApplyGroups(user, groups)
+}
+
+// applyMissing handles the external user which is not found in LDAP.
+// External users are also created by OpenID providers, they are not managed
+// by the synchronization. LDAP users were removed from LDAP, so they lose
+// the synchronized memberships and the admin flag.
+func (s *GroupSync) applyMissing(user db.User) error {
+	if !user.Ldap {
+		log.WithField("user", user.Username).Debug("User is not found in LDAP, groups are not synchronized")
+		return nil
+	}
+
+	log.WithField("user", user.Username).Info("User is removed from LDAP, synchronized roles are revoked")
+
+	return s.ApplyGroups(user, nil)
+}
+
+// ApplyGroups updates the admin flag and memberships of the user
+// in the managed projects according to the given group DNs.
+func (s *GroupSync) ApplyGroups(user db.User, groups []string) error {
+	state := membership.State{
+		ManageAdmin: s.conf.AdminGroupDN != "",
+		Admin:       s.conf.AdminGroupDN != "" && containsDN(groups, s.conf.AdminGroupDN),
+	}
+
+	// The first matching mapping wins when several mappings refer to the same project.
+	for _, m := range s.conf.Mappings {
+		state.Manage(m.ProjectID)
+
+		if containsDN(groups, m.GroupDN) {
+			state.Grant(m.ProjectID, db.ProjectUserRole(m.Role))
+		}
+	}
+
+	return membership.Apply(s.store, user, state)
+}
+
+func containsDN(dns []string, target string) bool {
+	return slices.ContainsFunc(dns, func(dn string) bool {
+		return equalDN(dn, target)
+	})
+}
+
+func equalDN(a, b string) bool {
+	dnA, errA := ldap.ParseDN(a)
+	dnB, errB := ldap.ParseDN(b)
+
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+
+	return dnA.EqualFold(dnB)
+}
@@ -0,0 +1,165 @@
+package ldap_sync
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupSync_ApplyGroups(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	managed, err := store.CreateProject(db.Project{Name: "Managed"})
+	assert.NoError(t, err)
+	unmanaged, err := store.CreateProject(db.Project{Name: "Unmanaged"})
+	assert.NoError(t, err)
+
+	user, err := store.CreateUserWithoutPassword(db.User{
+		Username: "john",
+		Name:     "John",
+		Email:    "john@example.org",
+		External: true,
+		Created:  tz.Now(),
+	})
+	assert.NoError(t, err)
+
+	_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: unmanaged.ID, UserID: user.ID, Role: db.ProjectOwner})
+	assert.NoError(t, err)
+
+	sync := NewGroupSync(store, &util.LdapGroupSyncConfig{
+		Enabled:      true,
+		AdminGroupDN: "cn=admins,ou=groups,dc=example,dc=org",
+		Mappings: []util.LdapGroupMapping{
+			{GroupDN: "cn=ops,ou=groups,dc=example,dc=org", ProjectID: managed.ID, Role: "manager"},
+			{GroupDN: "cn=dev,ou=groups,dc=example,dc=org", ProjectID: managed.ID, Role: "task_runner"},
+		},
+	})
+
+	err = sync.ApplyGroups(user, []string{
+		"CN=dev,OU=groups,DC=example,DC=org",
+		"cn=ops, ou=groups, dc=example, dc=org",
+		"cn=admins,ou=groups,dc=example,dc=org",
+	})
+	assert.NoError(t, err)
+
+	projectUser, err := store.GetProjectUser(managed.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, db.ProjectManager, projectUser.Role)
+
+	user, err = store.GetUser(user.ID)
+	assert.NoError(t, err)
+	assert.True(t, user.Admin)
+
+	err = sync.ApplyGroups(user, []string{"cn=dev,ou=groups,dc=example,dc=org"})
+	assert.NoError(t, err)
+
+	projectUser, err = store.GetProjectUser(managed.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, db.ProjectTaskRunner, projectUser.Role)
+
+	user, err = store.GetUser(user.ID)
+	assert.NoError(t, err)
+	assert.False(t, user.Admin)
+
+	err = sync.ApplyGroups(user, nil)
+	assert.NoError(t, err)
+
+	_, err = store.GetProjectUser(managed.ID, user.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	projectUser, err = store.GetProjectUser(unmanaged.ID, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, db.ProjectOwner, projectUser.Role)
+}
+
+func TestGroupSync_ApplyGroupsSkipsUnknownRole(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+	assert.NoError(t, err)
+
+	user, err := store.CreateUserWithoutPassword(db.User{
+		Username: "jane",
+		Name:     "Jane",
+		Email:    "jane@example.org",
+		External: true,
+		Created:  tz.Now(),
+	})
+	assert.NoError(t, err)
+
+	sync := NewGroupSync(store, &util.LdapGroupSyncConfig{
+		Enabled: true,
+		Mappings: []util.LdapGroupMapping{
+			{GroupDN: "cn=ops,ou=groups,dc=example,dc=org", ProjectID: proj.ID, Role: "unknown"},
+		},
+	})
+
+	err = sync.ApplyGroups(user, []string{"cn=ops,ou=groups,dc=example,dc=org"})
+	assert.NoError(t, err)
+
+	_, err = store.GetProjectUser(proj.ID, user.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+}
+
+func TestGroupSync_applyMissing(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+	assert.NoError(t, err)
+
+	ldapUser, err := store.CreateUserWithoutPassword(db.User{
+		Username: "john",
+		Name:     "John",
+		Email:    "john@example.org",
+		External: true,
+		Ldap:     true,
+		Admin:    true,
+		Created:  tz.Now(),
+	})
+	assert.NoError(t, err)
+
+	oidcUser, err := store.CreateUserWithoutPassword(db.User{
+		Username: "jane",
+		Name:     "Jane",
+		Email:    "jane@example.org",
+		External: true,
+		Admin:    true,
+		Created:  tz.Now(),
+	})
+	assert.NoError(t, err)
+
+	for _, u := range []db.User{ldapUser, oidcUser} {
+		_, err = store.CreateProjectUser(db.ProjectUser{ProjectID: proj.ID, UserID: u.ID, Role: db.ProjectManager})
+		assert.NoError(t, err)
+	}
+
+	sync := NewGroupSync(store, &util.LdapGroupSyncConfig{
+		Enabled:      true,
+		AdminGroupDN: "cn=admins,ou=groups,dc=example,dc=org",
+		Mappings: []util.LdapGroupMapping{
+			{GroupDN: "cn=ops,ou=groups,dc=example,dc=org", ProjectID: proj.ID, Role: "manager"},
+		},
+	})
+
+	assert.NoError(t, sync.applyMissing(ldapUser))
+	assert.NoError(t, sync.applyMissing(oidcUser))
+
+	_, err = store.GetProjectUser(proj.ID, ldapUser.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	ldapUser, err = store.GetUser(ldapUser.ID)
+	assert.NoError(t, err)
+	assert.False(t, ldapUser.Admin)
+
+	projectUser, err := store.GetProjectUser(proj.ID, oidcUser.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, db.ProjectManager, projectUser.Role)
+
+	oidcUser, err = store.GetUser(oidcUser.ID)
+	assert.NoError(t, err)
+	assert.True(t, oidcUser.Admin)
+}
@@ -0,0 +1,83 @@
+package membership
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// FromClaims builds the state from OIDC claims. The admin flag is managed
+// if any mapping grants it, projects are managed if any mapping refers to them.
+// The first matching mapping wins when several mappings refer to the same project.
+func FromClaims(claims map[string]any, mappings []util.OidcRoleMapping) State {
+	var state State
+
+	for _, m := range mappings {
+		matched := claimContains(claims, m.Claim, m.Value)
+
+		if m.Admin {
+			state.ManageAdmin = true
+			state.Admin = state.Admin || matched
+		}
+
+		if m.ProjectID == 0 {
+			continue
+		}
+
+		state.Manage(m.ProjectID)
+
+		if matched && m.Role != "" {
+			state.Grant(m.ProjectID, db.ProjectUserRole(m.Role))
+		}
+	}
+
+	return state
+}
+
+func lookupClaim(claims map[string]any, path string) (any, bool) {
+	var value any = claims
+
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = obj[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+func claimContains(claims map[string]any, path string, expected string) bool {
+	value, ok := lookupClaim(claims, path)
+	if !ok {
+		return false
+	}
+
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if fmt.Sprint(item) == expected {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, item := range v {
+			if item == expected {
+				return true
+			}
+		}
+		return false
+	case map[string]any, nil:
+		return false
+	default:
+		return fmt.Sprint(v) == expected
+	}
+}
@@ -0,0 +1,110 @@
+package membership
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// State describes the admin flag and project roles which an external
+// identity provider grants to the user.
+type State struct {
+	// ManageAdmin is true if the admin flag of the user is managed.
+	ManageAdmin bool
+	Admin       bool
+	// Projects contains IDs of the managed projects. Memberships in
+	// other projects are never touched.
+	Projects []int
+	// Roles contains roles granted in the managed projects.
+	// The user is removed from managed projects missing here.
+	Roles map[int]db.ProjectUserRole
+}
+
+// Grant adds the role in the project unless the project already has a role.
+func (s *State) Grant(projectID int, role db.ProjectUserRole) {
+	if s.Roles == nil {
+		s.Roles = make(map[int]db.ProjectUserRole)
+	}
+
+	if _, ok := s.Roles[projectID]; !ok {
+		s.Roles[projectID] = role
+	}
+}
+
+// Manage marks the project as managed.
+func (s *State) Manage(projectID int) {
+	if !slices.Contains(s.Projects, projectID) {
+		s.Projects = append(s.Projects, projectID)
+	}
+}
+
+// Apply updates the admin flag and memberships of the user in the managed
+// projects according to the state. Mappings which refer to non-existent
+// projects or unknown roles are skipped with a warning.
+func Apply(store db.Store, user db.User, state State) error {
+	if state.ManageAdmin && user.Admin != state.Admin {
+		user.Admin = state.Admin
+		if err := store.UpdateUser(db.UserWithPwd{User: user}); err != nil {
+			return err
+		}
+	}
+
+	for _, projectID := range state.Projects {
+		role, granted := state.Roles[projectID]
+
+		if err := applyProjectRole(store, user, projectID, role, granted); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyProjectRole(store db.Store, user db.User, projectID int, role db.ProjectUserRole, granted bool) error {
+	projectUser, err := store.GetProjectUser(projectID, user.ID)
+	isMember := err == nil
+
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+
+	switch {
+	case !granted && isMember:
+		return store.DeleteProjectUser(projectID, user.ID)
+	case !granted || (isMember && projectUser.Role == role):
+		return nil
+	}
+
+	if _, err = store.GetProject(projectID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			log.WithField("project", projectID).Warn("Role mapping refers to non-existent project")
+			return nil
+		}
+		return err
+	}
+
+	if !role.IsValid() {
+		if _, err = store.GetProjectOrGlobalRoleBySlug(projectID, string(role)); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				log.WithField("project", projectID).Warn("Role mapping refers to unknown role " + string(role))
+				return nil
+			}
+			return err
+		}
+	}
+
+	if isMember {
+		projectUser.Role = role
+		return store.UpdateProjectUser(projectUser)
+	}
+
+	_, err = store.CreateProjectUser(db.ProjectUser{
+		ProjectID: projectID,
+		UserID:    user.ID,
+		Role:      role,
+	})
+
+	return err
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// TaskPoolStats provides the state of the task pool.
+type TaskPoolStats interface {
+	QueueLen() int
+	RunningCount() int
+}
+
+var (
+	queueLengthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "task_queue_length"),
+		"Number of tasks waiting in the queue.",
+		nil, nil,
+	)
+
+	runningTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "running_tasks"),
+		"Number of running tasks.",
+		nil, nil,
+	)
+
+	runnerLastSeenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "runner_last_seen_seconds"),
+		"Seconds since the runner requested the server last time.",
+		[]string{"runner_id", "name", "project_id", "active"}, nil,
+	)
+)
+
+// poolCollector reads the state of the task pool and runners on every scrape.
+type poolCollector struct {
+	pool  TaskPoolStats
+	store db.Store
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueLengthDesc
+	ch <- runningTasksDesc
+	ch <- runnerLastSeenDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(queueLengthDesc, prometheus.GaugeValue, float64(c.pool.QueueLen()))
+	ch <- prometheus.MustNewConstMetric(runningTasksDesc, prometheus.GaugeValue, float64(c.pool.RunningCount()))
+
+	var runners []db.Runner
+	var err error
+
+	db.StoreSession(c.store, "metrics "+util.RandString(8), func() {
+		runners, err = c.store.GetAllRunners(false, false)
+	})
+
+	if err != nil {
+		log.WithError(err).WithField("context", "metrics").Error("Failed to get runners")
+		return
+	}
+
+	now := tz.Now()
+
+	for _, runner := range runners {
+		if runner.Touched == nil {
+			continue
+		}
+
+		projectID := ""
+		if runner.ProjectID != nil {
+			projectID = strconv.Itoa(*runner.ProjectID)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			runnerLastSeenDesc,
+			prometheus.GaugeValue,
+			now.Sub(*runner.Touched).Round(time.Second).Seconds(),
+			strconv.Itoa(runner.ID),
+			runner.Name,
+			projectID,
+			strconv.FormatBool(runner.Active),
+		)
+	}
+}
+
+// RegisterTaskPool exposes the state of the task pool and runners.
+func RegisterTaskPool(pool TaskPoolStats, store db.Store) {
+	registry.MustRegister(&poolCollector{pool: pool, store: store})
+}
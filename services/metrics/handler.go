@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler serves metrics in the Prometheus format. If the token is set,
+// requests must have it in the Authorization header as a bearer token.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Serve starts the metrics server on the separate address.
+// It blocks until the server fails.
+func Serve(config *util.MetricsConfig) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(config.Token))
+
+	log.WithFields(log.Fields{
+		"context": "metrics",
+		"listen":  config.Listen,
+	}).Info("Metrics server started")
+
+	if err := http.ListenAndServe(config.Listen, mux); err != nil {
+		log.WithError(err).WithField("context", "metrics").Error("Metrics server stopped")
+	}
+}
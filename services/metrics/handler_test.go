@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type poolStatsMock struct {
+	queued  int
+	running int
+}
+
+func (p poolStatsMock) QueueLen() int {
+	return p.queued
+}
+
+func (p poolStatsMock) RunningCount() int {
+	return p.running
+}
+
+func scrape(t *testing.T, token string, auth string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+
+	w := httptest.NewRecorder()
+	Handler(token).ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	runner, err := store.CreateRunner(db.Runner{Name: "builder", Active: true})
+	require.NoError(t, err)
+	require.NoError(t, store.TouchRunner(runner))
+
+	RegisterTaskPool(poolStatsMock{queued: 3, running: 2}, store)
+
+	start := time.Now().Add(-90 * time.Second)
+	end := time.Now()
+	TaskFinished(1, 2, "ansible", "success", &start, &end)
+	ScheduleFired(1, 5, "triggered")
+	IntegrationDelivered(1, 7, "triggered")
+	LogBatchWritten(10 * time.Millisecond)
+
+	w := scrape(t, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "semaphore_task_queue_length 3")
+	assert.Contains(t, body, "semaphore_running_tasks 2")
+	assert.Contains(t, body, `semaphore_tasks_finished_total{app="ansible",project_id="1",status="success",template_id="2"} 1`)
+	assert.Contains(t, body, `semaphore_task_duration_seconds_count{app="ansible",project_id="1",status="success",template_id="2"} 1`)
+	assert.Contains(t, body, `semaphore_schedule_fires_total{project_id="1",result="triggered",schedule_id="5"} 1`)
+	assert.Contains(t, body, `semaphore_integration_deliveries_total{integration_id="7",project_id="1",status="triggered"} 1`)
+	assert.Contains(t, body, "semaphore_task_log_batch_write_duration_seconds_count 1")
+	assert.Contains(t, body, `semaphore_runner_last_seen_seconds{active="true",name="builder",project_id="",runner_id="1"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, scrape(t, "secret", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, scrape(t, "secret", "Bearer wrong").Code)
+	assert.Equal(t, http.StatusOK, scrape(t, "secret", "Bearer secret").Code)
+}
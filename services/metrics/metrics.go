@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "semaphore"
+
+// registry keeps all metrics of the instance. It is separate from the default
+// registry of Prometheus to expose only metrics registered here.
+var registry = prometheus.NewRegistry()
+
+var (
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of finished tasks from start to end.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200},
+	}, []string{"project_id", "template_id", "app", "status"})
+
+	tasksFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_finished_total",
+		Help:      "Number of finished tasks by outcome.",
+	}, []string{"project_id", "template_id", "app", "status"})
+
+	scheduleFires = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "schedule_fires_total",
+		Help:      "Number of schedule runs by result.",
+	}, []string{"project_id", "schedule_id", "result"})
+
+	integrationDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "integration_deliveries_total",
+		Help:      "Number of integration deliveries by final status.",
+	}, []string{"project_id", "integration_id", "status"})
+
+	websocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_connections",
+		Help:      "Number of open websocket connections.",
+	})
+
+	logBatchWriteDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_log_batch_write_duration_seconds",
+		Help:      "Latency of writing batches of task output to the database.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		taskDuration,
+		tasksFinished,
+		scheduleFires,
+		integrationDeliveries,
+		websocketConnections,
+		logBatchWriteDuration,
+	)
+}
+
+func itoa(id int) string {
+	return strconv.Itoa(id)
+}
+
+// TaskFinished records the outcome of the task. The duration is recorded
+// only for tasks which were started.
+func TaskFinished(projectID int, templateID int, app string, status string, start *time.Time, end *time.Time) {
+	labels := []string{itoa(projectID), itoa(templateID), app, status}
+
+	tasksFinished.WithLabelValues(labels...).Inc()
+
+	if start != nil && end != nil {
+		taskDuration.WithLabelValues(labels...).Observe(end.Sub(*start).Seconds())
+	}
+}
+
+// ScheduleFired records the run of the schedule. The result is "triggered"
+// if the task was added, "skipped" if there are no new commits,
+// or "failed".
+func ScheduleFired(projectID int, scheduleID int, result string) {
+	scheduleFires.WithLabelValues(itoa(projectID), itoa(scheduleID), result).Inc()
+}
+
+func IntegrationDelivered(projectID int, integrationID int, status string) {
+	integrationDeliveries.WithLabelValues(itoa(projectID), itoa(integrationID), status).Inc()
+}
+
+func WebsocketConnected() {
+	websocketConnections.Inc()
+}
+
+func WebsocketDisconnected() {
+	websocketConnections.Dec()
+}
+
+func LogBatchWritten(duration time.Duration) {
+	logBatchWriteDuration.Observe(duration.Seconds())
+}
@@ -161,11 +161,21 @@ func (b *BackupDB) load(projectID int, store db.Store) (err error) {
 		return
 	}
 
-	b.keys, err = store.GetAccessKeys(projectID, db.GetAccessKeyOptions{IgnoreOwner: true}, db.RetrieveQueryParams{})
+	keys, err := store.GetAccessKeys(projectID, db.GetAccessKeyOptions{IgnoreOwner: true}, db.RetrieveQueryParams{})
 	if err != nil {
 		return
 	}
 
+	// Secrets of environments are not standalone keys,
+	// they are exported only within encrypted secrets.
+	for _, k := range keys {
+		if k.EnvironmentID != nil {
+			b.environmentSecrets = append(b.environmentSecrets, k)
+		} else {
+			b.keys = append(b.keys, k)
+		}
+	}
+
 	b.views, err = store.GetViews(projectID)
 	if err != nil {
 		return
@@ -486,11 +496,37 @@ func (b *BackupDB) format() (*BackupFormat, error) {
 }
 
 func GetBackup(projectID int, store db.Store) (*BackupFormat, error) {
+	return GetBackupWithSecrets(projectID, store, nil)
+}
+
+// GetBackupWithSecrets returns the backup of the project. If secrets are not nil,
+// secrets of keys and environments are included encrypted by the passphrase.
+func GetBackupWithSecrets(projectID int, store db.Store, secrets *SecretOptions) (*BackupFormat, error) {
 	backup := BackupDB{}
 	if err := backup.load(projectID, store); err != nil {
 		return nil, err
 	}
-	return backup.format()
+
+	res, err := backup.format()
+	if err != nil {
+		return nil, err
+	}
+
+	if secrets == nil {
+		return res, nil
+	}
+
+	data, err := backup.exportSecrets(secrets.EncryptionService)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Secrets, err = encryptBackupSecrets(data, secrets.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 func (b *BackupFormat) Marshal() (res string, err error) {
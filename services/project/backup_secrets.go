@@ -0,0 +1,303 @@
+package project
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/server"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	backupSecretsKDF    = "scrypt"
+	backupSecretsCipher = "aes-256-gcm"
+
+	// backupSecretsAAD binds the ciphertext to the format of the backup.
+	backupSecretsAAD = "semaphore-backup-secrets-v1"
+
+	backupScryptN = 1 << 15
+	backupScryptR = 8
+	backupScryptP = 1
+
+	// backupScryptMaxMemory limits memory used to derive the key of untrusted
+	// backups, scrypt uses 128*N*r bytes.
+	backupScryptMaxMemory = 256 << 20
+	// backupScryptMaxCost limits the CPU cost N*r*p of the key derivation.
+	backupScryptMaxCost = 1 << 23
+)
+
+// validScryptParams returns true if deriving the key with the parameters
+// of an untrusted backup does not use too much memory or CPU time.
+func validScryptParams(n, r, p int) bool {
+	if n <= 1 || n&(n-1) != 0 || r <= 0 || p <= 0 {
+		return false
+	}
+	// Bound every parameter first, so the products below do not overflow.
+	if n > backupScryptMaxMemory || r > backupScryptMaxMemory || p > backupScryptMaxCost {
+		return false
+	}
+	return int64(128)*int64(n)*int64(r) <= backupScryptMaxMemory &&
+		int64(n)*int64(r)*int64(p) <= backupScryptMaxCost
+}
+
+// SecretOptions enables backup and restore of secret material.
+type SecretOptions struct {
+	// Passphrase is used to derive the key which encrypts secrets in the backup.
+	Passphrase        string
+	EncryptionService server.AccessKeyEncryptionService
+}
+
+// BackupSecrets contains secrets of access keys and environments
+// encrypted by AES-256-GCM with the key derived from the passphrase by scrypt.
+type BackupSecrets struct {
+	KDF    string `backup:"kdf" json:"kdf"`
+	N      int    `backup:"n" json:"n"`
+	R      int    `backup:"r" json:"r"`
+	P      int    `backup:"p" json:"p"`
+	Salt   string `backup:"salt" json:"salt"`
+	Cipher string `backup:"cipher" json:"cipher"`
+	Nonce  string `backup:"nonce" json:"nonce"`
+	Data   string `backup:"data" json:"data"`
+}
+
+// backupSecretData is the plaintext of BackupSecrets.
+type backupSecretData struct {
+	// Keys contains secrets of access keys by key name.
+	Keys map[string]backupKeySecret `json:"keys"`
+	// Environments contains secrets of environments by environment name.
+	Environments map[string][]backupEnvironmentSecret `json:"environments"`
+}
+
+type backupKeySecret struct {
+	String        string            `json:"string,omitempty"`
+	LoginPassword *db.LoginPassword `json:"login_password,omitempty"`
+	SshKey        *db.SshKey        `json:"ssh,omitempty"`
+}
+
+type backupEnvironmentSecret struct {
+	Name   string                   `json:"name"`
+	Type   db.EnvironmentSecretType `json:"type"`
+	Secret string                   `json:"secret"`
+}
+
+func newBackupSecretData() *backupSecretData {
+	return &backupSecretData{
+		Keys:         make(map[string]backupKeySecret),
+		Environments: make(map[string][]backupEnvironmentSecret),
+	}
+}
+
+func (s backupKeySecret) fill(key *db.AccessKey) {
+	switch key.Type {
+	case db.AccessKeyString:
+		key.String = s.String
+	case db.AccessKeyLoginPassword:
+		if s.LoginPassword != nil {
+			key.LoginPassword = *s.LoginPassword
+		}
+	case db.AccessKeySSH:
+		if s.SshKey != nil {
+			key.SshKey = *s.SshKey
+		}
+	}
+}
+
+func newBackupKeySecret(key db.AccessKey) (res backupKeySecret, ok bool) {
+	switch key.Type {
+	case db.AccessKeyString:
+		res.String = key.String
+		ok = key.String != ""
+	case db.AccessKeyLoginPassword:
+		res.LoginPassword = &key.LoginPassword
+		ok = key.LoginPassword.Password != ""
+	case db.AccessKeySSH:
+		res.SshKey = &key.SshKey
+		ok = key.SshKey.PrivateKey != ""
+	}
+	return
+}
+
+func environmentSecretType(key db.AccessKey) db.EnvironmentSecretType {
+	if key.Owner == db.AccessKeyEnvironment {
+		return db.EnvironmentSecretEnv
+	}
+	return db.EnvironmentSecretVar
+}
+
+// exportSecrets collects secrets stored in the database. Secrets kept in
+// external secret storages are not exported, the keys refer to them.
+func (b *BackupDB) exportSecrets(encryptionService server.AccessKeyEncryptionService) (*backupSecretData, error) {
+	res := newBackupSecretData()
+
+	for _, k := range b.keys {
+		if k.SourceStorageID != nil {
+			continue
+		}
+
+		if err := encryptionService.DeserializeSecret(&k); err != nil {
+			return nil, fmt.Errorf("failed to read secret of key %s: %w", k.Name, err)
+		}
+
+		if secret, ok := newBackupKeySecret(k); ok {
+			res.Keys[k.Name] = secret
+		}
+	}
+
+	for _, k := range b.environmentSecrets {
+		if k.SourceStorageID != nil {
+			continue
+		}
+
+		envName, err := findNameByID[db.Environment](*k.EnvironmentID, b.environments)
+		if err != nil {
+			continue
+		}
+
+		if err = encryptionService.DeserializeSecret(&k); err != nil {
+			return nil, fmt.Errorf("failed to read secret of environment %s: %w", *envName, err)
+		}
+
+		res.Environments[*envName] = append(res.Environments[*envName], backupEnvironmentSecret{
+			Name:   k.Name,
+			Type:   environmentSecretType(k),
+			Secret: k.String,
+		})
+	}
+
+	return res, nil
+}
+
+// getKeySecret returns the secret of the restored key. Keys stored
+// in external secret storages have no secrets in the backup.
+func (b *BackupDB) getKeySecret(key db.AccessKey) (backupKeySecret, bool) {
+	if b.secrets == nil || key.SourceStorageID != nil {
+		return backupKeySecret{}, false
+	}
+
+	secret, ok := b.secrets.Keys[key.Name]
+	return secret, ok
+}
+
+func deriveBackupKey(passphrase string, salt []byte, n, r, p int) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+}
+
+func encryptBackupSecrets(data *backupSecretData, passphrase string) (*BackupSecrets, error) {
+	return EncryptBackupData(data, passphrase)
+}
+
+// EncryptBackupData encrypts the JSON encoded value by the key derived from the passphrase.
+func EncryptBackupData(value any, passphrase string) (*BackupSecrets, error) {
+	if passphrase == "" {
+		return nil, db.NewValidationError("passphrase can not be empty")
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveBackupKey(passphrase, salt, backupScryptN, backupScryptR, backupScryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newBackupGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, plaintext, []byte(backupSecretsAAD))
+
+	return &BackupSecrets{
+		KDF:    backupSecretsKDF,
+		N:      backupScryptN,
+		R:      backupScryptR,
+		P:      backupScryptP,
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+		Cipher: backupSecretsCipher,
+		Nonce:  base64.StdEncoding.EncodeToString(nonce),
+		Data:   base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+func (s *BackupSecrets) decrypt(passphrase string) (*backupSecretData, error) {
+	res := newBackupSecretData()
+	if err := s.Decrypt(passphrase, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Decrypt decrypts the data by the passphrase and decodes it into the value.
+// Wrong passphrase results in the validation error.
+func (s *BackupSecrets) Decrypt(passphrase string, value any) error {
+	if s.KDF != backupSecretsKDF || s.Cipher != backupSecretsCipher {
+		return fmt.Errorf("unsupported secrets encryption %s/%s", s.KDF, s.Cipher)
+	}
+
+	if !validScryptParams(s.N, s.R, s.P) {
+		return fmt.Errorf("unsupported secrets key derivation parameters")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(s.Nonce)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(s.Data)
+	if err != nil {
+		return err
+	}
+
+	key, err := deriveBackupKey(passphrase, salt, s.N, s.R, s.P)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newBackupGCM(key)
+	if err != nil {
+		return err
+	}
+
+	if len(nonce) != gcm.NonceSize() {
+		return fmt.Errorf("invalid secrets nonce")
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(backupSecretsAAD))
+	if err != nil {
+		return db.NewValidationError("invalid passphrase or corrupted backup secrets")
+	}
+
+	return json.Unmarshal(plaintext, value)
+}
+
+func newBackupGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
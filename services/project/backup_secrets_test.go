@@ -0,0 +1,147 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/services/server"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupSecrets_EncryptDecrypt(t *testing.T) {
+	data := newBackupSecretData()
+	data.Keys["deploy"] = backupKeySecret{SshKey: &db.SshKey{PrivateKey: "private"}}
+	data.Environments["prod"] = []backupEnvironmentSecret{
+		{Name: "TOKEN", Type: db.EnvironmentSecretEnv, Secret: "secret"},
+	}
+
+	secrets, err := encryptBackupSecrets(data, "passphrase")
+	require.NoError(t, err)
+	assert.NotContains(t, secrets.Data, "private")
+
+	res, err := secrets.decrypt("passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, data, res)
+
+	_, err = secrets.decrypt("wrong")
+	var validationErr *db.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = encryptBackupSecrets(data, "")
+	assert.Error(t, err)
+}
+
+func TestValidScryptParams(t *testing.T) {
+	assert.True(t, validScryptParams(backupScryptN, backupScryptR, backupScryptP))
+	assert.True(t, validScryptParams(1<<18, 8, 1))
+
+	// 128*N*r is 8 GiB.
+	assert.False(t, validScryptParams(1<<20, 64, 1))
+	assert.False(t, validScryptParams(1<<15, 8, 1<<20))
+	assert.False(t, validScryptParams(1<<15+1, 8, 1))
+	assert.False(t, validScryptParams(1<<62, 1<<62, 1))
+	assert.False(t, validScryptParams(0, 8, 1))
+}
+
+func TestBackupProject_WithSecrets(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	encryptionService := server.NewAccessKeyEncryptionService(store, store, store)
+
+	proj, err := store.CreateProject(db.Project{Name: "Secrets"})
+	require.NoError(t, err)
+
+	key := db.AccessKey{
+		Name:      "deploy",
+		ProjectID: &proj.ID,
+		Type:      db.AccessKeyLoginPassword,
+		LoginPassword: db.LoginPassword{
+			Login:    "root",
+			Password: "p@ssw0rd",
+		},
+	}
+	require.NoError(t, encryptionService.SerializeSecret(&key))
+	_, err = store.CreateAccessKey(key)
+	require.NoError(t, err)
+
+	env, err := store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "prod",
+		JSON:      "{}",
+	})
+	require.NoError(t, err)
+
+	envKey := db.AccessKey{
+		Name:          "TOKEN",
+		ProjectID:     &proj.ID,
+		EnvironmentID: &env.ID,
+		Type:          db.AccessKeyString,
+		String:        "t0ken",
+		Owner:         db.AccessKeyEnvironment,
+	}
+	require.NoError(t, encryptionService.SerializeSecret(&envKey))
+	_, err = store.CreateAccessKey(envKey)
+	require.NoError(t, err)
+
+	backup, err := GetBackupWithSecrets(proj.ID, store, &SecretOptions{
+		Passphrase:        "passphrase",
+		EncryptionService: encryptionService,
+	})
+	require.NoError(t, err)
+	require.Len(t, backup.Keys, 1)
+
+	str, err := backup.Marshal()
+	require.NoError(t, err)
+	assert.NotContains(t, str, "p@ssw0rd")
+	assert.NotContains(t, str, "t0ken")
+
+	restored := &BackupFormat{}
+	require.NoError(t, restored.Unmarshal(str))
+	require.NotNil(t, restored.Secrets)
+
+	user, err := store.CreateUser(db.UserWithPwd{
+		Pwd: "3412341234123",
+		User: db.User{
+			Username: "test",
+			Name:     "Test",
+			Email:    "test@example.com",
+			Admin:    true,
+		},
+	})
+	require.NoError(t, err)
+
+	restored.Meta.Name = "Secrets (wrong passphrase)"
+	_, err = restored.RestoreWithSecrets(user, store, &SecretOptions{
+		Passphrase:        "wrong",
+		EncryptionService: encryptionService,
+	})
+	assert.Error(t, err)
+
+	restored.Meta.Name = "Secrets (restored)"
+	newProj, err := restored.RestoreWithSecrets(user, store, &SecretOptions{
+		Passphrase:        "passphrase",
+		EncryptionService: encryptionService,
+	})
+	require.NoError(t, err)
+
+	keys, err := store.GetAccessKeys(newProj.ID, db.GetAccessKeyOptions{}, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NoError(t, encryptionService.DeserializeSecret(&keys[0]))
+	assert.Equal(t, key.LoginPassword, keys[0].LoginPassword)
+
+	envs, err := store.GetEnvironments(newProj.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, envs, 1)
+	require.NoError(t, encryptionService.FillEnvironmentSecrets(&envs[0], true))
+	require.Len(t, envs[0].Secrets, 1)
+	assert.Equal(t, "TOKEN", envs[0].Secrets[0].Name)
+	assert.Equal(t, db.EnvironmentSecretEnv, envs[0].Secrets[0].Type)
+	assert.Equal(t, "t0ken", envs[0].Secrets[0].Secret)
+}
@@ -149,6 +149,7 @@ func TestBackup_BackupSecretStorage(t *testing.T) {
   ],
   "meta": {
     "alert": false,
+    "container_image": "",
     "max_parallel_tasks": 0,
     "name": "Test 1234",
     "type": ""
@@ -75,6 +75,30 @@ func (e BackupEnvironment) Restore(store db.Store, b *BackupDB) error {
 		return err
 	}
 	b.environments = append(b.environments, newEnv)
+
+	if b.secrets == nil {
+		return nil
+	}
+
+	for _, secret := range b.secrets.Environments[e.Name] {
+		key := db.AccessKey{
+			Name:          secret.Name,
+			Type:          db.AccessKeyString,
+			String:        secret.Secret,
+			ProjectID:     &b.meta.ID,
+			EnvironmentID: &newEnv.ID,
+			Owner:         secret.Type.GetAccessKeyOwner(),
+		}
+
+		if err = b.encryptionService.SerializeSecret(&key); err != nil {
+			return err
+		}
+
+		if _, err = store.CreateAccessKey(key); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -98,19 +122,34 @@ func (e BackupSchedule) Verify(backup *BackupFormat) error {
 }
 
 func (e BackupSchedule) Restore(store db.Store, b *BackupDB) error {
+	v, err := e.build(b)
+	if err != nil {
+		return err
+	}
+
+	newSchedule, err := store.CreateSchedule(v)
+	if err != nil {
+		return err
+	}
+
+	b.schedules = append(b.schedules, newSchedule)
+	return nil
+}
+
+func (e BackupSchedule) build(b *BackupDB) (db.Schedule, error) {
 	v := e.Schedule
 	v.ProjectID = b.meta.ID
 
 	tpl := findEntityByName[db.Template](&e.Template, b.templates)
 	if tpl == nil {
-		return fmt.Errorf("template does not exist in templates[].name")
+		return v, fmt.Errorf("template does not exist in templates[].name")
 	}
 	v.TemplateID = tpl.ID
 
 	if e.CheckableRepository != nil {
 		repo := findEntityByName[db.Repository](e.CheckableRepository, b.repositories)
 		if repo == nil {
-			return fmt.Errorf("repo does not exist in repositories[].name")
+			return v, fmt.Errorf("repo does not exist in repositories[].name")
 		}
 		v.RepositoryID = &repo.ID
 	}
@@ -120,13 +159,7 @@ func (e BackupSchedule) Restore(store db.Store, b *BackupDB) error {
 		v.TaskParams.InventoryID = &inv.ID
 	}
 
-	newSchedule, err := store.CreateSchedule(v)
-	if err != nil {
-		return err
-	}
-
-	b.schedules = append(b.schedules, newSchedule)
-	return nil
+	return v, nil
 }
 
 func (e BackupAccessKey) Verify(backup *BackupFormat) error {
@@ -154,6 +187,13 @@ func (e BackupAccessKey) Restore(store db.Store, b *BackupDB) error {
 		key.SourceStorageID = &sourceStorage.ID
 	}
 
+	if secret, ok := b.getKeySecret(key); ok {
+		secret.fill(&key)
+		if err := b.encryptionService.SerializeSecret(&key); err != nil {
+			return err
+		}
+	}
+
 	newKey, err := store.CreateAccessKey(key)
 
 	if err != nil {
@@ -177,6 +217,15 @@ func (e BackupInventory) Verify(backup *BackupFormat) error {
 }
 
 func (e BackupInventory) Restore(store db.Store, b *BackupDB) error {
+	newInventory, err := store.CreateInventory(e.build(b))
+	if err != nil {
+		return err
+	}
+	b.inventories = append(b.inventories, newInventory)
+	return nil
+}
+
+func (e BackupInventory) build(b *BackupDB) db.Inventory {
 	var SSHKeyID *int
 	if e.SSHKey == nil {
 		SSHKeyID = nil
@@ -199,12 +248,7 @@ func (e BackupInventory) Restore(store db.Store, b *BackupDB) error {
 	inv.SSHKeyID = SSHKeyID
 	inv.BecomeKeyID = BecomeKeyID
 
-	newInventory, err := store.CreateInventory(inv)
-	if err != nil {
-		return err
-	}
-	b.inventories = append(b.inventories, newInventory)
-	return nil
+	return inv
 }
 
 func (e BackupRepository) Verify(backup *BackupFormat) error {
@@ -276,10 +320,48 @@ func (e BackupTemplate) Verify(backup *BackupFormat) error {
 }
 
 func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
+	template, err := e.build(b)
+	if err != nil {
+		return err
+	}
+
+	newTemplate, err := store.CreateTemplate(template)
+	if err != nil {
+		return err
+	}
+	b.templates = append(b.templates, newTemplate)
+
+	vaults, err := e.buildVaults(b, newTemplate.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, vault := range vaults {
+		if _, err = store.CreateTemplateVault(vault); err != nil {
+			return err
+		}
+	}
+
+	roles, err := e.buildRoles(store, b, newTemplate.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if _, err = store.CreateTemplateRole(role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e BackupTemplate) build(b *BackupDB) (template db.Template, err error) {
 	var InventoryID *int
 	if e.Inventory != nil {
 		if k := findEntityByName[db.Inventory](e.Inventory, b.inventories); k == nil {
-			return fmt.Errorf("inventory does not exist in inventories[].name")
+			err = fmt.Errorf("inventory does not exist in inventories[].name")
+			return
 		} else {
 			id := k.GetID()
 			InventoryID = &id
@@ -289,7 +371,8 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 	var EnvironmentID *int
 	if e.Environment != nil {
 		if k := findEntityByName[db.Environment](e.Environment, b.environments); k == nil {
-			return fmt.Errorf("environment does not exist in environments[].name")
+			err = fmt.Errorf("environment does not exist in environments[].name")
+			return
 		} else {
 			id := k.GetID()
 			EnvironmentID = &id
@@ -298,7 +381,8 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 
 	var RepositoryID int
 	if k := findEntityByName[db.Repository](&e.Repository, b.repositories); k == nil {
-		return fmt.Errorf("repository does not exist in repositories[].name")
+		err = fmt.Errorf("repository does not exist in repositories[].name")
+		return
 	} else {
 		RepositoryID = k.GetID()
 	}
@@ -319,7 +403,7 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 		ViewID = &k.ID
 	}
 
-	template := e.Template
+	template = e.Template
 	template.ProjectID = b.meta.ID
 	template.RepositoryID = RepositoryID
 	template.EnvironmentID = EnvironmentID
@@ -327,77 +411,89 @@ func (e BackupTemplate) Restore(store db.Store, b *BackupDB) error {
 	template.ViewID = ViewID
 	template.BuildTemplateID = BuildTemplateID
 
-	newTemplate, err := store.CreateTemplate(template)
-	if err != nil {
-		return err
-	}
-	b.templates = append(b.templates, newTemplate)
+	return
+}
 
-	if e.Vaults != nil {
-		for _, vault := range e.Vaults {
-			var VaultKeyID *int
+func (e BackupTemplate) buildVaults(b *BackupDB, templateID int) ([]db.TemplateVault, error) {
+	var res []db.TemplateVault
 
-			if vault.VaultKey != nil {
-				if k := findEntityByName[db.AccessKey](vault.VaultKey, b.keys); k == nil {
-					return fmt.Errorf("vaults[].vaultKey does not exist in keys[].name")
-				} else {
-					VaultKeyID = &k.ID
-				}
+	for _, vault := range e.Vaults {
+		var VaultKeyID *int
+
+		if vault.VaultKey != nil {
+			if k := findEntityByName[db.AccessKey](vault.VaultKey, b.keys); k == nil {
+				return nil, fmt.Errorf("vaults[].vaultKey does not exist in keys[].name")
+			} else {
+				VaultKeyID = &k.ID
 			}
+		}
+
+		tplVault := vault.TemplateVault
+		tplVault.ID = 0
+		tplVault.ProjectID = b.meta.ID
+		tplVault.TemplateID = templateID
+		tplVault.VaultKeyID = VaultKeyID
+
+		res = append(res, tplVault)
+	}
+
+	return res, nil
+}
 
-			tplVault := vault.TemplateVault
-			tplVault.ProjectID = b.meta.ID
-			tplVault.TemplateID = newTemplate.ID
-			tplVault.VaultKeyID = VaultKeyID
+func (e BackupTemplate) buildRoles(store db.Store, b *BackupDB, templateID int) ([]db.TemplateRolePerm, error) {
+	var res []db.TemplateRolePerm
 
-			_, err := store.CreateTemplateVault(tplVault)
+	for _, role := range e.Roles {
+		if role.IsGlobal {
+			r, err := store.GetGlobalRoleBySlug(role.Role)
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("global role does not exist: %s", role.Role)
 			}
+
+			res = append(res, db.TemplateRolePerm{
+				TemplateID:  templateID,
+				RoleSlug:    r.Slug,
+				ProjectID:   b.meta.ID,
+				Permissions: role.Permissions,
+			})
+
+			continue
 		}
-	}
 
-	if e.Roles != nil {
-		for _, role := range e.Roles {
-			if role.IsGlobal {
-				r, err := store.GetGlobalRoleBySlug(role.Role)
-				if err != nil {
-					return fmt.Errorf("global role does not exist: %s", role.Role)
-				}
+		k := findEntityByName[db.Role](&role.Role, b.roles)
+		if k == nil {
+			return nil, fmt.Errorf("roles[].role does not exist in roles[].name")
+		}
 
-				_, err = store.CreateTemplateRole(db.TemplateRolePerm{
-					TemplateID:  newTemplate.ID,
-					RoleSlug:    r.Slug,
-					ProjectID:   b.meta.ID,
-					Permissions: role.Permissions,
-				})
+		res = append(res, db.TemplateRolePerm{
+			TemplateID:  templateID,
+			RoleSlug:    k.Slug,
+			ProjectID:   b.meta.ID,
+			Permissions: role.Permissions,
+		})
+	}
 
-				if err != nil {
-					return err
-				}
+	return res, nil
+}
 
-				continue
-			}
-			if k := findEntityByName[db.Role](&role.Role, b.roles); k == nil {
-				return fmt.Errorf("roles[].role does not exist in roles[].name")
-			} else {
-				_, err = store.CreateTemplateRole(db.TemplateRolePerm{
-					TemplateID:  newTemplate.ID,
-					RoleSlug:    k.Slug,
-					ProjectID:   b.meta.ID,
-					Permissions: role.Permissions,
-				})
-				if err != nil {
-					return err
-				}
-			}
-		}
+func (e BackupIntegration) Restore(store db.Store, b *BackupDB) error {
+	integration, err := e.build(b)
+	if err != nil {
+		return err
 	}
 
+	newIntegration, err := store.CreateIntegration(integration)
+	if err != nil {
+		return err
+	}
+	b.integrations = append(b.integrations, newIntegration)
+
+	e.restoreChildren(store, b, newIntegration.ID)
+
 	return nil
 }
 
-func (e BackupIntegration) Restore(store db.Store, b *BackupDB) error {
+func (e BackupIntegration) build(b *BackupDB) (db.Integration, error) {
 	var authSecretID *int
 
 	if e.AuthSecret == nil {
@@ -408,12 +504,13 @@ func (e BackupIntegration) Restore(store db.Store, b *BackupDB) error {
 		authSecretID = &((*k).ID)
 	}
 
+	integration := e.Integration
+
 	tpl := findEntityByName[db.Template](&e.Template, b.templates)
 	if tpl == nil {
-		return fmt.Errorf("template does not exist in templates[].name")
+		return integration, fmt.Errorf("template does not exist in templates[].name")
 	}
 
-	integration := e.Integration
 	integration.ProjectID = b.meta.ID
 	integration.AuthSecretID = authSecretID
 	integration.TemplateID = tpl.ID
@@ -425,19 +522,20 @@ func (e BackupIntegration) Restore(store db.Store, b *BackupDB) error {
 		}
 	}
 
-	newIntegration, err := store.CreateIntegration(integration)
-	if err != nil {
-		return err
-	}
-	b.integrations = append(b.integrations, newIntegration)
+	return integration, nil
+}
 
+// restoreChildren creates matchers, extract values and aliases of the integration.
+func (e BackupIntegration) restoreChildren(store db.Store, b *BackupDB, integrationID int) {
 	for _, m := range e.Matchers {
-		m.IntegrationID = newIntegration.ID
+		m.ID = 0
+		m.IntegrationID = integrationID
 		_, _ = store.CreateIntegrationMatcher(b.meta.ID, m)
 	}
 
 	for _, v := range e.ExtractValues {
-		v.IntegrationID = newIntegration.ID
+		v.ID = 0
+		v.IntegrationID = integrationID
 		_, _ = store.CreateIntegrationExtractValue(b.meta.ID, v)
 	}
 
@@ -445,12 +543,10 @@ func (e BackupIntegration) Restore(store db.Store, b *BackupDB) error {
 		alias := db.IntegrationAlias{
 			Alias:         a,
 			ProjectID:     b.meta.ID,
-			IntegrationID: &newIntegration.ID,
+			IntegrationID: &integrationID,
 		}
 		_, _ = store.CreateIntegrationAlias(alias)
 	}
-
-	return nil
 }
 
 func (backup *BackupFormat) Verify() error {
@@ -504,9 +600,24 @@ func (backup *BackupFormat) Verify() error {
 }
 
 func (backup *BackupFormat) Restore(user db.User, store db.Store) (*db.Project, error) {
+	return backup.RestoreWithSecrets(user, store, nil)
+}
+
+// RestoreWithSecrets creates the project from the backup. If secrets are not nil,
+// secrets of the backup are decrypted by the passphrase and restored too.
+func (backup *BackupFormat) RestoreWithSecrets(user db.User, store db.Store, secrets *SecretOptions) (*db.Project, error) {
 	var b = BackupDB{}
 	project := backup.Meta.Project
 
+	if secrets != nil && backup.Secrets != nil {
+		data, err := backup.Secrets.decrypt(secrets.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		b.secrets = data
+		b.encryptionService = secrets.EncryptionService
+	}
+
 	// Prevent importing a project with a name that already exists
 	existingProjects, err := store.GetAllProjects()
 	if err == nil {
@@ -0,0 +1,783 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/semaphoreui/semaphore/db"
+	log "github.com/sirupsen/logrus"
+)
+
+type RestoreAction string
+
+const (
+	RestoreActionCreate RestoreAction = "create"
+	RestoreActionUpdate RestoreAction = "update"
+	RestoreActionDelete RestoreAction = "delete"
+)
+
+// RestoreChange is a change of the single object of the project.
+type RestoreChange struct {
+	Kind   string        `json:"kind"`
+	Name   string        `json:"name"`
+	Action RestoreAction `json:"action"`
+}
+
+// RestoreDiff contains changes which turn the existing project into the backup.
+type RestoreDiff struct {
+	Changes []RestoreChange `json:"changes"`
+	DryRun  bool            `json:"dry_run"`
+}
+
+type RestoreIntoOptions struct {
+	// DryRun only computes the diff without changing the project.
+	DryRun  bool
+	Secrets *SecretOptions
+}
+
+func (d *RestoreDiff) add(kind string, name string, action RestoreAction) {
+	d.Changes = append(d.Changes, RestoreChange{
+		Kind:   kind,
+		Name:   name,
+		Action: action,
+	})
+}
+
+type entryUpdate[T any] struct {
+	entry   T
+	current T
+}
+
+// entryPlan contains changes of objects of the single kind.
+type entryPlan[T any] struct {
+	creates []T
+	updates []entryUpdate[T]
+	deletes []T
+}
+
+// sameBackupEntry compares entries as they are stored in the backup,
+// so IDs of the objects and their references are ignored.
+func sameBackupEntry(a any, b any) (bool, error) {
+	dataA, err := marshalValue(reflect.ValueOf(a))
+	if err != nil {
+		return false, err
+	}
+
+	dataB, err := marshalValue(reflect.ValueOf(b))
+	if err != nil {
+		return false, err
+	}
+
+	jsonA, err := json.Marshal(dataA)
+	if err != nil {
+		return false, err
+	}
+
+	jsonB, err := json.Marshal(dataB)
+	if err != nil {
+		return false, err
+	}
+
+	return string(jsonA) == string(jsonB), nil
+}
+
+// planEntries matches entries of the backup with objects of the project by name.
+func planEntries[T any](
+	diff *RestoreDiff,
+	kind string,
+	entries []T,
+	current []T,
+	getName func(T) string,
+	getID func(T) int,
+) (plan entryPlan[T], err error) {
+	existing := make(map[string]T)
+	for _, o := range current {
+		// Entries which refer to missing objects are not formatted.
+		if getID(o) == 0 {
+			continue
+		}
+		existing[getName(o)] = o
+	}
+
+	matched := make(map[string]bool)
+
+	for _, e := range entries {
+		name := getName(e)
+
+		cur, ok := existing[name]
+		if !ok {
+			plan.creates = append(plan.creates, e)
+			diff.add(kind, name, RestoreActionCreate)
+			continue
+		}
+
+		matched[name] = true
+
+		var same bool
+		if same, err = sameBackupEntry(e, cur); err != nil {
+			return
+		}
+
+		if !same {
+			plan.updates = append(plan.updates, entryUpdate[T]{entry: e, current: cur})
+			diff.add(kind, name, RestoreActionUpdate)
+		}
+	}
+
+	for _, o := range current {
+		if getID(o) == 0 || matched[getName(o)] {
+			continue
+		}
+		plan.deletes = append(plan.deletes, o)
+		diff.add(kind, getName(o), RestoreActionDelete)
+	}
+
+	return
+}
+
+// planDependencies finds objects referred by the backup which do not exist
+// in the project. They are created, but never updated or deleted.
+func planDependencies[T BackupEntry](diff *RestoreDiff, kind string, entries []T, exists func(name string) bool) []T {
+	var res []T
+	for _, e := range entries {
+		if exists(e.GetName()) {
+			continue
+		}
+		res = append(res, e)
+		diff.add(kind, e.GetName(), RestoreActionCreate)
+	}
+	return res
+}
+
+func hasName[T db.BackupEntity](items []T) func(name string) bool {
+	return func(name string) bool {
+		return findEntityByName[T](&name, items) != nil
+	}
+}
+
+type restorePlan struct {
+	secretStorages []BackupSecretStorage
+	roles          []BackupRole
+	keys           []BackupAccessKey
+	repositories   []BackupRepository
+
+	views        entryPlan[BackupView]
+	environments entryPlan[BackupEnvironment]
+	inventories  entryPlan[BackupInventory]
+	templates    entryPlan[BackupTemplate]
+	schedules    entryPlan[BackupSchedule]
+	integrations entryPlan[BackupIntegration]
+}
+
+func (backup *BackupFormat) plan(current *BackupFormat, b *BackupDB, diff *RestoreDiff) (p restorePlan, err error) {
+	p.secretStorages = planDependencies(diff, "secret_storages", backup.SecretStorages, hasName(b.secretStorages))
+	p.roles = planDependencies(diff, "roles", backup.Roles, hasName(b.roles))
+	p.keys = planDependencies(diff, "keys", backup.Keys, hasName(b.keys))
+	p.repositories = planDependencies(diff, "repositories", backup.Repositories, hasName(b.repositories))
+
+	if p.views, err = planEntries(diff, "views", backup.Views, current.Views,
+		func(e BackupView) string { return e.Title },
+		func(e BackupView) int { return e.ID }); err != nil {
+		return
+	}
+
+	if p.environments, err = planEntries(diff, "environments", backup.Environments, current.Environments,
+		func(e BackupEnvironment) string { return e.Name },
+		func(e BackupEnvironment) int { return e.ID }); err != nil {
+		return
+	}
+
+	if p.inventories, err = planEntries(diff, "inventories", backup.Inventories, current.Inventories,
+		func(e BackupInventory) string { return e.Name },
+		func(e BackupInventory) int { return e.ID }); err != nil {
+		return
+	}
+
+	if p.templates, err = planEntries(diff, "templates", backup.Templates, current.Templates,
+		func(e BackupTemplate) string { return e.Name },
+		func(e BackupTemplate) int { return e.ID }); err != nil {
+		return
+	}
+
+	if p.schedules, err = planEntries(diff, "schedules", backup.Schedules, current.Schedules,
+		func(e BackupSchedule) string { return e.Name },
+		func(e BackupSchedule) int { return e.ID }); err != nil {
+		return
+	}
+
+	p.integrations, err = planEntries(diff, "integrations", backup.Integration, current.Integration,
+		func(e BackupIntegration) string { return e.Name },
+		func(e BackupIntegration) int { return e.ID })
+
+	return
+}
+
+// RestoreInto applies the backup to the existing project. Objects are matched
+// by name: missing ones are created, changed ones are updated and objects
+// absent in the backup are deleted. Keys, repositories, roles and secret storages
+// are only created if the backup refers to ones which do not exist.
+//
+// The backup is validated before the project is changed, so objects which
+// refer to missing ones or can not be stored are rejected before any write.
+// Changes are applied in the single transaction if the store supports it.
+// Otherwise (BoltDB) deletions are applied last, and if creating or updating
+// fails, created objects are deleted and updated objects are reverted.
+func (backup *BackupFormat) RestoreInto(projectID int, store db.Store, options RestoreIntoOptions) (*RestoreDiff, error) {
+	b := BackupDB{}
+	if err := b.load(projectID, store); err != nil {
+		return nil, err
+	}
+
+	current, err := b.format()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &RestoreDiff{
+		Changes: make([]RestoreChange, 0),
+		DryRun:  options.DryRun,
+	}
+
+	p, err := backup.plan(current, &b, diff)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = backup.validate(store, &b); err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		return diff, nil
+	}
+
+	if options.Secrets != nil && backup.Secrets != nil {
+		b.secrets, err = backup.Secrets.decrypt(options.Secrets.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		b.encryptionService = options.Secrets.EncryptionService
+	}
+
+	if ts, ok := store.(db.TransactionalStore); ok {
+		err = ts.Transaction(func(txStore db.Store) error {
+			return (&restoreTx{store: txStore, b: &b}).apply(p)
+		})
+	} else {
+		tx := &restoreTx{store: store, b: &b, compensate: true}
+		if err = tx.apply(p); err != nil {
+			tx.rollback()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// validate checks references and fields of all objects of the backup,
+// so applying the plan does not fail halfway because of the backup content.
+func (backup *BackupFormat) validate(store db.Store, b *BackupDB) error {
+	if err := backup.Verify(); err != nil {
+		return err
+	}
+
+	for _, o := range backup.Views {
+		v := o.View
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("error at view %s: %s", o.Title, err.Error())
+		}
+	}
+
+	for _, o := range backup.Environments {
+		env := o.Environment
+		if err := env.Validate(); err != nil {
+			return fmt.Errorf("error at environment %s: %s", o.Name, err.Error())
+		}
+	}
+
+	for _, o := range backup.Templates {
+		tpl := o.Template
+		if o.Inventory != nil {
+			// The inventory is resolved when the template is applied.
+			inventoryID := 0
+			tpl.InventoryID = &inventoryID
+		}
+
+		if err := tpl.Validate(); err != nil {
+			return fmt.Errorf("error at template %s: %s", o.Name, err.Error())
+		}
+
+		for _, role := range o.Roles {
+			var err error
+			if role.IsGlobal {
+				_, err = store.GetGlobalRoleBySlug(role.Role)
+			} else if getEntryByName[BackupRole](&role.Role, backup.Roles) == nil &&
+				findEntityByName[db.Role](&role.Role, b.roles) == nil {
+				err = fmt.Errorf("roles[].role does not exist in roles[].name")
+			}
+			if err != nil {
+				return fmt.Errorf("error at template %s: role %s: %s", o.Name, role.Role, err.Error())
+			}
+		}
+	}
+
+	for _, o := range backup.Integration {
+		integration := o.Integration
+		if err := integration.Validate(); err != nil {
+			return fmt.Errorf("error at integration %s: %s", o.Name, err.Error())
+		}
+
+		if getEntryByName[BackupTemplate](&o.Template, backup.Templates) == nil {
+			return fmt.Errorf("error at integration %s: template does not exist in templates[].name", o.Name)
+		}
+	}
+
+	return nil
+}
+
+// restoreTx applies the plan. If the store has no transactions,
+// it remembers how to revert applied changes.
+type restoreTx struct {
+	store      db.Store
+	b          *BackupDB
+	compensate bool
+	undo       []func() error
+}
+
+func (tx *restoreTx) onRollback(f func() error) {
+	if tx.compensate {
+		tx.undo = append(tx.undo, f)
+	}
+}
+
+func (tx *restoreTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			log.WithError(err).WithField("project_id", tx.b.meta.ID).Error("Failed to revert restored project")
+		}
+	}
+}
+
+func (tx *restoreTx) apply(p restorePlan) error {
+	projectID := tx.b.meta.ID
+	store := tx.store
+	b := tx.b
+
+	for _, o := range p.secretStorages {
+		if err := o.Restore(store, b); err != nil {
+			return fmt.Errorf("error at secret storage %s: %s", o.Name, err.Error())
+		}
+		id := b.secretStorages[len(b.secretStorages)-1].ID
+		tx.onRollback(func() error { return store.DeleteSecretStorage(projectID, id) })
+	}
+
+	for _, o := range p.roles {
+		if err := o.Restore(store, b); err != nil {
+			return fmt.Errorf("error at role %s: %s", o.Name, err.Error())
+		}
+		slug := b.roles[len(b.roles)-1].Slug
+		tx.onRollback(func() error { return store.DeleteRole(slug) })
+	}
+
+	for _, o := range p.keys {
+		if err := o.Restore(store, b); err != nil {
+			return fmt.Errorf("error at key %s: %s", o.Name, err.Error())
+		}
+		id := b.keys[len(b.keys)-1].ID
+		tx.onRollback(func() error { return store.DeleteAccessKey(projectID, id) })
+	}
+
+	for _, o := range p.repositories {
+		if err := o.Restore(store, b); err != nil {
+			return fmt.Errorf("error at repository %s: %s", o.Name, err.Error())
+		}
+		id := b.repositories[len(b.repositories)-1].ID
+		tx.onRollback(func() error { return store.DeleteRepository(projectID, id) })
+	}
+
+	if err := tx.applyViews(p.views); err != nil {
+		return err
+	}
+
+	if err := tx.applyEnvironments(p.environments); err != nil {
+		return err
+	}
+
+	if err := tx.applyInventories(p.inventories); err != nil {
+		return err
+	}
+
+	if err := tx.applyTemplates(p.templates); err != nil {
+		return err
+	}
+
+	if err := tx.applySchedules(p.schedules); err != nil {
+		return err
+	}
+
+	if err := tx.applyIntegrations(p.integrations); err != nil {
+		return err
+	}
+
+	return tx.applyDeletes(p)
+}
+
+func (tx *restoreTx) applyViews(plan entryPlan[BackupView]) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range plan.creates {
+		if err := o.Restore(tx.store, tx.b); err != nil {
+			return fmt.Errorf("error at view %s: %s", o.Title, err.Error())
+		}
+		id := tx.b.views[len(tx.b.views)-1].ID
+		tx.onRollback(func() error { return tx.store.DeleteView(projectID, id) })
+	}
+
+	for _, u := range plan.updates {
+		v := u.entry.View
+		v.ID = u.current.ID
+		v.ProjectID = projectID
+
+		if err := tx.store.UpdateView(v); err != nil {
+			return fmt.Errorf("error at view %s: %s", v.Title, err.Error())
+		}
+
+		orig := u.current.View
+		tx.onRollback(func() error { return tx.store.UpdateView(orig) })
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) applyEnvironments(plan entryPlan[BackupEnvironment]) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range plan.creates {
+		if err := o.Restore(tx.store, tx.b); err != nil {
+			return fmt.Errorf("error at environment %s: %s", o.Name, err.Error())
+		}
+		id := tx.b.environments[len(tx.b.environments)-1].ID
+		tx.onRollback(func() error { return tx.store.DeleteEnvironment(projectID, id) })
+	}
+
+	// Secrets of updated environments are kept as is.
+	for _, u := range plan.updates {
+		env := u.entry.Environment
+		env.ID = u.current.ID
+		env.ProjectID = projectID
+
+		if err := tx.store.UpdateEnvironment(env); err != nil {
+			return fmt.Errorf("error at environment %s: %s", env.Name, err.Error())
+		}
+
+		orig := u.current.Environment
+		tx.onRollback(func() error { return tx.store.UpdateEnvironment(orig) })
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) applyInventories(plan entryPlan[BackupInventory]) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range plan.creates {
+		if err := o.Restore(tx.store, tx.b); err != nil {
+			return fmt.Errorf("error at inventory %s: %s", o.Name, err.Error())
+		}
+		id := tx.b.inventories[len(tx.b.inventories)-1].ID
+		tx.onRollback(func() error { return tx.store.DeleteInventory(projectID, id) })
+	}
+
+	for _, u := range plan.updates {
+		inv := u.entry.build(tx.b)
+		inv.ID = u.current.ID
+
+		if err := tx.store.UpdateInventory(inv); err != nil {
+			return fmt.Errorf("error at inventory %s: %s", inv.Name, err.Error())
+		}
+
+		orig := u.current.Inventory
+		tx.onRollback(func() error { return tx.store.UpdateInventory(orig) })
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) applyTemplates(plan entryPlan[BackupTemplate]) error {
+	projectID := tx.b.meta.ID
+
+	// Deploy templates refer to build templates, so they are created last.
+	creates := make([]BackupTemplate, 0, len(plan.creates))
+	for _, o := range plan.creates {
+		if string(o.Type) != "deploy" {
+			creates = append(creates, o)
+		}
+	}
+	for _, o := range plan.creates {
+		if string(o.Type) == "deploy" {
+			creates = append(creates, o)
+		}
+	}
+
+	for _, o := range creates {
+		err := o.Restore(tx.store, tx.b)
+
+		// The template can be created even if its vaults or roles fail.
+		if tpl := findEntityByName[db.Template](&o.Name, tx.b.templates); tpl != nil {
+			id := tpl.ID
+			tx.onRollback(func() error { return tx.store.DeleteTemplate(projectID, id) })
+		}
+
+		if err != nil {
+			return fmt.Errorf("error at template %s: %s", o.Name, err.Error())
+		}
+	}
+
+	for _, u := range plan.updates {
+		if err := tx.updateTemplate(u); err != nil {
+			return fmt.Errorf("error at template %s: %s", u.entry.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) updateTemplate(u entryUpdate[BackupTemplate]) error {
+	projectID := tx.b.meta.ID
+	templateID := u.current.ID
+
+	tpl, err := u.entry.build(tx.b)
+	if err != nil {
+		return err
+	}
+	tpl.ID = templateID
+
+	vaults, err := u.entry.buildVaults(tx.b, templateID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := u.entry.buildRoles(tx.store, tx.b, templateID)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.store.UpdateTemplate(tpl); err != nil {
+		return err
+	}
+
+	orig := u.current.Template
+	tx.onRollback(func() error { return tx.store.UpdateTemplate(orig) })
+
+	if err = tx.store.UpdateTemplateVaults(projectID, templateID, vaults); err != nil {
+		return err
+	}
+
+	origVaults := make([]db.TemplateVault, len(orig.Vaults))
+	for i, v := range orig.Vaults {
+		v.ID = 0
+		origVaults[i] = v
+	}
+	tx.onRollback(func() error { return tx.store.UpdateTemplateVaults(projectID, templateID, origVaults) })
+
+	origRoles := tx.b.templateRoles[templateID]
+
+	newRoles, err := replaceTemplateRoles(tx.store, projectID, templateID, origRoles, roles)
+	tx.onRollback(func() error {
+		_, err2 := replaceTemplateRoles(tx.store, projectID, templateID, newRoles, origRoles)
+		return err2
+	})
+
+	return err
+}
+
+// replaceTemplateRoles deletes the old permissions of the template and creates the new ones.
+// It returns the created permissions even if it fails.
+func replaceTemplateRoles(
+	store db.Store,
+	projectID int,
+	templateID int,
+	old []db.TemplateRolePerm,
+	roles []db.TemplateRolePerm,
+) (created []db.TemplateRolePerm, err error) {
+	for _, r := range old {
+		if err = store.DeleteTemplateRole(projectID, templateID, r.ID); err != nil {
+			return
+		}
+	}
+
+	for _, r := range roles {
+		r.ID = 0
+		var newRole db.TemplateRolePerm
+		if newRole, err = store.CreateTemplateRole(r); err != nil {
+			return
+		}
+		created = append(created, newRole)
+	}
+
+	return
+}
+
+func (tx *restoreTx) applySchedules(plan entryPlan[BackupSchedule]) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range plan.creates {
+		if err := o.Restore(tx.store, tx.b); err != nil {
+			return fmt.Errorf("error at schedule %s: %s", o.Name, err.Error())
+		}
+		id := tx.b.schedules[len(tx.b.schedules)-1].ID
+		tx.onRollback(func() error { return tx.store.DeleteSchedule(projectID, id) })
+	}
+
+	for _, u := range plan.updates {
+		schedule, err := u.entry.build(tx.b)
+		if err != nil {
+			return fmt.Errorf("error at schedule %s: %s", u.entry.Name, err.Error())
+		}
+		schedule.ID = u.current.ID
+		schedule.TaskParamsID = u.current.TaskParamsID
+
+		if err = tx.store.UpdateSchedule(schedule); err != nil {
+			return fmt.Errorf("error at schedule %s: %s", schedule.Name, err.Error())
+		}
+
+		orig := u.current.Schedule
+		tx.onRollback(func() error { return tx.store.UpdateSchedule(orig) })
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) applyIntegrations(plan entryPlan[BackupIntegration]) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range plan.creates {
+		if err := o.Restore(tx.store, tx.b); err != nil {
+			return fmt.Errorf("error at integration %s: %s", o.Name, err.Error())
+		}
+		id := tx.b.integrations[len(tx.b.integrations)-1].ID
+		tx.onRollback(func() error { return tx.store.DeleteIntegration(projectID, id) })
+	}
+
+	for _, u := range plan.updates {
+		integration, err := u.entry.build(tx.b)
+		if err != nil {
+			return fmt.Errorf("error at integration %s: %s", u.entry.Name, err.Error())
+		}
+		integration.ID = u.current.ID
+		integration.TaskParamsID = u.current.TaskParamsID
+
+		if err = tx.store.UpdateIntegration(integration); err != nil {
+			return fmt.Errorf("error at integration %s: %s", integration.Name, err.Error())
+		}
+
+		orig := u.current
+		tx.onRollback(func() error {
+			if err2 := tx.store.UpdateIntegration(orig.Integration); err2 != nil {
+				return err2
+			}
+			if err2 := deleteIntegrationChildren(tx.store, projectID, orig.ID); err2 != nil {
+				return err2
+			}
+			orig.restoreChildren(tx.store, tx.b, orig.ID)
+			return nil
+		})
+
+		if err = deleteIntegrationChildren(tx.store, projectID, integration.ID); err != nil {
+			return fmt.Errorf("error at integration %s: %s", integration.Name, err.Error())
+		}
+
+		u.entry.restoreChildren(tx.store, tx.b, integration.ID)
+	}
+
+	return nil
+}
+
+func deleteIntegrationChildren(store db.Store, projectID int, integrationID int) error {
+	matchers, err := store.GetIntegrationMatchers(projectID, db.RetrieveQueryParams{}, integrationID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range matchers {
+		if err = store.DeleteIntegrationMatcher(projectID, m.ID, integrationID); err != nil {
+			return err
+		}
+	}
+
+	values, err := store.GetIntegrationExtractValues(projectID, db.RetrieveQueryParams{}, integrationID)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		if err = store.DeleteIntegrationExtractValue(projectID, v.ID, integrationID); err != nil {
+			return err
+		}
+	}
+
+	aliases, err := store.GetIntegrationAliases(projectID, &integrationID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range aliases {
+		if err = store.DeleteIntegrationAlias(projectID, a.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *restoreTx) applyDeletes(p restorePlan) error {
+	projectID := tx.b.meta.ID
+
+	for _, o := range p.schedules.deletes {
+		if err := tx.store.DeleteSchedule(projectID, o.ID); err != nil {
+			return fmt.Errorf("error at schedule %s: %s", o.Name, err.Error())
+		}
+	}
+
+	for _, o := range p.integrations.deletes {
+		if err := tx.store.DeleteIntegration(projectID, o.ID); err != nil {
+			return fmt.Errorf("error at integration %s: %s", o.Name, err.Error())
+		}
+	}
+
+	// Deploy templates refer to build templates, so they are deleted first.
+	for _, deploy := range []bool{true, false} {
+		for _, o := range p.templates.deletes {
+			if (string(o.Type) == "deploy") != deploy {
+				continue
+			}
+			if err := tx.store.DeleteTemplate(projectID, o.ID); err != nil {
+				return fmt.Errorf("error at template %s: %s", o.Name, err.Error())
+			}
+		}
+	}
+
+	for _, o := range p.inventories.deletes {
+		if err := tx.store.DeleteInventory(projectID, o.ID); err != nil {
+			return fmt.Errorf("error at inventory %s: %s", o.Name, err.Error())
+		}
+	}
+
+	for _, o := range p.environments.deletes {
+		if err := tx.store.DeleteEnvironment(projectID, o.ID); err != nil {
+			return fmt.Errorf("error at environment %s: %s", o.Name, err.Error())
+		}
+	}
+
+	for _, o := range p.views.deletes {
+		if err := tx.store.DeleteView(projectID, o.ID); err != nil {
+			return fmt.Errorf("error at view %s: %s", o.Title, err.Error())
+		}
+	}
+
+	return nil
+}
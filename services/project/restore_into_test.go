@@ -0,0 +1,244 @@
+package project
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createRestoreIntoProject(t *testing.T, store db.Store) db.Project {
+	proj, err := store.CreateProject(db.Project{Name: "Staging"})
+	require.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{
+		Name:      "None",
+		ProjectID: &proj.ID,
+		Type:      db.AccessKeyNone,
+	})
+	require.NoError(t, err)
+
+	repo, err := store.CreateRepository(db.Repository{
+		ProjectID: proj.ID,
+		SSHKeyID:  key.ID,
+		Name:      "Repo",
+		GitURL:    "git@example.com:test/test",
+		GitBranch: "master",
+	})
+	require.NoError(t, err)
+
+	inv, err := store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+		Name:      "Hosts",
+		Type:      db.InventoryStatic,
+		Inventory: "localhost",
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateInventory(db.Inventory{
+		ProjectID: proj.ID,
+		Name:      "Old",
+		Type:      db.InventoryStatic,
+	})
+	require.NoError(t, err)
+
+	env, err := store.CreateEnvironment(db.Environment{
+		ProjectID: proj.ID,
+		Name:      "Env",
+		JSON:      "{}",
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateTemplate(db.Template{
+		Name:          "Deploy",
+		Playbook:      "deploy.yml",
+		ProjectID:     proj.ID,
+		RepositoryID:  repo.ID,
+		InventoryID:   &inv.ID,
+		EnvironmentID: &env.ID,
+	})
+	require.NoError(t, err)
+
+	return proj
+}
+
+func TestRestoreInto(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	proj := createRestoreIntoProject(t, store)
+
+	backup, err := GetBackup(proj.ID, store)
+	require.NoError(t, err)
+
+	diff, err := backup.RestoreInto(proj.ID, store, RestoreIntoOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+
+	// Promoted definition: one inventory changed, one removed and a view added.
+	for i := range backup.Inventories {
+		if backup.Inventories[i].Name == "Hosts" {
+			backup.Inventories[i].Inventory.Inventory = "prod.example.com"
+		}
+	}
+	backup.Inventories = backup.Inventories[:1]
+	require.Equal(t, "Hosts", backup.Inventories[0].Name)
+	backup.Views = append(backup.Views, BackupView{View: db.View{Title: "Prod"}})
+
+	expected := []RestoreChange{
+		{Kind: "views", Name: "Prod", Action: RestoreActionCreate},
+		{Kind: "inventories", Name: "Hosts", Action: RestoreActionUpdate},
+		{Kind: "inventories", Name: "Old", Action: RestoreActionDelete},
+	}
+
+	diff, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, diff.DryRun)
+	assert.ElementsMatch(t, expected, diff.Changes)
+
+	inventories, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, inventories, 2)
+
+	diff, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{})
+	require.NoError(t, err)
+	assert.False(t, diff.DryRun)
+	assert.ElementsMatch(t, expected, diff.Changes)
+
+	inventories, err = store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	require.Len(t, inventories, 1)
+	assert.Equal(t, "prod.example.com", inventories[0].Inventory)
+
+	views, err := store.GetViews(proj.ID)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "Prod", views[0].Title)
+
+	diff, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+}
+
+func TestRestoreInto_RollbackOnError(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	proj := createRestoreIntoProject(t, store)
+
+	backup, err := GetBackup(proj.ID, store)
+	require.NoError(t, err)
+
+	backup.Views = append(backup.Views, BackupView{View: db.View{Title: "Prod"}})
+	backup.Inventories[0].Inventory.Inventory = "prod.example.com"
+	backup.Templates = append(backup.Templates, BackupTemplate{
+		Template:   db.Template{Name: "Broken", Playbook: "broken.yml"},
+		Repository: "Missing",
+	})
+
+	_, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{})
+	require.Error(t, err)
+
+	views, err := store.GetViews(proj.ID)
+	require.NoError(t, err)
+	assert.Empty(t, views)
+
+	inv, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	require.Len(t, inv, 2)
+	for _, o := range inv {
+		assert.NotEqual(t, "prod.example.com", o.Inventory)
+	}
+}
+
+func TestRestoreInto_InvalidBackup(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	proj := createRestoreIntoProject(t, store)
+
+	backup, err := GetBackup(proj.ID, store)
+	require.NoError(t, err)
+
+	// The backup deletes the inventory, but its template can not be stored.
+	for i := range backup.Inventories {
+		if backup.Inventories[i].Name == "Hosts" {
+			backup.Inventories = backup.Inventories[i : i+1]
+			break
+		}
+	}
+	backup.Templates[0].ContainerImage = "--privileged"
+
+	_, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{DryRun: true})
+	require.Error(t, err)
+
+	_, err = backup.RestoreInto(proj.ID, store, RestoreIntoOptions{})
+	require.Error(t, err)
+
+	inv, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, inv, 2)
+
+	templates, err := store.GetTemplates(proj.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Empty(t, templates[0].ContainerImage)
+}
+
+// failingDeleteStore fails to delete inventories.
+type failingDeleteStore struct {
+	db.Store
+}
+
+func (s failingDeleteStore) DeleteInventory(_ int, _ int) error {
+	return errors.New("test error")
+}
+
+func (s failingDeleteStore) Transaction(fn func(store db.Store) error) error {
+	return s.Store.(db.TransactionalStore).Transaction(func(store db.Store) error {
+		return fn(failingDeleteStore{store})
+	})
+}
+
+func TestRestoreInto_FailedDelete(t *testing.T) {
+	util.Config = &util.ConfigType{
+		TmpPath: "/tmp",
+	}
+
+	store := sql.CreateTestStore()
+	proj := createRestoreIntoProject(t, store)
+
+	backup, err := GetBackup(proj.ID, store)
+	require.NoError(t, err)
+
+	// The template is deleted before the inventory which can not be deleted.
+	backup.Templates = nil
+	backup.Inventories = backup.Inventories[:1]
+	backup.Views = append(backup.Views, BackupView{View: db.View{Title: "Prod"}})
+
+	_, err = backup.RestoreInto(proj.ID, failingDeleteStore{store}, RestoreIntoOptions{})
+	require.Error(t, err)
+
+	templates, err := store.GetTemplates(proj.ID, db.TemplateFilter{}, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "Deploy", templates[0].Name)
+
+	views, err := store.GetViews(proj.ID)
+	require.NoError(t, err)
+	assert.Empty(t, views)
+
+	inv, err := store.GetInventories(proj.ID, db.RetrieveQueryParams{}, nil)
+	require.NoError(t, err)
+	assert.Len(t, inv, 2)
+}
@@ -2,6 +2,7 @@ package project
 
 import (
 	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/services/server"
 )
 
 type BackupDB struct {
@@ -24,6 +25,12 @@ type BackupDB struct {
 	globalRoles    []db.Role
 	roles          []db.Role
 	templateRoles  map[int][]db.TemplateRolePerm
+
+	environmentSecrets []db.AccessKey
+
+	// secrets are decrypted secrets of the restored backup.
+	secrets           *backupSecretData
+	encryptionService server.AccessKeyEncryptionService
 }
 
 type BackupFormat struct {
@@ -39,6 +46,7 @@ type BackupFormat struct {
 	Schedules          []BackupSchedule      `backup:"schedules"`
 	SecretStorages     []BackupSecretStorage `backup:"secret_storages"`
 	Roles              []BackupRole          `backup:"roles"`
+	Secrets            *BackupSecrets        `backup:"secrets"`
 }
 
 type BackupMeta struct {
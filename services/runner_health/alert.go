@@ -0,0 +1,177 @@
+package runner_health
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/semaphoreui/semaphore/util/mailer"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// Alert is sent when a runner tag has no online runners.
+type Alert struct {
+	Tag string
+	// ProjectID is nil for the tag of global runners.
+	ProjectID *int
+	Project   string
+	URL       string
+	Chat      alertChat
+}
+
+type alertChat struct {
+	ID string
+}
+
+func render(name string, alert Alert) (string, error) {
+	tpl, err := template.ParseFS(templates, "templates/"+name)
+	if err != nil {
+		return "", err
+	}
+
+	body := bytes.NewBufferString("")
+	if err = tpl.Execute(body, alert); err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+// send delivers the alert by email to admins and to members of the project
+// of the runners and to the chats configured for alerts. Alerts of project
+// runners are sent only if alerts are enabled for the project.
+func (m *Monitor) send(alert Alert) {
+	logger := log.WithFields(log.Fields{
+		"tag":     alert.Tag,
+		"context": "runner_health",
+	})
+
+	logger.Warn("No online runners with the tag")
+
+	alert.URL = util.Config.WebHost + "/runners"
+	alert.Chat.ID = util.Config.TelegramChat
+
+	var project *db.Project
+
+	if alert.ProjectID != nil {
+		p, err := m.store.GetProject(*alert.ProjectID)
+		if err != nil {
+			logger.WithError(err).Error("Can't get project of the runner tag")
+			return
+		}
+
+		if !p.Alert {
+			return
+		}
+
+		project = &p
+		alert.Project = p.Name
+		alert.URL = fmt.Sprintf("%s/project/%d/runners", util.Config.WebHost, p.ID)
+
+		if p.AlertChat != nil && *p.AlertChat != "" {
+			alert.Chat.ID = *p.AlertChat
+		}
+	}
+
+	if util.Config.EmailAlert {
+		if err := m.sendMail(alert, project); err != nil {
+			logger.WithError(err).Error("Can't send email alert")
+		}
+	}
+
+	if util.Config.TelegramAlert && alert.Chat.ID != "" {
+		err := post(
+			fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", util.Config.TelegramToken),
+			"telegram.tmpl",
+			alert,
+		)
+		if err != nil {
+			logger.WithError(err).Error("Can't send telegram alert")
+		}
+	}
+
+	if util.Config.SlackAlert {
+		if err := post(util.Config.SlackUrl, "slack.tmpl", alert); err != nil {
+			logger.WithError(err).Error("Can't send slack alert")
+		}
+	}
+}
+
+func (m *Monitor) sendMail(alert Alert, project *db.Project) error {
+	body, err := render("email.tmpl", alert)
+	if err != nil {
+		return err
+	}
+
+	recipients := make(map[string]bool)
+
+	admins, err := m.store.GetAllAdmins()
+	if err != nil {
+		return err
+	}
+
+	for _, admin := range admins {
+		if admin.Alert {
+			recipients[admin.Email] = true
+		}
+	}
+
+	if project != nil {
+		users, err := m.store.GetProjectUsers(project.ID, db.RetrieveQueryParams{})
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			if user.Alert {
+				recipients[user.Email] = true
+			}
+		}
+	}
+
+	for email := range recipients {
+		if err = mailer.Send(
+			util.Config.EmailSecure,
+			util.Config.EmailTls,
+			util.Config.EmailHost,
+			util.Config.EmailPort,
+			util.Config.EmailUsername,
+			util.Config.EmailPassword,
+			util.Config.EmailSender,
+			email,
+			fmt.Sprintf("No online runners with tag '%s'", alert.Tag),
+			body,
+		); err != nil {
+			util.LogError(err)
+		}
+	}
+
+	return nil
+}
+
+func post(url string, templateName string, alert Alert) error {
+	body, err := render(templateName, alert)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response code %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package runner_health
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// minCheckInterval limits how often runners are checked when
+// the heartbeat timeout is short.
+const minCheckInterval = 5 * time.Second
+
+// healthHistoryRetention is how long health records of runners are kept.
+const healthHistoryRetention = 30 * 24 * time.Hour
+
+// pruneInterval is how often old health records are deleted.
+const pruneInterval = time.Hour
+
+// Leader reports whether this server is the leader of the HA cluster.
+// It is implemented by tasks.TaskStateStore.
+type Leader interface {
+	IsLeader() bool
+}
+
+// tagKey identifies the runner tag. Project runners and global runners
+// with the same tag serve different projects, so they are tracked separately.
+type tagKey struct {
+	projectID int
+	tag       string
+}
+
+// Monitor marks runners which have not contacted the server longer than
+// the heartbeat timeout as offline and sends alerts when a runner tag
+// has no online runners.
+type Monitor struct {
+	store db.Store
+	// leader prevents servers of the HA cluster from checking runners concurrently.
+	leader Leader
+	// pruned is the time when old health records were deleted last time.
+	pruned time.Time
+	// alertedTags contains tags with no online runners which were already alerted.
+	alertedTags map[tagKey]bool
+	// sendAlert is replaced in tests.
+	sendAlert func(alert Alert)
+}
+
+func NewMonitor(store db.Store, leader Leader) *Monitor {
+	m := &Monitor{
+		store:       store,
+		leader:      leader,
+		alertedTags: make(map[tagKey]bool),
+	}
+	m.sendAlert = m.send
+	return m
+}
+
+func heartbeatTimeout() time.Duration {
+	return time.Duration(util.Config.RunnerHeartbeatTimeoutSec) * time.Second
+}
+
+// Run periodically checks health of all runners and deletes old health records.
+// Only the leader of the HA cluster does it.
+func (m *Monitor) Run() {
+	interval := heartbeatTimeout() / 4
+	if interval < minCheckInterval {
+		interval = minCheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.tick(tz.Now())
+	}
+}
+
+func (m *Monitor) tick(now time.Time) {
+	if !m.leader.IsLeader() {
+		return
+	}
+
+	db.StoreSession(m.store, "runner health", func() {
+		if err := m.check(now); err != nil {
+			log.WithError(err).Error("Runner health check failed")
+		}
+
+		if now.Sub(m.pruned) < pruneInterval {
+			return
+		}
+
+		if err := m.store.DeleteRunnerHealthHistory(now.Add(-healthHistoryRetention)); err != nil {
+			log.WithError(err).Error("Can not delete old runner health records")
+			return
+		}
+
+		m.pruned = now
+	})
+}
+
+// IsOnline returns true if the runner has contacted the server within
+// the heartbeat timeout. Runners are always online if the health
+// monitoring is disabled.
+func IsOnline(runner db.Runner, now time.Time) bool {
+	return IsOnlineSince(runner, time.Time{}, now)
+}
+
+// IsOnlineSince is like IsOnline, but the runner is online during
+// the heartbeat timeout after since, even if it has not contacted the server.
+// It gives the runner, which is assigned a task at since, time to pick it up.
+func IsOnlineSince(runner db.Runner, since time.Time, now time.Time) bool {
+	timeout := heartbeatTimeout()
+
+	if timeout <= 0 {
+		return true
+	}
+
+	last := since
+	if runner.Touched != nil && runner.Touched.After(last) {
+		last = *runner.Touched
+	}
+
+	return !last.IsZero() && now.Sub(last) <= timeout
+}
+
+func (m *Monitor) check(now time.Time) error {
+	runners, err := m.store.GetAllRunners(true, false)
+	if err != nil {
+		return err
+	}
+
+	online := make(map[tagKey]int)
+
+	for _, runner := range runners {
+		// Ephemeral runners are checked by the tasks they are started for.
+		if runner.Ephemeral {
+			continue
+		}
+
+		health := db.RunnerHealthOffline
+		if IsOnline(runner, now) {
+			health = db.RunnerHealthOnline
+		}
+
+		if runner.Tag != "" {
+			key := tagKey{tag: runner.Tag}
+			if runner.ProjectID != nil {
+				key.projectID = *runner.ProjectID
+			}
+
+			if health == db.RunnerHealthOnline {
+				online[key]++
+			} else if _, ok := online[key]; !ok {
+				online[key] = 0
+			}
+		}
+
+		if health == runner.Health {
+			continue
+		}
+
+		message := "Runner is online"
+		if health == db.RunnerHealthOffline {
+			if runner.Touched == nil {
+				message = "Runner has never contacted the server"
+			} else {
+				message = fmt.Sprintf("Runner has not contacted the server for %s", now.Sub(*runner.Touched).Round(time.Second))
+			}
+		}
+
+		if _, err = m.store.SetRunnerHealth(runner, health, message); err != nil {
+			return err
+		}
+
+		log.WithFields(log.Fields{
+			"runner_id": runner.ID,
+			"health":    health,
+			"context":   "runner_health",
+		}).Info(message)
+	}
+
+	for key, n := range online {
+		if n > 0 {
+			delete(m.alertedTags, key)
+			continue
+		}
+
+		if m.alertedTags[key] {
+			continue
+		}
+
+		m.alertedTags[key] = true
+
+		alert := Alert{Tag: key.tag}
+		if key.projectID > 0 {
+			alert.ProjectID = &key.projectID
+		}
+
+		m.sendAlert(alert)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package runner_health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	store := sql.CreateTestStore()
+	util.Config.RunnerHeartbeatTimeoutSec = 60
+
+	connected, err := store.CreateRunner(db.Runner{Name: "connected", Active: true, Tag: "linux"})
+	require.NoError(t, err)
+	require.NoError(t, store.TouchRunner(connected))
+
+	neverConnected, err := store.CreateRunner(db.Runner{Name: "never connected", Active: true, Tag: "linux"})
+	require.NoError(t, err)
+
+	var alerts []Alert
+
+	m := NewMonitor(store, testLeader(true))
+	m.sendAlert = func(alert Alert) {
+		alerts = append(alerts, alert)
+	}
+
+	now := tz.Now()
+
+	require.NoError(t, m.check(now))
+
+	connected, err = store.GetGlobalRunner(connected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RunnerHealthOnline, connected.Health)
+
+	neverConnected, err = store.GetGlobalRunner(neverConnected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RunnerHealthOffline, neverConnected.Health)
+
+	assert.Empty(t, alerts)
+
+	// The tag has no online runners after the heartbeat timeout.
+	require.NoError(t, m.check(now.Add(2*time.Minute)))
+	require.NoError(t, m.check(now.Add(3*time.Minute)))
+
+	connected, err = store.GetGlobalRunner(connected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RunnerHealthOffline, connected.Health)
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "linux", alerts[0].Tag)
+	assert.Nil(t, alerts[0].ProjectID)
+
+	history, err := store.GetRunnerHealthHistory(connected.ID, db.RetrieveQueryParams{})
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, db.RunnerHealthOffline, history[0].Health)
+	assert.Equal(t, "Runner has not contacted the server for 2m0s", history[0].Message)
+	assert.Equal(t, db.RunnerHealthOnline, history[1].Health)
+
+	// The tag is alerted again only after it has recovered.
+	require.NoError(t, store.TouchRunner(connected))
+	require.NoError(t, m.check(tz.Now()))
+	require.NoError(t, m.check(tz.Now().Add(2*time.Minute)))
+
+	assert.Len(t, alerts, 2)
+}
+
+func TestIsOnlineSince(t *testing.T) {
+	util.Config = &util.ConfigType{RunnerHeartbeatTimeoutSec: 60}
+
+	now := tz.Now()
+	touched := now.Add(-5 * time.Minute)
+	runner := db.Runner{Touched: &touched}
+
+	assert.False(t, IsOnline(runner, now))
+	assert.True(t, IsOnlineSince(runner, now.Add(-30*time.Second), now))
+	assert.False(t, IsOnlineSince(runner, now.Add(-2*time.Minute), now))
+
+	util.Config.RunnerHeartbeatTimeoutSec = 0
+	assert.True(t, IsOnline(runner, now))
+}
+
+type testLeader bool
+
+func (l testLeader) IsLeader() bool { return bool(l) }
+
+func TestMonitor_Tick(t *testing.T) {
+	store := sql.CreateTestStore()
+	util.Config.RunnerHeartbeatTimeoutSec = 60
+
+	runner, err := store.CreateRunner(db.Runner{Name: "runner", Active: true})
+	require.NoError(t, err)
+
+	now := tz.Now()
+
+	// Only the leader checks runners.
+	NewMonitor(store, testLeader(false)).tick(now)
+
+	runner, err = store.GetGlobalRunner(runner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RunnerHealthUnknown, runner.Health)
+
+	m := NewMonitor(store, testLeader(true))
+	m.tick(now)
+
+	runner, err = store.GetGlobalRunner(runner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RunnerHealthOffline, runner.Health)
+	assert.Equal(t, now, m.pruned)
+}
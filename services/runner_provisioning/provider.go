@@ -0,0 +1,132 @@
+package runner_provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// containerLabel marks containers of ephemeral runners with the node which
+// has started them, so they can be removed after the restart of the server.
+const containerLabel = "io.semaphoreui.ephemeral-runner"
+
+// RunnerSpec describes the runner instance to start.
+type RunnerSpec struct {
+	Name string
+	Env  map[string]string
+}
+
+// Provider starts and stops runner instances.
+type Provider interface {
+	// Start starts the runner instance and returns its ID.
+	Start(ctx context.Context, spec RunnerSpec) (string, error)
+	Stop(ctx context.Context, instanceID string) error
+	// StopAll stops all instances started by the provider, including instances
+	// left by the previous run of the server, and returns their names.
+	StopAll(ctx context.Context) ([]string, error)
+}
+
+// ContainerProvider starts runners in containers with the Docker or Podman CLI.
+type ContainerProvider struct {
+	Engine  string
+	Image   string
+	Command []string
+	Network string
+	// Node is the value of the label of containers, only containers
+	// of the node are removed by StopAll.
+	Node string
+
+	// run executes the engine with additional environment variables,
+	// it is replaced in tests.
+	run func(ctx context.Context, env []string, name string, args ...string) ([]byte, error)
+}
+
+func NewContainerProvider(conf *util.RunnerProvisioningConfig) *ContainerProvider {
+	return &ContainerProvider{
+		Engine:  conf.GetEngine(),
+		Image:   conf.GetImage(),
+		Command: conf.Command,
+		Network: conf.Network,
+		Node:    conf.GetNodeID(),
+		run:     runCommand,
+	}
+}
+
+func runCommand(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+
+	out, err := cmd.Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return out, err
+}
+
+func (p *ContainerProvider) Start(ctx context.Context, spec RunnerSpec) (string, error) {
+	args := []string{"run", "--detach", "--name", spec.Name, "--label", containerLabel + "=" + p.Node}
+
+	if p.Network != "" {
+		args = append(args, "--network", p.Network)
+	}
+
+	names := make([]string, 0, len(spec.Env))
+	for name := range spec.Env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// Values are passed through the environment of the engine,
+	// so secrets like the runner token are not visible in arguments.
+	env := make([]string, 0, len(names))
+
+	for _, name := range names {
+		args = append(args, "--env", name)
+		env = append(env, name+"="+spec.Env[name])
+	}
+
+	args = append(args, p.Image)
+	args = append(args, p.Command...)
+
+	out, err := p.run(ctx, env, p.Engine, args...)
+	if err != nil {
+		return "", fmt.Errorf("can not start runner container: %w", err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (p *ContainerProvider) Stop(ctx context.Context, instanceID string) error {
+	if _, err := p.run(ctx, nil, p.Engine, "rm", "--force", instanceID); err != nil {
+		return fmt.Errorf("can not remove runner container: %w", err)
+	}
+	return nil
+}
+
+func (p *ContainerProvider) StopAll(ctx context.Context) ([]string, error) {
+	out, err := p.run(ctx, nil, p.Engine, "ps", "--all", "--format", "{{.Names}}",
+		"--filter", "label="+containerLabel+"="+p.Node)
+	if err != nil {
+		return nil, fmt.Errorf("can not list runner containers: %w", err)
+	}
+
+	names := strings.Fields(string(out))
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	if _, err = p.run(ctx, nil, p.Engine, append([]string{"rm", "--force"}, names...)...); err != nil {
+		return nil, fmt.Errorf("can not remove runner containers: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,53 @@
+package runner_provisioning
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerProvider(t *testing.T) {
+	var commands []string
+	var envs [][]string
+
+	p := &ContainerProvider{
+		Engine:  "podman",
+		Image:   "semaphoreui/runner:latest",
+		Network: "semaphore",
+		Node:    "node-1",
+		run: func(ctx context.Context, env []string, name string, args ...string) ([]byte, error) {
+			commands = append(commands, name+" "+strings.Join(args, " "))
+			envs = append(envs, env)
+			if args[0] == "ps" {
+				return []byte("semaphore-runner-1\nsemaphore-runner-2\n"), nil
+			}
+			return []byte("c1\n"), nil
+		},
+	}
+
+	id, err := p.Start(context.Background(), RunnerSpec{
+		Name: "semaphore-runner-1",
+		Env:  map[string]string{"B": "2", "A": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "c1", id)
+
+	require.NoError(t, p.Stop(context.Background(), id))
+
+	names, err := p.StopAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"semaphore-runner-1", "semaphore-runner-2"}, names)
+
+	assert.Equal(t, []string{
+		"podman run --detach --name semaphore-runner-1 --label io.semaphoreui.ephemeral-runner=node-1 --network semaphore --env A --env B semaphoreui/runner:latest",
+		"podman rm --force c1",
+		"podman ps --all --format {{.Names}} --filter label=io.semaphoreui.ephemeral-runner=node-1",
+		"podman rm --force semaphore-runner-1 semaphore-runner-2",
+	}, commands)
+
+	// Values of variables are passed through the environment of the engine.
+	assert.Equal(t, []string{"A=1", "B=2"}, envs[0])
+}
@@ -0,0 +1,219 @@
+package runner_provisioning
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// providerTimeout limits the time of starting and stopping runner instances.
+const providerTimeout = 2 * time.Minute
+
+// ErrLimitReached is returned by Provision when the maximum number
+// of ephemeral runners is running.
+var ErrLimitReached = errors.New("ephemeral runner limit reached")
+
+// Runner is the ephemeral runner started for a task.
+type Runner struct {
+	db.Runner
+	instanceID string
+}
+
+// Provisioner starts an ephemeral runner with a fresh one-off token for a task
+// and deletes the runner when the task is finished.
+type Provisioner struct {
+	store      db.Store
+	provider   Provider
+	maxRunners int
+	serverURL  string
+	env        map[string]string
+
+	mu      sync.Mutex
+	running int
+}
+
+func NewProvisioner(store db.Store, provider Provider, conf *util.RunnerProvisioningConfig) *Provisioner {
+	serverURL := conf.ServerURL
+	if serverURL == "" {
+		serverURL = util.Config.WebHost
+	}
+
+	return &Provisioner{
+		store:      store,
+		provider:   provider,
+		maxRunners: conf.MaxRunners,
+		serverURL:  serverURL,
+		env:        conf.Env,
+	}
+}
+
+// Cleanup removes ephemeral runners left by the previous run of the server.
+// Runners started by other servers of the HA cluster are not touched.
+func (p *Provisioner) Cleanup() error {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	names, err := p.provider.StopAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	db.StoreSession(p.store, "cleanup ephemeral runners", func() {
+		for _, name := range names {
+			runnerID, ok := parseInstanceName(name)
+			if !ok {
+				continue
+			}
+
+			var runner db.Runner
+			runner, err = p.store.GetGlobalRunner(runnerID)
+			if errors.Is(err, db.ErrNotFound) {
+				err = nil
+				continue
+			}
+			if err != nil {
+				return
+			}
+
+			if !runner.Ephemeral {
+				continue
+			}
+
+			if err = p.store.DeleteGlobalRunner(runner.ID); err != nil {
+				return
+			}
+		}
+	})
+
+	return err
+}
+
+// instanceName returns the name of the instance of the runner.
+func instanceName(runnerID int) string {
+	return fmt.Sprintf("semaphore-runner-%d", runnerID)
+}
+
+// parseInstanceName returns the ID of the runner from the name of its instance.
+func parseInstanceName(name string) (int, bool) {
+	str, ok := strings.CutPrefix(name, "semaphore-runner-")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(str)
+	return id, err == nil
+}
+
+func (p *Provisioner) acquire() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.maxRunners > 0 && p.running >= p.maxRunners {
+		return false
+	}
+
+	p.running++
+	return true
+}
+
+func (p *Provisioner) free() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+}
+
+// Provision starts the ephemeral runner for the task. It returns ErrLimitReached
+// if the maximum number of ephemeral runners is running.
+func (p *Provisioner) Provision(task db.Task) (runner *Runner, err error) {
+	if !p.acquire() {
+		return nil, ErrLimitReached
+	}
+
+	defer func() {
+		if err != nil {
+			p.free()
+		}
+	}()
+
+	var privateKey bytes.Buffer
+	publicKey, err := util.GeneratePrivateKey(&privateKey)
+	if err != nil {
+		return
+	}
+
+	var created db.Runner
+	db.StoreSession(p.store, "create ephemeral runner", func() {
+		created, err = p.store.CreateRunner(db.Runner{
+			Name:             fmt.Sprintf("Ephemeral runner for task %d", task.ID),
+			Active:           true,
+			Ephemeral:        true,
+			MaxParallelTasks: 1,
+			PublicKey:        &publicKey,
+		})
+	})
+	if err != nil {
+		return
+	}
+
+	env := make(map[string]string)
+	for name, value := range p.env {
+		env[name] = value
+	}
+	env["SEMAPHORE_WEB_ROOT"] = p.serverURL
+	env["SEMAPHORE_RUNNER_TOKEN"] = created.Token
+	env["SEMAPHORE_RUNNER_PRIVATE_KEY"] = privateKey.String()
+	env["SEMAPHORE_RUNNER_ONE_OFF"] = "true"
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	instanceID, err := p.provider.Start(ctx, RunnerSpec{
+		Name: instanceName(created.ID),
+		Env:  env,
+	})
+	if err != nil {
+		p.deleteRunner(created)
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"runner_id": created.ID,
+		"task_id":   task.ID,
+		"instance":  instanceID,
+	}).Info("Ephemeral runner started")
+
+	runner = &Runner{Runner: created, instanceID: instanceID}
+	return
+}
+
+// Release stops the ephemeral runner and deletes it.
+func (p *Provisioner) Release(runner *Runner) {
+	defer p.free()
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	if err := p.provider.Stop(ctx, runner.instanceID); err != nil {
+		log.WithError(err).WithField("runner_id", runner.ID).Error("Failed to stop ephemeral runner")
+	}
+
+	p.deleteRunner(runner.Runner)
+}
+
+func (p *Provisioner) deleteRunner(runner db.Runner) {
+	var err error
+	db.StoreSession(p.store, "delete ephemeral runner", func() {
+		err = p.store.DeleteGlobalRunner(runner.ID)
+	})
+	if err != nil {
+		log.WithError(err).WithField("runner_id", runner.ID).Error("Failed to delete ephemeral runner")
+	}
+}
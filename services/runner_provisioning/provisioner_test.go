@@ -0,0 +1,108 @@
+package runner_provisioning
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	started  map[string]RunnerSpec
+	startErr error
+}
+
+func (p *fakeProvider) Start(ctx context.Context, spec RunnerSpec) (string, error) {
+	if p.startErr != nil {
+		return "", p.startErr
+	}
+	p.started[spec.Name] = spec
+	return spec.Name, nil
+}
+
+func (p *fakeProvider) Stop(ctx context.Context, instanceID string) error {
+	delete(p.started, instanceID)
+	return nil
+}
+
+func (p *fakeProvider) StopAll(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range p.started {
+		names = append(names, name)
+	}
+	p.started = make(map[string]RunnerSpec)
+	return names, nil
+}
+
+func TestProvisioner(t *testing.T) {
+	store := sql.CreateTestStore()
+	util.Config = &util.ConfigType{WebHost: "http://semaphore:3000"}
+
+	provider := &fakeProvider{started: make(map[string]RunnerSpec)}
+
+	p := NewProvisioner(store, provider, &util.RunnerProvisioningConfig{
+		Enabled:    true,
+		MaxRunners: 1,
+		Env:        map[string]string{"SEMAPHORE_TMP_PATH": "/tmp/semaphore"},
+	})
+
+	runner, err := p.Provision(db.Task{ID: 10})
+	require.NoError(t, err)
+	assert.True(t, runner.Ephemeral)
+	assert.NotNil(t, runner.PublicKey)
+
+	spec, ok := provider.started[runner.instanceID]
+	require.True(t, ok)
+	assert.Equal(t, "http://semaphore:3000", spec.Env["SEMAPHORE_WEB_ROOT"])
+	assert.Equal(t, runner.Token, spec.Env["SEMAPHORE_RUNNER_TOKEN"])
+	assert.Equal(t, "true", spec.Env["SEMAPHORE_RUNNER_ONE_OFF"])
+	assert.Equal(t, "/tmp/semaphore", spec.Env["SEMAPHORE_TMP_PATH"])
+	assert.Contains(t, spec.Env["SEMAPHORE_RUNNER_PRIVATE_KEY"], "RSA PRIVATE KEY")
+
+	stored, err := store.GetRunnerByToken(runner.Token)
+	require.NoError(t, err)
+	assert.True(t, stored.Ephemeral)
+
+	_, err = p.Provision(db.Task{ID: 11})
+	assert.ErrorIs(t, err, ErrLimitReached)
+
+	p.Release(runner)
+	assert.Empty(t, provider.started)
+
+	_, err = store.GetGlobalRunner(runner.ID)
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	// The runner is deleted if its instance can not be started.
+	provider.startErr = errors.New("no image")
+	_, err = p.Provision(db.Task{ID: 11})
+	require.Error(t, err)
+
+	runners, err := store.GetAllRunners(false, true)
+	require.NoError(t, err)
+	assert.Empty(t, runners)
+
+	// The slot is freed when starting fails.
+	provider.startErr = nil
+	_, err = p.Provision(db.Task{ID: 12})
+	require.NoError(t, err)
+
+	static, err := store.CreateRunner(db.Runner{Name: "static", Active: true})
+	require.NoError(t, err)
+
+	// The ephemeral runner of another node has no instance on this node.
+	other, err := store.CreateRunner(db.Runner{Name: "other", Active: true, Ephemeral: true})
+	require.NoError(t, err)
+
+	require.NoError(t, p.Cleanup())
+	assert.Empty(t, provider.started)
+
+	runners, err = store.GetAllRunners(false, true)
+	require.NoError(t, err)
+	require.Len(t, runners, 2)
+	assert.ElementsMatch(t, []int{static.ID, other.ID}, []int{runners[0].ID, runners[1].ID})
+}
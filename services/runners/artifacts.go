@@ -0,0 +1,93 @@
+package runners
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// UploadArtifact sends the artifact of the job to the server.
+func (p *JobPool) UploadArtifact(task db.Task, name string, r io.Reader) error {
+	req, err := newArtifactsRequest("POST", task, "/artifacts?name="+url.QueryEscape(name), r)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%s", p.getResponseErrorMessage(resp))
+	}
+
+	return nil
+}
+
+// newArtifactsRequest returns the request to the artifacts API of the task.
+func newArtifactsRequest(method string, task db.Task, path string, body io.Reader) (*http.Request, error) {
+	address := fmt.Sprintf("%s/api/internal/runners/tasks/%d%s", util.Config.WebHost, task.ID, path)
+
+	req, err := http.NewRequest(method, address, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Runner-Token", util.Config.Runner.Token)
+
+	return req, nil
+}
+
+// GetBuildArtifacts requests the list of artifacts of the build task
+// deployed by the job.
+func (p *JobPool) GetBuildArtifacts(task db.Task) (artifacts []db.TaskArtifact, err error) {
+	req, err := newArtifactsRequest("GET", task, "/build_artifacts", nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s", p.getResponseErrorMessage(resp))
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&artifacts)
+	return
+}
+
+// OpenBuildArtifact downloads the artifact of the build task deployed by the job.
+func (p *JobPool) OpenBuildArtifact(task db.Task, artifact db.TaskArtifact) (io.ReadCloser, error) {
+	req, err := newArtifactsRequest("GET", task, fmt.Sprintf("/build_artifacts/%d", artifact.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close() //nolint:errcheck
+		return nil, fmt.Errorf("%s", p.getResponseErrorMessage(resp))
+	}
+
+	return resp.Body, nil
+}
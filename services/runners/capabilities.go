@@ -0,0 +1,66 @@
+package runners
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// appVersionTimeout limits the time of the version command of an app.
+const appVersionTimeout = 10 * time.Second
+
+var versionRegexp = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// appCommands are commands which print versions of the apps.
+var appCommands = map[db.TemplateApp][]string{
+	db.AppAnsible:    {"ansible", "--version"},
+	db.AppTerraform:  {"terraform", "version"},
+	db.AppTofu:       {"tofu", "version"},
+	db.AppTerragrunt: {"terragrunt", "--version"},
+	db.AppPulumi:     {"pulumi", "version"},
+	db.AppPython:     {"python3", "--version"},
+	db.AppPowerShell: {"pwsh", "--version"},
+	db.AppBash:       {"bash", "--version"},
+}
+
+// parseVersion returns the first version number in the output of the version command.
+func parseVersion(output []byte) string {
+	return string(versionRegexp.Find(output))
+}
+
+func appVersion(command []string) (version string, ok bool) {
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appVersionTimeout)
+	defer cancel()
+
+	// The app is installed even if it fails to print its version.
+	output, _ := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+
+	return parseVersion(output), true
+}
+
+// detectCapabilities finds apps installed on the runner.
+func detectCapabilities() db.RunnerCapabilities {
+	res := db.RunnerCapabilities{
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+		Apps:   make(map[db.TemplateApp]string),
+		Labels: util.Config.Runner.Labels,
+	}
+
+	for app, command := range appCommands {
+		if version, ok := appVersion(command); ok {
+			res.Apps[app] = version
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,23 @@
+package runners
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	assert.Equal(t, "2.15.0", parseVersion([]byte("ansible [core 2.15.0]\n  config file = None\n")))
+	assert.Equal(t, "1.5.7", parseVersion([]byte("Terraform v1.5.7\non linux_amd64\n")))
+	assert.Equal(t, "3.11.4", parseVersion([]byte("Python 3.11.4\n")))
+	assert.Equal(t, "", parseVersion([]byte("unknown")))
+}
+
+func TestAppCommands(t *testing.T) {
+	// Runners must report every app which the server expects them to detect.
+	for _, app := range db.DetectableApps {
+		assert.Contains(t, appCommands, app)
+	}
+	assert.Len(t, appCommands, len(db.DetectableApps))
+}
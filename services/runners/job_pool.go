@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/semaphoreui/semaphore/db_lib"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
 	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/services/tracing"
 	"github.com/semaphoreui/semaphore/util"
 	log "github.com/sirupsen/logrus"
 )
@@ -69,8 +71,23 @@ type JobPool struct {
 
 	queue []*job
 
+	// mu guards runningJobs and queue which are changed by the polling
+	// requests, the stream and the run loop.
+	mu sync.Mutex
+
 	processing int32
 
+	// streaming is 1 while the stream to the server is connected.
+	// Polling requests are not sent at this time.
+	streaming int32
+
+	// wakeup starts queued jobs without waiting for the queue ticker.
+	wakeup chan struct{}
+
+	// capabilities are sent to the server with the next progress.
+	// It is nil after the server has received them.
+	capabilities *db.RunnerCapabilities
+
 	keyInstaller db_lib.AccessKeyInstaller
 }
 
@@ -79,6 +96,7 @@ func NewJobPool(keyInstaller db_lib.AccessKeyInstaller) *JobPool {
 		runningJobs:  make(map[int]*runningJob),
 		queue:        make([]*job, 0),
 		processing:   0,
+		wakeup:       make(chan struct{}, 1),
 		keyInstaller: keyInstaller,
 	}
 }
@@ -147,6 +165,55 @@ func (p *JobPool) Unregister() (err error) {
 	return
 }
 
+// RotateToken requests the new token from the server and saves it.
+// The old token stops working when the server returns the new one.
+func (p *JobPool) RotateToken(configFilePath *string) (err error) {
+
+	if util.Config.Runner.Token == "" {
+		return fmt.Errorf("runner is not registered")
+	}
+
+	client := &http.Client{}
+
+	url := util.Config.WebHost + "/api/internal/runners/token"
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("X-Runner-Token", util.Config.Runner.Token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("encountered error while rotating runner token; server returned code %d", resp.StatusCode)
+		return
+	}
+
+	var res struct {
+		Token string `json:"token"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return
+	}
+
+	err = saveToken(res.Token, configFilePath)
+	if err != nil {
+		return fmt.Errorf("can not save the new token %s: %w", res.Token, err)
+	}
+
+	util.Config.Runner.Token = res.Token
+	return
+}
+
 func (p *JobPool) Run() {
 	logger := JobLogger{Context: "running"}
 
@@ -165,61 +232,35 @@ func (p *JobPool) Run() {
 		requestTimer.Stop()
 	}()
 
+	capabilities := detectCapabilities()
+	p.capabilities = &capabilities
+
+	if util.Config.Runner.Stream {
+		go p.runStream()
+	}
+
 	for {
 		select {
 
 		case <-queueTicker.C: // timer 5 seconds: get task from queue and run it
 			logger.Debug("Checking queue")
 
-			if len(p.queue) == 0 {
-				break
-			}
-
-			t := p.queue[0]
-			if t.status == task_logger.TaskFailStatus {
-				//delete failed TaskRunner from queue
-				p.queue = p.queue[1:]
-				logger.TaskInfo("Task dequeued", t.job.Task.ID, "failed")
-				break
-			}
+			p.mu.Lock()
+			p.startQueuedJob()
+			p.mu.Unlock()
 
-			p.runningJobs[t.job.Task.ID] = &runningJob{
-				job: t.job,
+		case <-p.wakeup: // new jobs received from the stream
+			p.mu.Lock()
+			for p.startQueuedJob() {
 			}
-
-			t.job.Logger = t.job.App.SetLogger(p.runningJobs[t.job.Task.ID])
-
-			go func(runningJob *runningJob) {
-				runningJob.SetStatus(task_logger.TaskRunningStatus)
-
-				err := runningJob.job.Run(t.username, t.incomingVersion, t.alias)
-
-				if runningJob.status.IsFinished() {
-					return
-				}
-
-				if err != nil {
-					logger.ActionError(err, "launch job", "job failed")
-					t.job.Logger.Log("Unable to launch the application. Please contact your system administrator for assistance.")
-
-					if runningJob.status == task_logger.TaskStoppingStatus {
-						runningJob.SetStatus(task_logger.TaskStoppedStatus)
-					} else {
-						runningJob.SetStatus(task_logger.TaskFailStatus)
-					}
-				} else {
-					runningJob.SetStatus(task_logger.TaskSuccessStatus)
-				}
-
-				logger.TaskInfo("Task finished", runningJob.job.Task.ID, string(runningJob.status))
-			}(p.runningJobs[t.job.Task.ID])
-
-			p.queue = p.queue[1:]
-			logger.TaskInfo("Task dequeued", t.job.Task.ID, string(t.job.Task.Status))
-			logger.TaskInfo("Task started", t.job.Task.ID, string(t.job.Task.Status))
+			p.mu.Unlock()
 
 		case <-requestTimer.C:
 
+			if atomic.LoadInt32(&p.streaming) == 1 {
+				break
+			}
+
 			go func() {
 
 				if !atomic.CompareAndSwapInt32(&p.processing, 0, 1) {
@@ -228,6 +269,9 @@ func (p *JobPool) Run() {
 
 				defer atomic.StoreInt32(&p.processing, 0)
 
+				p.mu.Lock()
+				defer p.mu.Unlock()
+
 				ok := p.sendProgress()
 
 				if ok && !launched {
@@ -235,9 +279,7 @@ func (p *JobPool) Run() {
 					fmt.Println("Runner connected")
 				}
 
-				if util.Config.Runner.OneOff && len(p.runningJobs) > 0 && !p.hasRunningJobs() {
-					os.Exit(0)
-				}
+				p.exitIfOneOffDone()
 
 				p.checkNewJobs()
 			}()
@@ -246,18 +288,78 @@ func (p *JobPool) Run() {
 	}
 }
 
-func (p *JobPool) sendProgress() (ok bool) {
+// exitIfOneOffDone stops the one-off runner when its jobs are finished.
+func (p *JobPool) exitIfOneOffDone() {
+	if util.Config.Runner.OneOff && len(p.runningJobs) > 0 && !p.hasRunningJobs() {
+		os.Exit(0)
+	}
+}
 
-	logger := JobLogger{Context: "sending_progress"}
+// startQueuedJob starts the first job of the queue. It reports whether
+// the queue has been changed.
+func (p *JobPool) startQueuedJob() bool {
+	logger := JobLogger{Context: "running"}
 
-	client := &http.Client{}
+	if len(p.queue) == 0 {
+		return false
+	}
 
-	url := util.Config.WebHost + "/api/internal/runners"
+	t := p.queue[0]
+	if t.status == task_logger.TaskFailStatus {
+		//delete failed TaskRunner from queue
+		p.queue = p.queue[1:]
+		logger.TaskInfo("Task dequeued", t.job.Task.ID, "failed")
+		return true
+	}
 
-	body := RunnerProgress{
-		Jobs: nil,
+	p.runningJobs[t.job.Task.ID] = &runningJob{
+		job: t.job,
 	}
 
+	t.job.Logger = t.job.App.SetLogger(p.runningJobs[t.job.Task.ID])
+
+	go func(runningJob *runningJob) {
+		runningJob.SetStatus(task_logger.TaskRunningStatus)
+
+		// Spans of the job continue the trace of the task on the server.
+		ctx := tracing.Extract(t.traceContext)
+		err := runningJob.job.Run(ctx, t.username, t.incomingVersion, t.alias)
+
+		if runningJob.status.IsFinished() {
+			return
+		}
+
+		if err != nil {
+			logger.ActionError(err, "launch job", "job failed")
+			t.job.Logger.Log("Unable to launch the application. Please contact your system administrator for assistance.")
+
+			if runningJob.status == task_logger.TaskStoppingStatus {
+				runningJob.SetStatus(task_logger.TaskStoppedStatus)
+			} else {
+				runningJob.SetStatus(task_logger.TaskFailStatus)
+			}
+		} else {
+			runningJob.SetStatus(task_logger.TaskSuccessStatus)
+		}
+
+		logger.TaskInfo("Task finished", runningJob.job.Task.ID, string(runningJob.status))
+	}(p.runningJobs[t.job.Task.ID])
+
+	p.queue = p.queue[1:]
+	logger.TaskInfo("Task dequeued", t.job.Task.ID, string(t.job.Task.Status))
+	logger.TaskInfo("Task started", t.job.Task.ID, string(t.job.Task.Status))
+
+	return true
+}
+
+// collectProgress takes new log records and statuses of running jobs.
+// Finished jobs are removed from the running list.
+func (p *JobPool) collectProgress() (body RunnerProgress) {
+
+	logger := JobLogger{Context: "sending_progress"}
+
+	body.Capabilities = p.capabilities
+
 	for id, j := range p.runningJobs {
 
 		body.Jobs = append(body.Jobs, JobProgress{
@@ -275,6 +377,19 @@ func (p *JobPool) sendProgress() (ok bool) {
 		}
 	}
 
+	return
+}
+
+func (p *JobPool) sendProgress() (ok bool) {
+
+	logger := JobLogger{Context: "sending_progress"}
+
+	client := &http.Client{}
+
+	url := util.Config.WebHost + "/api/internal/runners"
+
+	body := p.collectProgress()
+
 	jsonBytes, err := json.Marshal(body)
 
 	if err != nil {
@@ -300,6 +415,7 @@ func (p *JobPool) sendProgress() (ok bool) {
 		logger.ActionError(fmt.Errorf("invalid status code"), "send request", "the server returned error "+strconv.Itoa(resp.StatusCode))
 	} else {
 		ok = true
+		p.capabilities = nil
 	}
 
 	defer resp.Body.Close() //nolint:errcheck
@@ -352,6 +468,8 @@ func (p *JobPool) tryRegisterRunner(configFilePath *string) (ok bool) {
 		return
 	}
 
+	capabilities := detectCapabilities()
+
 	client := &http.Client{}
 
 	url := util.Config.WebHost + "/api/internal/runners"
@@ -361,6 +479,7 @@ func (p *JobPool) tryRegisterRunner(configFilePath *string) (ok bool) {
 		Webhook:           util.Config.Runner.Webhook,
 		MaxParallelTasks:  util.Config.Runner.MaxParallelTasks,
 		PublicKey:         &publicKey,
+		Capabilities:      &capabilities,
 	})
 
 	if err != nil {
@@ -402,53 +521,63 @@ func (p *JobPool) tryRegisterRunner(configFilePath *string) (ok bool) {
 		return
 	}
 
-	if util.Config.Runner.TokenFile != "" {
-		err = os.WriteFile(util.Config.Runner.TokenFile, []byte(res.Token), 0644)
-	} else {
-		if configFilePath == nil {
-			logger.ActionError(fmt.Errorf("config file path required"), "read input", "can not retrieve config file path")
-			return
-		}
+	err = saveToken(res.Token, configFilePath)
+	if err != nil {
+		logger.ActionError(err, "save token", "can not save runner token")
+		return
+	}
 
-		var configFileBuffer []byte
-		configFileBuffer, err = os.ReadFile(*configFilePath)
-		if err != nil {
-			logger.ActionError(err, "read config file", "can not read config file")
-			return
-		}
+	defer resp.Body.Close() //nolint:errcheck
 
-		config := util.ConfigType{}
-		err = json.Unmarshal(configFileBuffer, &config)
-		if err != nil {
-			logger.ActionError(err, "parse config file", "can not parse config file")
-			return
-		}
+	ok = true
+	return
+}
 
-		config.Runner.Token = res.Token
-		configFileBuffer, err = json.MarshalIndent(&config, " ", "\t")
-		if err != nil {
-			logger.ActionError(err, "marshal config file", "can not marshal config file")
-			return
-		}
+// saveToken writes the runner token to the token file or to the config file.
+func saveToken(token string, configFilePath *string) error {
+	if util.Config.Runner.TokenFile != "" {
+		return os.WriteFile(util.Config.Runner.TokenFile, []byte(token), 0644)
+	}
 
-		err = os.WriteFile(*configFilePath, configFileBuffer, 0644)
-		if err != nil {
-			logger.ActionError(err, "write config file", "can not write config file")
-			return
-		}
+	if configFilePath == nil {
+		return fmt.Errorf("config file path required")
 	}
 
-	defer resp.Body.Close() //nolint:errcheck
+	configFileBuffer, err := os.ReadFile(*configFilePath)
+	if err != nil {
+		return err
+	}
 
-	ok = true
-	return
+	config := util.ConfigType{}
+	err = json.Unmarshal(configFileBuffer, &config)
+	if err != nil {
+		return err
+	}
+
+	config.Runner.Token = token
+	configFileBuffer, err = json.MarshalIndent(&config, " ", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(*configFilePath, configFileBuffer, 0644)
 }
 
-func loadPrivateKey(privateKeyFilePath string) (*rsa.PrivateKey, error) {
-	keyData, err := os.ReadFile(privateKeyFilePath)
+// loadPrivateKey returns the private key passed in the config or read
+// from the private key file.
+func loadPrivateKey() (*rsa.PrivateKey, error) {
+	if util.Config.Runner.PrivateKey != "" {
+		return parsePrivateKey([]byte(util.Config.Runner.PrivateKey))
+	}
+
+	keyData, err := os.ReadFile(util.Config.Runner.PrivateKeyFile)
 	if err != nil {
 		return nil, err
 	}
+	return parsePrivateKey(keyData)
+}
+
+func parsePrivateKey(keyData []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(keyData)
 	if block == nil || block.Type != "RSA PRIVATE KEY" {
 		return nil, fmt.Errorf("invalid private key")
@@ -493,6 +622,51 @@ func decryptChunkedBytes(combinedCiphertext []byte, privateKey *rsa.PrivateKey)
 	return
 }
 
+// updateRunningJobs applies the state of jobs received from the server.
+// The job is killed if the server requests it.
+func (p *JobPool) updateRunningJobs(currentJobs []JobState) {
+	logger := JobLogger{Context: "updating jobs"}
+
+	for _, currJob := range currentJobs {
+		runJob, exists := p.runningJobs[currJob.ID]
+
+		if !exists {
+			continue
+		}
+
+		if runJob.status.IsFinished() {
+			continue
+		}
+
+		// Servers without the kill flag request killing by the stopping status.
+		if currJob.Kill || currJob.Status == task_logger.TaskStoppingStatus || currJob.Status == task_logger.TaskStoppedStatus {
+			if !runJob.job.IsKilled() {
+				logger.TaskInfo("Killing task", currJob.ID, string(runJob.status))
+				runJob.SetStatus(task_logger.TaskStoppingStatus)
+				runJob.job.Kill()
+			}
+			continue
+		}
+
+		switch runJob.status {
+		case task_logger.TaskRunningStatus:
+			if currJob.Status == task_logger.TaskStartingStatus || currJob.Status == task_logger.TaskWaitingStatus {
+				continue
+			}
+		case task_logger.TaskStoppingStatus:
+			if !currJob.Status.IsFinished() {
+				continue
+			}
+		case task_logger.TaskConfirmed:
+			if currJob.Status == task_logger.TaskWaitingConfirmation {
+				continue
+			}
+		}
+
+		runJob.SetStatus(currJob.Status)
+	}
+}
+
 // checkNewJobs tries to find runner to queued jobs
 func (p *JobPool) checkNewJobs() {
 
@@ -537,10 +711,23 @@ func (p *JobPool) checkNewJobs() {
 		return
 	}
 
-	if util.Config.Runner.PrivateKeyFile != "" {
+	response, ok := p.decodeState(body, logger)
+	if !ok {
+		return
+	}
+
+	p.applyState(response)
+}
+
+// decodeState decrypts the state received from the server if the runner
+// has the private key and parses it.
+func (p *JobPool) decodeState(body []byte, logger JobLogger) (response RunnerState, ok bool) {
+	var err error
+
+	if util.Config.Runner.PrivateKey != "" || util.Config.Runner.PrivateKeyFile != "" {
 		var pk *rsa.PrivateKey
 
-		pk, err = loadPrivateKey(util.Config.Runner.PrivateKeyFile)
+		pk, err = loadPrivateKey()
 		if err != nil {
 			logger.ActionError(err, "decrypt response body", "can not read private key")
 			return
@@ -554,13 +741,21 @@ func (p *JobPool) checkNewJobs() {
 		}
 	}
 
-	var response RunnerState
 	err = json.Unmarshal(body, &response)
 	if err != nil {
 		logger.ActionError(err, "parsing result json", "server's response has invalid format")
 		return
 	}
 
+	ok = true
+	return
+}
+
+// applyState clears the cache, updates running jobs and enqueues new jobs
+// by the state received from the server.
+func (p *JobPool) applyState(response RunnerState) {
+	logger := JobLogger{Context: "checking new jobs"}
+
 	if response.ClearCache {
 		if response.CacheCleanProjectID == nil {
 			if err2 := util.Config.ClearTmpDir(); err2 != nil {
@@ -581,38 +776,7 @@ func (p *JobPool) checkNewJobs() {
 		}
 	}
 
-	for _, currJob := range response.CurrentJobs {
-		runJob, exists := p.runningJobs[currJob.ID]
-
-		if !exists {
-			continue
-		}
-
-		if runJob.status == task_logger.TaskStoppingStatus || runJob.status == task_logger.TaskStoppedStatus {
-			p.runningJobs[currJob.ID].job.Kill()
-		}
-
-		if runJob.status.IsFinished() {
-			continue
-		}
-
-		switch runJob.status {
-		case task_logger.TaskRunningStatus:
-			if currJob.Status == task_logger.TaskStartingStatus || currJob.Status == task_logger.TaskWaitingStatus {
-				continue
-			}
-		case task_logger.TaskStoppingStatus:
-			if !currJob.Status.IsFinished() {
-				continue
-			}
-		case task_logger.TaskConfirmed:
-			if currJob.Status == task_logger.TaskWaitingConfirmation {
-				continue
-			}
-		}
-
-		runJob.SetStatus(currJob.Status)
-	}
+	p.updateRunningJobs(response.CurrentJobs)
 
 	if util.Config.Runner.OneOff {
 		if len(p.queue) > 0 || len(p.runningJobs) > 0 {
@@ -635,6 +799,7 @@ func (p *JobPool) checkNewJobs() {
 			username:        newJob.Username,
 			incomingVersion: newJob.IncomingVersion,
 			alias:           newJob.Alias,
+			traceContext:    newJob.TraceContext,
 
 			job: &tasks.LocalJob{
 				Task:         newJob.Task,
@@ -643,6 +808,7 @@ func (p *JobPool) checkNewJobs() {
 				Repository:   newJob.Repository,
 				Environment:  newJob.Environment,
 				KeyInstaller: p.keyInstaller,
+				Artifacts:    p,
 				App: db_lib.CreateApp(
 					newJob.Template,
 					newJob.Repository,
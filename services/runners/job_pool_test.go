@@ -0,0 +1,96 @@
+//go:build !windows
+
+package runners
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startRunningJob(t *testing.T, taskID int) (*runningJob, *exec.Cmd) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+
+	job := &runningJob{
+		status: task_logger.TaskRunningStatus,
+		job: &tasks.LocalJob{
+			Task:    db.Task{ID: taskID},
+			Process: cmd.Process,
+		},
+	}
+	job.job.Logger = job
+
+	return job, cmd
+}
+
+func TestJobPool_UpdateRunningJobs(t *testing.T) {
+	killed, killedCmd := startRunningJob(t, 1)
+	running, runningCmd := startRunningJob(t, 2)
+	defer runningCmd.Process.Kill() //nolint:errcheck
+
+	p := NewJobPool(nil)
+	p.runningJobs[1] = killed
+	p.runningJobs[2] = running
+
+	p.updateRunningJobs([]JobState{
+		{ID: 1, Status: task_logger.TaskStoppingStatus, Kill: true},
+		{ID: 2, Status: task_logger.TaskRunningStatus},
+		{ID: 3, Status: task_logger.TaskStoppingStatus, Kill: true},
+	})
+
+	assert.Equal(t, task_logger.TaskStoppingStatus, killed.status)
+	assert.True(t, killed.job.IsKilled())
+
+	assert.Equal(t, task_logger.TaskRunningStatus, running.status)
+	assert.False(t, running.job.IsKilled())
+
+	done := make(chan error, 1)
+	go func() { done <- killedCmd.Wait() }()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("process group was not killed")
+	}
+}
+
+func TestJobPool_CollectProgressRejectedPlan(t *testing.T) {
+	job := &runningJob{
+		status: task_logger.TaskWaitingConfirmation,
+		job:    &tasks.LocalJob{Task: db.Task{ID: 1}},
+	}
+	job.job.Logger = job
+
+	p := NewJobPool(nil)
+	p.runningJobs[1] = job
+
+	// The Terraform plan is rejected, the task keeps running to finish Terraform.
+	job.SetStatus(task_logger.TaskRejected)
+	job.Log("Plan rejected")
+
+	progress := p.collectProgress()
+	require.Len(t, progress.Jobs, 1)
+	assert.Equal(t, task_logger.TaskRejected, progress.Jobs[0].Status)
+	assert.Contains(t, p.runningJobs, 1)
+
+	job.SetStatus(task_logger.TaskRunningStatus)
+	job.Log("Apply cancelled")
+	job.SetStatus(task_logger.TaskSuccessStatus)
+
+	progress = p.collectProgress()
+	require.Len(t, progress.Jobs, 1)
+	assert.Equal(t, task_logger.TaskSuccessStatus, progress.Jobs[0].Status)
+	require.Len(t, progress.Jobs[0].LogRecords, 1)
+	assert.Equal(t, "Apply cancelled", progress.Jobs[0].LogRecords[0].Message)
+	assert.NotContains(t, p.runningJobs, 1)
+}
@@ -0,0 +1,211 @@
+package runners
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+const (
+	// streamProgressInterval is how often the runner sends new logs
+	// and statuses of jobs to the stream.
+	streamProgressInterval = 250 * time.Millisecond
+
+	streamWriteWait = 10 * time.Second
+	// streamPongWait must be longer than the ping period of the server.
+	streamPongWait = 60 * time.Second
+
+	streamMinBackoff = time.Second
+	streamMaxBackoff = time.Minute
+)
+
+// streamURL returns the websocket address of the runner stream on the server.
+func streamURL() (string, error) {
+	u, err := url.Parse(util.Config.WebHost)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/internal/runners/stream"
+
+	return u.String(), nil
+}
+
+// nextBackoff doubles the reconnection delay up to streamMaxBackoff.
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > streamMaxBackoff {
+		backoff = streamMaxBackoff
+	}
+	return backoff
+}
+
+// runStream keeps the stream to the server connected. The runner polls
+// the server while the stream is disconnected, so the stream is reconnected
+// with increasing delays without stopping the runner.
+func (p *JobPool) runStream() {
+	logger := JobLogger{Context: "stream"}
+
+	backoff := streamMinBackoff
+
+	for {
+		connected := time.Now()
+
+		err := p.connectStream()
+
+		// The connection which has worked for a while is not a failure to back off from.
+		if time.Since(connected) > streamMaxBackoff {
+			backoff = streamMinBackoff
+		}
+
+		delay := backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+		logger.ActionError(err, "connect stream", "stream is disconnected, reconnecting in "+delay.Round(time.Second).String())
+
+		time.Sleep(delay)
+
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// connectStream connects the stream and serves it until it is closed.
+func (p *JobPool) connectStream() error {
+	address, err := streamURL()
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("X-Runner-Token", util.Config.Runner.Token)
+
+	ws, resp, err := websocket.DefaultDialer.Dial(address, header)
+	if err != nil {
+		if resp != nil {
+			err = fmt.Errorf("%w: %s", err, p.getResponseErrorMessage(resp))
+		}
+		return err
+	}
+
+	defer ws.Close() //nolint:errcheck
+
+	logger := JobLogger{Context: "stream"}
+	logger.Info("Stream connected")
+
+	atomic.StoreInt32(&p.streaming, 1)
+	defer atomic.StoreInt32(&p.streaming, 0)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- p.readStream(ws)
+	}()
+
+	ticker := time.NewTicker(streamProgressInterval)
+	defer ticker.Stop()
+
+	sent := make(map[int]task_logger.TaskStatus)
+
+	for {
+		select {
+		case err = <-done:
+			return err
+		case <-ticker.C:
+			if err = p.writeProgress(ws, sent); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readStream applies states pushed by the server until the stream is closed.
+func (p *JobPool) readStream(ws *websocket.Conn) error {
+	logger := JobLogger{Context: "stream"}
+
+	_ = ws.SetReadDeadline(time.Now().Add(streamPongWait))
+	ws.SetPingHandler(func(data string) error {
+		_ = ws.SetReadDeadline(time.Now().Add(streamPongWait))
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(streamWriteWait))
+	})
+
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		_ = ws.SetReadDeadline(time.Now().Add(streamPongWait))
+
+		state, ok := p.decodeState(message, logger)
+		if !ok {
+			continue
+		}
+
+		p.mu.Lock()
+		p.applyState(state)
+		p.mu.Unlock()
+
+		select {
+		case p.wakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// writeProgress sends new logs and changed statuses of jobs. Nothing is sent
+// if nothing has changed since the previous call. sent keeps the statuses
+// which the server already knows.
+func (p *JobPool) writeProgress(ws *websocket.Conn, sent map[int]task_logger.TaskStatus) error {
+	p.mu.Lock()
+	progress := p.collectProgress()
+	p.mu.Unlock()
+
+	var changed []JobProgress
+
+	for _, job := range progress.Jobs {
+		if len(job.LogRecords) > 0 || sent[job.ID] != job.Status {
+			changed = append(changed, job)
+		}
+
+		if job.Status.IsFinished() {
+			delete(sent, job.ID)
+		} else {
+			sent[job.ID] = job.Status
+		}
+	}
+
+	if len(changed) > 0 || progress.Capabilities != nil {
+		message, err := json.Marshal(RunnerProgress{Jobs: changed, Capabilities: progress.Capabilities})
+		if err != nil {
+			return err
+		}
+
+		_ = ws.SetWriteDeadline(time.Now().Add(streamWriteWait))
+		if err = ws.WriteMessage(websocket.TextMessage, message); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	if progress.Capabilities != nil && p.capabilities == progress.Capabilities {
+		p.capabilities = nil
+	}
+	p.exitIfOneOffDone()
+	p.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package runners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamURL(t *testing.T) {
+	util.Config = &util.ConfigType{WebHost: "http://localhost:3000"}
+
+	address, err := streamURL()
+	require.NoError(t, err)
+	assert.Equal(t, "ws://localhost:3000/api/internal/runners/stream", address)
+
+	util.Config.WebHost = "https://example.com/semaphore/"
+	address, err = streamURL()
+	require.NoError(t, err)
+	assert.Equal(t, "wss://example.com/semaphore/api/internal/runners/stream", address)
+}
+
+func TestNextBackoff(t *testing.T) {
+	assert.Equal(t, 2*time.Second, nextBackoff(streamMinBackoff))
+	assert.Equal(t, streamMaxBackoff, nextBackoff(40*time.Second))
+	assert.Equal(t, streamMaxBackoff, nextBackoff(streamMaxBackoff))
+}
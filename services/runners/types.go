@@ -18,6 +18,8 @@ type JobData struct {
 	InventoryRepository *db.Repository `json:"inventory_repository" binding:"required"`
 	Repository          db.Repository  `json:"repository" binding:"required"`
 	Environment         db.Environment `json:"environment" binding:"required"`
+	// TraceContext contains the W3C trace context of the task on the server.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
 }
 
 type RunnerState struct {
@@ -32,6 +34,9 @@ type RunnerState struct {
 type JobState struct {
 	ID     int                    `json:"id" binding:"required"`
 	Status task_logger.TaskStatus `json:"status" binding:"required"`
+	// Kill requests the runner to terminate the job. The runner reports
+	// the stopped status when the process is killed.
+	Kill bool `json:"kill,omitempty"`
 }
 
 type LogRecord struct {
@@ -46,6 +51,8 @@ type CommitInfo struct {
 
 type RunnerProgress struct {
 	Jobs []JobProgress
+	// Capabilities are sent once after the runner starts.
+	Capabilities *db.RunnerCapabilities `json:",omitempty"`
 }
 
 type JobProgress struct {
@@ -60,6 +67,8 @@ type RunnerRegistration struct {
 	Webhook           string  `json:"webhook,omitempty"`
 	MaxParallelTasks  int     `json:"max_parallel_tasks"`
 	PublicKey         *string `json:"public_key,omitempty"`
+
+	Capabilities *db.RunnerCapabilities `json:"capabilities,omitempty"`
 }
 
 type jobLogRecord struct {
@@ -71,6 +80,7 @@ type job struct {
 	username        string
 	incomingVersion *string
 	alias           string
+	traceContext    map[string]string
 
 	// job presents remote or local job information
 	job    *tasks.LocalJob
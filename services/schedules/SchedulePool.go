@@ -1,10 +1,12 @@
 package schedules
 
 import (
+	"context"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/semaphoreui/semaphore/services/metrics"
 	"github.com/semaphoreui/semaphore/services/server"
 	"github.com/semaphoreui/semaphore/util"
 
@@ -12,7 +14,10 @@ import (
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
 	"github.com/semaphoreui/semaphore/services/tasks"
+	"github.com/semaphoreui/semaphore/services/tracing"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ScheduleRunner struct {
@@ -80,6 +85,17 @@ func (r ScheduleRunner) Run() {
 		defer r.pool.store.Close("schedule " + strconv.Itoa(r.scheduleID))
 	}
 
+	result := "failed"
+	defer func() {
+		metrics.ScheduleFired(r.projectID, r.scheduleID, result)
+	}()
+
+	ctx, span := tracing.Start(context.Background(), "schedule.run", trace.WithAttributes(
+		attribute.Int("semaphore.project_id", r.projectID),
+		attribute.Int("semaphore.schedule_id", r.scheduleID),
+	))
+	defer span.End()
+
 	schedule, err := r.pool.store.GetSchedule(r.projectID, r.scheduleID)
 	if err != nil {
 		log.Error(err)
@@ -94,6 +110,7 @@ func (r ScheduleRunner) Run() {
 			return
 		}
 		if !updated {
+			result = "skipped"
 			return
 		}
 	}
@@ -108,6 +125,7 @@ func (r ScheduleRunner) Run() {
 	task.ScheduleID = &schedule.ID
 
 	_, err = r.pool.taskPool.AddTask(
+		ctx,
 		task,
 		nil,
 		"",
@@ -117,7 +135,10 @@ func (r ScheduleRunner) Run() {
 
 	if err != nil {
 		log.Error(err)
+		return
 	}
+
+	result = "triggered"
 }
 
 type SchedulePool struct {
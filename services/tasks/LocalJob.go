@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"maps"
@@ -15,6 +16,7 @@ import (
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tracing"
 	"github.com/semaphoreui/semaphore/util"
 )
 
@@ -37,6 +39,11 @@ type LocalJob struct {
 	vaultFileInstallations map[string]ssh.AccessKeyInstallation
 
 	KeyInstaller db_lib.AccessKeyInstaller
+
+	// Artifacts uploads files matching artifact patterns of the template
+	// after the run and downloads artifacts of the build task before the
+	// deploy. Artifacts are not transferred if it is nil.
+	Artifacts ArtifactTransfer
 }
 
 func (t *LocalJob) IsKilled() bool {
@@ -46,11 +53,17 @@ func (t *LocalJob) IsKilled() bool {
 func (t *LocalJob) Kill() {
 	t.killed = true
 
+	if app, ok := t.App.(db_lib.ContainerizedApp); ok {
+		if err := app.StopContainers(); err != nil {
+			t.Log(err.Error())
+		}
+	}
+
 	if t.Process == nil {
 		return
 	}
 
-	err := t.Process.Kill()
+	err := killProcessGroup(t.Process)
 	if err != nil {
 		t.Log(err.Error())
 	}
@@ -544,7 +557,7 @@ func (t *LocalJob) getParams() (params any, err error) {
 	return
 }
 
-func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (err error) {
+func (t *LocalJob) Run(ctx context.Context, username string, incomingVersion *string, alias string) (err error) {
 
 	defer func() {
 		t.destroyKeys()
@@ -554,6 +567,10 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 
 	t.SetStatus(task_logger.TaskRunningStatus) // It is required for local mode. Don't delete
 
+	if err = db_lib.CheckContainerExecution(t.Template); err != nil {
+		return
+	}
+
 	environmentVariables, err := t.getEnvironmentENV()
 	if err != nil {
 		return
@@ -573,7 +590,7 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 		environmentVariables = append(environmentVariables, "TF_HTTP_ADDRESS="+util.GetPublicAliasURL("terraform", alias))
 	}
 
-	err = t.prepareRun(db_lib.LocalAppInstallingArgs{
+	err = t.prepareRun(ctx, db_lib.LocalAppInstallingArgs{
 		EnvironmentVars: environmentVariables,
 		TplParams:       tplParams,
 		Params:          params,
@@ -600,6 +617,15 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 		return
 	}
 
+	buildArtifactsDir, err := t.downloadBuildArtifacts()
+	if buildArtifactsDir != "" {
+		defer os.RemoveAll(buildArtifactsDir) //nolint:errcheck
+	}
+	if err != nil {
+		t.Log("Failed to download build artifacts: " + err.Error())
+		return
+	}
+
 	if t.Inventory.SSHKey.Type == db.AccessKeySSH && t.Inventory.SSHKeyID != nil {
 		environmentVariables = append(environmentVariables, fmt.Sprintf("SSH_AUTH_SOCK=%s", t.sshKeyInstallation.SSHAgent.SocketFile))
 	}
@@ -614,6 +640,12 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 				fmt.Sprintf("SEMAPHORE_TASK_INCOMING_VERSION=%s", *incomingVersion))
 		}
 
+		if buildArtifactsDir != "" {
+			environmentVariables = append(
+				environmentVariables,
+				fmt.Sprintf("SEMAPHORE_TASK_INCOMING_ARTIFACTS=%s", buildArtifactsDir))
+		}
+
 		if t.Template.Type == db.TemplateBuild && t.Task.Version != nil {
 			environmentVariables = append(
 				environmentVariables,
@@ -626,7 +658,13 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 		return nil
 	}
 
-	return t.App.Run(db_lib.LocalAppRunningArgs{
+	ctx, span := tracing.Start(ctx, "app.run")
+	defer func() { tracing.End(span, err) }()
+
+	// The app can continue the trace of the task.
+	environmentVariables = append(environmentVariables, tracing.EnvVars(ctx)...)
+
+	err = t.App.Run(db_lib.LocalAppRunningArgs{
 		CliArgs:         args,
 		EnvironmentVars: environmentVariables,
 		Inputs:          inputs,
@@ -637,9 +675,22 @@ func (t *LocalJob) Run(username string, incomingVersion *string, alias string) (
 		},
 	})
 
+	t.uploadArtifacts()
+
+	return
+}
+
+// tracePhase runs the phase of the job preparation in its own span.
+func tracePhase(ctx context.Context, name string, phase func() error) error {
+	_, span := tracing.Start(ctx, name)
+	err := phase()
+	tracing.End(span, err)
+	return err
 }
 
-func (t *LocalJob) prepareRun(installingArgs db_lib.LocalAppInstallingArgs) error {
+func (t *LocalJob) prepareRun(ctx context.Context, installingArgs db_lib.LocalAppInstallingArgs) (err error) {
+	ctx, span := tracing.Start(ctx, "task.prepare")
+	defer func() { tracing.End(span, err) }()
 
 	t.Log("Preparing: " + strconv.Itoa(t.Task.ID))
 
@@ -664,27 +715,29 @@ func (t *LocalJob) prepareRun(installingArgs db_lib.LocalAppInstallingArgs) erro
 			return err
 		}
 	} else {
-		if err := t.updateRepository(); err != nil {
+		if err := tracePhase(ctx, "repository.update", t.updateRepository); err != nil {
 			t.Log("Failed updating repository: " + err.Error())
 			return err
 		}
-		if err := t.checkoutRepository(); err != nil {
+		if err := tracePhase(ctx, "repository.checkout", t.checkoutRepository); err != nil {
 			t.Log("Failed to checkout repository to required commit: " + err.Error())
 			return err
 		}
 	}
 
-	if err := t.installInventory(); err != nil {
+	if err := tracePhase(ctx, "inventory.install", t.installInventory); err != nil {
 		t.Log("Failed to install inventory: " + err.Error())
 		return err
 	}
 
-	if err := t.App.InstallRequirements(installingArgs); err != nil {
+	if err := tracePhase(ctx, "requirements.install", func() error {
+		return t.App.InstallRequirements(installingArgs)
+	}); err != nil {
 		t.Log("Failed to install requirements: " + err.Error())
 		return err
 	}
 
-	if err := t.installVaultKeyFiles(); err != nil {
+	if err := tracePhase(ctx, "vault_keys.install", t.installVaultKeyFiles); err != nil {
 		t.Log("Failed to install vault password files: " + err.Error())
 		return err
 	}
@@ -2,7 +2,9 @@ package tasks
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -12,9 +14,17 @@ import (
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/runner_health"
+	"github.com/semaphoreui/semaphore/services/runner_provisioning"
+	"github.com/semaphoreui/semaphore/services/tracing"
 	"github.com/semaphoreui/semaphore/util"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// runnerCheckInterval is how often the server checks if the runner
+// which runs the task is still online.
+const runnerCheckInterval = 5 * time.Second
+
 type RemoteJob struct {
 	RunnerTag *string
 	Task      db.Task
@@ -87,7 +97,10 @@ func callRunnerWebhook(runner *db.Runner, tsk *TaskRunner, action string) (err e
 	return
 }
 
-func (t *RemoteJob) Run(username string, incomingVersion *string, alias string) (err error) {
+func (t *RemoteJob) Run(ctx context.Context, username string, incomingVersion *string, alias string) (err error) {
+
+	ctx, span := tracing.Start(ctx, "runner.job")
+	defer func() { tracing.End(span, err) }()
 
 	tsk := t.taskPool.GetTask(t.Task.ID)
 
@@ -98,67 +111,206 @@ func (t *RemoteJob) Run(username string, incomingVersion *string, alias string)
 	tsk.IncomingVersion = incomingVersion
 	tsk.Username = username
 	tsk.Alias = alias
+	tsk.TraceContext = tracing.Inject(ctx)
 	t.taskPool.state.UpdateRuntimeFields(tsk)
 
-	var runners []db.Runner
-	db.StoreSession(t.taskPool.store, "run remote job", func() {
-		var projectRunners []db.Runner
-		projectRunners, err = t.taskPool.store.GetRunners(t.Task.ProjectID, true, t.RunnerTag)
+	startTime := tz.Now()
+
+	var ephemeral *runner_provisioning.Runner
+	defer func() { t.releaseRunner(ephemeral) }()
+
+	for {
+		// The ephemeral runner which has gone offline is not needed anymore.
+		t.releaseRunner(ephemeral)
+		ephemeral = nil
+
+		var runner *db.Runner
+
+		runner, ephemeral, err = t.acquireRunner(tsk, startTime)
 		if err != nil {
 			return
 		}
-		var globalRunners []db.Runner
-		globalRunners, err = t.taskPool.store.GetAllRunners(true, true)
+
+		err = callRunnerWebhook(runner, tsk, "start")
+
 		if err != nil {
 			return
 		}
-		runners = append(runners, projectRunners...)
-		runners = append(runners, globalRunners...)
-	})
 
-	if err != nil {
+		span.SetAttributes(attribute.Int("semaphore.runner_id", runner.ID))
+
+		tsk.RunnerID = runner.ID
+		if ephemeral == nil {
+			t.taskPool.affinity.set(tsk.Template.RepositoryID, runner.ID)
+		}
+		if t.taskPool != nil && t.taskPool.state != nil {
+			t.taskPool.state.UpdateRuntimeFields(tsk)
+		}
+		t.taskPool.notifyRunnerStateChanged()
+
+		var runnerLost, taskTimedOut bool
+
+		tsk, runnerLost, taskTimedOut, err = t.waitForTask(runner, startTime)
+		if err != nil {
+			return
+		}
+
+		err = callRunnerWebhook(runner, tsk, "finish")
+
+		if err != nil {
+			return
+		}
+
+		if runnerLost {
+			log.WithFields(log.Fields{
+				"runner_id": runner.ID,
+				"task_id":   tsk.Task.ID,
+				"context":   "runner",
+			}).Warn("Runner went offline while running the task")
+
+			tsk.LostRunnerIDs = append(tsk.LostRunnerIDs, runner.ID)
+			t.taskPool.notifyRunnerStateChanged()
+
+			if tsk.Task.Status == task_logger.TaskStoppingStatus {
+				tsk.Log(fmt.Sprintf("Runner %s is offline. The task is stopped.", runner.Name))
+				tsk.SetStatus(task_logger.TaskStoppedStatus)
+				return
+			}
+
+			if tsk.Template.RunnerFailurePolicy != db.RunnerFailureRequeue {
+				err = fmt.Errorf("runner %s is offline", runner.Name)
+				return
+			}
+
+			tsk.Log(fmt.Sprintf("Runner %s is offline. The task is started again on another runner.", runner.Name))
+			tsk.SetStatus(task_logger.TaskStartingStatus)
+			continue
+		}
+
+		if tsk.Task.Status == task_logger.TaskFailStatus {
+			err = fmt.Errorf("task failed")
+		} else if taskTimedOut {
+			err = fmt.Errorf("task timed out")
+		}
+
 		return
 	}
+}
 
-	if len(runners) == 0 {
-		err = fmt.Errorf("no runners available")
+// acquireRunner finds the runner for the task. If no registered runner is
+// available, the ephemeral runner is started for the task. Tasks which target
+// runners by tag or selector are not run on ephemeral runners.
+func (t *RemoteJob) acquireRunner(tsk *TaskRunner, startTime time.Time) (runner *db.Runner, ephemeral *runner_provisioning.Runner, err error) {
+	runner, err = t.findRunner(tsk)
+
+	provisioner := t.taskPool.provisioner
+
+	if !errors.Is(err, errNoRunners) ||
+		provisioner == nil ||
+		t.RunnerTag != nil ||
+		tsk.Template.RunnerSelector != "" {
 		return
 	}
 
-	var runner *db.Runner
+	waiting := false
+
+	for {
+		ephemeral, err = provisioner.Provision(tsk.Task)
 
-	for _, r := range runners {
-		n := t.taskPool.GetNumberOfRunningTasksOfRunner(r.ID)
-		if n < r.MaxParallelTasks || r.MaxParallelTasks == 0 {
-			runner = &r
+		if !errors.Is(err, runner_provisioning.ErrLimitReached) {
 			break
 		}
+
+		if t.killed {
+			err = fmt.Errorf("task stopped")
+			return
+		}
+
+		if util.Config.MaxTaskDurationSec > 0 && int(tz.Now().Sub(startTime).Seconds()) > util.Config.MaxTaskDurationSec {
+			err = fmt.Errorf("task timed out")
+			return
+		}
+
+		if !waiting {
+			waiting = true
+			tsk.Log("Waiting for a free ephemeral runner")
+		}
+
+		time.Sleep(time.Second)
 	}
 
-	if runner == nil {
-		err = fmt.Errorf("no runners available")
+	if err != nil {
 		return
 	}
 
-	err = callRunnerWebhook(runner, tsk, "start")
+	tsk.Log(fmt.Sprintf("Ephemeral runner %d is started for the task", ephemeral.ID))
+	runner = &ephemeral.Runner
+	return
+}
+
+// releaseRunner stops and deletes the ephemeral runner.
+func (t *RemoteJob) releaseRunner(ephemeral *runner_provisioning.Runner) {
+	if ephemeral == nil {
+		return
+	}
+	t.taskPool.provisioner.Release(ephemeral)
+}
+
+// findRunner returns the runner for the task, see selectRunner.
+func (t *RemoteJob) findRunner(tsk *TaskRunner) (runner *db.Runner, err error) {
+	var runners []db.Runner
+	db.StoreSession(t.taskPool.store, "run remote job", func() {
+		var projectRunners []db.Runner
+		projectRunners, err = t.taskPool.store.GetRunners(t.Task.ProjectID, true, t.RunnerTag)
+		if err != nil {
+			return
+		}
+		var globalRunners []db.Runner
+		globalRunners, err = t.taskPool.store.GetAllRunners(true, true)
+		if err != nil {
+			return
+		}
+		runners = append(runners, projectRunners...)
+		runners = append(runners, globalRunners...)
+	})
 
 	if err != nil {
 		return
 	}
 
-	tsk.RunnerID = runner.ID
-	if t.taskPool != nil && t.taskPool.state != nil {
-		t.taskPool.state.UpdateRuntimeFields(tsk)
+	preferredID := 0
+	if tsk.Template.RunnerAffinity {
+		preferredID, _ = t.taskPool.affinity.get(tsk.Template.RepositoryID)
 	}
 
-	startTime := tz.Now()
+	return selectRunner(runners, tsk.Template, tsk.LostRunnerIDs, preferredID, t.taskPool.GetNumberOfRunningTasksOfRunner)
+}
 
-	taskTimedOut := false
+// getRunner reloads the runner to check when it has contacted the server last time.
+func (t *RemoteJob) getRunner(runnerID int, projectID *int) (runner db.Runner, err error) {
+	db.StoreSession(t.taskPool.store, "check remote job runner", func() {
+		if projectID == nil {
+			runner, err = t.taskPool.store.GetGlobalRunner(runnerID)
+		} else {
+			runner, err = t.taskPool.store.GetRunner(*projectID, runnerID)
+		}
+	})
+	return
+}
+
+// waitForTask waits until the runner finishes the task. runnerLost is true
+// if the runner has not contacted the server longer than the heartbeat timeout.
+func (t *RemoteJob) waitForTask(runner *db.Runner, startTime time.Time) (tsk *TaskRunner, runnerLost bool, taskTimedOut bool, err error) {
+	assigned := tz.Now()
+	lastCheck := assigned
+
+	// stoppingSince is the time when the server requested the runner to kill the job.
+	var stoppingSince *time.Time
 
 	for {
 		if util.Config.MaxTaskDurationSec > 0 && int(tz.Now().Sub(startTime).Seconds()) > util.Config.MaxTaskDurationSec {
 			taskTimedOut = true
-			break
+			return
 		}
 
 		time.Sleep(1_000_000_000)
@@ -172,28 +324,51 @@ func (t *RemoteJob) Run(username string, incomingVersion *string, alias string)
 		if tsk.Task.Status == task_logger.TaskSuccessStatus ||
 			tsk.Task.Status == task_logger.TaskStoppedStatus ||
 			tsk.Task.Status == task_logger.TaskFailStatus {
-			break
+			return
 		}
-	}
 
-	err = callRunnerWebhook(runner, tsk, "finish")
+		now := tz.Now()
 
-	if err != nil {
-		return
-	}
+		if now.Sub(lastCheck) >= runnerCheckInterval {
+			lastCheck = now
 
-	if tsk.Task.Status == task_logger.TaskFailStatus {
-		err = fmt.Errorf("task failed")
-	} else if taskTimedOut {
-		err = fmt.Errorf("task timed out")
-	}
+			current, getErr := t.getRunner(runner.ID, runner.ProjectID)
+			if getErr != nil && !errors.Is(getErr, db.ErrNotFound) {
+				log.WithError(getErr).WithField("runner_id", runner.ID).Error("Failed to check runner")
+			} else if getErr != nil || !runner_health.IsOnlineSince(current, assigned, now) {
+				runnerLost = true
+				return
+			}
+		}
 
-	return
+		if tsk.Task.Status != task_logger.TaskStoppingStatus {
+			continue
+		}
+
+		// The task is stopped when the runner confirms it has killed the job.
+		if stoppingSince == nil {
+			stoppingSince = &now
+			continue
+		}
+
+		if util.Config.RemoteStopTimeoutSec > 0 && now.Sub(*stoppingSince) > time.Duration(util.Config.RemoteStopTimeoutSec)*time.Second {
+			log.WithFields(log.Fields{
+				"runner_id": runner.ID,
+				"task_id":   tsk.Task.ID,
+				"context":   "runner",
+			}).Warn("Runner has not confirmed stopping the task")
+
+			tsk.Log(fmt.Sprintf("Runner has not confirmed stopping the task in %d seconds. The task is stopped forcibly.", util.Config.RemoteStopTimeoutSec))
+			tsk.SetStatus(task_logger.TaskStoppedStatus)
+			return
+		}
+	}
 }
 
+// Kill marks the job as killed. The stopping status of the task is passed
+// to the runner as the kill request on its next poll.
 func (t *RemoteJob) Kill() {
 	t.killed = true
-	// Do nothing because you can't kill remote process
 }
 
 func (t *RemoteJob) IsKilled() bool {
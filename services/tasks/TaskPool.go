@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -11,7 +12,12 @@ import (
 	"github.com/semaphoreui/semaphore/pkg/tz"
 	"github.com/semaphoreui/semaphore/pro/pkg/stage_parsers"
 	"github.com/semaphoreui/semaphore/pro_interfaces"
+	"github.com/semaphoreui/semaphore/services/metrics"
+	"github.com/semaphoreui/semaphore/services/runner_provisioning"
 	"github.com/semaphoreui/semaphore/services/server"
+	"github.com/semaphoreui/semaphore/services/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/semaphoreui/semaphore/db"
 	"github.com/semaphoreui/semaphore/db_lib"
@@ -65,6 +71,27 @@ type TaskPool struct {
 
 	// state provides pluggable storage for Queue, active projects, running tasks and aliases
 	state TaskStateStore
+
+	affinity *runnerAffinity
+
+	// provisioner starts ephemeral runners for remote tasks
+	// when no registered runner is available.
+	provisioner *runner_provisioning.Provisioner
+
+	// artifacts stores files produced by local tasks and passes
+	// artifacts of build tasks to deploy tasks.
+	artifacts ArtifactTransfer
+
+	// runnerState is notified when the state pushed to remote runners changes.
+	runnerState *stateSignal
+}
+
+func (p *TaskPool) SetArtifactTransfer(artifacts ArtifactTransfer) {
+	p.artifacts = artifacts
+}
+
+func (p *TaskPool) SetRunnerProvisioner(provisioner *runner_provisioning.Provisioner) {
+	p.provisioner = provisioner
 }
 
 func CreateTaskPool(
@@ -87,6 +114,8 @@ func CreateTaskPool(
 		encryptionService:      encryptionService,
 		logWriteService:        logWriteService,
 		keyInstallationService: keyInstallationService,
+		affinity:               newRunnerAffinity(),
+		runnerState:            newStateSignal(),
 	}
 	// attempt to start HA state store (no-op for memory)
 	_ = p.state.Start(p.hydrateTaskRunner)
@@ -114,6 +143,8 @@ func CreateTaskPoolWithState(
 		encryptionService:      encryptionService,
 		logWriteService:        logWriteService,
 		keyInstallationService: keyInstallationService,
+		affinity:               newRunnerAffinity(),
+		runnerState:            newStateSignal(),
 	}
 	_ = p.state.Start(p.hydrateTaskRunner)
 	return p
@@ -131,6 +162,14 @@ func (p *TaskPool) GetRunningTasks() (res []*TaskRunner) {
 	return p.state.RunningRange()
 }
 
+func (p *TaskPool) QueueLen() int {
+	return p.state.QueueLen()
+}
+
+func (p *TaskPool) RunningCount() int {
+	return p.state.RunningCount()
+}
+
 func (p *TaskPool) GetTask(id int) (task *TaskRunner) {
 	for _, t := range p.state.QueueRange() {
 		if t.Task.ID == id {
@@ -163,6 +202,8 @@ func (p *TaskPool) Run() {
 
 	go p.handleQueue()
 	go p.handleLogs()
+	go p.restoreTasksWaitingApproval()
+	go p.pruneIntegrationDeliveries()
 
 	for {
 		select {
@@ -212,10 +253,19 @@ func (p *TaskPool) handleQueue() {
 			if curr.Task.Status == task_logger.TaskFailStatus {
 				//delete failed TaskRunner from queue
 				_ = p.state.DequeueAt(i)
+				curr.endTrace()
 				log.Info("Task " + getTaskName(curr) + " removed from queue")
 				continue
 			}
 
+			if curr.Task.Status == task_logger.TaskWaitingApproval {
+				if curr.approvalExpired() {
+					p.expireTaskApproval(curr)
+				}
+				i = i + 1
+				continue
+			}
+
 			if p.blocks(curr) {
 				i = i + 1
 				continue
@@ -257,7 +307,9 @@ func (p *TaskPool) handleLogs() {
 
 func (p *TaskPool) flushLogs(logs *[]logRecord) {
 	if len(*logs) > 0 {
+		start := time.Now()
 		p.writeLogs(*logs)
+		metrics.LogBatchWritten(time.Since(start))
 		*logs = (*logs)[:0]
 	}
 }
@@ -318,6 +370,7 @@ func (p *TaskPool) writeLogs(logs []logRecord) {
 
 func runTask(task *TaskRunner, p *TaskPool) {
 	log.Info("Set resource locker with TaskRunner " + getTaskName(task))
+	task.endQueueSpan()
 	p.onTaskRun(task)
 
 	log.Info("Task " + getTaskName(task) + " started")
@@ -378,6 +431,7 @@ func (p *TaskPool) hydrateTaskRunner(taskID int, projectID int) (*TaskRunner, er
 			Logger:       app.SetLogger(tr),
 			App:          app,
 			KeyInstaller: p.keyInstallationService,
+			Artifacts:    p.artifacts,
 		}
 	}
 	tr.job = job
@@ -547,6 +601,27 @@ func (p *TaskPool) StopTasksByTemplate(projectID int, templateID int, forceStop
 	}
 }
 
+// StopQueuedIntegrationTasks stops tasks of the integration which are waiting
+// in the queue. Running tasks are not affected. Returns IDs of stopped tasks.
+func (p *TaskPool) StopQueuedIntegrationTasks(projectID int, integrationID int) (stopped []int) {
+	for _, t := range p.state.QueueRange() {
+		if t == nil || t.Task.IntegrationID == nil {
+			continue
+		}
+		if t.Task.ProjectID != projectID || *t.Task.IntegrationID != integrationID {
+			continue
+		}
+		if t.Task.Status != task_logger.TaskWaitingStatus {
+			continue
+		}
+		// Queued tasks in stopping status are finalized to stopped in run()
+		t.SetStatus(task_logger.TaskStoppingStatus)
+		stopped = append(stopped, t.Task.ID)
+	}
+
+	return
+}
+
 // GetQueuedTasks returns a snapshot of tasks currently queued
 func (p *TaskPool) GetQueuedTasks() []*TaskRunner {
 	return p.state.QueueRange()
@@ -609,6 +684,7 @@ func getNextBuildVersion(startVersion string, currentVersion string) string {
 // AddTask creates and queues a new task for execution in the task pool.
 //
 // Parameters:
+//   - ctx: Context of the caller, the span of the task is created in its trace
 //   - taskObj: The task object with initial configuration
 //   - userID: Optional ID of the user initiating the task
 //   - username: Username of the user initiating the task
@@ -627,18 +703,26 @@ func getNextBuildVersion(startVersion string, currentVersion string) string {
 //   - The newly created task with all properties set
 //   - An error if task creation or validation fails
 func (p *TaskPool) AddTask(
+	ctx context.Context,
 	taskObj db.Task,
 	userID *int,
 	username string,
 	projectID int,
 	needAlias bool,
 ) (newTask db.Task, err error) {
+	ctx, span := tracing.Start(ctx, "TaskPool.AddTask", trace.WithAttributes(
+		attribute.Int("semaphore.project_id", projectID),
+		attribute.Int("semaphore.template_id", taskObj.TemplateID),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	taskObj.Created = tz.Now()
 	taskObj.Status = task_logger.TaskWaitingStatus
 	taskObj.UserID = userID
 	taskObj.ProjectID = projectID
 	extraSecretVars := taskObj.Secret
 	taskObj.Secret = "{}"
+	taskObj.HasSecret = extraSecretVars != "" && extraSecretVars != "{}"
 
 	tpl, err := p.store.GetTemplate(projectID, taskObj.TemplateID)
 	if err != nil {
@@ -650,6 +734,17 @@ func (p *TaskPool) AddTask(
 		return
 	}
 
+	// The task records the inventory it runs on, so the history of deployments
+	// does not follow later changes of the default inventory of the template.
+	canOverrideInventory, err := tpl.CanOverrideInventory()
+	if err != nil {
+		return
+	}
+
+	if !canOverrideInventory || taskObj.InventoryID == nil {
+		taskObj.InventoryID = tpl.InventoryID
+	}
+
 	if tpl.Type == db.TemplateBuild { // get next version for TaskRunner if it is a Build
 		var builds []db.TaskWithTpl
 		builds, err = p.store.GetTemplateTasks(tpl.ProjectID, tpl.ID, db.RetrieveQueryParams{Count: 1})
@@ -670,6 +765,7 @@ func (p *TaskPool) AddTask(
 	}
 
 	taskRunner := NewTaskRunner(newTask, p, username, p.keyInstallationService)
+	taskRunner.startTrace(ctx)
 
 	if needAlias {
 		// A unique, randomly-generated identifier that persists throughout the task's lifecycle.
@@ -680,6 +776,7 @@ func (p *TaskPool) AddTask(
 	if err != nil {
 		taskRunner.Log("Error: " + err.Error())
 		taskRunner.SetStatus(task_logger.TaskFailStatus)
+		taskRunner.endTrace()
 		return
 	}
 
@@ -716,11 +813,18 @@ func (p *TaskPool) AddTask(
 			Logger:       app.SetLogger(taskRunner),
 			App:          app,
 			KeyInstaller: p.keyInstallationService,
+			Artifacts:    p.artifacts,
 		}
 	}
 
 	taskRunner.job = job
 
+	// The status is changed after the task is created, so approvers are notified.
+	if tpl.RequiredApprovals > 0 {
+		taskRunner.SetStatus(task_logger.TaskWaitingApproval)
+		newTask.Status = taskRunner.Task.Status
+	}
+
 	p.register <- taskRunner
 
 	taskRunner.createTaskEvent()
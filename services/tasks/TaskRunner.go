@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
@@ -11,7 +12,9 @@ import (
 	"github.com/semaphoreui/semaphore/db_lib"
 	"github.com/semaphoreui/semaphore/pkg/tz"
 	"github.com/semaphoreui/semaphore/pro_interfaces"
+	"github.com/semaphoreui/semaphore/services/metrics"
 	"github.com/semaphoreui/semaphore/services/tasks/hooks"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/semaphoreui/semaphore/api/sockets"
 	"github.com/semaphoreui/semaphore/db"
@@ -21,7 +24,7 @@ import (
 )
 
 type Job interface {
-	Run(username string, incomingVersion *string, alias string) error
+	Run(ctx context.Context, username string, incomingVersion *string, alias string) error
 	Kill()
 	IsKilled() bool
 }
@@ -38,6 +41,7 @@ type TaskRunner struct {
 	currentState  any
 
 	users        []int
+	outputUsers  []int // outputUsers can view the output of the task
 	alert        bool
 	alertChat    *string
 	pool         *TaskPool
@@ -46,10 +50,16 @@ type TaskRunner struct {
 	// job executes Ansible and returns stdout to Semaphore logs
 	job Job
 
-	RunnerID        int
+	RunnerID int
+	// LostRunnerIDs contains runners which went offline while running
+	// the task. The task is not assigned to them again.
+	LostRunnerIDs   []int
 	Username        string
 	IncomingVersion *string
 
+	// approvalNote describes the approval decision which is being notified.
+	approvalNote string
+
 	statusListeners []task_logger.StatusListener
 	logListeners    []task_logger.LogListener
 
@@ -57,6 +67,13 @@ type TaskRunner struct {
 	// For example, terraform task require an alias for run.
 	Alias string
 
+	// TraceContext is passed to the remote runner to continue the trace of the task.
+	TraceContext map[string]string
+
+	// ctx contains the span of the task. Spans of the job are its children.
+	ctx       context.Context
+	queueSpan trace.Span
+
 	logWG sync.WaitGroup
 }
 
@@ -83,22 +100,20 @@ func (t *TaskRunner) AddLogListener(l task_logger.LogListener) {
 }
 
 func (t *TaskRunner) saveStatus() {
-	for _, user := range t.users {
-		b, err := json.Marshal(&map[string]any{
-			"type":        "update",
-			"start":       t.Task.Start,
-			"end":         t.Task.End,
-			"status":      t.Task.Status,
-			"task_id":     t.Task.ID,
-			"template_id": t.Task.TemplateID,
-			"project_id":  t.Task.ProjectID,
-			"version":     t.Task.Version,
-		})
+	b, err := json.Marshal(&map[string]any{
+		"type":        "update",
+		"start":       t.Task.Start,
+		"end":         t.Task.End,
+		"status":      t.Task.Status,
+		"task_id":     t.Task.ID,
+		"template_id": t.Task.TemplateID,
+		"project_id":  t.Task.ProjectID,
+		"version":     t.Task.Version,
+	})
 
-		util.LogPanic(err)
+	util.LogPanic(err)
 
-		sockets.Message(user, b)
-	}
+	sockets.TaskMessage(t.users, t.Task.ProjectID, t.Task.ID, b)
 
 	if err := t.pool.store.UpdateTask(t.Task); err != nil {
 		t.panicOnError(err, "Failed to update TaskRunner status")
@@ -179,6 +194,9 @@ func (t *TaskRunner) run() {
 		t.Task.End = &now
 		t.saveStatus()
 		t.createTaskEvent()
+
+		metrics.TaskFinished(t.Task.ProjectID, t.Task.TemplateID, string(t.Template.App), string(t.Task.Status), t.Task.Start, t.Task.End)
+		t.endTrace()
 		t.pool.queueEvents <- PoolEvent{EventTypeFinished, t}
 	}()
 
@@ -188,6 +206,11 @@ func (t *TaskRunner) run() {
 		return
 	}
 
+	// The task was rejected by approvers, it is not run.
+	if t.Task.Status == task_logger.TaskApprovalRejected {
+		return
+	}
+
 	t.SetStatus(task_logger.TaskStartingStatus)
 	t.createTaskEvent()
 
@@ -211,7 +234,7 @@ func (t *TaskRunner) run() {
 
 	}
 
-	err = t.job.Run(username, incomingVersion, t.Alias)
+	err = t.job.Run(t.traceContext(), username, incomingVersion, t.Alias)
 
 	if err != nil {
 		if t.job.IsKilled() {
@@ -232,6 +255,10 @@ func (t *TaskRunner) run() {
 		t.SetStatus(task_logger.TaskSuccessStatus)
 	}
 
+	if t.Task.Status == task_logger.TaskSuccessStatus && t.Template.Type == db.TemplateDeploy {
+		t.saveEnvironmentVersion()
+	}
+
 	tpls, err := t.pool.store.GetTemplates(t.Task.ProjectID, db.TemplateFilter{
 		BuildTemplateID: &t.Task.TemplateID,
 		AutorunOnly:     true,
@@ -249,6 +276,7 @@ func (t *TaskRunner) run() {
 			BuildTaskID: &t.Task.ID,
 		}
 		_, err = t.pool.AddTask(
+			t.traceContext(),
 			task,
 			nil,
 			"",
@@ -262,6 +290,34 @@ func (t *TaskRunner) run() {
 	}
 }
 
+// saveEnvironmentVersion remembers the build version deployed to the
+// environment of the template.
+func (t *TaskRunner) saveEnvironmentVersion() {
+	if t.Template.EnvironmentID == nil {
+		return
+	}
+
+	buildTask := t.Task.GetIncomingBuildTask(t.pool.store)
+	if buildTask == nil {
+		return
+	}
+
+	err := t.pool.store.SetEnvironmentVersion(db.EnvironmentVersion{
+		ProjectID:       t.Task.ProjectID,
+		EnvironmentID:   *t.Template.EnvironmentID,
+		BuildTemplateID: buildTask.TemplateID,
+		TemplateID:      t.Template.ID,
+		TaskID:          &t.Task.ID,
+		BuildTaskID:     &buildTask.ID,
+		Version:         buildTask.Version,
+		Deployed:        tz.Now(),
+	})
+
+	if err != nil {
+		t.Log("Failed to save the deployed version: " + err.Error())
+	}
+}
+
 func (t *TaskRunner) prepareError(err error, errMsg string) error {
 	if errors.Is(err, db.ErrNotFound) {
 		t.Log(errMsg)
@@ -329,6 +385,10 @@ func (t *TaskRunner) populateDetails() error {
 	t.alert = project.Alert
 	t.alertChat = project.AlertChat
 
+	if t.Template.ContainerImage == "" {
+		t.Template.ContainerImage = project.ContainerImage
+	}
+
 	// get project users
 	projectUsers, err := t.pool.store.GetProjectUsers(t.Template.ProjectID, db.RetrieveQueryParams{})
 	if err != nil {
@@ -336,9 +396,20 @@ func (t *TaskRunner) populateDetails() error {
 	}
 
 	users := make(map[int]bool)
+	outputUsers := make(map[int]bool)
 
 	for _, user := range projectUsers {
 		users[user.ID] = true
+
+		var permissions db.ProjectUserPermission
+		permissions, err = db.GetProjectRolePermissions(t.pool.store, t.Template.ProjectID, user.Role)
+		if err != nil {
+			return err
+		}
+
+		if permissions.Can(db.CanViewTaskOutput) {
+			outputUsers[user.ID] = true
+		}
 	}
 
 	admins, err := t.pool.store.GetAllAdmins()
@@ -348,6 +419,7 @@ func (t *TaskRunner) populateDetails() error {
 
 	for _, admin := range admins {
 		users[admin.ID] = true
+		outputUsers[admin.ID] = true
 	}
 
 	t.users = []int{}
@@ -355,6 +427,11 @@ func (t *TaskRunner) populateDetails() error {
 		t.users = append(t.users, userID)
 	}
 
+	t.outputUsers = []int{}
+	for userID := range outputUsers {
+		t.outputUsers = append(t.outputUsers, userID)
+	}
+
 	// get inventory
 	canOverrideInventory, err := t.Template.CanOverrideInventory()
 	if err != nil {
@@ -39,18 +39,16 @@ func (t *TaskRunner) LogWithTime(now time.Time, msg string) {
 }
 
 func (t *TaskRunner) sendToWs(now time.Time, msg string) {
-	for _, user := range t.users {
-		b, err := json.Marshal(&map[string]any{
-			"type":       "log",
-			"output":     msg,
-			"time":       now,
-			"task_id":    t.Task.ID,
-			"project_id": t.Task.ProjectID,
-		})
-
-		util.LogPanic(err)
-		sockets.Message(user, b)
-	}
+	b, err := json.Marshal(&map[string]any{
+		"type":       "log",
+		"output":     msg,
+		"time":       now,
+		"task_id":    t.Task.ID,
+		"project_id": t.Task.ProjectID,
+	})
+
+	util.LogPanic(err)
+	sockets.TaskMessage(t.outputUsers, t.Task.ProjectID, t.Task.ID, b)
 }
 
 func (t *TaskRunner) LogfWithTime(now time.Time, format string, a ...any) {
@@ -122,19 +120,25 @@ func (t *TaskRunner) SetStatus(status task_logger.TaskStatus) {
 	}
 
 	if status.IsNotifiable() {
-		t.sendTelegramAlert()
-		t.sendSlackAlert()
-		t.sendRocketChatAlert()
-		t.sendMicrosoftTeamsAlert()
-		t.sendDingTalkAlert()
-		t.sendGotifyAlert()
+		t.sendChatAlerts()
 	}
 
+	t.pool.notifyRunnerStateChanged()
+
 	for _, l := range t.statusListeners {
 		l(status)
 	}
 }
 
+func (t *TaskRunner) sendChatAlerts() {
+	t.sendTelegramAlert()
+	t.sendSlackAlert()
+	t.sendRocketChatAlert()
+	t.sendMicrosoftTeamsAlert()
+	t.sendDingTalkAlert()
+	t.sendGotifyAlert()
+}
+
 func (t *TaskRunner) panicOnError(err error, msg string) {
 	if err == nil {
 		return
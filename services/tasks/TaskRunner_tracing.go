@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/services/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startTrace creates the span of the task and the span of waiting in the queue.
+// The span of the task ends when the task is finished.
+func (t *TaskRunner) startTrace(ctx context.Context) {
+	t.ctx, _ = tracing.Start(ctx, "task", trace.WithAttributes(
+		attribute.Int("semaphore.project_id", t.Task.ProjectID),
+		attribute.Int("semaphore.template_id", t.Task.TemplateID),
+		attribute.Int("semaphore.task_id", t.Task.ID),
+	))
+
+	_, t.queueSpan = tracing.Start(t.ctx, "task.queue")
+}
+
+// traceContext returns the context of the task span.
+func (t *TaskRunner) traceContext() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+func (t *TaskRunner) endQueueSpan() {
+	if t.queueSpan != nil {
+		t.queueSpan.End()
+	}
+}
+
+// endTrace ends spans of the task with the final status of the task.
+func (t *TaskRunner) endTrace() {
+	t.endQueueSpan()
+
+	span := trace.SpanFromContext(t.ctx)
+	span.SetAttributes(
+		attribute.String("semaphore.app", string(t.Template.App)),
+		attribute.String("semaphore.task_status", string(t.Task.Status)),
+	)
+
+	if t.RunnerID > 0 {
+		span.SetAttributes(attribute.Int("semaphore.runner_id", t.RunnerID))
+	}
+
+	if t.Task.Status != task_logger.TaskSuccessStatus {
+		span.SetStatus(codes.Error, string(t.Task.Status))
+	}
+
+	span.End()
+}
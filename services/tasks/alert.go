@@ -55,7 +55,7 @@ func (t *TaskRunner) sendMailAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -140,7 +140,7 @@ func (t *TaskRunner) sendTelegramAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 		Chat: alertChat{
 			ID: chatID,
@@ -209,7 +209,7 @@ func (t *TaskRunner) sendSlackAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -272,7 +272,7 @@ func (t *TaskRunner) sendRocketChatAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -334,7 +334,7 @@ func (t *TaskRunner) sendMicrosoftTeamsAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -396,7 +396,7 @@ func (t *TaskRunner) sendDingTalkAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -459,7 +459,7 @@ func (t *TaskRunner) sendGotifyAlert() {
 			URL:     t.taskLink(),
 			Result:  t.Task.Status.Format(),
 			Version: version,
-			Desc:    t.Task.Message,
+			Desc:    t.alertDesc(),
 		},
 	}
 
@@ -535,6 +535,15 @@ func (t *TaskRunner) alertInfos() (string, string) {
 	return author, version
 }
 
+// alertDesc returns the description of the task for alerts. The note about
+// the latest approval decision takes precedence over the task message.
+func (t *TaskRunner) alertDesc() string {
+	if t.approvalNote != "" {
+		return t.approvalNote
+	}
+	return t.Task.Message
+}
+
 func (t *TaskRunner) alertColor(kind string) string {
 	switch kind {
 	case "slack":
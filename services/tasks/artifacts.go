@@ -0,0 +1,216 @@
+package tasks
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/util"
+)
+
+// ArtifactTransfer stores files produced by tasks and gives deploy tasks
+// the files of their build tasks.
+type ArtifactTransfer interface {
+	UploadArtifact(task db.Task, name string, r io.Reader) error
+	// GetBuildArtifacts returns artifacts of the build task deployed by the task.
+	GetBuildArtifacts(task db.Task) ([]db.TaskArtifact, error)
+	OpenBuildArtifact(task db.Task, artifact db.TaskArtifact) (io.ReadCloser, error)
+}
+
+// matchArtifactPattern reports whether the slash separated name matches
+// the glob pattern. "**" matches zero or more directories.
+func matchArtifactPattern(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// findArtifacts returns slash separated paths of files in the directory
+// which match any of the patterns.
+func findArtifacts(dir string, patterns []string) (names []string, err error) {
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		for _, pattern := range patterns {
+			if matchArtifactPattern(pattern, name) {
+				names = append(names, name)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// uploadArtifacts uploads files matching artifact patterns of the template.
+// Failed uploads are logged and do not fail the task.
+func (t *LocalJob) uploadArtifacts() {
+	patterns := t.Template.ArtifactPatterns()
+
+	if t.Artifacts == nil || len(patterns) == 0 {
+		return
+	}
+
+	dir := t.Repository.GetFullPath(t.Template.ID)
+
+	names, err := findArtifacts(dir, patterns)
+	if err != nil {
+		t.Log("Failed to find artifacts: " + err.Error())
+		return
+	}
+
+	if len(names) == 0 {
+		t.Log("No artifacts found")
+		return
+	}
+
+	for _, name := range names {
+		err = t.uploadArtifact(dir, name)
+		if err != nil {
+			t.Log("Failed to upload artifact " + name + ": " + err.Error())
+			continue
+		}
+
+		t.Log("Artifact " + name + " uploaded")
+	}
+}
+
+func (t *LocalJob) uploadArtifact(dir string, name string) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+
+	return t.Artifacts.UploadArtifact(t.Task, name, f)
+}
+
+// buildArtifactsDir returns the directory to which artifacts of the build
+// task are downloaded.
+func (t *LocalJob) buildArtifactsDir() string {
+	return path.Join(util.Config.GetProjectTmpDir(t.Template.ProjectID), "artifacts_"+strconv.Itoa(t.Task.ID))
+}
+
+// downloadBuildArtifacts downloads artifacts of the build task deployed by
+// the task. The directory is created for every deploy task of the build,
+// even if the build has no artifacts, so scripts can always rely on it.
+// It returns an empty string if the task does not deploy a build.
+func (t *LocalJob) downloadBuildArtifacts() (dir string, err error) {
+	if t.Template.Type != db.TemplateDeploy || t.Task.BuildTaskID == nil {
+		return
+	}
+
+	dir = t.buildArtifactsDir()
+
+	if err = os.RemoveAll(dir); err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+
+	if t.Artifacts == nil {
+		return
+	}
+
+	artifacts, err := t.Artifacts.GetBuildArtifacts(t.Task)
+	if err != nil || len(artifacts) == 0 {
+		return
+	}
+
+	for _, artifact := range artifacts {
+		if err = t.downloadBuildArtifact(dir, artifact); err != nil {
+			return
+		}
+	}
+
+	t.Log("Build artifacts downloaded to " + dir)
+
+	return
+}
+
+func (t *LocalJob) downloadBuildArtifact(dir string, artifact db.TaskArtifact) (err error) {
+	filePath := filepath.Join(dir, filepath.FromSlash(artifact.Name))
+
+	// Names are checked when artifacts are uploaded, the check protects
+	// the runner from the compromised server.
+	if !strings.HasPrefix(filePath, filepath.Clean(dir)+string(filepath.Separator)) {
+		return fmt.Errorf("invalid artifact name %s", artifact.Name)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return
+	}
+
+	content, err := t.Artifacts.OpenBuildArtifact(t.Task, artifact)
+	if err != nil {
+		return
+	}
+	defer content.Close() //nolint:errcheck
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return
+	}
+
+	_, err = io.Copy(f, content)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return
+}
@@ -0,0 +1,125 @@
+package tasks
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchArtifactPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"dist/*.tar.gz", "dist/app.tar.gz", true},
+		{"dist/*.tar.gz", "dist/sub/app.tar.gz", false},
+		{"dist/**/*.tar.gz", "dist/app.tar.gz", true},
+		{"dist/**/*.tar.gz", "dist/a/b/app.tar.gz", true},
+		{"**/report.xml", "report.xml", true},
+		{"**/report.xml", "tests/report.xml", true},
+		{"dist/**", "dist/a/b", true},
+		{"report.xml", "tests/report.xml", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, matchArtifactPattern(tt.pattern, tt.name), tt.pattern+" "+tt.name)
+	}
+}
+
+func TestFindArtifacts(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"dist/app.tar.gz", "dist/app.txt", "tests/report.xml", ".git/report.xml"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(name), 0644))
+	}
+
+	names, err := findArtifacts(dir, []string{"dist/*.tar.gz", "**/report.xml"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dist/app.tar.gz", "tests/report.xml"}, names)
+}
+
+type testArtifactLogger struct {
+	task_logger.Logger
+	lines []string
+}
+
+func (l *testArtifactLogger) Log(msg string) {
+	l.lines = append(l.lines, msg)
+}
+
+// testArtifactTransfer keeps artifacts of the build task in memory.
+type testArtifactTransfer struct {
+	artifacts map[string]string
+}
+
+func (a *testArtifactTransfer) UploadArtifact(task db.Task, name string, r io.Reader) error {
+	return nil
+}
+
+func (a *testArtifactTransfer) GetBuildArtifacts(task db.Task) (res []db.TaskArtifact, err error) {
+	for name := range a.artifacts {
+		res = append(res, db.TaskArtifact{Name: name})
+	}
+	return
+}
+
+func (a *testArtifactTransfer) OpenBuildArtifact(task db.Task, artifact db.TaskArtifact) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(a.artifacts[artifact.Name])), nil
+}
+
+func TestLocalJob_DownloadBuildArtifacts(t *testing.T) {
+	util.Config = &util.ConfigType{TmpPath: t.TempDir()}
+
+	buildTaskID := 1
+
+	job := LocalJob{
+		Task:     db.Task{ID: 2, ProjectID: 1, BuildTaskID: &buildTaskID},
+		Template: db.Template{ProjectID: 1, Type: db.TemplateDeploy},
+		Logger:   &testArtifactLogger{},
+		Artifacts: &testArtifactTransfer{artifacts: map[string]string{
+			"dist/app.tar.gz": "app",
+			"version.txt":     "1.0.0",
+		}},
+	}
+
+	dir, err := job.downloadBuildArtifacts()
+	require.NoError(t, err)
+	assert.Equal(t, job.buildArtifactsDir(), dir)
+
+	content, err := os.ReadFile(filepath.Join(dir, "dist", "app.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "version.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", string(content))
+
+	// The directory is created even if the build has no artifacts.
+	job.Artifacts = &testArtifactTransfer{}
+
+	dir, err = job.downloadBuildArtifacts()
+	require.NoError(t, err)
+	assert.Equal(t, job.buildArtifactsDir(), dir)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Artifacts are not downloaded for other templates.
+	job.Template.Type = db.TemplateTask
+
+	dir, err = job.downloadBuildArtifacts()
+	require.NoError(t, err)
+	assert.Empty(t, dir)
+}
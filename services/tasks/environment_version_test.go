@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRunner_saveEnvironmentVersion(t *testing.T) {
+	store := sql.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "versions"})
+	require.NoError(t, err)
+
+	env, err := store.CreateEnvironment(db.Environment{ProjectID: proj.ID, Name: "staging", JSON: "{}"})
+	require.NoError(t, err)
+
+	build, err := store.CreateTemplate(db.Template{ProjectID: proj.ID, Name: "build", Playbook: "build.yml", Type: db.TemplateBuild})
+	require.NoError(t, err)
+
+	deploy, err := store.CreateTemplate(db.Template{
+		ProjectID:       proj.ID,
+		Name:            "deploy",
+		Playbook:        "deploy.yml",
+		Type:            db.TemplateDeploy,
+		BuildTemplateID: &build.ID,
+		EnvironmentID:   &env.ID,
+	})
+	require.NoError(t, err)
+
+	pool := &TaskPool{store: store}
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		buildTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: build.ID, Version: &version}, 0)
+		require.NoError(t, err)
+
+		deployTask, err := store.CreateTask(db.Task{ProjectID: proj.ID, TemplateID: deploy.ID, BuildTaskID: &buildTask.ID}, 0)
+		require.NoError(t, err)
+
+		tr := TaskRunner{Task: deployTask, Template: deploy, pool: pool}
+		tr.saveEnvironmentVersion()
+	}
+
+	versions, err := store.GetEnvironmentVersions(proj.ID, build.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, env.ID, versions[0].EnvironmentID)
+	assert.Equal(t, deploy.ID, versions[0].TemplateID)
+	require.NotNil(t, versions[0].Version)
+	assert.Equal(t, "1.1.0", *versions[0].Version)
+}
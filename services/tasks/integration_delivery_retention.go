@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	log "github.com/sirupsen/logrus"
+)
+
+// integrationDeliveryRetention is how long the delivery history of integrations is kept.
+const integrationDeliveryRetention = 30 * 24 * time.Hour
+
+// integrationDeliveryPruneInterval is how often old deliveries are deleted.
+const integrationDeliveryPruneInterval = time.Hour
+
+// pruneIntegrationDeliveries periodically deletes old integration deliveries.
+func (p *TaskPool) pruneIntegrationDeliveries() {
+	ticker := time.NewTicker(integrationDeliveryPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		p.deleteOldIntegrationDeliveries(tz.Now())
+		<-ticker.C
+	}
+}
+
+// deleteOldIntegrationDeliveries deletes deliveries older than the retention period.
+// In the HA cluster only the leader does it.
+func (p *TaskPool) deleteOldIntegrationDeliveries(now time.Time) {
+	if !p.state.IsLeader() {
+		return
+	}
+
+	db.StoreSession(p.store, "prune integration deliveries", func() {
+		if err := p.store.DeleteIntegrationDeliveries(now.Add(-integrationDeliveryRetention)); err != nil {
+			log.WithError(err).Error("Failed to delete old integration deliveries")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskPool_deleteOldIntegrationDeliveries(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{Name: "Test"})
+	require.NoError(t, err)
+
+	_, err = store.CreateIntegrationDelivery(db.IntegrationDelivery{
+		ProjectID:     proj.ID,
+		IntegrationID: 1,
+		Status:        db.IntegrationDeliveryTriggered,
+	})
+	require.NoError(t, err)
+
+	getDeliveries := func() []db.IntegrationDelivery {
+		deliveries, err := store.GetIntegrationDeliveries(proj.ID, 1, db.RetrieveQueryParams{})
+		require.NoError(t, err)
+		return deliveries
+	}
+
+	later := tz.Now().Add(integrationDeliveryRetention + time.Hour)
+
+	follower := CreateTaskPool(store, followerStateStore{NewMemoryTaskStateStore()}, nil, &InventoryServiceMock{}, &EncryptionServiceMock{}, &KeyInstallerMock{}, nil)
+	follower.deleteOldIntegrationDeliveries(later)
+	assert.Len(t, getDeliveries(), 1)
+
+	leader := CreateTaskPool(store, NewMemoryTaskStateStore(), nil, &InventoryServiceMock{}, &EncryptionServiceMock{}, &KeyInstallerMock{}, nil)
+	leader.deleteOldIntegrationDeliveries(tz.Now())
+	assert.Len(t, getDeliveries(), 1)
+
+	leader.deleteOldIntegrationDeliveries(later)
+	assert.Empty(t, getDeliveries())
+}
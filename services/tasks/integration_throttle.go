@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+// IntegrationThrottle keeps in-memory state of integration rate limits
+// and debounce windows.
+type IntegrationThrottle struct {
+	mu sync.Mutex
+
+	// deliveries contains times of deliveries accepted during the last minute, by integration ID.
+	deliveries map[int][]time.Time
+
+	// pending contains the latest debounced delivery waiting for the end of the window, by integration ID.
+	pending map[int]*pendingIntegrationDelivery
+
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func()) *time.Timer
+}
+
+type pendingIntegrationDelivery struct {
+	deliveryID int
+	fire       func()
+}
+
+func NewIntegrationThrottle() *IntegrationThrottle {
+	return &IntegrationThrottle{
+		deliveries: make(map[int][]time.Time),
+		pending:    make(map[int]*pendingIntegrationDelivery),
+		now:        time.Now,
+		afterFunc:  time.AfterFunc,
+	}
+}
+
+// Allow returns false if the integration exceeded its rate limit during the last minute.
+// Accepted deliveries are counted, rejected ones are not.
+func (t *IntegrationThrottle) Allow(integration db.Integration) bool {
+	if integration.RateLimit <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	windowStart := now.Add(-time.Minute)
+
+	recent := t.deliveries[integration.ID][:0]
+	for _, at := range t.deliveries[integration.ID] {
+		if at.After(windowStart) {
+			recent = append(recent, at)
+		}
+	}
+
+	if len(recent) >= integration.RateLimit {
+		t.deliveries[integration.ID] = recent
+		return false
+	}
+
+	t.deliveries[integration.ID] = append(recent, now)
+	return true
+}
+
+// Debounce schedules fire to be called at the end of the integration debounce window.
+// The window starts with the first delivery. If another delivery arrives before the window
+// ends, it replaces the pending one and the ID of the replaced delivery is returned.
+func (t *IntegrationThrottle) Debounce(integration db.Integration, deliveryID int, fire func()) (supersededID *int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.pending[integration.ID]; ok {
+		prevID := prev.deliveryID
+		prev.deliveryID = deliveryID
+		prev.fire = fire
+		return &prevID
+	}
+
+	t.pending[integration.ID] = &pendingIntegrationDelivery{
+		deliveryID: deliveryID,
+		fire:       fire,
+	}
+
+	t.afterFunc(time.Duration(integration.DebounceSec)*time.Second, func() {
+		t.mu.Lock()
+		p := t.pending[integration.ID]
+		delete(t.pending, integration.ID)
+		t.mu.Unlock()
+
+		if p != nil {
+			p.fire()
+		}
+	})
+
+	return nil
+}
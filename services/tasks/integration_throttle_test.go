@@ -0,0 +1,74 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrationThrottle_Allow(t *testing.T) {
+	throttle := NewIntegrationThrottle()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle.now = func() time.Time { return now }
+
+	integration := db.Integration{ID: 1, RateLimit: 2}
+
+	assert.True(t, throttle.Allow(integration))
+	assert.True(t, throttle.Allow(integration))
+	assert.False(t, throttle.Allow(integration))
+
+	// other integrations have their own limits
+	assert.True(t, throttle.Allow(db.Integration{ID: 2, RateLimit: 1}))
+
+	now = now.Add(61 * time.Second)
+	assert.True(t, throttle.Allow(integration))
+}
+
+func TestIntegrationThrottle_AllowWithoutLimit(t *testing.T) {
+	throttle := NewIntegrationThrottle()
+
+	integration := db.Integration{ID: 1}
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, throttle.Allow(integration))
+	}
+}
+
+func TestIntegrationThrottle_Debounce(t *testing.T) {
+	throttle := NewIntegrationThrottle()
+
+	var windowEnd func()
+	var window time.Duration
+
+	throttle.afterFunc = func(d time.Duration, f func()) *time.Timer {
+		window = d
+		windowEnd = f
+		return nil
+	}
+
+	integration := db.Integration{ID: 1, DebounceSec: 30}
+
+	var fired []int
+
+	superseded := throttle.Debounce(integration, 10, func() { fired = append(fired, 10) })
+	assert.Nil(t, superseded)
+	assert.Equal(t, 30*time.Second, window)
+
+	superseded = throttle.Debounce(integration, 11, func() { fired = append(fired, 11) })
+	assert.NotNil(t, superseded)
+	assert.Equal(t, 10, *superseded)
+
+	superseded = throttle.Debounce(integration, 12, func() { fired = append(fired, 12) })
+	assert.NotNil(t, superseded)
+	assert.Equal(t, 11, *superseded)
+
+	windowEnd()
+
+	assert.Equal(t, []int{12}, fired)
+
+	// the window is closed, the next delivery starts a new one
+	assert.Nil(t, throttle.Debounce(integration, 13, func() {}))
+}
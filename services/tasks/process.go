@@ -0,0 +1,23 @@
+//go:build !windows
+
+package tasks
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// killProcessGroup kills the process with all processes of its group.
+// Apps start their processes in their own groups, so children of the app,
+// for example forks of ansible-playbook, are killed too.
+func killProcessGroup(p *os.Process) error {
+	err := syscall.Kill(-p.Pid, syscall.SIGKILL)
+
+	if errors.Is(err, syscall.ESRCH) || errors.Is(err, syscall.EPERM) {
+		// The process is not the leader of the group.
+		return p.Kill()
+	}
+
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package tasks
+
+import (
+	"os"
+)
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/semaphoreui/semaphore/db"
+)
+
+var errNoRunners = errors.New("no runners available")
+
+// runnerAffinity remembers which runner has run every repository last time.
+// The runner keeps the repository in its cache, so the next task of the
+// repository starts faster on it.
+type runnerAffinity struct {
+	mu      sync.Mutex
+	runners map[int]int
+}
+
+func newRunnerAffinity() *runnerAffinity {
+	return &runnerAffinity{runners: make(map[int]int)}
+}
+
+func (a *runnerAffinity) set(repositoryID int, runnerID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.runners[repositoryID] = runnerID
+}
+
+func (a *runnerAffinity) get(repositoryID int) (runnerID int, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	runnerID, ok = a.runners[repositoryID]
+	return
+}
+
+// selectRunner picks the runner for the task of the template. Runners are
+// filtered by the selector of the template, the app of the template, their
+// health and capacity. The preferred runner is picked if it passes the filter,
+// otherwise the runner with the fewest running tasks is picked. Earlier runners
+// win ties, so project runners are preferred to global runners.
+func selectRunner(
+	runners []db.Runner,
+	tpl db.Template,
+	excludedIDs []int,
+	preferredID int,
+	runningTasks func(runnerID int) int,
+) (*db.Runner, error) {
+	selector, err := db.ParseRunnerSelector(tpl.RunnerSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *db.Runner
+	resLoad := 0
+
+	for i := range runners {
+		r := &runners[i]
+
+		// Ephemeral runners are started for specific tasks.
+		if r.Ephemeral || slices.Contains(excludedIDs, r.ID) {
+			continue
+		}
+
+		// Offline runners with webhooks can be started by the webhook.
+		if r.Health == db.RunnerHealthOffline && r.Webhook == "" {
+			continue
+		}
+
+		if !r.Capabilities.HasApp(tpl.App) {
+			continue
+		}
+
+		if !selector.Matches(r.SelectorLabels()) {
+			continue
+		}
+
+		n := runningTasks(r.ID)
+		if r.MaxParallelTasks > 0 && n >= r.MaxParallelTasks {
+			continue
+		}
+
+		if r.ID == preferredID {
+			return r, nil
+		}
+
+		if res == nil || n < resLoad {
+			res = r
+			resLoad = n
+		}
+	}
+
+	if res == nil {
+		return nil, errNoRunners
+	}
+
+	return res, nil
+}
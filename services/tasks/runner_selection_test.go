@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectRunner(t *testing.T) {
+	linux := db.RunnerCapabilities{OS: "linux", Apps: map[db.TemplateApp]string{db.AppAnsible: "2.15.0"}}
+	windows := db.RunnerCapabilities{OS: "windows", Apps: map[db.TemplateApp]string{db.AppPowerShell: "7.4.0"}}
+
+	runners := []db.Runner{
+		{ID: 1, Capabilities: linux, MaxParallelTasks: 2},
+		{ID: 2, Capabilities: linux},
+		{ID: 3, Capabilities: windows},
+		// Runners which have not reported capabilities match any app.
+		{ID: 4},
+		{ID: 5, Capabilities: linux, Health: db.RunnerHealthOffline},
+		// Ephemeral runners are never picked for other tasks.
+		{ID: 6, Capabilities: linux, Ephemeral: true},
+	}
+
+	load := map[int]int{1: 1, 2: 3, 4: 2}
+	runningTasks := func(runnerID int) int { return load[runnerID] }
+
+	ansible := db.Template{App: db.AppAnsible}
+
+	// The least-loaded runner with the app is picked.
+	r, err := selectRunner(runners, ansible, nil, 0, runningTasks)
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.ID)
+
+	// The preferred runner is picked if it matches.
+	r, err = selectRunner(runners, ansible, nil, 2, runningTasks)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.ID)
+
+	r, err = selectRunner(runners, ansible, nil, 3, runningTasks)
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.ID)
+
+	// Runners without spare capacity and excluded runners are skipped.
+	load[1] = 2
+	r, err = selectRunner(runners, ansible, []int{4}, 0, runningTasks)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.ID)
+
+	r, err = selectRunner(runners, db.Template{App: db.AppPowerShell, RunnerSelector: "os=windows"}, nil, 0, runningTasks)
+	require.NoError(t, err)
+	assert.Equal(t, 3, r.ID)
+
+	_, err = selectRunner(runners, db.Template{App: db.AppAnsible, RunnerSelector: "os=windows"}, nil, 0, runningTasks)
+	assert.Error(t, err)
+}
+
+func TestRunnerAffinity(t *testing.T) {
+	a := newRunnerAffinity()
+
+	_, ok := a.get(1)
+	assert.False(t, ok)
+
+	a.set(1, 5)
+	a.set(1, 7)
+
+	runnerID, ok := a.get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 7, runnerID)
+}
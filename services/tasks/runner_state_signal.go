@@ -0,0 +1,43 @@
+package tasks
+
+import "sync"
+
+// stateSignal notifies waiters that the state has changed. The channel
+// returned by wait is closed on the next change.
+type stateSignal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func newStateSignal() *stateSignal {
+	return &stateSignal{ch: make(chan struct{})}
+}
+
+func (s *stateSignal) wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ch
+}
+
+func (s *stateSignal) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.ch)
+	s.ch = make(chan struct{})
+}
+
+// RunnerStateChanged returns the channel which is closed when tasks of remote
+// runners change: a task is assigned to the runner, its status changes or its runner is lost.
+func (p *TaskPool) RunnerStateChanged() <-chan struct{} {
+	if p.runnerState == nil {
+		return nil
+	}
+	return p.runnerState.wait()
+}
+
+func (p *TaskPool) notifyRunnerStateChanged() {
+	if p == nil || p.runnerState == nil {
+		return
+	}
+	p.runnerState.notify()
+}
@@ -0,0 +1,33 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestStateSignal(t *testing.T) {
+	s := newStateSignal()
+
+	changed := s.wait()
+	assert.False(t, isClosed(changed))
+
+	s.notify()
+	assert.True(t, isClosed(changed))
+
+	// New waiters wait for the next change.
+	assert.False(t, isClosed(s.wait()))
+
+	// The pool without the signal is not notified.
+	var pool *TaskPool
+	assert.NotPanics(t, pool.notifyRunnerStateChanged)
+}
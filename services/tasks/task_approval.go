@@ -0,0 +1,210 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	log "github.com/sirupsen/logrus"
+)
+
+// taskApprovalLock serializes approval decisions so concurrent approvers
+// can not release the same task twice.
+var taskApprovalLock sync.Mutex
+
+// DecideTaskApproval records the decision of the user about a task which is
+// waiting for approval. The task is released to the queue when the number of
+// approvals required by the template is reached and is rejected on the first
+// rejection. The requester can not approve own task and every user can
+// decide only once.
+func (p *TaskPool) DecideTaskApproval(targetTask db.Task, user db.User, approved bool, comment string) error {
+	taskApprovalLock.Lock()
+	defer taskApprovalLock.Unlock()
+
+	tsk := p.GetTask(targetTask.ID)
+
+	if tsk == nil || tsk.Task.Status != task_logger.TaskWaitingApproval {
+		return db.NewValidationError("task is not waiting for approval")
+	}
+
+	if tsk.Task.UserID != nil && *tsk.Task.UserID == user.ID {
+		return db.NewValidationError("task can not be approved by its requester")
+	}
+
+	approvals, err := p.store.GetTaskApprovals(tsk.Task.ProjectID, tsk.Task.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range approvals {
+		if a.UserID == user.ID {
+			return db.NewValidationError("user has already decided about this task")
+		}
+	}
+
+	approval, err := p.store.CreateTaskApproval(db.TaskApproval{
+		TaskID:    tsk.Task.ID,
+		ProjectID: tsk.Task.ProjectID,
+		UserID:    user.ID,
+		Approved:  approved,
+		Comment:   comment,
+	})
+	if err != nil {
+		return err
+	}
+
+	approvals = append(approvals, approval)
+
+	verb := "approved"
+	if !approved {
+		verb = "rejected"
+	}
+
+	note := "Task " + verb + " by " + user.Username
+	if comment != "" {
+		note += ": " + comment
+	}
+
+	tsk.notifyApprovalDecision(&user.ID, note)
+
+	if !approved {
+		tsk.SetStatus(task_logger.TaskApprovalRejected)
+		return nil
+	}
+
+	if countApprovals(approvals) >= tsk.Template.RequiredApprovals {
+		tsk.Log("Task received all required approvals")
+		tsk.SetStatus(task_logger.TaskWaitingStatus)
+	}
+
+	return nil
+}
+
+func countApprovals(approvals []db.TaskApproval) (res int) {
+	users := make(map[int]bool)
+
+	for _, a := range approvals {
+		if a.Approved && !users[a.UserID] {
+			users[a.UserID] = true
+			res++
+		}
+	}
+
+	return
+}
+
+// approvalExpired returns true if the task has been waiting for approval
+// longer than the timeout configured in the template.
+func (t *TaskRunner) approvalExpired() bool {
+	if t.Task.Status != task_logger.TaskWaitingApproval || t.Template.ApprovalTimeout <= 0 {
+		return false
+	}
+
+	deadline := t.Task.Created.Add(time.Duration(t.Template.ApprovalTimeout) * time.Minute)
+
+	return tz.Now().After(deadline)
+}
+
+func (p *TaskPool) expireTaskApproval(t *TaskRunner) {
+	taskApprovalLock.Lock()
+	defer taskApprovalLock.Unlock()
+
+	if t.Task.Status != task_logger.TaskWaitingApproval {
+		return
+	}
+
+	db.StoreSession(p.store, "expire task approval", func() {
+		t.notifyApprovalDecision(nil, "Task rejected: approval timeout of "+
+			strconv.Itoa(t.Template.ApprovalTimeout)+" minute(s) expired")
+		t.SetStatus(task_logger.TaskApprovalRejected)
+	})
+}
+
+// restoreTasksWaitingApproval puts tasks which were waiting for approval
+// before the restart back to the queue. Decisions are stored in the database,
+// so received approvals are kept. Secret variables of tasks are not stored,
+// so tasks created with secrets fail instead of running without them.
+// In the HA cluster only the leader restores tasks.
+func (p *TaskPool) restoreTasksWaitingApproval() {
+	if !p.state.IsLeader() {
+		return
+	}
+
+	var restored []*TaskRunner
+
+	db.StoreSession(p.store, "restore tasks waiting approval", func() {
+		projects, err := p.store.GetAllProjects()
+		if err != nil {
+			log.WithError(err).Error("Failed to load tasks waiting for approval")
+			return
+		}
+
+		for _, project := range projects {
+			tasks, err := p.store.GetProjectTasks(project.ID, db.RetrieveQueryParams{
+				TaskFilter: &db.TaskFilter{
+					Status: []task_logger.TaskStatus{task_logger.TaskWaitingApproval},
+				},
+			})
+			if err != nil {
+				log.WithError(err).WithField("project_id", project.ID).Error("Failed to load tasks waiting for approval")
+				continue
+			}
+
+			for _, tsk := range tasks {
+				if tsk.Status != task_logger.TaskWaitingApproval || p.GetTask(tsk.ID) != nil {
+					continue
+				}
+
+				t, err := p.hydrateTaskRunner(tsk.ID, tsk.ProjectID)
+				if err != nil {
+					log.WithError(err).WithField("task_id", tsk.ID).Error("Failed to restore task waiting for approval")
+					continue
+				}
+
+				if t.Task.HasSecret {
+					log.WithField("task_id", tsk.ID).Warn("Task waiting for approval failed: secret variables are lost after the restart")
+					t.Log("Secret variables of the task are lost after the restart, run the task again")
+					t.SetStatus(task_logger.TaskFailStatus)
+					now := tz.Now()
+					t.Task.End = &now
+					t.saveStatus()
+					t.createTaskEvent()
+					continue
+				}
+
+				restored = append(restored, t)
+			}
+		}
+	})
+
+	for _, t := range restored {
+		p.register <- t
+	}
+}
+
+// notifyApprovalDecision writes the decision to the task log and the event
+// log and sends it to the configured chat alerts.
+func (t *TaskRunner) notifyApprovalDecision(userID *int, note string) {
+	t.Log(note)
+
+	desc := fmt.Sprintf("Task ID %d (%s): %s", t.Task.ID, t.Template.Name, note)
+	objType := db.EventTask
+
+	if _, err := t.pool.store.CreateEvent(db.Event{
+		UserID:      userID,
+		ProjectID:   &t.Task.ProjectID,
+		ObjectType:  &objType,
+		ObjectID:    &t.Task.ID,
+		Description: &desc,
+	}); err != nil {
+		log.WithError(err).Error("Failed to create task approval event")
+	}
+
+	t.approvalNote = note
+	t.sendChatAlerts()
+	t.approvalNote = ""
+}
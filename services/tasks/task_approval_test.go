@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/semaphoreui/semaphore/db"
+	"github.com/semaphoreui/semaphore/db/bolt"
+	"github.com/semaphoreui/semaphore/pkg/task_logger"
+	"github.com/semaphoreui/semaphore/pkg/tz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountApprovals(t *testing.T) {
+	approvals := []db.TaskApproval{
+		{UserID: 1, Approved: true},
+		{UserID: 1, Approved: true},
+		{UserID: 2, Approved: false},
+		{UserID: 3, Approved: true},
+	}
+
+	assert.Equal(t, 2, countApprovals(approvals))
+}
+
+func TestTaskRunner_approvalExpired(t *testing.T) {
+	tr := TaskRunner{
+		Task: db.Task{
+			Status:  task_logger.TaskWaitingApproval,
+			Created: tz.Now().Add(-10 * time.Minute),
+		},
+	}
+
+	assert.False(t, tr.approvalExpired(), "no timeout configured")
+
+	tr.Template.ApprovalTimeout = 15
+	assert.False(t, tr.approvalExpired())
+
+	tr.Template.ApprovalTimeout = 5
+	assert.True(t, tr.approvalExpired())
+
+	tr.Task.Status = task_logger.TaskWaitingStatus
+	assert.False(t, tr.approvalExpired(), "task already approved")
+}
+
+func TestTaskPool_restoreTasksWaitingApproval(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	proj, err := store.CreateProject(db.Project{})
+	require.NoError(t, err)
+
+	key, err := store.CreateAccessKey(db.AccessKey{ProjectID: &proj.ID, Type: db.AccessKeyNone})
+	require.NoError(t, err)
+
+	repo, err := store.CreateRepository(db.Repository{
+		ProjectID: proj.ID,
+		SSHKeyID:  key.ID,
+		Name:      "Test",
+		GitURL:    "git@example.com:test/test",
+		GitBranch: "master",
+	})
+	require.NoError(t, err)
+
+	inv, err := store.CreateInventory(db.Inventory{ProjectID: proj.ID})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{
+		Name:              "Deploy",
+		Playbook:          "deploy.yml",
+		ProjectID:         proj.ID,
+		RepositoryID:      repo.ID,
+		InventoryID:       &inv.ID,
+		RequiredApprovals: 1,
+	})
+	require.NoError(t, err)
+
+	waiting, err := store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskWaitingApproval,
+		Created:    tz.Now(),
+	}, 0)
+	require.NoError(t, err)
+
+	withSecret, err := store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskWaitingApproval,
+		Created:    tz.Now(),
+		HasSecret:  true,
+	}, 0)
+	require.NoError(t, err)
+
+	_, err = store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskSuccessStatus,
+		Created:    tz.Now(),
+	}, 0)
+	require.NoError(t, err)
+
+	pool := CreateTaskPool(store, NewMemoryTaskStateStore(), nil, &InventoryServiceMock{}, &EncryptionServiceMock{}, &KeyInstallerMock{}, &mockLogWriteService{})
+
+	done := make(chan struct{})
+	go func() {
+		pool.restoreTasksWaitingApproval()
+		close(done)
+	}()
+
+	select {
+	case restored := <-pool.register:
+		assert.Equal(t, waiting.ID, restored.Task.ID)
+		assert.Equal(t, task_logger.TaskWaitingApproval, restored.Task.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("task waiting for approval is not restored")
+	}
+
+	select {
+	case <-done:
+	case <-pool.register:
+		t.Fatal("only tasks waiting for approval must be restored")
+	case <-time.After(5 * time.Second):
+		t.Fatal("restore is not finished")
+	}
+
+	// Secrets of the task are lost, it must not run without them.
+	withSecret, err = store.GetTask(proj.ID, withSecret.ID)
+	require.NoError(t, err)
+	assert.Equal(t, task_logger.TaskFailStatus, withSecret.Status)
+	assert.NotNil(t, withSecret.End)
+}
+
+type followerStateStore struct {
+	*MemoryTaskStateStore
+}
+
+func (s followerStateStore) IsLeader() bool { return false }
+
+func TestTaskPool_restoreTasksWaitingApprovalFollower(t *testing.T) {
+	store := bolt.CreateTestStore()
+
+	pool := CreateTaskPool(store, followerStateStore{NewMemoryTaskStateStore()}, nil, &InventoryServiceMock{}, &EncryptionServiceMock{}, &KeyInstallerMock{}, nil)
+
+	proj, err := store.CreateProject(db.Project{})
+	require.NoError(t, err)
+
+	tpl, err := store.CreateTemplate(db.Template{Name: "Deploy", Playbook: "deploy.yml", ProjectID: proj.ID, RequiredApprovals: 1})
+	require.NoError(t, err)
+
+	_, err = store.CreateTask(db.Task{
+		ProjectID:  proj.ID,
+		TemplateID: tpl.ID,
+		Status:     task_logger.TaskWaitingApproval,
+		Created:    tz.Now(),
+	}, 0)
+	require.NoError(t, err)
+
+	pool.restoreTasksWaitingApproval()
+
+	assert.Empty(t, pool.register)
+}
@@ -47,6 +47,10 @@ type TaskStateStore interface {
 	// LoadRuntimeFields fills runtime fields (RunnerID, Username, IncomingVersion, Alias)
 	// from the backend into the provided task. No-op if not supported.
 	LoadRuntimeFields(task *TaskRunner)
+
+	// IsLeader reports whether this instance is the leader of the HA cluster.
+	// Background jobs which change the shared state run only on the leader.
+	IsLeader() bool
 }
 
 // MemoryTaskStateStore is an in-memory implementation of TaskStateStore
@@ -76,6 +80,9 @@ func (s *MemoryTaskStateStore) DeleteClaim(_ int)                 {}
 func (s *MemoryTaskStateStore) UpdateRuntimeFields(_ *TaskRunner) {}
 func (s *MemoryTaskStateStore) LoadRuntimeFields(_ *TaskRunner)   {}
 
+// The single process is always the leader
+func (s *MemoryTaskStateStore) IsLeader() bool { return true }
+
 // Queue
 func (s *MemoryTaskStateStore) Enqueue(task *TaskRunner) {
 	s.mu.Lock()
@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/semaphoreui/semaphore/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/semaphoreui/semaphore"
+
+// propagator serializes the trace context in the W3C Trace Context format.
+// It is used instead of the global propagator to pass the context to runners
+// and playbooks even if tracing is disabled on the server.
+var propagator = propagation.TraceContext{}
+
+// Init sets up the global tracer provider which exports spans to the OTLP/HTTP
+// endpoint. It returns the function which flushes the remaining spans.
+// Spans are not recorded if tracing is not enabled.
+func Init(config *util.TracingConfig, defaultServiceName string) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+
+	if !config.IsEnabled() {
+		return
+	}
+
+	var opts []otlptracehttp.Option
+
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(config.Endpoint))
+	}
+
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(util.Version()),
+	))
+	if err != nil {
+		return
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	shutdown = provider.Shutdown
+	return
+}
+
+// Tracer returns the tracer of Semaphore from the global tracer provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start creates the span which is a child of the span in the context.
+func Start(ctx context.Context, name string, attrs ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, attrs...)
+}
+
+// End ends the span and marks it as failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context which can be passed to another process.
+// It returns nil if the context has no span.
+func Inject(ctx context.Context) map[string]string {
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract returns the context with the remote span from the trace context
+// created by Inject.
+func Extract(traceContext map[string]string) context.Context {
+	return propagator.Extract(context.Background(), propagation.MapCarrier(traceContext))
+}
+
+// EnvVars returns environment variables which allow the running app to join
+// the trace. TRACEPARENT is supported by OpenTelemetry tools, for example the
+// community.general.opentelemetry callback of Ansible.
+func EnvVars(ctx context.Context) []string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	return []string{
+		"TRACEPARENT=" + Inject(ctx)["traceparent"],
+		"SEMAPHORE_TRACE_ID=" + spanContext.TraceID().String(),
+		"SEMAPHORE_SPAN_ID=" + spanContext.SpanID().String(),
+	}
+}
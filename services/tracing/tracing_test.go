@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/semaphoreui/semaphore/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector receives spans over OTLP/HTTP like an OpenTelemetry collector.
+type collector struct {
+	mu       sync.Mutex
+	spans    map[string]*tracepb.Span
+	services []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req collectortrace.ExportTraceServiceRequest
+	if err = proto.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		for _, attr := range rs.Resource.Attributes {
+			if attr.Key == "service.name" {
+				c.services = append(c.services, attr.Value.GetStringValue())
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans[span.Name] = span
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	out, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	_, _ = w.Write(out)
+}
+
+func TestTracing(t *testing.T) {
+	c := &collector{spans: make(map[string]*tracepb.Span)}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	shutdown, err := Init(&util.TracingConfig{
+		Enabled:  true,
+		Endpoint: server.URL + "/v1/traces",
+	}, "semaphore")
+	require.NoError(t, err)
+
+	ctx, taskSpan := Start(context.Background(), "task")
+
+	// The runner receives the trace context in the job data.
+	traceContext := Inject(ctx)
+	require.Contains(t, traceContext, "traceparent")
+
+	runnerCtx, runSpan := Start(Extract(traceContext), "app.run")
+	env := EnvVars(runnerCtx)
+	End(runSpan, io.ErrUnexpectedEOF)
+	taskSpan.End()
+
+	require.NoError(t, shutdown(context.Background()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	assert.Equal(t, []string{"semaphore"}, c.services)
+	require.Contains(t, c.spans, "task")
+	require.Contains(t, c.spans, "app.run")
+
+	task := c.spans["task"]
+	run := c.spans["app.run"]
+
+	traceID := hex.EncodeToString(task.TraceId)
+	assert.Equal(t, task.TraceId, run.TraceId)
+	assert.Equal(t, task.SpanId, run.ParentSpanId)
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, run.Status.Code)
+
+	assert.Contains(t, env, "SEMAPHORE_TRACE_ID="+traceID)
+	assert.Contains(t, env, "SEMAPHORE_SPAN_ID="+hex.EncodeToString(run.SpanId))
+	assert.Contains(t, env, "TRACEPARENT=00-"+traceID+"-"+hex.EncodeToString(run.SpanId)+"-01")
+}
+
+func TestMiddleware(t *testing.T) {
+	assert.Nil(t, Inject(context.Background()))
+	assert.Nil(t, EnvVars(context.Background()))
+
+	var traceparent string
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = Inject(r.Context())["traceparent"]
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// The span joins the trace of the caller even if the tracer does not record it.
+	req := httptest.NewRequest(http.MethodPost, "/api/project/1/tasks", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+}
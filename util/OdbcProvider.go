@@ -16,6 +16,27 @@ type OidcProvider struct {
 	NameClaim        string       `json:"name_claim" default:"preferred_username"`
 	EmailClaim       string       `json:"email_claim" default:"email"`
 	Order            int          `json:"order"`
+	// RefreshSession keeps the session alive only while the provider accepts
+	// the refresh token, so users revoked by the provider lose access.
+	// The offline_access scope may be required to get the refresh token.
+	RefreshSession bool `json:"refresh_session,omitempty"`
+	// PostLogoutRedirectURL is the address the provider returns the user
+	// to after logout. Login page is used if empty.
+	PostLogoutRedirectURL string `json:"post_logout_redirect_url,omitempty"`
+	// RoleMappings grant the admin flag and project roles by claim values.
+	// They are applied on every login.
+	RoleMappings []OidcRoleMapping `json:"role_mappings,omitempty"`
+}
+
+// OidcRoleMapping matches users whose claim equals the value or, for list
+// claims like groups, contains the value. Claim can refer to nested
+// claims by dot separated path, for example realm_access.roles.
+type OidcRoleMapping struct {
+	Claim     string `json:"claim"`
+	Value     string `json:"value"`
+	Admin     bool   `json:"admin,omitempty"`
+	ProjectID int    `json:"project_id,omitempty"`
+	Role      string `json:"role,omitempty"`
 }
 
 type ClaimsProvider interface {
@@ -18,6 +18,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -76,6 +77,45 @@ func (p *LdapMappings) GetNameClaim() string {
 	return p.CN
 }
 
+// LdapGroupMapping grants the role in the project to members of the LDAP group.
+type LdapGroupMapping struct {
+	GroupDN   string `json:"group_dn"`
+	ProjectID int    `json:"project_id"`
+	Role      string `json:"role"`
+}
+
+// LdapGroupSyncConfig describes synchronization of project roles and
+// the admin flag of LDAP users with LDAP groups.
+type LdapGroupSyncConfig struct {
+	Enabled  bool   `json:"enabled,omitempty" env:"SEMAPHORE_LDAP_GROUP_SYNC_ENABLED"`
+	SearchDN string `json:"search_dn,omitempty" env:"SEMAPHORE_LDAP_GROUP_SEARCH_DN"`
+	// SearchFilter is used to find groups of the user, %s is replaced by the user DN.
+	SearchFilter string `json:"search_filter,omitempty" env:"SEMAPHORE_LDAP_GROUP_SEARCH_FILTER"`
+	// AdminGroupDN grants the admin flag to its members when set.
+	AdminGroupDN string             `json:"admin_group_dn,omitempty" env:"SEMAPHORE_LDAP_ADMIN_GROUP_DN"`
+	Mappings     []LdapGroupMapping `json:"mappings,omitempty" env:"SEMAPHORE_LDAP_GROUP_MAPPINGS"`
+	// SyncInterval is the period of the background synchronization in minutes.
+	SyncInterval int `json:"sync_interval,omitempty" env:"SEMAPHORE_LDAP_GROUP_SYNC_INTERVAL"`
+}
+
+func (p *LdapGroupSyncConfig) IsEnabled() bool {
+	return p != nil && p.Enabled
+}
+
+func (p *LdapGroupSyncConfig) GetSearchFilter() string {
+	if p.SearchFilter == "" {
+		return "(&(objectClass=groupOfNames)(member=%s))"
+	}
+	return p.SearchFilter
+}
+
+func (p *LdapGroupSyncConfig) GetSyncInterval() time.Duration {
+	if p.SyncInterval <= 0 {
+		return time.Hour
+	}
+	return time.Duration(p.SyncInterval) * time.Minute
+}
+
 type oidcEndpoint struct {
 	IssuerURL   string   `json:"issuer"`
 	AuthURL     string   `json:"auth"`
@@ -83,6 +123,9 @@ type oidcEndpoint struct {
 	UserInfoURL string   `json:"userinfo"`
 	JWKSURL     string   `json:"jwks"`
 	Algorithms  []string `json:"algorithms"`
+	// EndSessionURL is used for logout when the provider
+	// does not support discovery.
+	EndSessionURL string `json:"end_session"`
 }
 
 const (
@@ -110,6 +153,9 @@ type RunnerConfig struct {
 	Token             string `json:"token,omitempty" env:"SEMAPHORE_RUNNER_TOKEN"`
 	TokenFile         string `json:"token_file,omitempty" env:"SEMAPHORE_RUNNER_TOKEN_FILE"`
 	PrivateKeyFile    string `json:"private_key_file,omitempty" env:"SEMAPHORE_RUNNER_PRIVATE_KEY_FILE"`
+	// PrivateKey is the PEM encoded private key. It is used instead of
+	// PrivateKeyFile by runners which have no persistent storage.
+	PrivateKey string `json:"-" env:"SEMAPHORE_RUNNER_PRIVATE_KEY"`
 
 	// OneOff indicates than runner runs only one job and exit. It is very useful for dynamic runners.
 	// How it works?
@@ -123,6 +169,16 @@ type RunnerConfig struct {
 	Webhook string `json:"webhook,omitempty" env:"SEMAPHORE_RUNNER_WEBHOOK"`
 
 	MaxParallelTasks int `json:"max_parallel_tasks,omitempty" default:"1" env:"SEMAPHORE_RUNNER_MAX_PARALLEL_TASKS"`
+
+	// Labels are reported to the server with the detected apps, OS and
+	// architecture of the runner. Templates select runners by them.
+	Labels map[string]string `json:"labels,omitempty" env:"SEMAPHORE_RUNNER_LABELS"`
+
+	// Stream enables the persistent websocket connection to the server.
+	// The server pushes new jobs and stop requests and the runner sends logs
+	// as soon as they appear. The runner polls the server while the stream is
+	// not connected.
+	Stream bool `json:"stream,omitempty" env:"SEMAPHORE_RUNNER_STREAM"`
 }
 
 type TLSConfig struct {
@@ -185,11 +241,179 @@ type ScheduleConfig struct {
 	Timezone string `json:"timezone,omitempty" env:"SEMAPHORE_SCHEDULE_TIMEZONE" default:"UTC"`
 }
 
+// GitopsConfig configures synchronization of projects with
+// definitions stored in their repositories.
+type GitopsConfig struct {
+	Enabled bool `json:"enabled,omitempty" env:"SEMAPHORE_GITOPS_ENABLED"`
+	// SyncInterval is the period of the background synchronization in seconds.
+	SyncInterval int `json:"sync_interval,omitempty" env:"SEMAPHORE_GITOPS_SYNC_INTERVAL"`
+}
+
+func (c *GitopsConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+func (c *GitopsConfig) GetSyncInterval() time.Duration {
+	if c == nil || c.SyncInterval <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.SyncInterval) * time.Second
+}
+
 type DebuggingConfig struct {
 	ApiDelay     string `json:"api_delay,omitempty" env:"SEMAPHORE_API_DELAY"`
 	PprofDumpDir string `json:"pprof_dump_dir,omitempty" env:"SEMAPHORE_PPROF_DUMP_DIR"`
 }
 
+// MetricsConfig configures the Prometheus metrics endpoint.
+type MetricsConfig struct {
+	Enabled bool `json:"enabled" env:"SEMAPHORE_METRICS_ENABLED"`
+	// Listen is the address of the separate metrics server, for example ":9100".
+	// Metrics are served by the main server on /metrics if it is empty.
+	Listen string `json:"listen,omitempty" env:"SEMAPHORE_METRICS_LISTEN"`
+	// Token is required as the bearer token if it is set.
+	Token string `json:"token,omitempty" env:"SEMAPHORE_METRICS_TOKEN"`
+}
+
+func (c *MetricsConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// TracingConfig configures the export of OpenTelemetry traces over OTLP/HTTP.
+type TracingConfig struct {
+	Enabled bool `json:"enabled" env:"SEMAPHORE_TRACING_ENABLED"`
+	// Endpoint is the URL of the collector, for example "http://localhost:4318/v1/traces".
+	// The standard OTEL_EXPORTER_OTLP_* variables are used if it is empty.
+	Endpoint string            `json:"endpoint,omitempty" env:"SEMAPHORE_TRACING_ENDPOINT"`
+	Headers  map[string]string `json:"headers,omitempty" env:"SEMAPHORE_TRACING_HEADERS"`
+	// ServiceName defaults to "semaphore" for the server and "semaphore-runner" for runners.
+	ServiceName string `json:"service_name,omitempty" env:"SEMAPHORE_TRACING_SERVICE_NAME"`
+}
+
+func (c *TracingConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// RunnerProvisioningConfig configures ephemeral runners which the server
+// starts in containers for tasks when no registered runner is available.
+type RunnerProvisioningConfig struct {
+	Enabled bool `json:"enabled" env:"SEMAPHORE_RUNNER_PROVISIONING_ENABLED"`
+	// Engine is the container engine binary, "docker" or "podman".
+	Engine string `json:"engine,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_ENGINE"`
+	// Image is the runner image, "semaphoreui/runner:latest" by default.
+	Image string `json:"image,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_IMAGE"`
+	// Command overrides the command of the image.
+	Command []string `json:"command,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_COMMAND"`
+	// Network is the container network which the server is reachable from.
+	Network string `json:"network,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_NETWORK"`
+	// ServerURL is the URL of the server for runners. WebHost is used if it is empty.
+	ServerURL string `json:"server_url,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_SERVER_URL"`
+	// Env is passed to the runner containers.
+	Env map[string]string `json:"env,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_ENV"`
+	// MaxRunners limits the number of ephemeral runners. 0 means no limit.
+	MaxRunners int `json:"max_runners,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_MAX_RUNNERS"`
+	// NodeID identifies runners started by this server, so servers of the HA cluster
+	// remove only their own runners. The host name is used by default.
+	NodeID string `json:"node_id,omitempty" env:"SEMAPHORE_RUNNER_PROVISIONING_NODE_ID"`
+}
+
+func (c *RunnerProvisioningConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+func (c *RunnerProvisioningConfig) GetEngine() string {
+	if c == nil || c.Engine == "" {
+		return "docker"
+	}
+	return c.Engine
+}
+
+func (c *RunnerProvisioningConfig) GetNodeID() string {
+	if c != nil && c.NodeID != "" {
+		return c.NodeID
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "default"
+	}
+	return hostname
+}
+
+func (c *RunnerProvisioningConfig) GetImage() string {
+	if c == nil || c.Image == "" {
+		return "semaphoreui/runner:latest"
+	}
+	return c.Image
+}
+
+// ContainerExecutionConfig configures running of local tasks inside
+// containers. Tasks run in containers if their templates or projects
+// have container images.
+type ContainerExecutionConfig struct {
+	Enabled bool `json:"enabled" env:"SEMAPHORE_CONTAINER_EXECUTION_ENABLED"`
+	// Engine is the container engine binary, "docker" or "podman".
+	Engine string `json:"engine,omitempty" env:"SEMAPHORE_CONTAINER_EXECUTION_ENGINE"`
+	// CPUs limits the number of CPUs of the container, for example "1.5".
+	CPUs string `json:"cpus,omitempty" env:"SEMAPHORE_CONTAINER_EXECUTION_CPUS"`
+	// Memory limits the memory of the container, for example "512m".
+	Memory string `json:"memory,omitempty" env:"SEMAPHORE_CONTAINER_EXECUTION_MEMORY"`
+	// Network is the container network, the default network of the engine is used if it is empty.
+	Network string `json:"network,omitempty" env:"SEMAPHORE_CONTAINER_EXECUTION_NETWORK"`
+}
+
+func (c *ContainerExecutionConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+func (c *ContainerExecutionConfig) GetEngine() string {
+	if c == nil || c.Engine == "" {
+		return "docker"
+	}
+	return c.Engine
+}
+
+// ArtifactsS3Config configures the S3-compatible storage of task artifacts.
+type ArtifactsS3Config struct {
+	// Endpoint is the host and the port of the storage, for example "s3.amazonaws.com".
+	Endpoint  string `json:"endpoint" env:"SEMAPHORE_ARTIFACTS_S3_ENDPOINT"`
+	Bucket    string `json:"bucket" env:"SEMAPHORE_ARTIFACTS_S3_BUCKET"`
+	Region    string `json:"region,omitempty" env:"SEMAPHORE_ARTIFACTS_S3_REGION"`
+	AccessKey string `json:"access_key,omitempty" env:"SEMAPHORE_ARTIFACTS_S3_ACCESS_KEY"`
+	SecretKey string `json:"secret_key,omitempty" env:"SEMAPHORE_ARTIFACTS_S3_SECRET_KEY"`
+	// Prefix is prepended to keys of artifacts.
+	Prefix string `json:"prefix,omitempty" env:"SEMAPHORE_ARTIFACTS_S3_PREFIX"`
+	// Insecure disables TLS.
+	Insecure bool `json:"insecure,omitempty" env:"SEMAPHORE_ARTIFACTS_S3_INSECURE"`
+}
+
+// ArtifactsConfig configures the storage of files uploaded by tasks.
+type ArtifactsConfig struct {
+	// Path is the directory of artifacts, <tmp_path>/artifacts by default.
+	// It is not used if S3 is configured.
+	Path string             `json:"path,omitempty" env:"SEMAPHORE_ARTIFACTS_PATH"`
+	S3   *ArtifactsS3Config `json:"s3,omitempty"`
+	// RetentionDays is the number of days artifacts are kept. Artifacts are
+	// kept until their tasks are deleted if it is 0.
+	RetentionDays int `json:"retention_days,omitempty" env:"SEMAPHORE_ARTIFACTS_RETENTION_DAYS"`
+	// MaxFileSize is the maximum size of an artifact in megabytes, 100 by default.
+	MaxFileSize int `json:"max_file_size,omitempty" env:"SEMAPHORE_ARTIFACTS_MAX_FILE_SIZE"`
+}
+
+func (c *ArtifactsConfig) GetPath() string {
+	if c == nil || c.Path == "" {
+		return path.Join(Config.TmpPath, "artifacts")
+	}
+	return c.Path
+}
+
+// GetMaxFileSize returns the maximum size of an artifact in bytes.
+func (c *ArtifactsConfig) GetMaxFileSize() int64 {
+	if c == nil || c.MaxFileSize <= 0 {
+		return 100 << 20
+	}
+	return int64(c.MaxFileSize) << 20
+}
+
 type HARedisConfig struct {
 	Addr          string `json:"addr,omitempty" env:"SEMAPHORE_HA_REDIS_ADDR"`
 	DB            int    `json:"db,omitempty" env:"SEMAPHORE_HA_REDIS_DB"`
@@ -278,6 +502,8 @@ type ConfigType struct {
 	LdapMappings     *LdapMappings `json:"ldap_mappings,omitempty"`
 	LdapNeedTLS      bool          `json:"ldap_needtls,omitempty" env:"SEMAPHORE_LDAP_NEEDTLS"`
 
+	LdapGroupSync *LdapGroupSyncConfig `json:"ldap_group_sync,omitempty"`
+
 	// Telegram, Slack, Rocket.Chat, Microsoft Teams, DingTalk, and Gotify alerting
 	TelegramAlert       bool   `json:"telegram_alert,omitempty" env:"SEMAPHORE_TELEGRAM_ALERT"`
 	TelegramChat        string `json:"telegram_chat,omitempty" env:"SEMAPHORE_TELEGRAM_CHAT"`
@@ -305,6 +531,14 @@ type ConfigType struct {
 
 	RunnerRegistrationToken string `json:"runner_registration_token,omitempty" env:"SEMAPHORE_RUNNER_REGISTRATION_TOKEN"`
 
+	// RemoteStopTimeoutSec is how long the server waits for the runner to confirm
+	// stopping the task. The task is stopped forcibly after that.
+	RemoteStopTimeoutSec int `json:"remote_stop_timeout_sec,omitempty" default:"60" rule:"^[0-9]{1,10}$" env:"SEMAPHORE_REMOTE_STOP_TIMEOUT_SEC"`
+
+	// RunnerHeartbeatTimeoutSec is how long the runner may not contact the server
+	// before it is marked offline. Zero disables the health monitoring of runners.
+	RunnerHeartbeatTimeoutSec int `json:"runner_heartbeat_timeout_sec,omitempty" default:"120" rule:"^[0-9]{1,10}$" env:"SEMAPHORE_RUNNER_HEARTBEAT_TIMEOUT_SEC"`
+
 	// feature switches
 	PasswordLoginDisable     bool `json:"password_login_disable,omitempty" env:"SEMAPHORE_PASSWORD_LOGIN_DISABLED"`
 	NonAdminCanCreateProject bool `json:"non_admin_can_create_project,omitempty" env:"SEMAPHORE_NON_ADMIN_CAN_CREATE_PROJECT"`
@@ -329,8 +563,20 @@ type ConfigType struct {
 
 	Schedule *ScheduleConfig `json:"schedule,omitempty"`
 
+	Gitops *GitopsConfig `json:"gitops,omitempty"`
+
 	Debugging *DebuggingConfig `json:"debugging,omitempty"`
 
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+
+	Tracing *TracingConfig `json:"tracing,omitempty"`
+
+	RunnerProvisioning *RunnerProvisioningConfig `json:"runner_provisioning,omitempty"`
+
+	ContainerExecution *ContainerExecutionConfig `json:"container_execution,omitempty"`
+
+	Artifacts *ArtifactsConfig `json:"artifacts,omitempty"`
+
 	HA *HAConfig `json:"ha,omitempty"`
 }
 
@@ -716,12 +962,12 @@ func setConfigValue(attribute reflect.Value, value string) {
 
 		switch kind {
 		case reflect.Slice:
-			var arr []string
-			err := json.Unmarshal([]byte(value), &arr)
+			arr := reflect.New(attribute.Type())
+			err := json.Unmarshal([]byte(value), arr.Interface())
 			if err != nil {
 				panic(err)
 			}
-			attribute.Set(reflect.ValueOf(arr))
+			attribute.Set(arr.Elem())
 		case reflect.Map:
 			mapType := attribute.Type()
 			mapValue := reflect.New(mapType)
@@ -54,3 +54,17 @@ func (conf *ConfigType) GetSysProcAttr() (res *syscall.SysProcAttr) {
 
 	return
 }
+
+// GetProcessGroupSysProcAttr returns the attributes of GetSysProcAttr which
+// also start the process in its own process group. It allows stopping
+// the process together with its children.
+func (conf *ConfigType) GetProcessGroupSysProcAttr() *syscall.SysProcAttr {
+	res := conf.GetSysProcAttr()
+	if res == nil {
+		res = &syscall.SysProcAttr{}
+	}
+
+	res.Setpgid = true
+
+	return res
+}
@@ -10,3 +10,7 @@ func (conf *ConfigType) GetSysProcAttr() (res *syscall.SysProcAttr) {
 
 	return
 }
+
+func (conf *ConfigType) GetProcessGroupSysProcAttr() *syscall.SysProcAttr {
+	return conf.GetSysProcAttr()
+}
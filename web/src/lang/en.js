@@ -343,6 +343,16 @@ export default {
 
   runner_tag: 'Runner tag',
   allow_parallel_tasks: 'Allow parallel tasks',
+  token_scopes: 'Scopes',
+  token_last_used: 'Last used',
+  token_full_access: 'Full access',
+  token_scope_read_only: 'Read only',
+  token_scope_run_tasks: 'Run tasks',
+  token_scope_manage_resources: 'Manage resources',
+  required_approvals: 'Required approvals',
+  required_approvals_hint: 'Number of users, other than the requester, who must approve a task before it starts',
+  approval_timeout: 'Approval timeout (minutes)',
+  approval_timeout_hint: 'Task is rejected automatically if it is not approved in time. 0 means no timeout',
   task_prompts: 'Prompts',
   template_advanced: 'Advanced options',
   template_app_options: '{app} options',
@@ -367,6 +377,12 @@ export default {
   canUpdateProject: 'Can update project',
   canManageProjectResources: 'Can manage project resources',
   canManageProjectUsers: 'Can manage project users',
+  canViewSecrets: 'Can view keys and secret storages',
+  canManageSchedules: 'Can manage schedules',
+  canManageIntegrations: 'Can manage integrations',
+  canApproveTasks: 'Can approve tasks (e.g. Terraform plans)',
+  canStopOthersTasks: "Can stop other users' tasks",
+  canViewTaskOutput: 'Can view task output',
   newRole: 'New Role',
   editRole: 'Edit Role',
   deleteRole: 'Delete Role',